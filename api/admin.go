@@ -22,22 +22,24 @@ import (
 func InitAdmin() {
 	l4g.Debug(utils.T("api.admin.init.debug"))
 
-	BaseRoutes.Admin.Handle("/logs", ApiUserRequired(getLogs)).Methods("GET")
-	BaseRoutes.Admin.Handle("/audits", ApiUserRequired(getAllAudits)).Methods("GET")
-	BaseRoutes.Admin.Handle("/config", ApiUserRequired(getConfig)).Methods("GET")
-	BaseRoutes.Admin.Handle("/save_config", ApiUserRequired(saveConfig)).Methods("POST")
-	BaseRoutes.Admin.Handle("/test_email", ApiUserRequired(testEmail)).Methods("POST")
+	BaseRoutes.Admin.Handle("/logs", ApiScope(model.SCOPE_ADMIN, ApiUserRequired(getLogs))).Methods("GET")
+	BaseRoutes.Admin.Handle("/audits", ApiScope(model.SCOPE_ADMIN, ApiUserRequired(getAllAudits))).Methods("GET")
+	BaseRoutes.Admin.Handle("/config", ApiScope(model.SCOPE_ADMIN, ApiUserRequired(getConfig))).Methods("GET")
+	BaseRoutes.Admin.Handle("/save_config", ApiScope(model.SCOPE_ADMIN, ApiUserRequired(saveConfig))).Methods("POST")
+	BaseRoutes.Admin.Handle("/test_email", ApiScope(model.SCOPE_ADMIN, ApiUserRequired(testEmail))).Methods("POST")
 	BaseRoutes.Admin.Handle("/client_props", ApiAppHandler(getClientConfig)).Methods("GET")
 	BaseRoutes.Admin.Handle("/log_client", ApiAppHandler(logClient)).Methods("POST")
-	BaseRoutes.Admin.Handle("/analytics/{id:[A-Za-z0-9]+}/{name:[A-Za-z0-9_]+}", ApiUserRequired(getAnalytics)).Methods("GET")
-	BaseRoutes.Admin.Handle("/analytics/{name:[A-Za-z0-9_]+}", ApiUserRequired(getAnalytics)).Methods("GET")
-	BaseRoutes.Admin.Handle("/save_compliance_report", ApiUserRequired(saveComplianceReport)).Methods("POST")
-	BaseRoutes.Admin.Handle("/compliance_reports", ApiUserRequired(getComplianceReports)).Methods("GET")
-	BaseRoutes.Admin.Handle("/download_compliance_report/{id:[A-Za-z0-9]+}", ApiUserRequiredTrustRequester(downloadComplianceReport)).Methods("GET")
+	BaseRoutes.Admin.Handle("/analytics/{id:[A-Za-z0-9]+}/{name:[A-Za-z0-9_]+}", ApiScope(model.SCOPE_ADMIN, ApiUserRequired(getAnalytics))).Methods("GET")
+	BaseRoutes.Admin.Handle("/analytics/{name:[A-Za-z0-9_]+}", ApiScope(model.SCOPE_ADMIN, ApiUserRequired(getAnalytics))).Methods("GET")
+	BaseRoutes.Admin.Handle("/save_compliance_report", ApiScope(model.SCOPE_ADMIN, ApiUserRequired(saveComplianceReport))).Methods("POST")
+	BaseRoutes.Admin.Handle("/compliance_reports", ApiScope(model.SCOPE_ADMIN, ApiUserRequired(getComplianceReports))).Methods("GET")
+	BaseRoutes.Admin.Handle("/download_compliance_report/{id:[A-Za-z0-9]+}", ApiScope(model.SCOPE_ADMIN, ApiUserRequiredTrustRequester(downloadComplianceReport))).Methods("GET")
 	BaseRoutes.Admin.Handle("/upload_brand_image", ApiAdminSystemRequired(uploadBrandImage)).Methods("POST")
 	BaseRoutes.Admin.Handle("/get_brand_image", ApiAppHandlerTrustRequester(getBrandImage)).Methods("GET")
 	BaseRoutes.Admin.Handle("/reset_mfa", ApiAdminSystemRequired(adminResetMfa)).Methods("POST")
 	BaseRoutes.Admin.Handle("/reset_password", ApiAdminSystemRequired(adminResetPassword)).Methods("POST")
+	BaseRoutes.Admin.Handle("/ldap_sync_now", ApiAdminSystemRequired(ldapSyncNow)).Methods("POST")
+	BaseRoutes.Admin.Handle("/ldap_sync_status", ApiAdminSystemRequired(getLdapSyncStatus)).Methods("GET")
 }
 
 func getLogs(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -163,6 +165,9 @@ func saveConfig(c *Context, w http.ResponseWriter, r *http.Request) {
 	utils.SaveConfig(utils.CfgFileName, cfg)
 	utils.LoadConfig(utils.CfgFileName)
 
+	// the MFA settings may have changed so every user has to be checked again
+	mfaSatisfiedCache.Purge()
+
 	rdata := map[string]string{}
 	rdata["status"] = "OK"
 	w.Write([]byte(model.MapToJson(rdata)))
@@ -535,7 +540,7 @@ func adminResetPassword(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	newPassword := props["new_password"]
-	if len(newPassword) < model.MIN_PASSWORD_LENGTH {
+	if len(newPassword) == 0 {
 		c.SetInvalidParam("adminResetPassword", "new_password")
 		return
 	}
@@ -551,3 +556,41 @@ func adminResetPassword(c *Context, w http.ResponseWriter, r *http.Request) {
 	rdata["status"] = "ok"
 	w.Write([]byte(model.MapToJson(rdata)))
 }
+
+func getLdapInterface(c *Context, where string) einterfaces.LdapInterface {
+	ldapInterface := einterfaces.GetLdapInterface()
+	if ldapInterface == nil || !*utils.Cfg.LdapSettings.Enable {
+		c.Err = model.NewLocAppError(where, "api.admin.ldap.not_available.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return nil
+	}
+
+	return ldapInterface
+}
+
+func ldapSyncNow(c *Context, w http.ResponseWriter, r *http.Request) {
+	ldapInterface := getLdapInterface(c, "ldapSyncNow")
+	if ldapInterface == nil {
+		return
+	}
+
+	if err := ldapInterface.SyncNow(); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("")
+
+	rdata := map[string]string{}
+	rdata["status"] = "ok"
+	w.Write([]byte(model.MapToJson(rdata)))
+}
+
+func getLdapSyncStatus(c *Context, w http.ResponseWriter, r *http.Request) {
+	ldapInterface := getLdapInterface(c, "getLdapSyncStatus")
+	if ldapInterface == nil {
+		return
+	}
+
+	w.Write([]byte(ldapInterface.GetSyncStatus().ToJson()))
+}
@@ -96,6 +96,9 @@ func TestGetConfig(t *testing.T) {
 		if cfg.SqlSettings.AtRestEncryptKey != model.FAKE_SETTING {
 			t.Fatal("did not sanitize properly")
 		}
+		if cfg.ServiceSettings.PostActionSecret != model.FAKE_SETTING {
+			t.Fatal("did not sanitize properly")
+		}
 		if !strings.Contains(strings.Join(cfg.SqlSettings.DataSourceReplicas, " "), model.FAKE_SETTING) && len(cfg.SqlSettings.DataSourceReplicas) != 0 {
 			t.Fatal("did not sanitize properly")
 		}
@@ -111,10 +114,17 @@ func TestSaveConfig(t *testing.T) {
 
 	*utils.Cfg.TeamSettings.EnableOpenServer = false
 
+	userId := model.NewId()
+	mfaSatisfiedCache.Add(userId, true)
+
 	if _, err := th.SystemAdminClient.SaveConfig(utils.Cfg); err != nil {
 		t.Fatal(err)
 	}
 
+	if _, ok := mfaSatisfiedCache.Get(userId); ok {
+		t.Fatal("should have cleared the users who don't need MFA")
+	}
+
 	*utils.Cfg.TeamSettings.EnableOpenServer = true
 }
 
@@ -436,7 +446,7 @@ func TestAdminResetPassword(t *testing.T) {
 	Client := th.SystemAdminClient
 	team := th.SystemAdminTeam
 
-	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, team)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
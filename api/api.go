@@ -72,18 +72,24 @@ func InitApi() {
 	BaseRoutes.Public = BaseRoutes.ApiRoot.PathPrefix("/public").Subrouter()
 
 	InitUser()
+	InitUserAccessToken()
+	InitBot()
 	InitTeam()
 	InitChannel()
 	InitPost()
+	InitReaction()
 	InitWebSocket()
 	InitFile()
 	InitCommand()
 	InitAdmin()
 	InitOAuth()
+	InitSaml()
 	InitWebhook()
 	InitPreference()
 	InitLicense()
 
+	loadPostActionSecret()
+
 	// 404 on any api route before web.go has a chance to serve it
 	Srv.Router.Handle("/api/{anything:.*}", http.HandlerFunc(Handle404))
 
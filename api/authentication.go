@@ -20,6 +20,19 @@ func checkPasswordAndAllCriteria(user *model.User, password string, mfaToken str
 		return err
 	}
 
+	if err := checkUserPasswordNotExpired(user); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func checkUserPasswordNotExpired(user *model.User) *model.AppError {
+	days := *utils.Cfg.PasswordSettings.ExpiryDays
+	if days > 0 && model.GetMillis()-user.LastPasswordUpdate > int64(days)*24*60*60*1000 {
+		return model.NewLocAppError("checkUserPasswordNotExpired", "api.user.check_user_password.expired.app_error", nil, "user_id="+user.Id)
+	}
+
 	return nil
 }
 
@@ -85,8 +98,40 @@ func checkUserAdditionalAuthenticationCriteria(user *model.User, mfaToken string
 	return nil
 }
 
+// isMfaEnabled returns true if MFA is turned on and there's an implementation to check the
+// codes with.
+func isMfaEnabled() bool {
+	return *utils.Cfg.ServiceSettings.EnableMultifactorAuthentication && einterfaces.GetMfaInterface() != nil
+}
+
+// mfaSatisfiedCache holds the users MfaRequired doesn't need to look up again, either because
+// they've activated MFA or because MFA is never enforced for them.
+var mfaSatisfiedCache *utils.Cache = utils.NewLru(model.SESSION_CACHE_SIZE)
+
+// isMfaExempt returns true for the users who can't use MFA. Users who sign in with an SSO
+// service are left to their identity provider.
+func isMfaExempt(user *model.User) bool {
+	return user.IsBot || (len(user.AuthService) > 0 && user.AuthService != model.USER_AUTH_SERVICE_LDAP)
+}
+
+// isMfaEnforced returns true if the user isn't allowed to use the server without MFA.
+func isMfaEnforced(user *model.User) bool {
+	if !isMfaEnabled() || isMfaExempt(user) {
+		return false
+	}
+
+	switch *utils.Cfg.ServiceSettings.EnforceMultifactorAuthentication {
+	case model.MFA_ENFORCE_ALL:
+		return true
+	case model.MFA_ENFORCE_SYSTEM_ADMIN:
+		return model.IsInRole(user.Roles, model.ROLE_SYSTEM_ADMIN)
+	}
+
+	return false
+}
+
 func checkUserMfa(user *model.User, token string) *model.AppError {
-	if !user.MfaActive || !utils.IsLicensed || !*utils.License.Features.MFA || !*utils.Cfg.ServiceSettings.EnableMultifactorAuthentication {
+	if !user.MfaActive || !*utils.Cfg.ServiceSettings.EnableMultifactorAuthentication {
 		return nil
 	}
 
@@ -95,7 +140,14 @@ func checkUserMfa(user *model.User, token string) *model.AppError {
 		return model.NewLocAppError("checkUserMfa", "api.user.check_user_mfa.not_available.app_error", nil, "")
 	}
 
-	if ok, err := mfaInterface.ValidateToken(user.MfaSecret, token); err != nil {
+	if ok, err := mfaInterface.ValidateToken(user, token); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+
+	// a recovery code can be used once instead of a code from the user's device
+	if ok, err := mfaInterface.UseRecoveryCode(user, token); err != nil {
 		return err
 	} else if !ok {
 		return model.NewLocAppError("checkUserMfa", "api.user.check_user_mfa.bad_code.app_error", nil, "")
@@ -129,7 +181,11 @@ func checkUserNotDisabled(user *model.User) *model.AppError {
 func authenticateUser(user *model.User, password, mfaToken string) (*model.User, *model.AppError) {
 	ldapAvailable := *utils.Cfg.LdapSettings.Enable && einterfaces.GetLdapInterface() != nil
 
-	if user.AuthService == model.USER_AUTH_SERVICE_LDAP {
+	if user.IsBot {
+		err := model.NewLocAppError("login", "api.user.login.bot.app_error", nil, "user_id="+user.Id)
+		err.StatusCode = http.StatusUnauthorized
+		return user, err
+	} else if user.AuthService == model.USER_AUTH_SERVICE_LDAP {
 		if !ldapAvailable {
 			err := model.NewLocAppError("login", "api.user.login_ldap.not_available.app_error", nil, "")
 			err.StatusCode = http.StatusNotImplemented
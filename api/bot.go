@@ -0,0 +1,164 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func InitBot() {
+	l4g.Debug(utils.T("api.bot.init.debug"))
+
+	BaseRoutes.Users.Handle("/bots", ApiUserRequired(getBots)).Methods("GET")
+	BaseRoutes.Users.Handle("/bots/create", ApiUserRequired(createBot)).Methods("POST")
+}
+
+// canManageBot returns true if the session user owns the bot, either directly or as an
+// admin of the team that owns it.
+func canManageBot(c *Context, bot *model.User) bool {
+	if !bot.IsBot {
+		return false
+	}
+
+	if c.IsSystemAdmin() {
+		return true
+	}
+
+	if bot.BotOwnerType == model.BOT_OWNER_TYPE_USER {
+		return bot.BotOwnerId == c.Session.UserId
+	}
+
+	if teamMember := c.Session.GetTeamByTeamId(bot.BotOwnerId); teamMember != nil {
+		return teamMember.IsTeamAdmin()
+	}
+
+	return false
+}
+
+// hasPermissionsToUserOrBot is HasPermissionsToUser extended to the owners of a bot so that
+// they can manage the tokens it authenticates with.
+func hasPermissionsToUserOrBot(c *Context, userId string, where string) bool {
+	if c.Session.UserId != userId && !c.IsSystemAdmin() {
+		if result := <-Srv.Store.User().Get(userId); result.Err == nil && canManageBot(c, result.Data.(*model.User)) {
+			return true
+		}
+	}
+
+	return c.HasPermissionsToUser(userId, where)
+}
+
+func createBot(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkUserAccessTokensEnabled(c, "createBot") {
+		return
+	}
+
+	props := model.MapFromJson(r.Body)
+
+	username := strings.ToLower(props["username"])
+	if !model.IsValidUsername(username) {
+		c.SetInvalidParam("createBot", "username")
+		return
+	}
+
+	bot := &model.User{
+		Username:     username,
+		Nickname:     props["display_name"],
+		IsBot:        true,
+		BotOwnerType: props["owner_type"],
+		BotOwnerId:   props["owner_id"],
+	}
+
+	teamId := props["team_id"]
+
+	switch bot.BotOwnerType {
+	case model.BOT_OWNER_TYPE_USER:
+		if len(bot.BotOwnerId) == 0 {
+			bot.BotOwnerId = c.Session.UserId
+		}
+	case model.BOT_OWNER_TYPE_TEAM:
+		teamId = bot.BotOwnerId
+	default:
+		c.SetInvalidParam("createBot", "owner_type")
+		return
+	}
+
+	if c.Session.IsBot() || !canManageBot(c, bot) {
+		c.Err = model.NewLocAppError("createBot", "api.bot.permissions.app_error", nil, "owner_id="+bot.BotOwnerId)
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	// team admins already have to own team bots so only bots owned by a user are restricted here
+	if *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations && bot.BotOwnerType == model.BOT_OWNER_TYPE_USER && !c.IsSystemAdmin() {
+		c.Err = model.NewLocAppError("createBot", "api.command.admin_only.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	var team *model.Team
+	if len(teamId) > 0 {
+		if !c.HasPermissionsToTeam(teamId, "createBot") {
+			return
+		}
+
+		if result := <-Srv.Store.Team().Get(teamId); result.Err != nil {
+			c.Err = result.Err
+			return
+		} else {
+			team = result.Data.(*model.Team)
+		}
+	}
+
+	// bots never receive email, the address only has to be unique
+	bot.Email = model.NewId() + "@localhost"
+	bot.EmailVerified = true
+
+	c.LogAudit("attempt - username=" + bot.Username)
+
+	rbot, err := CreateUser(bot)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if team != nil {
+		if err := JoinUserToTeam(team, rbot); err != nil {
+			c.Err = err
+			return
+		}
+	}
+
+	c.LogAudit("success - bot_id=" + rbot.Id)
+
+	w.Write([]byte(rbot.ToJson()))
+}
+
+func getBots(c *Context, w http.ResponseWriter, r *http.Request) {
+	ownerIds := []string{c.Session.UserId}
+	for _, teamMember := range c.Session.TeamMembers {
+		if teamMember.IsTeamAdmin() || c.IsSystemAdmin() {
+			ownerIds = append(ownerIds, teamMember.TeamId)
+		}
+	}
+
+	bots := make(map[string]*model.User)
+
+	for _, ownerId := range ownerIds {
+		if result := <-Srv.Store.User().GetBotsByOwner(ownerId); result.Err != nil {
+			c.Err = result.Err
+			return
+		} else {
+			for _, bot := range result.Data.([]*model.User) {
+				bots[bot.Id] = bot
+			}
+		}
+	}
+
+	w.Write([]byte(model.UserMapToJson(bots)))
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestBots(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	Client := th.BasicClient
+
+	enableUserAccessTokens := *utils.Cfg.ServiceSettings.EnableUserAccessTokens
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableUserAccessTokens = enableUserAccessTokens
+	}()
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = true
+
+	if _, err := Client.CreateBot("bot"+model.NewId(), "Team Bot", model.BOT_OWNER_TYPE_TEAM, th.BasicTeam.Id, ""); err == nil {
+		t.Fatal("should have failed - only team admins can create bots for a team")
+	}
+
+	if _, err := Client.CreateBot("bot"+model.NewId(), "Other Bot", model.BOT_OWNER_TYPE_USER, th.BasicUser2.Id, ""); err == nil {
+		t.Fatal("should have failed - can't create bots for another user")
+	}
+
+	bot := Client.Must(Client.CreateBot("bot"+model.NewId(), "Build Bot", model.BOT_OWNER_TYPE_USER, "", th.BasicTeam.Id)).Data.(*model.User)
+	if !bot.IsBot || bot.BotOwnerId != th.BasicUser.Id {
+		t.Fatal("should have created a bot owned by the user")
+	}
+
+	if bots := Client.Must(Client.GetBots()).Data.(map[string]*model.User); bots[bot.Id] == nil {
+		t.Fatal("should have listed the bot")
+	}
+
+	if _, err := th.CreateClient().Login(bot.Username, ""); err == nil {
+		t.Fatal("should have failed - bots can't log in")
+	}
+
+	token := Client.Must(Client.CreateUserAccessToken(bot.Id, "ci")).Data.(*model.UserAccessToken)
+
+	botClient := th.CreateClient()
+	botClient.AuthToken = token.Token
+	botClient.AuthType = model.HEADER_BEARER
+	botClient.SetTeamId(th.BasicTeam.Id)
+
+	th.LoginBasic2()
+	if _, err := Client.CreateUserAccessToken(bot.Id, "ci"); err == nil {
+		t.Fatal("should have failed - only the owner can manage the bot's tokens")
+	}
+
+	th.LoginBasic()
+	Client.Must(Client.AddChannelMember(th.BasicChannel.Id, bot.Id))
+
+	post := botClient.Must(botClient.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "build passed"})).Data.(*model.Post)
+	if !post.IsFromBot() {
+		t.Fatal("should have marked the post as coming from a bot")
+	}
+
+	post = Client.Must(Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "not a bot", Props: model.StringInterface{model.POST_PROP_FROM_BOT: "true"}})).Data.(*model.Post)
+	if post.IsFromBot() {
+		t.Fatal("should not have let a user pretend to be a bot")
+	}
+
+	teamBot := th.SystemAdminClient.Must(th.SystemAdminClient.CreateBot("bot"+model.NewId(), "Team Bot", model.BOT_OWNER_TYPE_TEAM, th.SystemAdminTeam.Id, "")).Data.(*model.User)
+	if teamBot.BotOwnerType != model.BOT_OWNER_TYPE_TEAM {
+		t.Fatal("should have created a bot owned by the team")
+	}
+}
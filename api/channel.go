@@ -22,9 +22,10 @@ const (
 func InitChannel() {
 	l4g.Debug(utils.T("api.channel.init.debug"))
 
-	BaseRoutes.Channels.Handle("/", ApiUserRequiredActivity(getChannels, false)).Methods("GET")
-	BaseRoutes.Channels.Handle("/more", ApiUserRequired(getMoreChannels)).Methods("GET")
-	BaseRoutes.Channels.Handle("/counts", ApiUserRequiredActivity(getChannelCounts, false)).Methods("GET")
+	BaseRoutes.Channels.Handle("/", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequiredActivity(getChannels, false))).Methods("GET")
+	BaseRoutes.Channels.Handle("/more", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getMoreChannels))).Methods("GET")
+	BaseRoutes.Channels.Handle("/deleted", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getDeletedChannels))).Methods("GET")
+	BaseRoutes.Channels.Handle("/counts", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequiredActivity(getChannelCounts, false))).Methods("GET")
 	BaseRoutes.Channels.Handle("/create", ApiUserRequired(createChannel)).Methods("POST")
 	BaseRoutes.Channels.Handle("/create_direct", ApiUserRequired(createDirectChannel)).Methods("POST")
 	BaseRoutes.Channels.Handle("/update", ApiUserRequired(updateChannel)).Methods("POST")
@@ -34,15 +35,17 @@ func InitChannel() {
 
 	BaseRoutes.NeedChannelName.Handle("/join", ApiUserRequired(join)).Methods("POST")
 
-	BaseRoutes.NeedChannel.Handle("/", ApiUserRequiredActivity(getChannel, false)).Methods("GET")
-	BaseRoutes.NeedChannel.Handle("/extra_info", ApiUserRequired(getChannelExtraInfo)).Methods("GET")
-	BaseRoutes.NeedChannel.Handle("/extra_info/{member_limit:-?[0-9]+}", ApiUserRequired(getChannelExtraInfo)).Methods("GET")
+	BaseRoutes.NeedChannel.Handle("/", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequiredActivity(getChannel, false))).Methods("GET")
+	BaseRoutes.NeedChannel.Handle("/extra_info", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getChannelExtraInfo))).Methods("GET")
+	BaseRoutes.NeedChannel.Handle("/extra_info/{member_limit:-?[0-9]+}", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getChannelExtraInfo))).Methods("GET")
 	BaseRoutes.NeedChannel.Handle("/join", ApiUserRequired(join)).Methods("POST")
 	BaseRoutes.NeedChannel.Handle("/leave", ApiUserRequired(leave)).Methods("POST")
 	BaseRoutes.NeedChannel.Handle("/delete", ApiUserRequired(deleteChannel)).Methods("POST")
+	BaseRoutes.NeedChannel.Handle("/restore", ApiUserRequired(restoreChannel)).Methods("POST")
 	BaseRoutes.NeedChannel.Handle("/add", ApiUserRequired(addMember)).Methods("POST")
 	BaseRoutes.NeedChannel.Handle("/remove", ApiUserRequired(removeMember)).Methods("POST")
-	BaseRoutes.NeedChannel.Handle("/update_last_viewed_at", ApiUserRequired(updateLastViewedAt)).Methods("POST")
+	BaseRoutes.NeedChannel.Handle("/update_last_viewed_at", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(updateLastViewedAt))).Methods("POST")
+	BaseRoutes.NeedChannel.Handle("/pinned", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getPinnedPosts))).Methods("GET")
 }
 
 func createChannel(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -407,6 +410,22 @@ func getMoreChannels(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func getDeletedChannels(c *Context, w http.ResponseWriter, r *http.Request) {
+
+	// user is already in the team
+
+	if result := <-Srv.Store.Channel().GetDeletedChannels(c.TeamId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if HandleEtag(result.Data.(*model.ChannelList).Etag(), w, r) {
+		return
+	} else {
+		data := result.Data.(*model.ChannelList)
+		w.Header().Set(model.HEADER_ETAG_SERVER, data.Etag())
+		w.Write([]byte(data.ToJson()))
+	}
+}
+
 func getChannelCounts(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	// user is already in the team
@@ -684,28 +703,32 @@ func deleteChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// the hooks are deleted at the same time as the channel so that restoreChannel can tell
+		// them apart from the ones that were deleted before
 		now := model.GetMillis()
 		for _, hook := range incomingHooks {
-			go func() {
-				if result := <-Srv.Store.Webhook().DeleteIncoming(hook.Id, now); result.Err != nil {
-					l4g.Error(utils.T("api.channel.delete_channel.incoming_webhook.error"), hook.Id)
+			go func(hookId string) {
+				if result := <-Srv.Store.Webhook().DeleteIncoming(hookId, now); result.Err != nil {
+					l4g.Error(utils.T("api.channel.delete_channel.incoming_webhook.error"), hookId)
 				}
-			}()
+			}(hook.Id)
 		}
 
 		for _, hook := range outgoingHooks {
-			go func() {
-				if result := <-Srv.Store.Webhook().DeleteOutgoing(hook.Id, now); result.Err != nil {
-					l4g.Error(utils.T("api.channel.delete_channel.outgoing_webhook.error"), hook.Id)
+			go func(hookId string) {
+				if result := <-Srv.Store.Webhook().DeleteOutgoing(hookId, now); result.Err != nil {
+					l4g.Error(utils.T("api.channel.delete_channel.outgoing_webhook.error"), hookId)
 				}
-			}()
+			}(hook.Id)
 		}
 
-		if dresult := <-Srv.Store.Channel().Delete(channel.Id, model.GetMillis()); dresult.Err != nil {
+		if dresult := <-Srv.Store.Channel().Delete(channel.Id, now); dresult.Err != nil {
 			c.Err = dresult.Err
 			return
 		}
 
+		DeleteChannelPostsFromIndexAndForget(channel.Id)
+
 		c.LogAudit("name=" + channel.Name)
 
 		go func() {
@@ -729,6 +752,100 @@ func deleteChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func restoreChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+
+	params := mux.Vars(r)
+	id := params["channel_id"]
+
+	props := model.MapFromJson(r.Body)
+	restoreHooks := props["restore_hooks"] == "true"
+
+	sc := Srv.Store.Channel().Get(id)
+	uc := Srv.Store.User().Get(c.Session.UserId)
+
+	if cresult := <-sc; cresult.Err != nil {
+		c.Err = cresult.Err
+		return
+	} else if uresult := <-uc; uresult.Err != nil {
+		c.Err = uresult.Err
+		return
+	} else {
+		channel := cresult.Data.(*model.Channel)
+		user := uresult.Data.(*model.User)
+
+		if !c.HasPermissionsToTeam(channel.TeamId, "restoreChannel") {
+			return
+		}
+
+		// the team admin check below is for the team of the request
+		if channel.TeamId != c.TeamId {
+			c.Err = model.NewLocAppError("restoreChannel", "api.channel.restore_channel.permissions.app_error", nil, "")
+			c.Err.StatusCode = http.StatusForbidden
+			return
+		}
+
+		// the members of a channel are kept when it's deleted so a channel admin can restore it too
+		isChannelAdmin := false
+		if mresult := <-Srv.Store.Channel().GetMember(id, c.Session.UserId); mresult.Err == nil {
+			isChannelAdmin = strings.Contains(mresult.Data.(model.ChannelMember).Roles, model.CHANNEL_ROLE_ADMIN)
+		}
+
+		if !isChannelAdmin && !c.IsTeamAdmin() {
+			c.Err = model.NewLocAppError("restoreChannel", "api.channel.restore_channel.permissions.app_error", nil, "")
+			c.Err.StatusCode = http.StatusForbidden
+			return
+		}
+
+		if channel.DeleteAt == 0 {
+			c.Err = model.NewLocAppError("restoreChannel", "api.channel.restore_channel.not_deleted.app_error", nil, "")
+			c.Err.StatusCode = http.StatusBadRequest
+			return
+		}
+
+		now := model.GetMillis()
+		if rresult := <-Srv.Store.Channel().Restore(channel.Id, now); rresult.Err != nil {
+			c.Err = rresult.Err
+			return
+		}
+
+		if restoreHooks {
+			ihc := Srv.Store.Webhook().RestoreIncomingByChannel(channel.Id, channel.DeleteAt, now)
+			ohc := Srv.Store.Webhook().RestoreOutgoingByChannel(channel.Id, channel.DeleteAt, now)
+
+			if result := <-ihc; result.Err != nil {
+				l4g.Error(utils.T("api.channel.restore_channel.incoming_webhook.error"), channel.Id, result.Err.Error())
+			}
+
+			if result := <-ohc; result.Err != nil {
+				l4g.Error(utils.T("api.channel.restore_channel.outgoing_webhook.error"), channel.Id, result.Err.Error())
+			}
+		}
+
+		IndexChannelPostsAndForget(channel.Id)
+
+		c.LogAudit("name=" + channel.Name)
+
+		go func() {
+			InvalidateCacheForChannel(channel.Id)
+			message := model.NewMessage(c.TeamId, channel.Id, c.Session.UserId, model.ACTION_CHANNEL_RESTORED)
+			PublishAndForget(message)
+
+			post := &model.Post{
+				ChannelId: channel.Id,
+				Message:   fmt.Sprintf(c.T("api.channel.restore_channel.restored"), user.Username),
+				Type:      model.POST_CHANNEL_RESTORED,
+			}
+			if _, err := CreatePost(c, post, false); err != nil {
+				l4g.Error(utils.T("api.channel.restore_channel.failed_post.error"), err)
+			}
+		}()
+
+		channel.DeleteAt = 0
+		channel.UpdateAt = now
+		w.Write([]byte(channel.ToJson()))
+	}
+}
+
 func updateLastViewedAt(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["channel_id"]
@@ -1023,3 +1140,35 @@ func updateNotifyProps(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 }
+
+func getPinnedPosts(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("getPinnedPosts", "channelId")
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	pchan := Srv.Store.Post().GetPinnedPosts(channelId)
+
+	if !c.HasPermissionsToChannel(cchan, "getPinnedPosts") {
+		return
+	}
+
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		list := result.Data.(*model.PostList)
+
+		if err := addReactionsToPostList(list); err != nil {
+			c.Err = err
+			return
+		}
+
+		list.StripActionIntegrations()
+		w.Write([]byte(list.ToJson()))
+	}
+}
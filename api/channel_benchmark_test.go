@@ -127,11 +127,11 @@ func BenchmarkJoinChannel(b *testing.B) {
 	}
 
 	// Secondary test user to join channels created by primary test user
-	user := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "That Guy", Password: "pwd"}
+	user := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "That Guy", Password: "passwd"}
 	user = th.BasicClient.Must(th.BasicClient.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, th.BasicTeam)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
-	th.BasicClient.Login(user.Email, "pwd")
+	th.BasicClient.Login(user.Email, "passwd")
 
 	// Benchmark Start
 	b.ResetTimer()
@@ -433,7 +433,7 @@ func TestJoinChannelById(t *testing.T) {
 
 	user3 := th.CreateUser(th.BasicClient)
 	LinkUserToTeam(user3, team)
-	Client.Login(user3.Email, "pwd")
+	Client.Login(user3.Email, "passwd")
 
 	if _, err := Client.JoinChannel(rchannel.Id); err == nil {
 		t.Fatal("shoudn't be able to join direct channel")
@@ -463,7 +463,7 @@ func TestJoinChannelByName(t *testing.T) {
 
 	user3 := th.CreateUser(th.BasicClient)
 	LinkUserToTeam(user3, team)
-	Client.Login(user3.Email, "pwd")
+	Client.Login(user3.Email, "passwd")
 
 	if _, err := Client.JoinChannelByName(rchannel.Name); err == nil {
 		t.Fatal("shoudn't be able to join direct channel")
@@ -521,7 +521,7 @@ func TestDeleteChannel(t *testing.T) {
 
 	Client.AddChannelMember(channelMadeByCA.Id, userTeamAdmin.Id)
 
-	Client.Login(userTeamAdmin.Email, "pwd")
+	Client.Login(userTeamAdmin.Email, "passwd")
 
 	channel1 := &model.Channel{DisplayName: "A Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
 	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
@@ -568,6 +568,97 @@ func TestDeleteChannel(t *testing.T) {
 	}
 }
 
+func TestRestoreChannel(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	team := th.BasicTeam
+
+	enableIncomingHooks := utils.Cfg.ServiceSettings.EnableIncomingWebhooks
+	enableAdminOnlyHooks := *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableIncomingWebhooks = enableIncomingHooks
+		*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = enableAdminOnlyHooks
+	}()
+	utils.Cfg.ServiceSettings.EnableIncomingWebhooks = true
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = false
+
+	channel1 := &model.Channel{DisplayName: "A Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	channel2 := &model.Channel{DisplayName: "B Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	hook1 := Client.Must(Client.CreateIncomingWebhook(&model.IncomingWebhook{ChannelId: channel1.Id})).Data.(*model.IncomingWebhook)
+	hook2 := Client.Must(Client.CreateIncomingWebhook(&model.IncomingWebhook{ChannelId: channel2.Id})).Data.(*model.IncomingWebhook)
+
+	if _, err := Client.RestoreChannel(channel1.Id, true); err == nil {
+		t.Fatal("should have failed to restore a channel that isn't deleted")
+	}
+
+	Client.Must(Client.DeleteChannel(channel1.Id))
+	Client.Must(Client.DeleteChannel(channel2.Id))
+
+	// the hooks are deleted asynchronously
+	time.Sleep(100 * time.Millisecond)
+
+	deleted := Client.Must(Client.GetDeletedChannels("")).Data.(*model.ChannelList)
+	if len(deleted.Channels) != 2 {
+		t.Fatal("should have returned both deleted channels")
+	}
+
+	th.LoginBasic2()
+
+	if _, err := Client.RestoreChannel(channel1.Id, true); err == nil {
+		t.Fatal("should have failed to restore a channel you're not an admin of")
+	}
+
+	team2 := th.CreateTeam(th.BasicClient)
+	LinkUserToTeam(th.BasicUser2, team2)
+	UpdateUserToTeamAdmin(th.BasicUser2, team2)
+
+	Client.SetTeamId(team2.Id)
+	if _, err := Client.RestoreChannel(channel1.Id, true); err == nil {
+		t.Fatal("should have failed to restore a channel of another team")
+	}
+	Client.SetTeamId(team.Id)
+
+	th.LoginBasic()
+
+	if result, err := Client.RestoreChannel(channel1.Id, true); err != nil {
+		t.Fatal(err)
+	} else if result.Data.(*model.Channel).DeleteAt != 0 {
+		t.Fatal("should have been restored")
+	}
+
+	Client.Must(Client.RestoreChannel(channel2.Id, false))
+
+	post := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}
+	Client.Must(Client.CreatePost(post))
+
+	hooks := Client.Must(Client.ListIncomingWebhooks()).Data.([]*model.IncomingWebhook)
+	found1, found2 := false, false
+	for _, hook := range hooks {
+		if hook.Id == hook1.Id {
+			found1 = true
+		} else if hook.Id == hook2.Id {
+			found2 = true
+		}
+	}
+
+	if !found1 {
+		t.Fatal("should have restored the hook")
+	}
+
+	if found2 {
+		t.Fatal("shouldn't have restored the hook")
+	}
+
+	deleted = Client.Must(Client.GetDeletedChannels("")).Data.(*model.ChannelList)
+	if len(deleted.Channels) != 0 {
+		t.Fatal("should have no deleted channels left")
+	}
+}
+
 func TestGetChannelExtraInfo(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
@@ -603,13 +694,13 @@ func TestGetChannelExtraInfo(t *testing.T) {
 
 	Client2 := model.NewClient("http://localhost" + utils.Cfg.ServiceSettings.ListenAddress)
 
-	user2 := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Tester 2", Password: "pwd"}
+	user2 := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Tester 2", Password: "passwd"}
 	user2 = Client2.Must(Client2.CreateUser(user2, "")).Data.(*model.User)
 	LinkUserToTeam(user2, team)
 	Client2.SetTeamId(team.Id)
 	store.Must(Srv.Store.User().VerifyEmail(user2.Id))
 
-	Client2.Login(user2.Email, "pwd")
+	Client2.Login(user2.Email, "passwd")
 	Client2.Must(Client2.JoinChannel(channel1.Id))
 
 	if cache_result, err := Client.GetChannelExtraInfo(channel1.Id, -1, currentEtag); err != nil {
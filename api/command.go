@@ -43,8 +43,10 @@ func InitCommand() {
 
 	BaseRoutes.Commands.Handle("/execute", ApiUserRequired(executeCommand)).Methods("POST")
 	BaseRoutes.Commands.Handle("/list", ApiUserRequired(listCommands)).Methods("GET")
+	BaseRoutes.Commands.Handle("/suggest", ApiUserRequired(suggestCommands)).Methods("POST")
 
 	BaseRoutes.Commands.Handle("/create", ApiUserRequired(createCommand)).Methods("POST")
+	BaseRoutes.Commands.Handle("/update", ApiUserRequired(updateCommand)).Methods("POST")
 	BaseRoutes.Commands.Handle("/list_team_commands", ApiUserRequired(listTeamCommands)).Methods("GET")
 	BaseRoutes.Commands.Handle("/regen_token", ApiUserRequired(regenCommandToken)).Methods("POST")
 	BaseRoutes.Commands.Handle("/delete", ApiUserRequired(deleteCommand)).Methods("POST")
@@ -56,12 +58,26 @@ func InitCommand() {
 }
 
 func listCommands(c *Context, w http.ResponseWriter, r *http.Request) {
+	commands, err := getAutocompleteCommands(c)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	for _, cmd := range commands {
+		cmd.Sanitize()
+	}
+
+	w.Write([]byte(model.CommandListToJson(commands)))
+}
+
+// getAutocompleteCommands returns copies of the built-in and team commands that can be autocompleted.
+func getAutocompleteCommands(c *Context) ([]*model.Command, *model.AppError) {
 	commands := make([]*model.Command, 0, 32)
 	seen := make(map[string]bool)
 	for _, value := range commandProviders {
 		cpy := *value.GetCommand(c)
 		if cpy.AutoComplete && !seen[cpy.Id] {
-			cpy.Sanitize()
 			seen[cpy.Trigger] = true
 			commands = append(commands, &cpy)
 		}
@@ -69,13 +85,11 @@ func listCommands(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	if *utils.Cfg.ServiceSettings.EnableCommands {
 		if result := <-Srv.Store.Command().GetByTeam(c.TeamId); result.Err != nil {
-			c.Err = result.Err
-			return
+			return nil, result.Err
 		} else {
 			teamCmds := result.Data.([]*model.Command)
 			for _, cmd := range teamCmds {
 				if cmd.AutoComplete && !seen[cmd.Id] {
-					cmd.Sanitize()
 					seen[cmd.Trigger] = true
 					commands = append(commands, cmd)
 				}
@@ -83,7 +97,7 @@ func listCommands(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	w.Write([]byte(model.CommandListToJson(commands)))
+	return commands, nil
 }
 
 func executeCommand(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -298,6 +312,67 @@ func createCommand(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func updateCommand(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !*utils.Cfg.ServiceSettings.EnableCommands {
+		c.Err = model.NewLocAppError("updateCommand", "api.command.disabled.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	if *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations {
+		if !(c.IsSystemAdmin() || c.IsTeamAdmin()) {
+			c.Err = model.NewLocAppError("updateCommand", "api.command.admin_only.app_error", nil, "")
+			c.Err.StatusCode = http.StatusForbidden
+			return
+		}
+	}
+
+	c.LogAudit("attempt")
+
+	updatedCmd := model.CommandFromJson(r.Body)
+
+	if updatedCmd == nil {
+		c.SetInvalidParam("updateCommand", "command")
+		return
+	}
+
+	var cmd *model.Command
+	if result := <-Srv.Store.Command().Get(updatedCmd.Id); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		cmd = result.Data.(*model.Command)
+
+		if c.TeamId != cmd.TeamId || (c.Session.UserId != cmd.CreatorId && !c.IsTeamAdmin()) {
+			c.LogAudit("fail - inappropriate permissions")
+			c.Err = model.NewLocAppError("updateCommand", "api.command.update.permissions.app_error", nil, "user_id="+c.Session.UserId)
+			c.Err.StatusCode = http.StatusForbidden
+			return
+		}
+	}
+
+	// the token, creator and team of a command can't be changed here
+	cmd.Trigger = updatedCmd.Trigger
+	cmd.Method = updatedCmd.Method
+	cmd.Username = updatedCmd.Username
+	cmd.IconURL = updatedCmd.IconURL
+	cmd.AutoComplete = updatedCmd.AutoComplete
+	cmd.AutoCompleteDesc = updatedCmd.AutoCompleteDesc
+	cmd.AutoCompleteHint = updatedCmd.AutoCompleteHint
+	cmd.AutocompleteData = updatedCmd.AutocompleteData
+	cmd.DisplayName = updatedCmd.DisplayName
+	cmd.Description = updatedCmd.Description
+	cmd.URL = updatedCmd.URL
+
+	if result := <-Srv.Store.Command().Update(cmd); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		c.LogAudit("success")
+		w.Write([]byte(result.Data.(*model.Command).ToJson()))
+	}
+}
+
 func listTeamCommands(c *Context, w http.ResponseWriter, r *http.Request) {
 	if !*utils.Cfg.ServiceSettings.EnableCommands {
 		c.Err = model.NewLocAppError("listTeamCommands", "api.command.disabled.app_error", nil, "")
@@ -0,0 +1,291 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	COMMAND_SUGGESTIONS_MAX = 25
+)
+
+// autocompleteListTimeout is how long to wait for a command to return the items of a dynamic list,
+// suggestions are requested as the user types so a slow command must not hold them up.
+var autocompleteListTimeout = 2 * time.Second
+
+type suggestionsBySuggestion []*model.SuggestCommand
+
+func (s suggestionsBySuggestion) Len() int           { return len(s) }
+func (s suggestionsBySuggestion) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s suggestionsBySuggestion) Less(i, j int) bool { return s[i].Suggestion < s[j].Suggestion }
+
+// suggestCommands returns the suggestions for the last word of a partially typed command. The
+// command isn't trimmed since a trailing space means the previous word is complete.
+func suggestCommands(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+	command := strings.TrimLeft(props["command"], " ")
+	channelId := strings.TrimSpace(props["channelId"])
+
+	if len(command) == 0 || strings.Index(command, "/") != 0 {
+		c.Err = model.NewLocAppError("suggestCommands", "api.command.suggest_commands.start.app_error", nil, "")
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	if len(channelId) > 0 {
+		cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+
+		if !c.HasPermissionsToChannel(cchan, "suggestCommands") {
+			return
+		}
+	}
+
+	commands, err := getAutocompleteCommands(c)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	suggestions := []*model.SuggestCommand{}
+	trigger, rest, typed := nextAutocompleteWord(command[1:])
+
+	if !typed {
+		for _, cmd := range commands {
+			if strings.HasPrefix(cmd.Trigger, trigger) {
+				suggestions = append(suggestions, &model.SuggestCommand{
+					Complete:    "/" + cmd.Trigger,
+					Suggestion:  "/" + cmd.Trigger,
+					Hint:        cmd.AutoCompleteHint,
+					Description: cmd.AutoCompleteDesc,
+				})
+			}
+		}
+
+		sort.Sort(suggestionsBySuggestion(suggestions))
+	} else {
+		for _, cmd := range commands {
+			if cmd.Trigger == trigger && cmd.AutocompleteData != nil {
+				suggestions = getAutocompleteSuggestions(c, cmd, channelId, cmd.AutocompleteData, "/"+trigger+" ", rest)
+				break
+			}
+		}
+	}
+
+	w.Write([]byte(model.SuggestCommandListToJson(suggestions)))
+}
+
+// nextAutocompleteWord splits the first word off the text. typed is false if the word is still
+// being typed, ie. it isn't followed by a space.
+func nextAutocompleteWord(text string) (word string, rest string, typed bool) {
+	text = strings.TrimLeft(text, " ")
+
+	if i := strings.Index(text, " "); i != -1 {
+		return text[:i], text[i+1:], true
+	}
+
+	return text, "", false
+}
+
+// getAutocompleteSuggestions walks down the subcommands and arguments of data following the words
+// already typed, parsed is the part of the command that has been matched so far.
+func getAutocompleteSuggestions(c *Context, cmd *model.Command, channelId string, data *model.AutocompleteData, parsed string, toComplete string) []*model.SuggestCommand {
+	suggestions := []*model.SuggestCommand{}
+
+	if len(data.SubCommands) > 0 {
+		word, rest, typed := nextAutocompleteWord(toComplete)
+
+		if !typed {
+			for _, sub := range data.SubCommands {
+				if strings.HasPrefix(sub.Trigger, word) {
+					suggestions = append(suggestions, &model.SuggestCommand{
+						Complete:    parsed + sub.Trigger,
+						Suggestion:  sub.Trigger,
+						Hint:        sub.Hint,
+						Description: sub.HelpText,
+					})
+				}
+			}
+
+			return suggestions
+		}
+
+		if sub := data.GetSubCommand(word); sub != nil {
+			return getAutocompleteSuggestions(c, cmd, channelId, sub, parsed+word+" ", rest)
+		}
+
+		return suggestions
+	}
+
+	for i, arg := range data.Arguments {
+		// a trailing text argument takes the rest of the command, like the message of /msg
+		if i == len(data.Arguments)-1 && arg.Type == model.AUTOCOMPLETE_ARG_TYPE_TEXT {
+			toComplete = strings.TrimLeft(toComplete, " ")
+			return append(suggestions, &model.SuggestCommand{
+				Complete:    parsed + toComplete,
+				Hint:        arg.Hint,
+				Description: arg.HelpText,
+			})
+		}
+
+		word, rest, typed := nextAutocompleteWord(toComplete)
+
+		if !typed {
+			return getArgumentSuggestions(c, cmd, channelId, arg, parsed, word)
+		}
+
+		parsed += word + " "
+		toComplete = rest
+	}
+
+	return suggestions
+}
+
+func getArgumentSuggestions(c *Context, cmd *model.Command, channelId string, arg *model.AutocompleteArg, parsed string, word string) []*model.SuggestCommand {
+	suggestions := []*model.SuggestCommand{}
+
+	switch arg.Type {
+	case model.AUTOCOMPLETE_ARG_TYPE_TEXT:
+		suggestions = append(suggestions, &model.SuggestCommand{
+			Complete:    parsed + word,
+			Hint:        arg.Hint,
+			Description: arg.HelpText,
+		})
+
+	case model.AUTOCOMPLETE_ARG_TYPE_STATIC_LIST:
+		suggestions = getListItemSuggestions(arg.Items, parsed, word)
+
+	case model.AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST:
+		if items, err := fetchAutocompleteListItems(c, cmd, channelId, arg, strings.TrimPrefix(parsed, "/"+cmd.Trigger+" ")+word); err != nil {
+			l4g.Error(utils.T("api.command.suggest_commands.dynamic_list.error"), cmd.Trigger, err.Error())
+		} else {
+			suggestions = getListItemSuggestions(items, parsed, word)
+		}
+
+	case model.AUTOCOMPLETE_ARG_TYPE_USER:
+		prefix := strings.ToLower(strings.TrimPrefix(word, "@"))
+
+		if result := <-Srv.Store.User().GetProfiles(c.TeamId); result.Err != nil {
+			l4g.Error(utils.T("api.command.suggest_commands.users.error"), result.Err.Error())
+		} else {
+			for _, user := range result.Data.(map[string]*model.User) {
+				if user.DeleteAt == 0 && strings.HasPrefix(user.Username, prefix) {
+					suggestions = append(suggestions, &model.SuggestCommand{
+						Complete:    parsed + "@" + user.Username,
+						Suggestion:  "@" + user.Username,
+						Description: user.GetFullName(),
+					})
+				}
+			}
+		}
+
+	case model.AUTOCOMPLETE_ARG_TYPE_CHANNEL:
+		prefix := strings.ToLower(strings.TrimPrefix(word, "~"))
+
+		if result := <-Srv.Store.Channel().GetChannels(c.TeamId, c.Session.UserId); result.Err != nil {
+			l4g.Error(utils.T("api.command.suggest_commands.channels.error"), result.Err.Error())
+		} else {
+			for _, channel := range result.Data.(*model.ChannelList).Channels {
+				if channel.Type != model.CHANNEL_DIRECT && strings.HasPrefix(channel.Name, prefix) {
+					suggestions = append(suggestions, &model.SuggestCommand{
+						Complete:    parsed + "~" + channel.Name,
+						Suggestion:  "~" + channel.Name,
+						Description: channel.DisplayName,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Sort(suggestionsBySuggestion(suggestions))
+
+	if len(suggestions) > COMMAND_SUGGESTIONS_MAX {
+		suggestions = suggestions[:COMMAND_SUGGESTIONS_MAX]
+	}
+
+	return suggestions
+}
+
+func getListItemSuggestions(items []*model.AutocompleteListItem, parsed string, word string) []*model.SuggestCommand {
+	suggestions := []*model.SuggestCommand{}
+
+	for _, item := range items {
+		// the items of a dynamic list come from the integration
+		if item != nil && strings.HasPrefix(item.Item, word) {
+			suggestions = append(suggestions, &model.SuggestCommand{
+				Complete:    parsed + item.Item,
+				Suggestion:  item.Item,
+				Hint:        item.Hint,
+				Description: item.HelpText,
+			})
+		}
+	}
+
+	return suggestions
+}
+
+// fetchAutocompleteListItems asks the command's URL for the values of a dynamic list. The request
+// is the one sent when the command is executed with autocomplete and the name of the argument
+// added, the command is expected to answer with a JSON list of items.
+func fetchAutocompleteListItems(c *Context, cmd *model.Command, channelId string, arg *model.AutocompleteArg, text string) ([]*model.AutocompleteListItem, error) {
+	// built-in commands don't have a URL to ask
+	if len(cmd.URL) == 0 {
+		return nil, nil
+	}
+
+	p := url.Values{}
+	p.Set("token", cmd.Token)
+	p.Set("team_id", cmd.TeamId)
+	p.Set("channel_id", channelId)
+	p.Set("user_id", c.Session.UserId)
+	p.Set("command", "/"+cmd.Trigger)
+	p.Set("text", text)
+	p.Set("autocomplete", "true")
+	p.Set("autocomplete_arg", arg.Name)
+
+	var req *http.Request
+	var err error
+	if cmd.Method == model.COMMAND_METHOD_GET {
+		separator := "?"
+		if strings.Contains(cmd.URL, "?") {
+			separator = "&"
+		}
+		req, err = http.NewRequest("GET", cmd.URL+separator+p.Encode(), nil)
+	} else {
+		req, err = http.NewRequest("POST", cmd.URL, strings.NewReader(p.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	client := newIntegrationHttpClient()
+	client.Timeout = autocompleteListTimeout
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+
+	return model.AutocompleteListItemsFromJson(resp.Body), nil
+}
@@ -77,13 +77,46 @@ func (me *LoadTestProvider) GetTrigger() string {
 func (me *LoadTestProvider) GetCommand(c *Context) *model.Command {
 	return &model.Command{
 		Trigger:          CMD_LOADTEST,
-		AutoComplete:     false,
+		AutoComplete:     utils.Cfg.ServiceSettings.EnableTesting,
 		AutoCompleteDesc: "Debug Load Testing",
 		AutoCompleteHint: "help",
+		AutocompleteData: getLoadTestAutocompleteData(),
 		DisplayName:      "loadtest",
 	}
 }
 
+func getLoadTestAutocompleteData() *model.AutocompleteData {
+	data := model.NewAutocompleteData(CMD_LOADTEST, "[command]", "Debug Load Testing")
+
+	setup := model.NewAutocompleteData("setup", "[teams] [fuzz] <Num Channels> <Num Users> <Num Posts>", "Creates a testing environment in current team")
+	setup.AddArgument("options", "[teams] [fuzz] <Num Channels> <Num Users> <Num Posts>", "", model.AUTOCOMPLETE_ARG_TYPE_TEXT)
+	data.AddCommand(setup)
+
+	users := model.NewAutocompleteData("users", "[fuzz] <Min Users> <Max Users>", "Add a specified number of random users to current team")
+	users.AddArgument("options", "[fuzz] <Min Users> <Max Users>", "", model.AUTOCOMPLETE_ARG_TYPE_TEXT)
+	data.AddCommand(users)
+
+	channels := model.NewAutocompleteData("channels", "[fuzz] <Min Channels> <Max Channels>", "Add a specified number of random channels to current team")
+	channels.AddArgument("options", "[fuzz] <Min Channels> <Max Channels>", "", model.AUTOCOMPLETE_ARG_TYPE_TEXT)
+	data.AddCommand(channels)
+
+	posts := model.NewAutocompleteData("posts", "[fuzz] <Min Posts> <Max Posts> <Max Images>", "Add some random posts to current channel")
+	posts.AddArgument("options", "[fuzz] <Min Posts> <Max Posts> <Max Images>", "", model.AUTOCOMPLETE_ARG_TYPE_TEXT)
+	data.AddCommand(posts)
+
+	url := model.NewAutocompleteData("url", "<url>", "Add a post containing the text from a given url to current channel")
+	url.AddArgument("url", "<url>", "", model.AUTOCOMPLETE_ARG_TYPE_TEXT)
+	data.AddCommand(url)
+
+	json := model.NewAutocompleteData("json", "<url>", "Add a post using the JSON file as payload to the current channel")
+	json.AddArgument("url", "<url>", "", model.AUTOCOMPLETE_ARG_TYPE_TEXT)
+	data.AddCommand(json)
+
+	data.AddCommand(model.NewAutocompleteData("help", "", "Show the load testing commands"))
+
+	return data
+}
+
 func (me *LoadTestProvider) DoCommand(c *Context, channelId string, message string) *model.CommandResponse {
 
 	//This command is only available when EnableTesting is true
@@ -33,6 +33,56 @@ func TestLoadTestHelpCommands(t *testing.T) {
 	time.Sleep(2 * time.Second)
 }
 
+func TestLoadTestSuggestCommands(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enableTesting := utils.Cfg.ServiceSettings.EnableTesting
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableTesting = enableTesting
+	}()
+
+	utils.Cfg.ServiceSettings.EnableTesting = false
+
+	suggestions := Client.Must(Client.SuggestCommands("/loadt", "")).Data.([]*model.SuggestCommand)
+	if len(suggestions) != 0 {
+		t.Fatal("shouldn't have suggested /loadtest when testing is disabled", suggestions)
+	}
+
+	utils.Cfg.ServiceSettings.EnableTesting = true
+
+	suggestions = Client.Must(Client.SuggestCommands("/loadt", "")).Data.([]*model.SuggestCommand)
+	if len(suggestions) != 1 || suggestions[0].Complete != "/loadtest" {
+		t.Fatal("should have suggested /loadtest", suggestions)
+	}
+
+	subcommands := []string{"setup", "users", "channels", "posts", "url", "json", "help"}
+
+	suggestions = Client.Must(Client.SuggestCommands("/loadtest ", "")).Data.([]*model.SuggestCommand)
+	if len(suggestions) != len(subcommands) {
+		t.Fatal("should have suggested every subcommand", suggestions)
+	}
+
+	for i, subcommand := range subcommands {
+		if suggestions[i].Complete != "/loadtest "+subcommand {
+			t.Fatal("should have suggested the subcommand", subcommand, suggestions[i])
+		}
+
+		if subcommand == "help" {
+			continue
+		}
+
+		if suggestions := Client.Must(Client.SuggestCommands("/loadtest "+subcommand+" ", "")).Data.([]*model.SuggestCommand); len(suggestions) != 1 || len(suggestions[0].Hint) == 0 {
+			t.Fatal("should have shown the hint of the subcommand's arguments", subcommand, suggestions)
+		}
+	}
+
+	suggestions = Client.Must(Client.SuggestCommands("/loadtest p", "")).Data.([]*model.SuggestCommand)
+	if len(suggestions) != 1 || suggestions[0].Complete != "/loadtest posts" {
+		t.Fatal("should have suggested the matching subcommand", suggestions)
+	}
+}
+
 func TestLoadTestSetupCommands(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
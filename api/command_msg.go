@@ -24,11 +24,16 @@ func (me *msgProvider) GetTrigger() string {
 }
 
 func (me *msgProvider) GetCommand(c *Context) *model.Command {
+	data := model.NewAutocompleteData(CMD_MSG, c.T("api.command_msg.hint"), c.T("api.command_msg.desc"))
+	data.AddArgument("user", "@username", c.T("api.command_msg.autocomplete.user"), model.AUTOCOMPLETE_ARG_TYPE_USER)
+	data.AddArgument("message", c.T("api.command_msg.autocomplete.message_hint"), c.T("api.command_msg.autocomplete.message"), model.AUTOCOMPLETE_ARG_TYPE_TEXT)
+
 	return &model.Command{
 		Trigger:          CMD_MSG,
 		AutoComplete:     true,
 		AutoCompleteDesc: c.T("api.command_msg.desc"),
 		AutoCompleteHint: c.T("api.command_msg.hint"),
+		AutocompleteData: data,
 		DisplayName:      c.T("api.command_msg.name"),
 	}
 }
@@ -4,6 +4,9 @@
 package api
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -130,6 +133,70 @@ func TestRegenToken(t *testing.T) {
 	}
 }
 
+func TestUpdateCommand(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	Client := th.SystemAdminClient
+
+	enableCommands := *utils.Cfg.ServiceSettings.EnableCommands
+	enableAdminOnlyIntegrations := *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableCommands = &enableCommands
+		*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = enableAdminOnlyIntegrations
+	}()
+	*utils.Cfg.ServiceSettings.EnableCommands = true
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = true
+
+	cmd := &model.Command{URL: "http://nowhere.com", Method: model.COMMAND_METHOD_POST, Trigger: "trigger"}
+	cmd = Client.Must(Client.CreateCommand(cmd)).Data.(*model.Command)
+
+	updated := *cmd
+	updated.URL = "http://somewhere.com"
+	updated.Trigger = "other"
+	updated.Token = model.NewId()
+	updated.CreatorId = model.NewId()
+
+	if result, err := Client.UpdateCommand(&updated); err != nil {
+		t.Fatal(err)
+	} else {
+		rcmd := result.Data.(*model.Command)
+
+		if rcmd.URL != updated.URL || rcmd.Trigger != updated.Trigger {
+			t.Fatal("didn't update the command")
+		}
+
+		if rcmd.Token != cmd.Token || rcmd.CreatorId != cmd.CreatorId {
+			t.Fatal("shouldn't have changed the token or the creator")
+		}
+	}
+
+	updated.URL = "junk"
+	if _, err := Client.UpdateCommand(&updated); err == nil {
+		t.Fatal("should have failed - invalid url")
+	}
+
+	updated.URL = "http://somewhere.com"
+	updated.Id = model.NewId()
+	if _, err := Client.UpdateCommand(&updated); err == nil {
+		t.Fatal("should have failed - missing command")
+	}
+
+	updated.Id = cmd.Id
+
+	LinkUserToTeam(th.BasicUser, th.SystemAdminTeam)
+	th.LoginBasic()
+	th.BasicClient.SetTeamId(th.SystemAdminTeam.Id)
+
+	if _, err := th.BasicClient.UpdateCommand(&updated); err == nil {
+		t.Fatal("should have failed - not admin")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = false
+
+	if _, err := th.BasicClient.UpdateCommand(&updated); err == nil {
+		t.Fatal("should have failed - not the creator")
+	}
+}
+
 func TestDeleteCommand(t *testing.T) {
 	th := Setup().InitSystemAdmin()
 	Client := th.SystemAdminClient
@@ -207,3 +274,91 @@ func TestTestCommand(t *testing.T) {
 		t.Fatal("Test command failed to send")
 	}
 }
+
+func TestSuggestCommands(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	Client := th.SystemAdminClient
+	user2 := th.CreateUser(Client)
+	LinkUserToTeam(user2, th.SystemAdminTeam)
+
+	enableCommands := *utils.Cfg.ServiceSettings.EnableCommands
+	listTimeout := autocompleteListTimeout
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableCommands = &enableCommands
+		autocompleteListTimeout = listTimeout
+	}()
+	*utils.Cfg.ServiceSettings.EnableCommands = true
+	autocompleteListTimeout = 100 * time.Millisecond
+
+	if _, err := Client.SuggestCommands("msg", ""); err == nil {
+		t.Fatal("should have failed - not a command")
+	}
+
+	suggestions := Client.Must(Client.SuggestCommands("/ms", "")).Data.([]*model.SuggestCommand)
+	if len(suggestions) == 0 || suggestions[0].Complete != "/msg" {
+		t.Fatal("should have suggested /msg", suggestions)
+	}
+
+	suggestions = Client.Must(Client.SuggestCommands("/msg @"+user2.Username[:5], "")).Data.([]*model.SuggestCommand)
+	if len(suggestions) != 1 || suggestions[0].Complete != "/msg @"+user2.Username {
+		t.Fatal("should have suggested the user", suggestions)
+	}
+
+	suggestions = Client.Must(Client.SuggestCommands("/msg @"+user2.Username+" hello the", "")).Data.([]*model.SuggestCommand)
+	if len(suggestions) != 1 || suggestions[0].Complete != "/msg @"+user2.Username+" hello the" || len(suggestions[0].Hint) == 0 {
+		t.Fatal("should have shown the hint of the message", suggestions)
+	}
+
+	args := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		args <- r.Form.Get("autocomplete_arg") + ":" + r.Form.Get("text")
+
+		if strings.Contains(r.Form.Get("text"), "slow") {
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		w.Write([]byte(`[{"item": "production"}, {"item": "staging"}]`))
+	}))
+	defer server.Close()
+
+	data := model.NewAutocompleteData("deploy", "[command]", "")
+	start := model.NewAutocompleteData("start", "[environment]", "")
+	start.AddArgument("environment", "", "", model.AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST)
+	data.AddCommand(start)
+	stop := model.NewAutocompleteData("stop", "[reason]", "")
+	stop.AddStaticListArgument("reason", "", []*model.AutocompleteListItem{{Item: "done"}, {Item: "failed"}})
+	data.AddCommand(stop)
+
+	cmd := &model.Command{URL: server.URL, Method: model.COMMAND_METHOD_POST, Trigger: "deploy", AutoComplete: true, AutocompleteData: data}
+	Client.Must(Client.CreateCommand(cmd))
+
+	suggestions = Client.Must(Client.SuggestCommands("/deploy st", "")).Data.([]*model.SuggestCommand)
+	if len(suggestions) != 2 || suggestions[0].Complete != "/deploy start" || suggestions[1].Complete != "/deploy stop" {
+		t.Fatal("should have suggested the subcommands", suggestions)
+	}
+
+	suggestions = Client.Must(Client.SuggestCommands("/deploy stop ", "")).Data.([]*model.SuggestCommand)
+	if len(suggestions) != 2 || suggestions[0].Suggestion != "done" {
+		t.Fatal("should have suggested the static list", suggestions)
+	}
+
+	suggestions = Client.Must(Client.SuggestCommands("/deploy start pro", th.SystemAdminChannel.Id)).Data.([]*model.SuggestCommand)
+	if len(suggestions) != 1 || suggestions[0].Complete != "/deploy start production" {
+		t.Fatal("should have suggested the dynamic list", suggestions)
+	}
+
+	if arg := <-args; arg != "environment:start pro" {
+		t.Fatal("should have asked for the argument", arg)
+	}
+
+	suggestions = Client.Must(Client.SuggestCommands("/deploy restart ", "")).Data.([]*model.SuggestCommand)
+	if len(suggestions) != 0 {
+		t.Fatal("shouldn't have suggested anything for an unknown subcommand", suggestions)
+	}
+
+	suggestions = Client.Must(Client.SuggestCommands("/deploy start slow", th.SystemAdminChannel.Id)).Data.([]*model.SuggestCommand)
+	if len(suggestions) != 0 {
+		t.Fatal("shouldn't have waited for a slow command", suggestions)
+	}
+}
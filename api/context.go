@@ -45,43 +45,51 @@ type Context struct {
 }
 
 func ApiAppHandler(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, false, false, true, false, false, false}
+	return &handler{h, false, false, true, false, false, false, ""}
 }
 
 func AppHandler(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, false, false, false, false, false, false}
+	return &handler{h, false, false, false, false, false, false, ""}
 }
 
 func AppHandlerIndependent(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, false, false, false, false, true, false}
+	return &handler{h, false, false, false, false, true, false, ""}
 }
 
 func ApiUserRequired(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, true, false, true, true, false, false}
+	return &handler{h, true, false, true, true, false, false, ""}
 }
 
 func ApiUserRequiredActivity(h func(*Context, http.ResponseWriter, *http.Request), isUserActivity bool) http.Handler {
-	return &handler{h, true, false, true, isUserActivity, false, false}
+	return &handler{h, true, false, true, isUserActivity, false, false, ""}
 }
 
 func UserRequired(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, true, false, false, false, false, false}
+	return &handler{h, true, false, false, false, false, false, ""}
 }
 
 func ApiAdminSystemRequired(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, true, true, true, false, false, false}
+	return &handler{h, true, true, true, false, false, false, model.SCOPE_ADMIN}
 }
 
 func ApiAppHandlerTrustRequester(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, false, false, true, false, false, true}
+	return &handler{h, false, false, true, false, false, true, ""}
 }
 
 func ApiUserRequiredTrustRequester(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, true, false, true, true, false, true}
+	return &handler{h, true, false, true, true, false, true, ""}
 }
 
 func ApiAppHandlerTrustRequesterIndependent(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	return &handler{h, false, false, true, false, true, true}
+	return &handler{h, false, false, true, false, true, true, ""}
+}
+
+// ApiScope declares the OAuth scope a token needs to use the route. OAuth tokens can only be
+// used on routes without a declared scope when they were granted full access.
+func ApiScope(scope string, h http.Handler) http.Handler {
+	scoped := *h.(*handler)
+	scoped.scope = scope
+	return &scoped
 }
 
 type handler struct {
@@ -92,6 +100,7 @@ type handler struct {
 	isUserActivity     bool
 	isTeamIndependent  bool
 	trustRequester     bool
+	scope              string
 }
 
 func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -191,10 +200,25 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		c.SystemAdminRequired()
 	}
 
+	if c.Err == nil && (h.requireUser || h.requireSystemAdmin) {
+		c.MfaRequired(r)
+	}
+
+	// OAuth tokens are checked on every route, including the ones that don't require a user, and
+	// routes without a declared scope need full access
+	if c.Err == nil && c.Session.IsOAuth && !c.Session.HasScope(h.scope) {
+		c.Err = model.NewLocAppError("ServeHTTP", "api.context.oauth_scope.app_error", nil, "required="+h.scope+" granted="+c.Session.Scope)
+		c.Err.StatusCode = http.StatusForbidden
+	}
+
 	if c.Err == nil && len(c.TeamId) > 0 && !h.isTeamIndependent {
 		c.HasPermissionsToTeam(c.TeamId, "TeamRoute")
 	}
 
+	if c.Err == nil && c.Session.IsUserAccessToken() {
+		c.LogAudit("user_access_token_id=" + c.Session.Props[model.SESSION_PROP_USER_ACCESS_TOKEN_ID])
+	}
+
 	if c.Err == nil && h.isUserActivity && token != "" && len(c.Session.UserId) > 0 {
 		go func() {
 			if err := (<-Srv.Store.User().UpdateUserAndSessionActivity(c.Session.UserId, c.Session.Id, model.GetMillis())).Err; err != nil {
@@ -291,6 +315,41 @@ func (c *Context) UserRequired() {
 	}
 }
 
+// MfaRequired keeps a user who has to use MFA but hasn't activated it yet from doing anything
+// other than setting it up. Integrations authenticating with a token aren't affected.
+func (c *Context) MfaRequired(r *http.Request) {
+	enforce := *utils.Cfg.ServiceSettings.EnforceMultifactorAuthentication
+	if enforce == model.MFA_ENFORCE_OFF || !isMfaEnabled() {
+		return
+	}
+
+	if c.Session.IsOAuth || c.Session.IsUserAccessToken() || c.Session.IsBot() {
+		return
+	}
+
+	if r.URL.Path == model.API_URL_SUFFIX+"/users/generate_mfa_qr" || r.URL.Path == model.API_URL_SUFFIX+"/users/update_mfa" {
+		return
+	}
+
+	// avoid looking the user up when the session can't be affected
+	if enforce == model.MFA_ENFORCE_SYSTEM_ADMIN && !c.IsSystemAdmin() {
+		return
+	}
+
+	if _, ok := mfaSatisfiedCache.Get(c.Session.UserId); ok {
+		return
+	}
+
+	if result := <-Srv.Store.User().Get(c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+	} else if user := result.Data.(*model.User); user.MfaActive || isMfaExempt(user) {
+		mfaSatisfiedCache.AddWithExpiresInSecs(user.Id, true, int64(*utils.Cfg.ServiceSettings.SessionCacheInMinutes*60))
+	} else if isMfaEnforced(user) {
+		c.Err = model.NewLocAppError("MfaRequired", "api.context.mfa_required.app_error", nil, "user_id="+user.Id)
+		c.Err.StatusCode = http.StatusForbidden
+	}
+}
+
 func (c *Context) SystemAdminRequired() {
 	if len(c.Session.UserId) == 0 {
 		c.Err = model.NewLocAppError("", "api.context.session_expired.app_error", nil, "SystemAdminRequired")
@@ -560,11 +619,20 @@ func GetSession(token string) *model.Session {
 	var session *model.Session
 	if ts, ok := sessionCache.Get(token); ok {
 		session = ts.(*model.Session)
+
+		// personal access tokens stop working as soon as they're disabled, not when the cache expires
+		if session.IsUserAccessToken() && !*utils.Cfg.ServiceSettings.EnableUserAccessTokens {
+			sessionCache.Remove(token)
+			return nil
+		}
 	}
 
 	if session == nil {
 		if sessionResult := <-Srv.Store.Session().Get(token); sessionResult.Err != nil {
-			l4g.Error(utils.T("api.context.invalid_token.error"), token, sessionResult.Err.DetailedError)
+			// tokens that don't belong to a session may be personal access tokens
+			if session = getSessionForUserAccessToken(token); session == nil {
+				l4g.Error(utils.T("api.context.invalid_token.error"), token, sessionResult.Err.DetailedError)
+			}
 		} else {
 			session = sessionResult.Data.(*model.Session)
 
@@ -594,6 +662,15 @@ func RemoveAllSessionsForUserId(userId string) {
 	}
 }
 
+// RemoveSessionFromCacheById drops the session with the given id from the cache of this server.
+func RemoveSessionFromCacheById(sessionId string) {
+	for _, key := range sessionCache.Keys() {
+		if ts, ok := sessionCache.Get(key); ok && ts.(*model.Session).Id == sessionId {
+			sessionCache.Remove(key)
+		}
+	}
+}
+
 func AddSessionToCache(session *model.Session) {
 	sessionCache.AddWithExpiresInSecs(session.Token, session, int64(*utils.Cfg.ServiceSettings.SessionCacheInMinutes*60))
 }
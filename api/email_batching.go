@@ -0,0 +1,212 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"fmt"
+	"html/template"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	EMAIL_BATCHING_TASK_INTERVAL = 60 * time.Second
+
+	// a notification that still can't be sent after this many attempts is dropped
+	EMAIL_BATCHING_MAX_ATTEMPTS = 5
+)
+
+func StartEmailBatchingJob() {
+	go func() {
+		for {
+			time.Sleep(EMAIL_BATCHING_TASK_INTERVAL)
+			sendBatchedEmailNotifications()
+		}
+	}()
+}
+
+func queueNotificationEmail(c *Context, post *model.Post, user *model.User, team *model.Team, delay int64) {
+	if !utils.Cfg.EmailSettings.SendEmailNotifications {
+		return
+	}
+
+	notification := &model.QueuedNotification{
+		UserId:    user.Id,
+		TeamId:    team.Id,
+		ChannelId: post.ChannelId,
+		PostId:    post.Id,
+		SiteURL:   c.GetSiteURL(),
+		CreateAt:  model.GetMillis(),
+	}
+	notification.SendAt = notification.CreateAt + delay
+
+	if result := <-Srv.Store.NotificationQueue().Save(notification); result.Err != nil {
+		l4g.Error(utils.T("api.email_batching.queue_notification_email.error"), user.Id, post.Id, result.Err)
+	}
+}
+
+func sendBatchedEmailNotifications() {
+	result := <-Srv.Store.NotificationQueue().GetDueUserIds(model.GetMillis())
+	if result.Err != nil {
+		l4g.Error(utils.T("api.email_batching.send_batched_email_notifications.due.error"), result.Err)
+		return
+	}
+
+	for _, userId := range result.Data.([]string) {
+		sendBatchedEmailNotification(userId)
+	}
+}
+
+func sendBatchedEmailNotification(userId string) {
+	uchan := Srv.Store.User().Get(userId)
+
+	var notifications []*model.QueuedNotification
+	if result := <-Srv.Store.NotificationQueue().GetForUser(userId); result.Err != nil {
+		l4g.Error(utils.T("api.email_batching.send_batched_email_notification.queue.error"), userId, result.Err)
+		return
+	} else {
+		notifications = result.Data.([]*model.QueuedNotification)
+	}
+
+	if len(notifications) == 0 {
+		return
+	}
+
+	// Remove the notifications before sending them so that a digest is only
+	// sent once even if several servers run the job at the same time, they're
+	// put back in the queue if the digest can't be sent
+	if result := <-Srv.Store.NotificationQueue().DeleteForUser(userId, notifications[len(notifications)-1].CreateAt); result.Err != nil {
+		l4g.Error(utils.T("api.email_batching.send_batched_email_notification.queue.error"), userId, result.Err)
+		return
+	} else if result.Data.(int64) == 0 {
+		return
+	}
+
+	var user *model.User
+	if result := <-uchan; result.Err != nil {
+		l4g.Error(utils.T("api.email_batching.send_batched_email_notification.user.error"), userId, result.Err)
+		requeueNotificationEmails(notifications)
+		return
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	if user.DeleteAt > 0 || user.NotifyProps["email"] == "false" {
+		return
+	}
+
+	userLocale := utils.GetUserTranslations(user.Locale)
+
+	teams := make(map[string]*model.Team)
+	senders := make(map[string]*model.User)
+
+	var postsHtml string
+	count := 0
+
+	for _, notification := range notifications {
+		var post *model.Post
+		if result := <-Srv.Store.Post().Get(notification.PostId); result.Err != nil {
+			continue
+		} else {
+			list := result.Data.(*model.PostList)
+			post = list.Posts[list.Order[0]]
+		}
+
+		var channel *model.Channel
+		if result := <-Srv.Store.Channel().Get(notification.ChannelId); result.Err != nil {
+			continue
+		} else {
+			channel = result.Data.(*model.Channel)
+		}
+
+		team, ok := teams[notification.TeamId]
+		if !ok {
+			if result := <-Srv.Store.Team().Get(notification.TeamId); result.Err != nil {
+				continue
+			} else {
+				team = result.Data.(*model.Team)
+				teams[team.Id] = team
+			}
+		}
+
+		sender, ok := senders[post.UserId]
+		if !ok {
+			if result := <-Srv.Store.User().Get(post.UserId); result.Err != nil {
+				continue
+			} else {
+				sender = result.Data.(*model.User)
+				senders[sender.Id] = sender
+			}
+		}
+
+		channelName := channel.DisplayName
+		if channel.Type == model.CHANNEL_DIRECT {
+			channelName = userLocale("api.email_batching.send_batched_email_notification.direct_message")
+		}
+
+		tm := time.Unix(post.CreateAt/1000, 0)
+		zone, _ := tm.Zone()
+
+		postPage := utils.NewHTMLTemplate("post_batched_post", user.Locale)
+		postPage.Props["PostMessage"] = getNotificationEmailMessage(post, userLocale)
+		postPage.Props["PostLink"] = notification.SiteURL + "/" + team.Name + "/pl/" + post.Id
+		postPage.Props["Button"] = userLocale("api.email_batching.send_batched_email_notification.button")
+		postPage.Html["Info"] = template.HTML(userLocale("api.templates.post_body.info",
+			map[string]interface{}{"ChannelName": channelName, "SenderName": sender.Username,
+				"Hour": fmt.Sprintf("%02d", tm.Hour()), "Minute": fmt.Sprintf("%02d", tm.Minute()),
+				"TimeZone": zone, "Month": userLocale(tm.Month().String()), "Day": fmt.Sprintf("%d", tm.Day())}))
+
+		postsHtml += postPage.Render()
+		count++
+	}
+
+	if count == 0 {
+		return
+	}
+
+	siteURL := notifications[len(notifications)-1].SiteURL
+	tm := time.Now()
+	month := userLocale(tm.Month().String())
+
+	subjectPage := utils.NewHTMLTemplate("post_subject", user.Locale)
+	subjectPage.Props["Subject"] = userLocale("api.email_batching.send_batched_email_notification.subject",
+		map[string]interface{}{"NotificationCount": count,
+			"Month": month[:3], "Day": fmt.Sprintf("%d", tm.Day()), "Year": fmt.Sprintf("%d", tm.Year())})
+	subjectPage.Props["SiteName"] = utils.Cfg.TeamSettings.SiteName
+
+	bodyPage := utils.NewHTMLTemplate("post_batched_body", user.Locale)
+	bodyPage.Props["SiteURL"] = siteURL
+	bodyPage.Props["BodyText"] = userLocale("api.email_batching.send_batched_email_notification.body_text", map[string]interface{}{"NotificationCount": count})
+	bodyPage.Html["Posts"] = template.HTML(postsHtml)
+
+	if err := utils.SendMail(user.Email, subjectPage.Render(), bodyPage.Render()); err != nil {
+		l4g.Error(utils.T("api.post.send_notifications_and_forget.send.error"), user.Email, err)
+		requeueNotificationEmails(notifications)
+	}
+}
+
+// requeueNotificationEmails puts notifications that couldn't be sent back in the queue so that
+// they're retried later, waiting twice as long after each failed attempt. Notifications that have
+// failed too many times are dropped.
+func requeueNotificationEmails(notifications []*model.QueuedNotification) {
+	now := model.GetMillis()
+
+	for _, notification := range notifications {
+		notification.Attempts++
+
+		if notification.Attempts >= EMAIL_BATCHING_MAX_ATTEMPTS {
+			l4g.Error(utils.T("api.email_batching.requeue_notification_emails.dropped.error"), notification.UserId, notification.PostId, notification.Attempts)
+			continue
+		}
+
+		notification.SendAt = now + int64(EMAIL_BATCHING_TASK_INTERVAL/time.Millisecond)<<uint(notification.Attempts-1)
+
+		if result := <-Srv.Store.NotificationQueue().Save(notification); result.Err != nil {
+			l4g.Error(utils.T("api.email_batching.requeue_notification_emails.error"), notification.UserId, notification.PostId, result.Err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestSendBatchedEmailNotificationFailure(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	sendEmailNotifications := utils.Cfg.EmailSettings.SendEmailNotifications
+	smtpServer := utils.Cfg.EmailSettings.SMTPServer
+	smtpPort := utils.Cfg.EmailSettings.SMTPPort
+	defer func() {
+		utils.Cfg.EmailSettings.SendEmailNotifications = sendEmailNotifications
+		utils.Cfg.EmailSettings.SMTPServer = smtpServer
+		utils.Cfg.EmailSettings.SMTPPort = smtpPort
+	}()
+	utils.Cfg.EmailSettings.SendEmailNotifications = true
+
+	// nothing listens on this port so sending the digest fails
+	utils.Cfg.EmailSettings.SMTPServer = "localhost"
+	utils.Cfg.EmailSettings.SMTPPort = "1"
+
+	post := Client.Must(Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+
+	notification := &model.QueuedNotification{
+		UserId:    th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		ChannelId: post.ChannelId,
+		PostId:    post.Id,
+		SiteURL:   "http://localhost:8065",
+		CreateAt:  model.GetMillis() - 1000,
+	}
+	notification.SendAt = notification.CreateAt
+	store.Must(Srv.Store.NotificationQueue().Save(notification))
+
+	sendBatchedEmailNotification(th.BasicUser.Id)
+
+	notifications := store.Must(Srv.Store.NotificationQueue().GetForUser(th.BasicUser.Id)).([]*model.QueuedNotification)
+	if len(notifications) != 1 || notifications[0].PostId != post.Id {
+		t.Fatal("should have put the notification back in the queue")
+	}
+
+	if notifications[0].SendAt <= model.GetMillis() || notifications[0].Attempts != 1 {
+		t.Fatal("should have delayed the retry")
+	}
+
+	firstSendAt := notifications[0].SendAt
+	store.Must(Srv.Store.NotificationQueue().PermanentDeleteByUser(th.BasicUser.Id))
+
+	notification.SendAt = notification.CreateAt
+	notification.Attempts = 1
+	store.Must(Srv.Store.NotificationQueue().Save(notification))
+
+	sendBatchedEmailNotification(th.BasicUser.Id)
+
+	notifications = store.Must(Srv.Store.NotificationQueue().GetForUser(th.BasicUser.Id)).([]*model.QueuedNotification)
+	if len(notifications) != 1 || notifications[0].Attempts != 2 || notifications[0].SendAt <= firstSendAt {
+		t.Fatal("should have backed off before the next retry")
+	}
+
+	store.Must(Srv.Store.NotificationQueue().PermanentDeleteByUser(th.BasicUser.Id))
+
+	notification.SendAt = notification.CreateAt
+	notification.Attempts = EMAIL_BATCHING_MAX_ATTEMPTS - 1
+	store.Must(Srv.Store.NotificationQueue().Save(notification))
+
+	sendBatchedEmailNotification(th.BasicUser.Id)
+
+	if notifications := store.Must(Srv.Store.NotificationQueue().GetForUser(th.BasicUser.Id)).([]*model.QueuedNotification); len(notifications) != 0 {
+		t.Fatal("should have dropped the notification after too many attempts")
+	}
+
+	store.Must(Srv.Store.NotificationQueue().PermanentDeleteByUser(th.BasicUser.Id))
+}
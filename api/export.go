@@ -220,6 +220,27 @@ func ExportPosts(writer ExportWriter, options *ExportOptions, channelId string)
 		}
 	}
 
+	// Get the reactions
+	var reactions []*model.Reaction
+	if result := <-Srv.Store.Reaction().GetForExport(channelId); result.Err != nil {
+		return result.Err
+	} else {
+		reactions = result.Data.([]*model.Reaction)
+	}
+
+	// Export the reactions
+	if reactionsFile, err := writer.Create(EXPORT_POSTS_FOLDER + "/" + channelId + "_reactions.json"); err != nil {
+		return model.NewLocAppError("ExportPosts", "api.export.open_file.app_error", nil, err.Error())
+	} else {
+		result, err2 := json.Marshal(reactions)
+		if err2 != nil {
+			return model.NewLocAppError("ExportPosts", "api.export.json.app_error", nil, err2.Error())
+		}
+		if _, err3 := reactionsFile.Write([]byte(result)); err3 != nil {
+			return model.NewLocAppError("ExportPosts", "api.export.write_file.app_error", nil, err3.Error())
+		}
+	}
+
 	return nil
 }
 
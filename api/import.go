@@ -19,6 +19,8 @@ func ImportPost(post *model.Post) {
 
 	if result := <-Srv.Store.Post().Save(post); result.Err != nil {
 		l4g.Debug(utils.T("api.import.import_post.saving.debug"), post.UserId, post.Message)
+	} else {
+		IndexPostAndForget(result.Data.(*model.Post))
 	}
 }
 
@@ -4,10 +4,12 @@
 package api
 
 import (
+	"bytes"
 	"crypto/tls"
 	b64 "encoding/base64"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -17,6 +19,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
 )
 
@@ -24,6 +27,13 @@ func InitOAuth() {
 	l4g.Debug(utils.T("api.oauth.init.debug"))
 
 	BaseRoutes.OAuth.Handle("/register", ApiUserRequired(registerOAuthApp)).Methods("POST")
+	BaseRoutes.OAuth.Handle("/list", ApiUserRequired(getOAuthApps)).Methods("GET")
+	BaseRoutes.OAuth.Handle("/app/{client_id:[A-Za-z0-9]+}", ApiUserRequired(getOAuthAppInfo)).Methods("GET")
+	BaseRoutes.OAuth.Handle("/update", ApiUserRequired(updateOAuthApp)).Methods("POST")
+	BaseRoutes.OAuth.Handle("/regen_secret", ApiUserRequired(regenerateOAuthAppSecret)).Methods("POST")
+	BaseRoutes.OAuth.Handle("/delete", ApiUserRequired(deleteOAuthApp)).Methods("POST")
+	BaseRoutes.OAuth.Handle("/authorized", ApiUserRequired(getAuthorizedOAuthApps)).Methods("GET")
+	BaseRoutes.OAuth.Handle("/deauthorize", ApiUserRequired(deauthorizeOAuthApp)).Methods("POST")
 	BaseRoutes.OAuth.Handle("/allow", ApiUserRequired(allowOAuth)).Methods("GET")
 	BaseRoutes.OAuth.Handle("/{service:[A-Za-z]+}/complete", AppHandlerIndependent(completeOAuth)).Methods("GET")
 	BaseRoutes.OAuth.Handle("/{service:[A-Za-z]+}/login", AppHandlerIndependent(loginWithOAuth)).Methods("GET")
@@ -74,6 +84,235 @@ func registerOAuthApp(c *Context, w http.ResponseWriter, r *http.Request) {
 
 }
 
+func getOAuthApps(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		c.Err = model.NewLocAppError("getOAuthApps", "api.oauth.register_oauth_app.turn_off.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	var achan store.StoreChannel
+	if c.IsSystemAdmin() {
+		achan = Srv.Store.OAuth().GetApps()
+	} else {
+		achan = Srv.Store.OAuth().GetAppByUser(c.Session.UserId)
+	}
+
+	if result := <-achan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		apps := result.Data.([]*model.OAuthApp)
+		for _, app := range apps {
+			app.Sanitize()
+		}
+
+		w.Write([]byte(model.OAuthAppListToJson(apps)))
+	}
+}
+
+func getOAuthAppInfo(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		c.Err = model.NewLocAppError("getOAuthAppInfo", "api.oauth.register_oauth_app.turn_off.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	clientId := mux.Vars(r)["client_id"]
+	if len(clientId) != 26 {
+		c.SetInvalidParam("getOAuthAppInfo", "client_id")
+		return
+	}
+
+	if result := <-Srv.Store.OAuth().GetApp(clientId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		app := result.Data.(*model.OAuthApp)
+		app.Sanitize()
+
+		w.Write([]byte(app.ToJson()))
+	}
+}
+
+// getManagedOAuthApp returns the app with the given id if the current user is allowed to change it.
+func getManagedOAuthApp(c *Context, where string, clientId string) *model.OAuthApp {
+	if len(clientId) != 26 {
+		c.SetInvalidParam(where, "id")
+		return nil
+	}
+
+	var app *model.OAuthApp
+	if result := <-Srv.Store.OAuth().GetApp(clientId); result.Err != nil {
+		c.Err = result.Err
+		return nil
+	} else {
+		app = result.Data.(*model.OAuthApp)
+	}
+
+	if app.CreatorId != c.Session.UserId && !c.IsSystemAdmin() {
+		c.LogAudit("fail - inappropriate permissions")
+		c.Err = model.NewLocAppError(where, "api.oauth.manage_app.permissions.app_error", nil, "user_id="+c.Session.UserId)
+		c.Err.StatusCode = http.StatusForbidden
+		return nil
+	}
+
+	return app
+}
+
+func updateOAuthApp(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		c.Err = model.NewLocAppError("updateOAuthApp", "api.oauth.register_oauth_app.turn_off.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	app := model.OAuthAppFromJson(r.Body)
+
+	if app == nil {
+		c.SetInvalidParam("updateOAuthApp", "app")
+		return
+	}
+
+	c.LogAudit("attempt")
+
+	if getManagedOAuthApp(c, "updateOAuthApp", app.Id) == nil {
+		return
+	}
+
+	if result := <-Srv.Store.OAuth().UpdateApp(app); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		app = result.Data.([2]*model.OAuthApp)[0]
+		app.Sanitize()
+
+		c.LogAudit("client_id=" + app.Id)
+
+		w.Write([]byte(app.ToJson()))
+	}
+}
+
+func regenerateOAuthAppSecret(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		c.Err = model.NewLocAppError("regenerateOAuthAppSecret", "api.oauth.register_oauth_app.turn_off.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	c.LogAudit("attempt")
+
+	props := model.MapFromJson(r.Body)
+
+	app := getManagedOAuthApp(c, "regenerateOAuthAppSecret", props["id"])
+	if app == nil {
+		return
+	}
+
+	secret := model.NewId()
+
+	if result := <-Srv.Store.OAuth().UpdateAppSecret(app.Id, model.HashPassword(secret)); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	app.ClientSecret = secret
+
+	c.LogAudit("client_id=" + app.Id)
+
+	w.Write([]byte(app.ToJson()))
+}
+
+func deleteOAuthApp(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		c.Err = model.NewLocAppError("deleteOAuthApp", "api.oauth.register_oauth_app.turn_off.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	c.LogAudit("attempt")
+
+	props := model.MapFromJson(r.Body)
+
+	app := getManagedOAuthApp(c, "deleteOAuthApp", props["id"])
+	if app == nil {
+		return
+	}
+
+	if c.Err = revokeOAuthAppTokens(app.Id, ""); c.Err != nil {
+		return
+	}
+
+	if result := <-Srv.Store.OAuth().DeleteApp(app.Id); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	c.LogAudit("client_id=" + app.Id)
+
+	w.Write([]byte(model.MapToJson(props)))
+}
+
+func getAuthorizedOAuthApps(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		c.Err = model.NewLocAppError("getAuthorizedOAuthApps", "api.oauth.register_oauth_app.turn_off.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	if result := <-Srv.Store.OAuth().GetAuthorizedApps(c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		apps := result.Data.([]*model.OAuthApp)
+		for _, app := range apps {
+			app.Sanitize()
+		}
+
+		w.Write([]byte(model.OAuthAppListToJson(apps)))
+	}
+}
+
+func deauthorizeOAuthApp(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		c.Err = model.NewLocAppError("deauthorizeOAuthApp", "api.oauth.register_oauth_app.turn_off.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	props := model.MapFromJson(r.Body)
+
+	clientId := props["id"]
+	if len(clientId) != 26 {
+		c.SetInvalidParam("deauthorizeOAuthApp", "id")
+		return
+	}
+
+	if c.Err = revokeOAuthAppTokens(clientId, c.Session.UserId); c.Err != nil {
+		return
+	}
+
+	c.LogAudit("client_id=" + clientId)
+
+	w.Write([]byte(model.MapToJson(props)))
+}
+
+// revokeOAuthAppTokens revokes the access tokens issued to an app, only the ones granted
+// by the given user when userId isn't empty.
+func revokeOAuthAppTokens(clientId string, userId string) *model.AppError {
+	if result := <-Srv.Store.OAuth().GetAccessDataByApp(clientId, userId); result.Err != nil {
+		return result.Err
+	} else {
+		for _, accessData := range result.Data.([]*model.AccessData) {
+			if err := RevokeAccessToken(accessData.Token); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func allowOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
 		c.Err = model.NewLocAppError("allowOAuth", "api.oauth.allow_oauth.turn_off.app_error", nil, "")
@@ -127,6 +366,14 @@ func allowOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	scope, ok := model.NormalizeScope(scope)
+	if !ok {
+		c.LogAudit("fail - unknown scope requested")
+		responseData["redirect"] = redirectUri + "?error=invalid_scope&state=" + state
+		w.Write([]byte(model.MapToJson(responseData)))
+		return
+	}
+
 	authData := &model.AuthData{UserId: c.Session.UserId, ClientId: clientId, CreateAt: model.GetMillis(), RedirectUri: redirectUri, State: state, Scope: scope}
 	authData.Code = model.HashPassword(fmt.Sprintf("%v:%v:%v:%v", clientId, redirectUri, authData.CreateAt, c.Session.UserId))
 
@@ -155,7 +402,8 @@ func RevokeAccessToken(token string) *model.AppError {
 		accessData = result.Data.(*model.AccessData)
 	}
 
-	tchan := Srv.Store.OAuth().RemoveAccessData(token)
+	// also clean up the rotated access data issued from the same authorization
+	tchan := Srv.Store.OAuth().RemoveAccessDataFamily(accessData.AuthCode)
 	cchan := Srv.Store.OAuth().RemoveAuthData(accessData.AuthCode)
 
 	if result := <-tchan; result.Err != nil {
@@ -291,7 +539,7 @@ func getAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 
 	grantType := r.FormValue("grant_type")
-	if grantType != model.ACCESS_TOKEN_GRANT_TYPE {
+	if grantType != model.ACCESS_TOKEN_GRANT_TYPE && grantType != model.REFRESH_TOKEN_GRANT_TYPE {
 		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.bad_grant.app_error", nil, "")
 		return
 	}
@@ -308,6 +556,11 @@ func getAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if grantType == model.REFRESH_TOKEN_GRANT_TYPE {
+		refreshAccessToken(c, w, clientId, secret, r.FormValue("refresh_token"))
+		return
+	}
+
 	code := r.FormValue("code")
 	if len(code) == 0 {
 		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.missing_code.app_error", nil, "")
@@ -390,7 +643,14 @@ func getAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 		user = result.Data.(*model.User)
 	}
 
-	session := &model.Session{UserId: user.Id, Roles: user.Roles, IsOAuth: true}
+	if user.DeleteAt > 0 {
+		c.LogAuditWithUserId(user.Id, "fail - user is deactivated")
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.user_deactivated.app_error", nil, "")
+		return
+	}
+
+	session := &model.Session{UserId: user.Id, Roles: user.Roles, IsOAuth: true, Scope: authData.Scope}
+	session.SetExpireInDays(*utils.Cfg.ServiceSettings.SessionLengthSSOInDays)
 
 	if result := <-Srv.Store.Session().Save(session); result.Err != nil {
 		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.internal_session.app_error", nil, "")
@@ -400,7 +660,7 @@ func getAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 		AddSessionToCache(session)
 	}
 
-	accessData := &model.AccessData{AuthCode: authData.Code, Token: session.Token, RedirectUri: callback}
+	accessData := &model.AccessData{ClientId: clientId, UserId: user.Id, AuthCode: authData.Code, Token: session.Token, RefreshToken: model.NewId(), RedirectUri: callback, Scope: authData.Scope}
 
 	if result := <-Srv.Store.OAuth().SaveAccessData(accessData); result.Err != nil {
 		l4g.Error(result.Err)
@@ -408,7 +668,7 @@ func getAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessRsp := &model.AccessResponse{AccessToken: session.Token, TokenType: model.ACCESS_TOKEN_TYPE, ExpiresIn: int32(*utils.Cfg.ServiceSettings.SessionLengthSSOInDays * 60 * 60 * 24)}
+	accessRsp := &model.AccessResponse{AccessToken: session.Token, TokenType: model.ACCESS_TOKEN_TYPE, ExpiresIn: int32(*utils.Cfg.ServiceSettings.SessionLengthSSOInDays * 60 * 60 * 24), Scope: accessData.Scope, RefreshToken: accessData.RefreshToken}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "no-store")
@@ -419,6 +679,151 @@ func getAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(accessRsp.ToJson()))
 }
 
+// refreshAccessToken exchanges a refresh token for a new access token and session, rotating the
+// refresh token at the same time. Presenting a refresh token that has already been exchanged means
+// it has leaked, so every token issued from the same authorization is revoked.
+func refreshAccessToken(c *Context, w http.ResponseWriter, clientId string, secret string, refreshToken string) {
+	if len(refreshToken) != 26 {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.missing_refresh_token.app_error", nil, "")
+		return
+	}
+
+	achan := Srv.Store.OAuth().GetApp(clientId)
+	tchan := Srv.Store.OAuth().GetAccessDataByRefreshToken(refreshToken)
+
+	var app *model.OAuthApp
+	if result := <-achan; result.Err != nil {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.credentials.app_error", nil, "")
+		return
+	} else {
+		app = result.Data.(*model.OAuthApp)
+	}
+
+	if !model.ComparePassword(app.ClientSecret, secret) {
+		c.LogAudit("fail - invalid client credentials")
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.credentials.app_error", nil, "")
+		return
+	}
+
+	var oldAccessData *model.AccessData
+	if result := <-tchan; result.Err != nil {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.internal.app_error", nil, "")
+		return
+	} else if result.Data == nil {
+		c.LogAudit("fail - invalid refresh token")
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.refresh_token.app_error", nil, "")
+		return
+	} else {
+		oldAccessData = result.Data.(*model.AccessData)
+	}
+
+	if oldAccessData.ClientId != clientId {
+		c.LogAudit("fail - refresh token was issued to another client")
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.refresh_token.app_error", nil, "")
+		return
+	}
+
+	if oldAccessData.IsRotated() {
+		c.LogAuditWithUserId(oldAccessData.UserId, "fail - refresh token has been used previously")
+		revokeRefreshTokenFamily(oldAccessData.AuthCode)
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.refresh_token_reused.app_error", nil, "")
+		return
+	}
+
+	var user *model.User
+	if result := <-Srv.Store.User().Get(oldAccessData.UserId); result.Err != nil {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.internal_user.app_error", nil, "")
+		return
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	// a deactivated user keeps no access through the app
+	if user.DeleteAt > 0 {
+		c.LogAuditWithUserId(user.Id, "fail - user is deactivated")
+		revokeRefreshTokenFamily(oldAccessData.AuthCode)
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.user_deactivated.app_error", nil, "")
+		return
+	}
+
+	session := &model.Session{UserId: user.Id, Roles: user.Roles, IsOAuth: true, Scope: oldAccessData.Scope}
+	session.SetExpireInDays(*utils.Cfg.ServiceSettings.SessionLengthSSOInDays)
+
+	if result := <-Srv.Store.Session().Save(session); result.Err != nil {
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.internal_session.app_error", nil, "")
+		return
+	} else {
+		session = result.Data.(*model.Session)
+	}
+
+	accessData := &model.AccessData{
+		ClientId:     clientId,
+		UserId:       user.Id,
+		AuthCode:     oldAccessData.AuthCode,
+		Token:        session.Token,
+		RefreshToken: model.NewId(),
+		RedirectUri:  oldAccessData.RedirectUri,
+		Scope:        oldAccessData.Scope,
+	}
+
+	if result := <-Srv.Store.OAuth().RotateAccessData(oldAccessData.Token, accessData); result.Err != nil {
+		<-Srv.Store.Session().Remove(session.Token)
+
+		// another request exchanged the same refresh token first
+		if result.Err.Id == "store.sql_oauth.rotate_access_data.rotated.app_error" {
+			c.LogAuditWithUserId(user.Id, "fail - refresh token has been used previously")
+			revokeRefreshTokenFamily(oldAccessData.AuthCode)
+			c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.refresh_token_reused.app_error", nil, "")
+			return
+		}
+
+		l4g.Error(result.Err)
+		c.Err = model.NewLocAppError("getAccessToken", "web.get_access_token.internal_saving.app_error", nil, "")
+		return
+	}
+
+	AddSessionToCache(session)
+
+	// the access token that was refreshed is no longer valid
+	if result := <-Srv.Store.Session().Remove(oldAccessData.Token); result.Err != nil {
+		l4g.Error(utils.T("web.get_access_token.revoking.error") + result.Err.Message)
+	}
+	sessionCache.Remove(oldAccessData.Token)
+
+	accessRsp := &model.AccessResponse{AccessToken: session.Token, TokenType: model.ACCESS_TOKEN_TYPE, ExpiresIn: int32(*utils.Cfg.ServiceSettings.SessionLengthSSOInDays * 60 * 60 * 24), Scope: accessData.Scope, RefreshToken: accessData.RefreshToken}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	c.LogAuditWithUserId(user.Id, "success - refreshed")
+
+	w.Write([]byte(accessRsp.ToJson()))
+}
+
+// revokeRefreshTokenFamily revokes every access token and session issued from an authorization code.
+func revokeRefreshTokenFamily(authCode string) {
+	if result := <-Srv.Store.OAuth().GetAccessDataFamily(authCode); result.Err != nil {
+		l4g.Error(utils.T("web.get_access_token.revoking.error") + result.Err.Message)
+		return
+	} else {
+		for _, accessData := range result.Data.([]*model.AccessData) {
+			if sresult := <-Srv.Store.Session().Remove(accessData.Token); sresult.Err != nil {
+				l4g.Error(utils.T("web.get_access_token.revoking.error") + sresult.Err.Message)
+			}
+			sessionCache.Remove(accessData.Token)
+		}
+	}
+
+	if result := <-Srv.Store.OAuth().RemoveAccessDataFamily(authCode); result.Err != nil {
+		l4g.Error(utils.T("web.get_access_token.revoking.error") + result.Err.Message)
+	}
+
+	if result := <-Srv.Store.OAuth().RemoveAuthData(authCode); result.Err != nil {
+		l4g.Error(utils.T("web.get_access_token.revoking.error") + result.Err.Message)
+	}
+}
+
 func loginWithOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	service := params["service"]
@@ -489,10 +894,27 @@ func signupWithOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// getSSOService returns the settings of an enabled SSO service, or nil if it isn't enabled.
+// OpenID Connect providers fill in the endpoints that were left blank through discovery.
+func getSSOService(service string) (*model.SSOSettings, *model.AppError) {
+	sso := utils.Cfg.GetSSOService(service)
+	if sso == nil || !sso.Enable {
+		return nil, nil
+	}
+
+	if provider, ok := einterfaces.GetOauthProvider(service).(einterfaces.OpenIdConnectProvider); ok {
+		return provider.GetSSOSettings()
+	}
+
+	return sso, nil
+}
+
 func GetAuthorizationCode(c *Context, service string, props map[string]string, loginHint string) (string, *model.AppError) {
 
-	sso := utils.Cfg.GetSSOService(service)
-	if sso != nil && !sso.Enable {
+	sso, err := getSSOService(service)
+	if err != nil {
+		return "", err
+	} else if sso == nil {
 		return "", model.NewLocAppError("GetAuthorizationCode", "api.user.get_authorization_code.unsupported.app_error", nil, "service="+service)
 	}
 
@@ -519,8 +941,10 @@ func GetAuthorizationCode(c *Context, service string, props map[string]string, l
 }
 
 func AuthorizeOAuthUser(service, code, state, redirectUri string) (io.ReadCloser, string, map[string]string, *model.AppError) {
-	sso := utils.Cfg.GetSSOService(service)
-	if sso == nil || !sso.Enable {
+	sso, appErr := getSSOService(service)
+	if appErr != nil {
+		return nil, "", nil, appErr
+	} else if sso == nil {
 		return nil, "", nil, model.NewLocAppError("AuthorizeOAuthUser", "api.user.authorize_oauth_user.unsupported.app_error", nil, "service="+service)
 	}
 
@@ -573,21 +997,44 @@ func AuthorizeOAuthUser(service, code, state, redirectUri string) (io.ReadCloser
 		return nil, "", nil, model.NewLocAppError("AuthorizeOAuthUser", "api.user.authorize_oauth_user.missing.app_error", nil, "")
 	}
 
-	p = url.Values{}
-	p.Set("access_token", ar.AccessToken)
-	req, _ = http.NewRequest("GET", sso.UserApiEndpoint, strings.NewReader(""))
+	oidc, isOpenId := einterfaces.GetOauthProvider(service).(einterfaces.OpenIdConnectProvider)
+	if isOpenId && len(ar.IdToken) == 0 {
+		return nil, "", nil, model.NewLocAppError("AuthorizeOAuthUser", "api.user.authorize_oauth_user.missing_id_token.app_error", nil, "")
+	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Bearer "+ar.AccessToken)
+	var userInfo io.ReadCloser
 
-	if resp, err := client.Do(req); err != nil {
-		return nil, "", nil, model.NewLocAppError("AuthorizeOAuthUser", "api.user.authorize_oauth_user.service.app_error",
-			map[string]interface{}{"Service": service}, err.Error())
-	} else {
-		return resp.Body, teamId, stateProps, nil
+	// OpenID Connect users are identified by their ID token, the userinfo endpoint is optional
+	if !isOpenId || len(sso.UserApiEndpoint) > 0 {
+		p = url.Values{}
+		p.Set("access_token", ar.AccessToken)
+		req, _ = http.NewRequest("GET", sso.UserApiEndpoint, strings.NewReader(""))
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+ar.AccessToken)
+
+		if resp, err := client.Do(req); err != nil {
+			return nil, "", nil, model.NewLocAppError("AuthorizeOAuthUser", "api.user.authorize_oauth_user.service.app_error",
+				map[string]interface{}{"Service": service}, err.Error())
+		} else {
+			userInfo = resp.Body
+		}
+	}
+
+	if !isOpenId {
+		return userInfo, teamId, stateProps, nil
 	}
 
+	if userInfo != nil {
+		defer userInfo.Close()
+	}
+
+	if data, err := oidc.GetUserDataFromIdToken(ar.IdToken, userInfo); err != nil {
+		return nil, "", nil, err
+	} else {
+		return ioutil.NopCloser(bytes.NewReader(data)), teamId, stateProps, nil
+	}
 }
 
 func CompleteSwitchWithOAuth(c *Context, w http.ResponseWriter, r *http.Request, service string, userData io.ReadCloser, email string) {
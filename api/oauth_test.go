@@ -139,3 +139,99 @@ func TestAllowOAuth(t *testing.T) {
 		}
 	}
 }
+
+func TestOAuthAppManagement(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	Client := th.BasicClient
+
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		if _, err := Client.ListOAuthApps(); err == nil {
+			t.Fatal("should have failed - oauth service providing turned off")
+		}
+		return
+	}
+
+	app := &model.OAuthApp{Name: "TestApp" + model.NewId(), Homepage: "https://nowhere.com", Description: "test", CallbackUrls: []string{"https://nowhere.com"}}
+	app = Client.Must(Client.RegisterApp(app)).Data.(*model.OAuthApp)
+
+	if apps := Client.Must(Client.ListOAuthApps()).Data.([]*model.OAuthApp); len(apps) != 1 || apps[0].Id != app.Id || len(apps[0].ClientSecret) != 0 {
+		t.Fatal("should have listed the user's app without its secret")
+	}
+
+	if apps := th.SystemAdminClient.Must(th.SystemAdminClient.ListOAuthApps()).Data.([]*model.OAuthApp); len(apps) == 0 {
+		t.Fatal("should have listed every app for a system admin")
+	}
+
+	th.LoginBasic2()
+
+	if rapp := Client.Must(Client.GetOAuthAppInfo(app.Id)).Data.(*model.OAuthApp); rapp.Name != app.Name || len(rapp.ClientSecret) != 0 {
+		t.Fatal("should have returned the app info without its secret")
+	}
+
+	app.Name = "NewName"
+	if _, err := Client.UpdateOAuthApp(app); err == nil {
+		t.Fatal("should have failed - not the creator of the app")
+	}
+
+	if _, err := Client.RegenerateOAuthAppSecret(app.Id); err == nil {
+		t.Fatal("should have failed - not the creator of the app")
+	}
+
+	if _, err := Client.DeleteOAuthApp(app.Id); err == nil {
+		t.Fatal("should have failed - not the creator of the app")
+	}
+
+	th.LoginBasic()
+
+	if rapp := Client.Must(Client.UpdateOAuthApp(app)).Data.(*model.OAuthApp); rapp.Name != "NewName" {
+		t.Fatal("should have updated the app")
+	}
+
+	if rapp := Client.Must(Client.RegenerateOAuthAppSecret(app.Id)).Data.(*model.OAuthApp); len(rapp.ClientSecret) != 26 || rapp.ClientSecret == app.ClientSecret {
+		t.Fatal("should have returned a new secret")
+	}
+
+	Client.Must(Client.DeleteOAuthApp(app.Id))
+
+	if _, err := Client.GetOAuthAppInfo(app.Id); err == nil {
+		t.Fatal("should have deleted the app")
+	}
+}
+
+func TestOAuthAuthorizedApps(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		return
+	}
+
+	app := &model.OAuthApp{Name: "TestApp" + model.NewId(), Homepage: "https://nowhere.com", Description: "test", CallbackUrls: []string{"https://nowhere.com"}}
+	app = Client.Must(Client.RegisterApp(app)).Data.(*model.OAuthApp)
+
+	redirect := Client.Must(Client.AllowOAuth(model.AUTHCODE_RESPONSE_TYPE, app.Id, app.CallbackUrls[0], "all", "123")).Data.(map[string]string)["redirect"]
+	rurl, _ := url.Parse(redirect)
+
+	data := url.Values{"grant_type": []string{model.ACCESS_TOKEN_GRANT_TYPE}, "client_id": []string{app.Id}, "client_secret": []string{app.ClientSecret}, "code": []string{rurl.Query().Get("code")}, "redirect_uri": []string{app.CallbackUrls[0]}}
+	rsp := Client.Must(Client.GetAccessToken(data)).Data.(*model.AccessResponse)
+
+	if apps := Client.Must(Client.GetAuthorizedOAuthApps()).Data.([]*model.OAuthApp); len(apps) != 1 || apps[0].Id != app.Id {
+		t.Fatal("should have listed the authorized app")
+	}
+
+	oauthClient := model.NewClient(Client.Url)
+	oauthClient.SetOAuthToken(rsp.AccessToken)
+	if _, err := oauthClient.GetAllPreferences(); err != nil {
+		t.Fatal(err)
+	}
+
+	Client.Must(Client.DeauthorizeOAuthApp(app.Id))
+
+	if apps := Client.Must(Client.GetAuthorizedOAuthApps()).Data.([]*model.OAuthApp); len(apps) != 0 {
+		t.Fatal("should have removed the app from the authorized apps")
+	}
+
+	if _, err := oauthClient.GetAllPreferences(); err == nil {
+		t.Fatal("should have revoked the access token")
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"fmt"
 	l4g "github.com/alecthomas/log4go"
 	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
+	goi18n "github.com/nicksnyder/go-i18n/i18n"
 	"html/template"
 	"net/http"
 	"net/url"
@@ -22,22 +24,38 @@ import (
 	"time"
 )
 
+const (
+	REINDEX_BATCH_SIZE = 1000
+)
+
 func InitPost() {
 	l4g.Debug(utils.T("api.post.init.debug"))
 
-	BaseRoutes.NeedTeam.Handle("/posts/search", ApiUserRequired(searchPosts)).Methods("POST")
-	BaseRoutes.NeedTeam.Handle("/posts/{post_id}", ApiUserRequired(getPostById)).Methods("GET")
-	BaseRoutes.NeedTeam.Handle("/pltmp/{post_id}", ApiUserRequired(getPermalinkTmp)).Methods("GET")
-
-	BaseRoutes.Posts.Handle("/create", ApiUserRequired(createPost)).Methods("POST")
-	BaseRoutes.Posts.Handle("/update", ApiUserRequired(updatePost)).Methods("POST")
-	BaseRoutes.Posts.Handle("/page/{offset:[0-9]+}/{limit:[0-9]+}", ApiUserRequiredActivity(getPosts, false)).Methods("GET")
-	BaseRoutes.Posts.Handle("/since/{time:[0-9]+}", ApiUserRequiredActivity(getPostsSince, false)).Methods("GET")
-
-	BaseRoutes.NeedPost.Handle("/get", ApiUserRequired(getPost)).Methods("GET")
-	BaseRoutes.NeedPost.Handle("/delete", ApiUserRequired(deletePost)).Methods("POST")
-	BaseRoutes.NeedPost.Handle("/before/{offset:[0-9]+}/{num_posts:[0-9]+}", ApiUserRequired(getPostsBefore)).Methods("GET")
-	BaseRoutes.NeedPost.Handle("/after/{offset:[0-9]+}/{num_posts:[0-9]+}", ApiUserRequired(getPostsAfter)).Methods("GET")
+	BaseRoutes.NeedTeam.Handle("/posts/search", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(searchPosts))).Methods("POST")
+	BaseRoutes.NeedTeam.Handle("/posts/flagged/{offset:[0-9]+}/{limit:[0-9]+}", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getFlaggedPosts))).Methods("GET")
+	BaseRoutes.NeedTeam.Handle("/posts/{post_id}", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getPostById))).Methods("GET")
+	BaseRoutes.NeedTeam.Handle("/posts/{post_id}/thread", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getPostThread))).Methods("GET")
+	BaseRoutes.NeedTeam.Handle("/posts/{post_id}/history", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getPostEditHistory))).Methods("GET")
+	BaseRoutes.NeedTeam.Handle("/threads/followed", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getFollowedThreads))).Methods("GET")
+	BaseRoutes.NeedTeam.Handle("/pltmp/{post_id}", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getPermalinkTmp))).Methods("GET")
+
+	BaseRoutes.Posts.Handle("/create", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(createPost))).Methods("POST")
+	BaseRoutes.Posts.Handle("/update", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(updatePost))).Methods("POST")
+	BaseRoutes.Posts.Handle("/page/{offset:[0-9]+}/{limit:[0-9]+}", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequiredActivity(getPosts, false))).Methods("GET")
+	BaseRoutes.Posts.Handle("/since/{time:[0-9]+}", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequiredActivity(getPostsSince, false))).Methods("GET")
+	BaseRoutes.Posts.Handle("/cursor/{limit:[0-9]+}", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequiredActivity(getPostsByCursor, false))).Methods("GET")
+
+	BaseRoutes.NeedPost.Handle("/get", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getPost))).Methods("GET")
+	BaseRoutes.NeedPost.Handle("/delete", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(deletePost))).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/before/{offset:[0-9]+}/{num_posts:[0-9]+}", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getPostsBefore))).Methods("GET")
+	BaseRoutes.NeedPost.Handle("/after/{offset:[0-9]+}/{num_posts:[0-9]+}", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getPostsAfter))).Methods("GET")
+	BaseRoutes.NeedPost.Handle("/cursor/{direction:before|after|around}/{limit:[0-9]+}", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(getPostsByCursor))).Methods("GET")
+	BaseRoutes.NeedPost.Handle("/pin", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(pinPost))).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/unpin", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(unpinPost))).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/follow", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(followThread))).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/unfollow", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(unfollowThread))).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/thread/viewed", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(viewThread))).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/actions/{action_id:[A-Za-z0-9_-]+}", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(doPostAction))).Methods("POST")
 }
 
 func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -54,6 +72,9 @@ func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// only integrations can add actions to their posts
+	post.StripActions()
+
 	if rp, err := CreatePost(c, post, true); err != nil {
 		c.Err = err
 
@@ -69,7 +90,7 @@ func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
 			l4g.Error(utils.T("api.post.create_post.last_viewed.error"), post.ChannelId, c.Session.UserId, result.Err)
 		}
 
-		w.Write([]byte(rp.ToJson()))
+		w.Write([]byte(rp.WithoutActionIntegrations().ToJson()))
 	}
 }
 
@@ -108,6 +129,13 @@ func CreatePost(c *Context, post *model.Post, triggerWebhooks bool) (*model.Post
 
 	post.UserId = c.Session.UserId
 
+	// only posts made through a bot's token are marked as coming from a bot
+	if c.Session.IsBot() {
+		post.AddProp(model.POST_PROP_FROM_BOT, "true")
+	} else if post.Props != nil {
+		delete(post.Props, model.POST_PROP_FROM_BOT)
+	}
+
 	if len(post.Filenames) > 0 {
 		doRemove := false
 		for i := len(post.Filenames) - 1; i >= 0; i-- {
@@ -148,7 +176,7 @@ func CreatePost(c *Context, post *model.Post, triggerWebhooks bool) (*model.Post
 		rpost = result.Data.(*model.Post)
 
 		handlePostEventsAndForget(c, rpost, triggerWebhooks)
-
+		IndexPostAndForget(rpost)
 	}
 
 	return rpost, nil
@@ -220,6 +248,8 @@ func CreateWebhookPost(c *Context, channelId, text, overrideUsername, overrideIc
 		}
 	}
 
+	post.PrepareActions()
+
 	if _, err := CreatePost(c, post, false); err != nil {
 		return nil, model.NewLocAppError("CreateWebhookPost", "api.post.create_webhook_post.creating.app_error", nil, "err="+err.Message)
 	}
@@ -360,10 +390,6 @@ func handleWebhookEventsAndForget(c *Context, post *model.Post, team *model.Team
 			return
 		}
 
-		if channel.Type != model.CHANNEL_OPEN {
-			return
-		}
-
 		hchan := Srv.Store.Webhook().GetOutgoingByTeam(c.TeamId)
 
 		hooks := []*model.OutgoingWebhook{}
@@ -387,93 +413,53 @@ func handleWebhookEventsAndForget(c *Context, post *model.Post, team *model.Team
 
 		firstWord := splitWords[0]
 
-		relevantHooks := []*model.OutgoingWebhook{}
-
 		for _, hook := range hooks {
-			if hook.ChannelId == post.ChannelId {
-				if len(hook.TriggerWords) == 0 || hook.HasTriggerWord(firstWord) {
-					relevantHooks = append(relevantHooks, hook)
-				}
-			} else if len(hook.ChannelId) == 0 && hook.HasTriggerWord(firstWord) {
-				relevantHooks = append(relevantHooks, hook)
-			}
-		}
+			triggerWord := ""
 
-		for _, hook := range relevantHooks {
-			go func(hook *model.OutgoingWebhook) {
-				p := url.Values{}
-				p.Set("token", hook.Token)
-
-				p.Set("team_id", hook.TeamId)
-				p.Set("team_domain", team.Name)
-
-				p.Set("channel_id", post.ChannelId)
-				p.Set("channel_name", channel.Name)
-
-				p.Set("timestamp", strconv.FormatInt(post.CreateAt/1000, 10))
-
-				p.Set("user_id", post.UserId)
-				p.Set("user_name", user.Username)
+			if hook.ChannelId == post.ChannelId && len(hook.TriggerWords) == 0 {
+				triggerWord = firstWord
+			} else if hook.ChannelId == post.ChannelId || len(hook.ChannelId) == 0 {
+				triggerWord = hook.GetTriggerWord(post.Message)
+			}
 
-				p.Set("text", post.Message)
-				p.Set("trigger_word", firstWord)
+			if len(triggerWord) == 0 {
+				continue
+			}
 
-				tr := &http.Transport{
-					TLSClientConfig: &tls.Config{InsecureSkipVerify: *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
+			// messages outside of open channels are only sent to hooks that opted in and whose
+			// creator can read them
+			if channel.Type != model.CHANNEL_OPEN {
+				if !hook.IncludePrivateChannels {
+					continue
 				}
-				client := &http.Client{Transport: tr}
-
-				for _, url := range hook.CallbackURLs {
-					go func(url string) {
-						req, _ := http.NewRequest("POST", url, strings.NewReader(p.Encode()))
-						req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-						req.Header.Set("Accept", "application/json")
-						if resp, err := client.Do(req); err != nil {
-							l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.event_post.error"), err.Error())
-						} else {
-							respProps := model.MapFromJson(resp.Body)
-
-							// copy the context and create a mock session for posting the message
-							mockSession := model.Session{
-								UserId:      hook.CreatorId,
-								TeamMembers: []*model.TeamMember{{TeamId: hook.TeamId, UserId: hook.CreatorId}},
-								IsOAuth:     false,
-							}
-
-							newContext := &Context{
-								Session:      mockSession,
-								RequestId:    model.NewId(),
-								IpAddress:    "",
-								Path:         c.Path,
-								Err:          nil,
-								teamURLValid: c.teamURLValid,
-								teamURL:      c.teamURL,
-								siteURL:      c.siteURL,
-								T:            c.T,
-								Locale:       c.Locale,
-								TeamId:       hook.TeamId,
-							}
 
-							if text, ok := respProps["text"]; ok {
-								if _, err := CreateWebhookPost(newContext, post.ChannelId, text, respProps["username"], respProps["icon_url"], post.Props, post.Type); err != nil {
-									l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.create_post.error"), err)
-								}
-							}
-						}
-					}(url)
+				if result := <-Srv.Store.Channel().CheckPermissionsToNoTeam(channel.Id, hook.CreatorId); result.Err != nil || result.Data.(int64) != 1 {
+					continue
 				}
+			}
 
-			}(hook)
-		}
+			payload := &model.OutgoingWebhookPayload{
+				Token:       hook.Token,
+				TeamId:      hook.TeamId,
+				TeamDomain:  team.Name,
+				ChannelId:   post.ChannelId,
+				ChannelName: channel.Name,
+				Timestamp:   post.CreateAt / 1000,
+				UserId:      post.UserId,
+				UserName:    user.Username,
+				PostId:      post.Id,
+				Text:        post.Message,
+				TriggerWord: triggerWord,
+			}
 
+			triggerOutgoingWebhook(c, hook, payload, post.ChannelId, post.Id)
+		}
 	}()
 
 }
 
 func sendNotifications(c *Context, post *model.Post, team *model.Team, channel *model.Channel, profileMap map[string]*model.User, members []model.ChannelMember) {
 	var channelName string
-	var bodyText string
-	var subjectText string
 
 	var mentionedUsers []string
 
@@ -501,7 +487,7 @@ func sendNotifications(c *Context, post *model.Post, team *model.Team, channel *
 		if _, ok := otherUser.NotifyProps["email"]; ok && otherUser.NotifyProps["email"] == "false" {
 			sendEmail = false
 		}
-		if sendEmail && (otherUser.IsOffline() || otherUser.IsAway()) {
+		if sendEmail && !otherUser.IsBot && (otherUser.IsOffline() || otherUser.IsAway()) {
 			toEmailMap[otherUserId] = true
 		}
 
@@ -582,6 +568,11 @@ func sendNotifications(c *Context, post *model.Post, team *model.Team, channel *
 				if post.UserId == userId && post.Props["from_webhook"] != "true" {
 					continue
 				}
+
+				// nobody reads a bot's notifications
+				if profileMap[userId].IsBot {
+					continue
+				}
 				sendEmail := true
 				if _, ok := profileMap[userId].NotifyProps["email"]; ok && profileMap[userId].NotifyProps["email"] == "false" {
 					sendEmail = false
@@ -599,17 +590,18 @@ func sendNotifications(c *Context, post *model.Post, team *model.Team, channel *
 		}
 	}
 
+	var threadFollowers []string
+	if len(post.RootId) > 0 {
+		threadFollowers = updateThreadFollowers(post, profileMap)
+	}
+
 	if len(toEmailMap) != 0 {
 		mentionedUsers = make([]string, 0, len(toEmailMap))
 		for k := range toEmailMap {
 			mentionedUsers = append(mentionedUsers, k)
 		}
 
-		teamURL := c.GetSiteURL() + "/" + team.Name
-
 		// Build and send the emails
-		tm := time.Unix(post.CreateAt/1000, 0)
-
 		for id, doSend := range toEmailMap {
 
 			if !doSend {
@@ -623,70 +615,14 @@ func sendNotifications(c *Context, post *model.Post, team *model.Team, channel *
 
 			userLocale := utils.GetUserTranslations(profileMap[id].Locale)
 
-			if channel.Type == model.CHANNEL_DIRECT {
-				bodyText = userLocale("api.post.send_notifications_and_forget.message_body")
-				subjectText = userLocale("api.post.send_notifications_and_forget.message_subject")
-			} else {
-				bodyText = userLocale("api.post.send_notifications_and_forget.mention_body")
-				subjectText = userLocale("api.post.send_notifications_and_forget.mention_subject")
+			if channel.Type != model.CHANNEL_DIRECT {
 				channelName = channel.DisplayName
 			}
 
-			month := userLocale(tm.Month().String())
-			day := fmt.Sprintf("%d", tm.Day())
-			year := fmt.Sprintf("%d", tm.Year())
-			zone, _ := tm.Zone()
-
-			subjectPage := utils.NewHTMLTemplate("post_subject", profileMap[id].Locale)
-			subjectPage.Props["Subject"] = userLocale("api.templates.post_subject",
-				map[string]interface{}{"SubjectText": subjectText, "TeamDisplayName": team.DisplayName,
-					"Month": month[:3], "Day": day, "Year": year})
-			subjectPage.Props["SiteName"] = utils.Cfg.TeamSettings.SiteName
-
-			bodyPage := utils.NewHTMLTemplate("post_body", profileMap[id].Locale)
-			bodyPage.Props["SiteURL"] = c.GetSiteURL()
-			bodyPage.Props["PostMessage"] = model.ClearMentionTags(post.Message)
-			bodyPage.Props["TeamLink"] = teamURL + "/channels/" + channel.Name
-			bodyPage.Props["BodyText"] = bodyText
-			bodyPage.Props["Button"] = userLocale("api.templates.post_body.button")
-			bodyPage.Html["Info"] = template.HTML(userLocale("api.templates.post_body.info",
-				map[string]interface{}{"ChannelName": channelName, "SenderName": senderName,
-					"Hour": fmt.Sprintf("%02d", tm.Hour()), "Minute": fmt.Sprintf("%02d", tm.Minute()),
-					"TimeZone": zone, "Month": month, "Day": day}))
-
-			// attempt to fill in a message body if the post doesn't have any text
-			if len(strings.TrimSpace(bodyPage.Props["PostMessage"])) == 0 && len(post.Filenames) > 0 {
-				// extract the filenames from their paths and determine what type of files are attached
-				filenames := make([]string, len(post.Filenames))
-				onlyImages := true
-				for i, filename := range post.Filenames {
-					var err error
-					if filenames[i], err = url.QueryUnescape(filepath.Base(filename)); err != nil {
-						// this should never error since filepath was escaped using url.QueryEscape
-						filenames[i] = filepath.Base(filename)
-					}
-
-					ext := filepath.Ext(filename)
-					onlyImages = onlyImages && model.IsFileExtImage(ext)
-				}
-				filenamesString := strings.Join(filenames, ", ")
-
-				var attachmentPrefix string
-				if onlyImages {
-					attachmentPrefix = "Image"
-				} else {
-					attachmentPrefix = "File"
-				}
-				if len(post.Filenames) > 1 {
-					attachmentPrefix += "s"
-				}
-
-				bodyPage.Props["PostMessage"] = userLocale("api.post.send_notifications_and_forget.sent",
-					map[string]interface{}{"Prefix": attachmentPrefix, "Filenames": filenamesString})
-			}
-
-			if err := utils.SendMail(profileMap[id].Email, subjectPage.Render(), bodyPage.Render()); err != nil {
-				l4g.Error(utils.T("api.post.send_notifications_and_forget.send.error"), profileMap[id].Email, err)
+			if delay := model.GetEmailIntervalMillis(profileMap[id].NotifyProps["email_interval"]); delay > 0 {
+				queueNotificationEmail(c, post, profileMap[id], team, delay)
+			} else {
+				sendNotificationEmail(c, post, profileMap[id], team, channel, channelName, senderName)
 			}
 
 			if *utils.Cfg.EmailSettings.SendPushNotifications {
@@ -759,8 +695,16 @@ func sendNotifications(c *Context, post *model.Post, team *model.Team, channel *
 		}
 	}
 
+	// Thread followers are notified of replies through the mentions list so that
+	// clients alert them even when the channel itself is muted
+	for _, id := range threadFollowers {
+		if _, ok := toEmailMap[id]; !ok {
+			mentionedUsers = append(mentionedUsers, id)
+		}
+	}
+
 	message := model.NewMessage(c.TeamId, post.ChannelId, post.UserId, model.ACTION_POSTED)
-	message.Add("post", post.ToJson())
+	message.Add("post", post.WithoutActionIntegrations().ToJson())
 	message.Add("channel_type", channel.Type)
 
 	if len(post.Filenames) != 0 {
@@ -782,6 +726,86 @@ func sendNotifications(c *Context, post *model.Post, team *model.Team, channel *
 	PublishAndForget(message)
 }
 
+func sendNotificationEmail(c *Context, post *model.Post, user *model.User, team *model.Team, channel *model.Channel, channelName string, senderName string) {
+	userLocale := utils.GetUserTranslations(user.Locale)
+
+	var bodyText string
+	var subjectText string
+	if channel.Type == model.CHANNEL_DIRECT {
+		bodyText = userLocale("api.post.send_notifications_and_forget.message_body")
+		subjectText = userLocale("api.post.send_notifications_and_forget.message_subject")
+	} else {
+		bodyText = userLocale("api.post.send_notifications_and_forget.mention_body")
+		subjectText = userLocale("api.post.send_notifications_and_forget.mention_subject")
+	}
+
+	teamURL := c.GetSiteURL() + "/" + team.Name
+	tm := time.Unix(post.CreateAt/1000, 0)
+
+	month := userLocale(tm.Month().String())
+	day := fmt.Sprintf("%d", tm.Day())
+	year := fmt.Sprintf("%d", tm.Year())
+	zone, _ := tm.Zone()
+
+	subjectPage := utils.NewHTMLTemplate("post_subject", user.Locale)
+	subjectPage.Props["Subject"] = userLocale("api.templates.post_subject",
+		map[string]interface{}{"SubjectText": subjectText, "TeamDisplayName": team.DisplayName,
+			"Month": month[:3], "Day": day, "Year": year})
+	subjectPage.Props["SiteName"] = utils.Cfg.TeamSettings.SiteName
+
+	bodyPage := utils.NewHTMLTemplate("post_body", user.Locale)
+	bodyPage.Props["SiteURL"] = c.GetSiteURL()
+	bodyPage.Props["PostMessage"] = getNotificationEmailMessage(post, userLocale)
+	bodyPage.Props["TeamLink"] = teamURL + "/channels/" + channel.Name
+	bodyPage.Props["BodyText"] = bodyText
+	bodyPage.Props["Button"] = userLocale("api.templates.post_body.button")
+	bodyPage.Html["Info"] = template.HTML(userLocale("api.templates.post_body.info",
+		map[string]interface{}{"ChannelName": channelName, "SenderName": senderName,
+			"Hour": fmt.Sprintf("%02d", tm.Hour()), "Minute": fmt.Sprintf("%02d", tm.Minute()),
+			"TimeZone": zone, "Month": month, "Day": day}))
+
+	if err := utils.SendMail(user.Email, subjectPage.Render(), bodyPage.Render()); err != nil {
+		l4g.Error(utils.T("api.post.send_notifications_and_forget.send.error"), user.Email, err)
+	}
+}
+
+func getNotificationEmailMessage(post *model.Post, userLocale goi18n.TranslateFunc) string {
+	message := model.ClearMentionTags(post.Message)
+
+	// attempt to fill in a message body if the post doesn't have any text
+	if len(strings.TrimSpace(message)) == 0 && len(post.Filenames) > 0 {
+		// extract the filenames from their paths and determine what type of files are attached
+		filenames := make([]string, len(post.Filenames))
+		onlyImages := true
+		for i, filename := range post.Filenames {
+			var err error
+			if filenames[i], err = url.QueryUnescape(filepath.Base(filename)); err != nil {
+				// this should never error since filepath was escaped using url.QueryEscape
+				filenames[i] = filepath.Base(filename)
+			}
+
+			ext := filepath.Ext(filename)
+			onlyImages = onlyImages && model.IsFileExtImage(ext)
+		}
+		filenamesString := strings.Join(filenames, ", ")
+
+		var attachmentPrefix string
+		if onlyImages {
+			attachmentPrefix = "Image"
+		} else {
+			attachmentPrefix = "File"
+		}
+		if len(post.Filenames) > 1 {
+			attachmentPrefix += "s"
+		}
+
+		message = userLocale("api.post.send_notifications_and_forget.sent",
+			map[string]interface{}{"Prefix": attachmentPrefix, "Filenames": filenamesString})
+	}
+
+	return message
+}
+
 func updateMentionCountAndForget(channelId, userId string) {
 	go func() {
 		if result := <-Srv.Store.Channel().IncrementMentionCount(channelId, userId); result.Err != nil {
@@ -790,6 +814,58 @@ func updateMentionCountAndForget(channelId, userId string) {
 	}()
 }
 
+// updateThreadFollowers makes the author of a reply follow its thread, along with
+// the author of the root post when this is the first reply. It returns the other
+// followers that are still members of the channel after bumping their thread mention count.
+func updateThreadFollowers(post *model.Post, profileMap map[string]*model.User) []string {
+	var followers []*model.ThreadFollower
+	if result := <-Srv.Store.Thread().GetFollowers(post.RootId); result.Err != nil {
+		l4g.Error(utils.T("api.post.update_thread_followers.get_followers.error"), post.RootId, result.Err)
+		return nil
+	} else {
+		followers = result.Data.([]*model.ThreadFollower)
+	}
+
+	if len(followers) == 0 {
+		if result := <-Srv.Store.Post().Get(post.RootId); result.Err != nil {
+			l4g.Error(utils.T("api.post.update_thread_followers.get_root.error"), post.RootId, result.Err)
+		} else if root := result.Data.(*model.PostList).Posts[post.RootId]; root != nil && root.UserId != post.UserId {
+			follower := &model.ThreadFollower{RootId: post.RootId, UserId: root.UserId, ChannelId: post.ChannelId}
+			if result := <-Srv.Store.Thread().SaveFollower(follower); result.Err != nil {
+				l4g.Error(utils.T("api.post.update_thread_followers.save_follower.error"), root.UserId, post.RootId, result.Err)
+			} else {
+				followers = append(followers, follower)
+			}
+		}
+	}
+
+	// bots reply to threads without following them
+	if !post.IsFromBot() {
+		if result := <-Srv.Store.Thread().SaveFollower(&model.ThreadFollower{RootId: post.RootId, UserId: post.UserId, ChannelId: post.ChannelId}); result.Err != nil {
+			l4g.Error(utils.T("api.post.update_thread_followers.save_follower.error"), post.UserId, post.RootId, result.Err)
+		}
+	}
+
+	var notified []string
+	for _, follower := range followers {
+		if follower.UserId == post.UserId {
+			continue
+		}
+
+		if _, ok := profileMap[follower.UserId]; !ok {
+			continue
+		}
+
+		if result := <-Srv.Store.Thread().IncrementMentionCount(post.RootId, follower.UserId); result.Err != nil {
+			l4g.Error(utils.T("api.post.update_thread_followers.increment_mention_count.error"), follower.UserId, post.RootId, result.Err)
+		}
+
+		notified = append(notified, follower.UserId)
+	}
+
+	return notified
+}
+
 func checkForOutOfChannelMentions(c *Context, post *model.Post, channel *model.Channel, allProfiles map[string]*model.User, members []model.ChannelMember) {
 	// don't check for out of channel mentions in direct channels
 	if channel.Type == model.CHANNEL_DIRECT {
@@ -874,7 +950,7 @@ func SendEphemeralPost(teamId, userId string, post *model.Post) {
 	}
 
 	message := model.NewMessage(teamId, post.ChannelId, userId, model.ACTION_EPHEMERAL_MESSAGE)
-	message.Add("post", post.ToJson())
+	message.Add("post", post.WithoutActionIntegrations().ToJson())
 
 	PublishAndForget(message)
 }
@@ -923,6 +999,9 @@ func updatePost(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	hashtags, _ := model.ParseHashtags(post.Message)
 
+	// the actions of an integration's post don't survive being edited by a user
+	oldPost.StripActions()
+
 	if result := <-Srv.Store.Post().Update(oldPost, post.Message, hashtags); result.Err != nil {
 		c.Err = result.Err
 		return
@@ -930,11 +1009,12 @@ func updatePost(c *Context, w http.ResponseWriter, r *http.Request) {
 		rpost := result.Data.(*model.Post)
 
 		message := model.NewMessage(c.TeamId, rpost.ChannelId, c.Session.UserId, model.ACTION_POST_EDITED)
-		message.Add("post", rpost.ToJson())
+		message.Add("post", rpost.WithoutActionIntegrations().ToJson())
 
 		PublishAndForget(message)
+		IndexPostAndForget(rpost)
 
-		w.Write([]byte(rpost.ToJson()))
+		w.Write([]byte(rpost.WithoutActionIntegrations().ToJson()))
 	}
 }
 
@@ -959,7 +1039,7 @@ func getPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, id, c.Session.UserId)
+	cchan := Srv.Store.Channel().CheckReadPermissionsTo(c.TeamId, id, c.Session.UserId)
 	etagChan := Srv.Store.Post().GetEtag(id)
 
 	if !c.HasPermissionsToChannel(cchan, "getPosts") {
@@ -980,12 +1060,76 @@ func getPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 	} else {
 		list := result.Data.(*model.PostList)
 
+		if err := addReactionsToPostList(list); err != nil {
+			c.Err = err
+			return
+		}
+
 		w.Header().Set(model.HEADER_ETAG_SERVER, etag)
+		list.StripActionIntegrations()
 		w.Write([]byte(list.ToJson()))
 	}
 
 }
 
+func getFlaggedPosts(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	offset, err := strconv.Atoi(params["offset"])
+	if err != nil {
+		c.SetInvalidParam("getFlaggedPosts", "offset")
+		return
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	if err != nil {
+		c.SetInvalidParam("getFlaggedPosts", "limit")
+		return
+	}
+
+	var list *model.PostList
+	if result := <-Srv.Store.Post().GetFlaggedPosts(c.Session.UserId, offset, limit); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		list = result.Data.(*model.PostList)
+	}
+
+	// Only return the posts from channels the user can still read
+	channelAccess := make(map[string]bool)
+	posts := &model.PostList{}
+
+	for _, postId := range list.Order {
+		post := list.Posts[postId]
+
+		allowed, ok := channelAccess[post.ChannelId]
+		if !ok {
+			if result := <-Srv.Store.Channel().CheckPermissionsToNoTeam(post.ChannelId, c.Session.UserId); result.Err != nil {
+				c.Err = result.Err
+				return
+			} else {
+				allowed = result.Data.(int64) == 1
+				channelAccess[post.ChannelId] = allowed
+			}
+		}
+
+		if allowed {
+			posts.AddPost(post)
+			posts.AddOrder(post.Id)
+		}
+	}
+
+	posts.MakeNonNil()
+
+	if err := addReactionsToPostList(posts); err != nil {
+		c.Err = err
+		return
+	}
+
+	posts.StripActionIntegrations()
+	w.Write([]byte(posts.ToJson()))
+}
+
 func getPostsSince(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
@@ -1001,7 +1145,7 @@ func getPostsSince(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, id, c.Session.UserId)
+	cchan := Srv.Store.Channel().CheckReadPermissionsTo(c.TeamId, id, c.Session.UserId)
 	pchan := Srv.Store.Post().GetPostsSince(id, time)
 
 	if !c.HasPermissionsToChannel(cchan, "getPostsSince") {
@@ -1014,6 +1158,12 @@ func getPostsSince(c *Context, w http.ResponseWriter, r *http.Request) {
 	} else {
 		list := result.Data.(*model.PostList)
 
+		if err := addReactionsToPostList(list); err != nil {
+			c.Err = err
+			return
+		}
+
+		list.StripActionIntegrations()
 		w.Write([]byte(list.ToJson()))
 	}
 
@@ -1034,7 +1184,7 @@ func getPost(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	cchan := Srv.Store.Channel().CheckReadPermissionsTo(c.TeamId, channelId, c.Session.UserId)
 	pchan := Srv.Store.Post().Get(postId)
 
 	if !c.HasPermissionsToChannel(cchan, "getPost") {
@@ -1055,7 +1205,13 @@ func getPost(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if err := addReactionsToPostList(list); err != nil {
+			c.Err = err
+			return
+		}
+
 		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
+		list.StripActionIntegrations()
 		w.Write([]byte(list.ToJson()))
 	}
 }
@@ -1081,7 +1237,7 @@ func getPostById(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 		post := list.Posts[list.Order[0]]
 
-		cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, post.ChannelId, c.Session.UserId)
+		cchan := Srv.Store.Channel().CheckReadPermissionsTo(c.TeamId, post.ChannelId, c.Session.UserId)
 		if !c.HasPermissionsToChannel(cchan, "getPostById") {
 			return
 		}
@@ -1090,11 +1246,199 @@ func getPostById(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if err := addReactionsToPostList(list); err != nil {
+			c.Err = err
+			return
+		}
+
 		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
+		list.StripActionIntegrations()
 		w.Write([]byte(list.ToJson()))
 	}
 }
 
+func getPostThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("getPostThread", "postId")
+		return
+	}
+
+	var post *model.Post
+	if result := <-Srv.Store.Post().Get(postId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		post = result.Data.(*model.PostList).Posts[postId]
+	}
+
+	cchan := Srv.Store.Channel().CheckReadPermissionsTo(c.TeamId, post.ChannelId, c.Session.UserId)
+	if !c.HasPermissionsToChannel(cchan, "getPostThread") {
+		return
+	}
+
+	rootId := post.RootId
+	if rootId == "" {
+		rootId = post.Id
+	}
+
+	if result := <-Srv.Store.Post().GetPostThread(rootId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		list := result.Data.(*model.PostList)
+
+		if HandleEtag(list.Etag(), w, r) {
+			return
+		}
+
+		if err := addReactionsToPostList(list); err != nil {
+			c.Err = err
+			return
+		}
+
+		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
+		list.StripActionIntegrations()
+		w.Write([]byte(list.ToJson()))
+	}
+}
+
+func getPostEditHistory(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("getPostEditHistory", "postId")
+		return
+	}
+
+	var post *model.Post
+	if result := <-Srv.Store.Post().Get(postId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		post = result.Data.(*model.PostList).Posts[postId]
+	}
+
+	cchan := Srv.Store.Channel().CheckReadPermissionsTo(c.TeamId, post.ChannelId, c.Session.UserId)
+	if !c.HasPermissionsToChannel(cchan, "getPostEditHistory") {
+		return
+	}
+
+	if result := <-Srv.Store.Post().GetEditHistory(postId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		list := result.Data.(*model.PostList)
+		list.MakeNonNil()
+
+		list.StripActionIntegrations()
+		w.Write([]byte(list.ToJson()))
+	}
+}
+
+func getFollowedThreads(c *Context, w http.ResponseWriter, r *http.Request) {
+	if result := <-Srv.Store.Thread().GetFollowedThreads(c.TeamId, c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(model.ThreadFollowerListToJson(result.Data.([]*model.ThreadFollower))))
+	}
+}
+
+func followThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	rootId, channelId := getThreadRootId(c, r, "followThread")
+	if c.Err != nil {
+		return
+	}
+
+	follower := &model.ThreadFollower{RootId: rootId, UserId: c.Session.UserId, ChannelId: channelId}
+	if result := <-Srv.Store.Thread().SaveFollower(follower); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(result.Data.(*model.ThreadFollower).ToJson()))
+	}
+}
+
+func unfollowThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	rootId, _ := getThreadRootId(c, r, "unfollowThread")
+	if c.Err != nil {
+		return
+	}
+
+	if result := <-Srv.Store.Thread().RemoveFollower(rootId, c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	data := make(map[string]string)
+	data["root_id"] = rootId
+	w.Write([]byte(model.MapToJson(data)))
+}
+
+func viewThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	rootId, _ := getThreadRootId(c, r, "viewThread")
+	if c.Err != nil {
+		return
+	}
+
+	if result := <-Srv.Store.Thread().UpdateLastViewedAt(rootId, c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	data := make(map[string]string)
+	data["root_id"] = rootId
+	w.Write([]byte(model.MapToJson(data)))
+}
+
+// getThreadRootId resolves the post in the request to the root of its thread,
+// checking that the user can see the channel the post belongs to.
+func getThreadRootId(c *Context, r *http.Request, where string) (string, string) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam(where, "channelId")
+		return "", ""
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam(where, "postId")
+		return "", ""
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	pchan := Srv.Store.Post().Get(postId)
+
+	if !c.HasPermissionsToChannel(cchan, where) {
+		return "", ""
+	}
+
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return "", ""
+	} else {
+		post := result.Data.(*model.PostList).Posts[postId]
+
+		if post.ChannelId != channelId {
+			c.Err = model.NewLocAppError(where, "api.post.thread.permissions.app_error", nil, "")
+			c.Err.StatusCode = http.StatusForbidden
+			return "", ""
+		}
+
+		if post.RootId != "" {
+			return post.RootId, channelId
+		}
+
+		return post.Id, channelId
+	}
+}
+
 func getPermalinkTmp(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
@@ -1120,7 +1464,7 @@ func getPermalinkTmp(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, post.ChannelId, c.Session.UserId)
+		cchan := Srv.Store.Channel().CheckReadPermissionsTo(c.TeamId, post.ChannelId, c.Session.UserId)
 		if !c.HasPermissionsToChannel(cchan, "getPermalinkTmp") {
 			// If we don't have permissions attempt to join the channel to fix the problem
 			if err, _ := JoinChannelById(c, c.Session.UserId, post.ChannelId); err != nil {
@@ -1137,7 +1481,13 @@ func getPermalinkTmp(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if err := addReactionsToPostList(list); err != nil {
+			c.Err = err
+			return
+		}
+
 		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
+		list.StripActionIntegrations()
 		w.Write([]byte(list.ToJson()))
 	}
 }
@@ -1194,10 +1544,11 @@ func deletePost(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 
 		message := model.NewMessage(c.TeamId, post.ChannelId, c.Session.UserId, model.ACTION_POST_DELETED)
-		message.Add("post", post.ToJson())
+		message.Add("post", post.WithoutActionIntegrations().ToJson())
 
 		PublishAndForget(message)
 		DeletePostFilesAndForget(c.TeamId, post)
+		DeletePostFromIndexAndForget(postId)
 
 		result := make(map[string]string)
 		result["id"] = postId
@@ -1222,6 +1573,60 @@ func DeletePostFilesAndForget(teamId string, post *model.Post) {
 	}()
 }
 
+func pinPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	changePostPinned(c, w, r, true)
+}
+
+func unpinPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	changePostPinned(c, w, r, false)
+}
+
+func changePostPinned(c *Context, w http.ResponseWriter, r *http.Request, isPinned bool) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("changePostPinned", "channelId")
+		return
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("changePostPinned", "postId")
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	pchan := Srv.Store.Post().Get(postId)
+
+	if !c.HasPermissionsToChannel(cchan, "changePostPinned") {
+		return
+	}
+
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if post := result.Data.(*model.PostList).Posts[postId]; post.ChannelId != channelId {
+		c.Err = model.NewLocAppError("changePostPinned", "api.post.change_post_pinned.permissions.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	if result := <-Srv.Store.Post().UpdatePinned(postId, isPinned); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		rpost := result.Data.(*model.Post)
+
+		message := model.NewMessage(c.TeamId, rpost.ChannelId, c.Session.UserId, model.ACTION_POST_EDITED)
+		message.Add("post", rpost.WithoutActionIntegrations().ToJson())
+
+		PublishAndForget(message)
+
+		w.Write([]byte(rpost.WithoutActionIntegrations().ToJson()))
+	}
+}
+
 func getPostsBefore(c *Context, w http.ResponseWriter, r *http.Request) {
 	getPostsBeforeOrAfter(c, w, r, true)
 }
@@ -1257,7 +1662,7 @@ func getPostsBeforeOrAfter(c *Context, w http.ResponseWriter, r *http.Request, b
 		return
 	}
 
-	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, id, c.Session.UserId)
+	cchan := Srv.Store.Channel().CheckReadPermissionsTo(c.TeamId, id, c.Session.UserId)
 	// We can do better than this etag in this situation
 	etagChan := Srv.Store.Post().GetEtag(id)
 
@@ -1283,7 +1688,74 @@ func getPostsBeforeOrAfter(c *Context, w http.ResponseWriter, r *http.Request, b
 	} else {
 		list := result.Data.(*model.PostList)
 
+		if err := addReactionsToPostList(list); err != nil {
+			c.Err = err
+			return
+		}
+
+		w.Header().Set(model.HEADER_ETAG_SERVER, etag)
+		list.StripActionIntegrations()
+		w.Write([]byte(list.ToJson()))
+	}
+}
+
+func getPostsByCursor(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	id := params["channel_id"]
+	if len(id) != 26 {
+		c.SetInvalidParam("getPostsByCursor", "channelId")
+		return
+	}
+
+	// without a cursor post the newest page of the channel is returned
+	postId := params["post_id"]
+	if len(postId) > 0 && len(postId) != 26 {
+		c.SetInvalidParam("getPostsByCursor", "postId")
+		return
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	if err != nil || limit <= 0 {
+		c.SetInvalidParam("getPostsByCursor", "limit")
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckReadPermissionsTo(c.TeamId, id, c.Session.UserId)
+	etagChan := Srv.Store.Post().GetEtag(id)
+
+	if !c.HasPermissionsToChannel(cchan, "getPostsByCursor") {
+		return
+	}
+
+	etag := (<-etagChan).Data.(string)
+	if HandleEtag(etag, w, r) {
+		return
+	}
+
+	var pchan store.StoreChannel
+	switch params["direction"] {
+	case "after":
+		pchan = Srv.Store.Post().GetPostsAfterCursor(id, postId, limit)
+	case "around":
+		pchan = Srv.Store.Post().GetPostsAroundCursor(id, postId, limit)
+	default:
+		pchan = Srv.Store.Post().GetPostsBeforeCursor(id, postId, limit)
+	}
+
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		list := result.Data.(*model.PostList)
+
+		if err := addReactionsToPostList(list); err != nil {
+			c.Err = err
+			return
+		}
+
 		w.Header().Set(model.HEADER_ETAG_SERVER, etag)
+		list.StripActionIntegrations()
 		w.Write([]byte(list.ToJson()))
 	}
 }
@@ -1302,7 +1774,48 @@ func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		isOrSearch = val.(bool)
 	}
 
+	page := 0
+	if val, ok := props["page"].(float64); ok {
+		page = int(val)
+	}
+
+	perPage := 100
+	if val, ok := props["per_page"].(float64); ok {
+		perPage = int(val)
+	}
+
+	if page < 0 {
+		c.SetInvalidParam("search", "page")
+		return
+	}
+
+	if perPage <= 0 || perPage > 200 {
+		c.SetInvalidParam("search", "per_page")
+		return
+	}
+
 	paramsList := model.ParseSearchParams(terms)
+
+	if IsSearchEngineEnabled() {
+		for _, params := range paramsList {
+			params.OrTerms = isOrSearch
+		}
+
+		if posts, err := searchPostsWithEngine(c, paramsList, page, perPage); err != nil {
+			c.Err = err
+			return
+		} else if err := addReactionsToPostList(posts); err != nil {
+			c.Err = err
+			return
+		} else {
+			w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+			posts.StripActionIntegrations()
+			w.Write([]byte(posts.ToJson()))
+		}
+
+		return
+	}
+
 	channels := []store.StoreChannel{}
 
 	for _, params := range paramsList {
@@ -1324,6 +1837,198 @@ func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := addReactionsToPostList(posts); err != nil {
+		c.Err = err
+		return
+	}
+
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	posts.StripActionIntegrations()
 	w.Write([]byte(posts.ToJson()))
 }
+
+func IsSearchEngineEnabled() bool {
+	return *utils.Cfg.SearchSettings.Enable && einterfaces.GetSearchEngine() != nil
+}
+
+func IndexPostAndForget(post *model.Post) {
+	if !IsSearchEngineEnabled() {
+		return
+	}
+
+	go func() {
+		if err := einterfaces.GetSearchEngine().IndexPost(post); err != nil {
+			l4g.Error(utils.T("api.post.index_post.error"), post.Id, err)
+		}
+	}()
+}
+
+func DeletePostFromIndexAndForget(postId string) {
+	if !IsSearchEngineEnabled() {
+		return
+	}
+
+	go func() {
+		if err := einterfaces.GetSearchEngine().DeletePost(postId); err != nil {
+			l4g.Error(utils.T("api.post.delete_post_from_index.error"), postId, err)
+		}
+	}()
+}
+
+func DeleteChannelPostsFromIndexAndForget(channelId string) {
+	if !IsSearchEngineEnabled() {
+		return
+	}
+
+	go func() {
+		if err := einterfaces.GetSearchEngine().DeleteChannelPosts(channelId); err != nil {
+			l4g.Error(utils.T("api.post.delete_channel_posts_from_index.error"), channelId, err)
+		}
+	}()
+}
+
+func DeleteUserPostsFromIndexAndForget(userId string) {
+	if !IsSearchEngineEnabled() {
+		return
+	}
+
+	go func() {
+		if err := einterfaces.GetSearchEngine().DeleteUserPosts(userId); err != nil {
+			l4g.Error(utils.T("api.post.delete_user_posts_from_index.error"), userId, err)
+		}
+	}()
+}
+
+// IndexChannelPostsAndForget indexes every live post in the channel again, used when a deleted
+// channel is restored.
+func IndexChannelPostsAndForget(channelId string) {
+	if !IsSearchEngineEnabled() {
+		return
+	}
+
+	go func() {
+		if result := <-Srv.Store.Post().GetForExport(channelId); result.Err != nil {
+			l4g.Error(utils.T("api.post.index_channel_posts.error"), channelId, result.Err)
+		} else {
+			for _, post := range result.Data.([]*model.Post) {
+				if err := einterfaces.GetSearchEngine().IndexPost(post); err != nil {
+					l4g.Error(utils.T("api.post.index_post.error"), post.Id, err)
+				}
+			}
+		}
+	}()
+}
+
+// ReindexPosts clears the search index and indexes every live post again, returning the
+// number of posts indexed.
+func ReindexPosts() (int, *model.AppError) {
+	engine := einterfaces.GetSearchEngine()
+
+	if err := engine.PurgeIndex(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	var createAt int64
+	postId := ""
+
+	for {
+		var posts []*model.Post
+		if result := <-Srv.Store.Post().GetPostsBatchForIndexing(createAt, postId, REINDEX_BATCH_SIZE); result.Err != nil {
+			return count, result.Err
+		} else {
+			posts = result.Data.([]*model.Post)
+		}
+
+		for _, post := range posts {
+			if err := engine.IndexPost(post); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if len(posts) < REINDEX_BATCH_SIZE {
+			return count, nil
+		}
+
+		createAt = posts[len(posts)-1].CreateAt
+		postId = posts[len(posts)-1].Id
+	}
+}
+
+// searchPostsWithEngine runs the search against the configured search engine, limited to the
+// channels the user belongs to on the current team, and loads the matching posts in ranked order.
+func searchPostsWithEngine(c *Context, paramsList []*model.SearchParams, page int, perPage int) (*model.PostList, *model.AppError) {
+	posts := &model.PostList{}
+	posts.MakeNonNil()
+
+	var channels []*model.Channel
+	if result := <-Srv.Store.Channel().GetChannels(c.TeamId, c.Session.UserId); result.Err != nil {
+		if result.Err.Id == "store.sql_channel.get_channels.not_found.app_error" {
+			return posts, nil
+		}
+		return nil, result.Err
+	} else {
+		channels = result.Data.(*model.ChannelList).Channels
+	}
+
+	for _, params := range paramsList {
+		// don't allow users to search for everything
+		if params.Terms == "*" {
+			continue
+		}
+
+		channelIds := []string{}
+		for _, channel := range channels {
+			if len(params.InChannels) == 0 {
+				channelIds = append(channelIds, channel.Id)
+				continue
+			}
+
+			for _, name := range params.InChannels {
+				if channel.Name == name {
+					channelIds = append(channelIds, channel.Id)
+					break
+				}
+			}
+		}
+
+		userIds := []string{}
+		for _, username := range params.FromUsers {
+			if result := <-Srv.Store.User().GetByUsername(username); result.Err == nil {
+				userIds = append(userIds, result.Data.(*model.User).Id)
+			}
+		}
+
+		if len(channelIds) == 0 || (len(params.FromUsers) > 0 && len(userIds) == 0) {
+			continue
+		}
+
+		postIds, err := einterfaces.GetSearchEngine().SearchPosts(channelIds, userIds, params, page, perPage)
+		if err != nil {
+			return nil, err
+		}
+
+		if result := <-Srv.Store.Post().GetPostsByIds(postIds); result.Err != nil {
+			return nil, result.Err
+		} else {
+			found := make(map[string]*model.Post)
+			for _, post := range result.Data.([]*model.Post) {
+				found[post.Id] = post
+			}
+
+			// keep the order the engine ranked the posts in and skip any the index hasn't caught up on deleting
+			list := &model.PostList{Order: make([]string, 0, len(postIds))}
+			for _, postId := range postIds {
+				if post, ok := found[postId]; ok {
+					list.AddPost(post)
+					list.AddOrder(post.Id)
+				}
+			}
+
+			posts.Extend(list)
+		}
+	}
+
+	return posts, nil
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// postActionSecret is used to sign the requests of post actions when PostActionSecret isn't set, it's
+// generated once and stored in the database so that every server of a cluster uses the same one.
+var postActionSecret string
+
+func loadPostActionSecret() {
+	if result := <-Srv.Store.System().Get(); result.Err == nil && len(result.Data.(model.StringMap)[model.SYSTEM_POST_ACTION_SECRET]) == 0 {
+		// saving fails if another server of the cluster stored its secret first, that one is used then
+		<-Srv.Store.System().Save(&model.System{Name: model.SYSTEM_POST_ACTION_SECRET, Value: model.NewRandomString(32)})
+	}
+
+	if result := <-Srv.Store.System().Get(); result.Err != nil {
+		l4g.Error(utils.T("api.post.load_post_action_secret.error"), result.Err.Error())
+	} else {
+		postActionSecret = result.Data.(model.StringMap)[model.SYSTEM_POST_ACTION_SECRET]
+	}
+}
+
+func getPostActionSecret() string {
+	if len(utils.Cfg.ServiceSettings.PostActionSecret) > 0 {
+		return utils.Cfg.ServiceSettings.PostActionSecret
+	}
+
+	return postActionSecret
+}
+
+func doPostAction(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("doPostAction", "channelId")
+		return
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("doPostAction", "postId")
+		return
+	}
+
+	actionId := params["action_id"]
+	props := model.MapFromJson(r.Body)
+	selectedOption := props["selected_option"]
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	pchan := Srv.Store.Post().Get(postId)
+
+	if !c.HasPermissionsToChannel(cchan, "doPostAction") {
+		return
+	}
+
+	var post *model.Post
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		post = result.Data.(*model.PostList).Posts[postId]
+	}
+
+	if post.ChannelId != channelId || post.DeleteAt != 0 {
+		c.Err = model.NewLocAppError("doPostAction", "api.post.do_action.permissions.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	action := post.GetAction(actionId)
+	if action == nil {
+		c.Err = model.NewLocAppError("doPostAction", "api.post.do_action.not_found.app_error", nil, "action_id="+actionId)
+		c.Err.StatusCode = http.StatusNotFound
+		return
+	}
+
+	if action.Type == model.POST_ACTION_TYPE_SELECT && !action.HasOption(selectedOption) {
+		c.SetInvalidParam("doPostAction", "selected_option")
+		return
+	}
+
+	request := &model.PostActionIntegrationRequest{
+		UserId:         c.Session.UserId,
+		ChannelId:      channelId,
+		TeamId:         c.TeamId,
+		PostId:         postId,
+		ActionId:       actionId,
+		SelectedOption: selectedOption,
+		Context:        action.Integration.Context,
+	}
+
+	resp, err := doPostActionRequest(action.Integration.URL, request)
+	if err != nil {
+		c.Err = model.NewLocAppError("doPostAction", "api.post.do_action.request.app_error", nil, "url="+action.Integration.URL+", err="+err.Error())
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	if resp.Update != nil {
+		if result := <-Srv.Store.Post().Overwrite(updatePostFromAction(post, resp.Update)); result.Err != nil {
+			c.Err = result.Err
+			return
+		} else {
+			post = result.Data.(*model.Post)
+
+			message := model.NewMessage(c.TeamId, post.ChannelId, c.Session.UserId, model.ACTION_POST_EDITED)
+			message.Add("post", post.WithoutActionIntegrations().ToJson())
+
+			PublishAndForget(message)
+			IndexPostAndForget(post)
+		}
+	}
+
+	if len(resp.EphemeralText) > 0 {
+		SendEphemeralPost(
+			c.TeamId,
+			c.Session.UserId,
+			&model.Post{
+				ChannelId: channelId,
+				UserId:    post.UserId,
+				RootId:    post.RootId,
+				Message:   resp.EphemeralText,
+				Props:     model.StringInterface{"from_webhook": "true"},
+			},
+		)
+	}
+
+	c.LogAudit("action_id=" + actionId)
+
+	w.Write([]byte(post.WithoutActionIntegrations().ToJson()))
+}
+
+// doPostActionRequest sends the request to the integration, signed the same way as outgoing
+// webhooks but with the server's post action secret since actions don't belong to a hook.
+func doPostActionRequest(url string, request *model.PostActionIntegrationRequest) (*model.PostActionIntegrationResponse, error) {
+	body := request.ToJson()
+
+	req, err := http.NewRequest("POST", url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(model.HEADER_WEBHOOK_TIMESTAMP, timestamp)
+	req.Header.Set(model.HEADER_WEBHOOK_SIGNATURE, "sha256="+model.SignOutgoingWebhook(getPostActionSecret(), timestamp, body))
+
+	resp, err := newIntegrationHttpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.New(resp.Status)
+	}
+
+	// integrations that only want to acknowledge the action can return an empty body
+	if response := model.PostActionIntegrationResponseFromJson(resp.Body); response != nil {
+		return response, nil
+	}
+
+	l4g.Debug(utils.T("api.post.do_action.empty_response.debug"), url)
+
+	return &model.PostActionIntegrationResponse{}, nil
+}
+
+// updatePostFromAction applies the update returned by an integration to the post. Only the message
+// and attachments can be changed, the props set by the server when the post was created are kept.
+func updatePostFromAction(post *model.Post, update *model.Post) *model.Post {
+	post.Message = update.Message
+	post.Hashtags, _ = model.ParseHashtags(post.Message)
+
+	if update.Props != nil {
+		props := model.StringInterface{}
+		for key, val := range update.Props {
+			props[key] = val
+		}
+
+		for _, key := range []string{"from_webhook", "override_username", "override_icon_url"} {
+			if val, ok := post.Props[key]; ok {
+				props[key] = val
+			} else {
+				delete(props, key)
+			}
+		}
+
+		post.Props = props
+	}
+
+	post.PrepareActions()
+
+	return post
+}
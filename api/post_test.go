@@ -4,11 +4,16 @@
 package api
 
 import (
+	"fmt"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/search"
 	"github.com/mattermost/platform/utils"
+	"io/ioutil"
 	"net/http"
-	//"strings"
-	"fmt"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -157,6 +162,38 @@ func TestUpdatePost(t *testing.T) {
 	}
 }
 
+func TestGetPostEditHistory(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel1 := th.BasicChannel
+
+	post1 := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}
+	post1 = Client.Must(Client.CreatePost(post1)).Data.(*model.Post)
+	original := post1.Message
+
+	if list := Client.Must(Client.GetPostEditHistory(post1.Id)).Data.(*model.PostList); len(list.Order) != 0 {
+		t.Fatal("should have no edit history")
+	}
+
+	post1.Message = "a" + model.NewId() + "a"
+	Client.Must(Client.UpdatePost(post1))
+
+	list := Client.Must(Client.GetPostEditHistory(post1.Id)).Data.(*model.PostList)
+	if len(list.Order) != 1 {
+		t.Fatal("should have one previous revision")
+	}
+
+	if revision := list.Posts[list.Order[0]]; revision.Message != original || revision.OriginalId != post1.Id {
+		t.Fatal("should have returned the original message")
+	}
+
+	th.LoginBasic2()
+
+	if _, err := Client.GetPostEditHistory(post1.Id); err == nil {
+		t.Fatal("should not be able to see the history of a post in a channel the user is not a member of")
+	}
+}
+
 func TestGetPosts(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
@@ -212,6 +249,48 @@ func TestGetPosts(t *testing.T) {
 	}
 }
 
+func TestGetPostsInArchivedChannel(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	team := th.BasicTeam
+
+	channel1 := &model.Channel{DisplayName: "A Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	channel2 := &model.Channel{DisplayName: "B Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_PRIVATE, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	post1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel2.Id, Message: "a" + model.NewId() + "a"}))
+
+	Client.Must(Client.DeleteChannel(channel1.Id))
+	Client.Must(Client.DeleteChannel(channel2.Id))
+
+	if posts := Client.Must(Client.GetPosts(channel1.Id, 0, 10, "")).Data.(*model.PostList); posts.Posts[post1.Id] == nil {
+		t.Fatal("should have been able to read the archived channel")
+	}
+
+	if _, err := Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}); err == nil {
+		t.Fatal("should have failed - the channel is archived")
+	}
+
+	th.LoginBasic2()
+
+	if posts := Client.Must(Client.GetPosts(channel1.Id, 0, 10, "")).Data.(*model.PostList); posts.Posts[post1.Id] == nil {
+		t.Fatal("should have been able to read the archived open channel without being a member")
+	}
+
+	Client.Must(Client.GetPost(channel1.Id, post1.Id, ""))
+
+	if _, err := Client.GetPosts(channel2.Id, 0, 10, ""); err == nil {
+		t.Fatal("should have failed - the archived channel is private")
+	}
+
+	if _, err := Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}); err == nil {
+		t.Fatal("should have failed - the channel is archived")
+	}
+}
+
 func TestGetPostsSince(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
@@ -340,6 +419,44 @@ func TestGetPostsBeforeAfter(t *testing.T) {
 	}
 }
 
+func TestGetPostsByCursor(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel1 := th.CreateChannel(Client, th.BasicTeam)
+
+	var posts []*model.Post
+	for i := 0; i < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+		posts = append(posts, th.CreatePost(Client, channel1))
+	}
+
+	r1 := Client.Must(Client.GetLatestPostsCursor(channel1.Id, 2, "")).Data.(*model.PostList)
+	if len(r1.Order) != 2 || r1.Order[0] != posts[4].Id || r1.Order[1] != posts[3].Id {
+		t.Fatal("wrong order")
+	}
+
+	r2 := Client.Must(Client.GetPostsBeforeCursor(channel1.Id, r1.Order[1], 2, "")).Data.(*model.PostList)
+	if len(r2.Order) != 2 || r2.Order[0] != posts[2].Id || r2.Order[1] != posts[1].Id {
+		t.Fatal("wrong order")
+	}
+
+	r3 := Client.Must(Client.GetPostsAfterCursor(channel1.Id, posts[0].Id, 2, "")).Data.(*model.PostList)
+	if len(r3.Order) != 2 || r3.Order[0] != posts[2].Id || r3.Order[1] != posts[1].Id {
+		t.Fatal("wrong order")
+	}
+
+	r4 := Client.Must(Client.GetPostsAroundCursor(channel1.Id, posts[2].Id, 1, "")).Data.(*model.PostList)
+	if len(r4.Order) != 3 || r4.Order[0] != posts[3].Id || r4.Order[1] != posts[2].Id || r4.Order[2] != posts[1].Id {
+		t.Fatal("wrong order")
+	}
+
+	th.LoginBasic2()
+
+	if _, err := Client.GetPostsBeforeCursor(channel1.Id, posts[4].Id, 2, ""); err == nil {
+		t.Fatal("should not be able to page a channel the user is not a member of")
+	}
+}
+
 func TestSearchPosts(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
@@ -386,6 +503,66 @@ func TestSearchPosts(t *testing.T) {
 	}
 }
 
+func TestSearchPostsWithEngine(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel1 := th.BasicChannel
+
+	dir, err := ioutil.TempDir("", "searchindex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	enableSearch := *utils.Cfg.SearchSettings.Enable
+	previousEngine := einterfaces.GetSearchEngine()
+	defer func() {
+		*utils.Cfg.SearchSettings.Enable = enableSearch
+		einterfaces.RegisterSearchEngine(previousEngine)
+	}()
+	*utils.Cfg.SearchSettings.Enable = true
+	einterfaces.RegisterSearchEngine(search.NewLocalSearchEngine(dir))
+
+	post1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "engine search for post1"})).Data.(*model.Post)
+	post2 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "engine search engine search for post2"})).Data.(*model.Post)
+	post3 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "#enginetag for post3"})).Data.(*model.Post)
+
+	// posts are indexed in the background
+	time.Sleep(100 * time.Millisecond)
+
+	r1 := Client.Must(Client.SearchPosts("engine search", false)).Data.(*model.PostList)
+	if len(r1.Order) != 2 || r1.Order[0] != post2.Id || r1.Order[1] != post1.Id {
+		t.Fatal("should have ranked the results", r1.Order)
+	}
+
+	r2 := Client.Must(Client.SearchPostsWithPaging("engine search", false, 1, 1)).Data.(*model.PostList)
+	if len(r2.Order) != 1 || r2.Order[0] != post1.Id {
+		t.Fatal("should have returned the second page")
+	}
+
+	r3 := Client.Must(Client.SearchPosts("#enginetag", false)).Data.(*model.PostList)
+	if len(r3.Order) != 1 || r3.Order[0] != post3.Id {
+		t.Fatal("should have found the hashtag")
+	}
+
+	Client.Must(Client.DeletePost(channel1.Id, post1.Id))
+	time.Sleep(100 * time.Millisecond)
+
+	if r4 := Client.Must(Client.SearchPosts("engine search", false)).Data.(*model.PostList); len(r4.Order) != 1 {
+		t.Fatal("should have removed the deleted post from the index")
+	}
+
+	if _, err := Client.SearchPostsWithPaging("engine", false, 0, 1000); err == nil {
+		t.Fatal("should have failed with too large a page")
+	}
+
+	th.LoginBasic2()
+
+	if r5 := Client.Must(Client.SearchPosts("engine search", false)).Data.(*model.PostList); len(r5.Order) != 0 {
+		t.Fatal("should not have found posts in channels the user is not a member of")
+	}
+}
+
 func TestSearchHashtagPosts(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
@@ -630,6 +807,251 @@ func TestDeletePosts(t *testing.T) {
 	Client.Must(Client.DeletePost(channel1.Id, post4.Id))
 }
 
+func TestGetPostThread(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel1 := th.BasicChannel
+
+	post1 := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}
+	post1 = Client.Must(Client.CreatePost(post1)).Data.(*model.Post)
+
+	time.Sleep(10 * time.Millisecond)
+	post1a1 := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a", RootId: post1.Id}
+	post1a1 = Client.Must(Client.CreatePost(post1a1)).Data.(*model.Post)
+
+	time.Sleep(10 * time.Millisecond)
+	post1a2 := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a", RootId: post1.Id, ParentId: post1a1.Id}
+	post1a2 = Client.Must(Client.CreatePost(post1a2)).Data.(*model.Post)
+
+	time.Sleep(10 * time.Millisecond)
+	post2 := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}
+	post2 = Client.Must(Client.CreatePost(post2)).Data.(*model.Post)
+
+	for _, id := range []string{post1.Id, post1a1.Id} {
+		list := Client.Must(Client.GetPostThread(id, "")).Data.(*model.PostList)
+
+		if len(list.Order) != 3 {
+			t.Fatal("should have returned the whole thread")
+		}
+
+		if list.Order[0] != post1a2.Id || list.Order[2] != post1.Id {
+			t.Fatal("thread should be ordered newest first")
+		}
+
+		if _, ok := list.Posts[post2.Id]; ok {
+			t.Fatal("should not have returned posts outside the thread")
+		}
+	}
+
+	if _, err := Client.GetPostThread("junk", ""); err == nil {
+		t.Fatal("should have failed with bad post id")
+	}
+
+	th.LoginBasic2()
+
+	if _, err := Client.GetPostThread(post1.Id, ""); err == nil {
+		t.Fatal("should have failed for a user outside the channel")
+	}
+}
+
+func TestFollowThread(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel1 := th.BasicChannel
+
+	post1 := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}
+	post1 = Client.Must(Client.CreatePost(post1)).Data.(*model.Post)
+
+	th.LoginBasic2()
+	Client.Must(Client.JoinChannel(channel1.Id))
+
+	reply := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a", RootId: post1.Id}
+	reply = Client.Must(Client.CreatePost(reply)).Data.(*model.Post)
+
+	// Notifications are sent asynchronously
+	time.Sleep(100 * time.Millisecond)
+
+	followed := Client.Must(Client.GetFollowedThreads()).Data.([]*model.ThreadFollower)
+	if len(followed) != 1 || followed[0].RootId != post1.Id {
+		t.Fatal("replying should follow the thread")
+	}
+
+	th.LoginBasic()
+
+	followed = Client.Must(Client.GetFollowedThreads()).Data.([]*model.ThreadFollower)
+	if len(followed) != 1 || followed[0].RootId != post1.Id {
+		t.Fatal("the root author should follow the thread after the first reply")
+	}
+
+	if followed[0].MentionCount != 1 {
+		t.Fatal("the reply should have counted as a thread mention")
+	}
+
+	Client.Must(Client.ViewThread(channel1.Id, reply.Id))
+
+	followed = Client.Must(Client.GetFollowedThreads()).Data.([]*model.ThreadFollower)
+	if followed[0].MentionCount != 0 {
+		t.Fatal("viewing the thread should have cleared the mention count")
+	}
+
+	Client.Must(Client.UnfollowThread(channel1.Id, post1.Id))
+
+	followed = Client.Must(Client.GetFollowedThreads()).Data.([]*model.ThreadFollower)
+	if len(followed) != 0 {
+		t.Fatal("should have unfollowed the thread")
+	}
+
+	if follower := Client.Must(Client.FollowThread(channel1.Id, reply.Id)).Data.(*model.ThreadFollower); follower.RootId != post1.Id {
+		t.Fatal("following a reply should follow its root")
+	}
+
+	channel2 := th.CreateChannel(Client, th.BasicTeam)
+	post2 := &model.Post{ChannelId: channel2.Id, Message: "a" + model.NewId() + "a"}
+	post2 = Client.Must(Client.CreatePost(post2)).Data.(*model.Post)
+
+	Client.Must(Client.FollowThread(channel2.Id, post2.Id))
+	Client.Must(Client.LeaveChannel(channel2.Id))
+
+	followed = Client.Must(Client.GetFollowedThreads()).Data.([]*model.ThreadFollower)
+	if len(followed) != 1 || followed[0].RootId != post1.Id {
+		t.Fatal("shouldn't return threads in channels the user has left")
+	}
+
+	team2 := th.CreateTeam(th.BasicClient)
+
+	Client.SetTeamId(team2.Id)
+	followed = Client.Must(Client.GetFollowedThreads()).Data.([]*model.ThreadFollower)
+	if len(followed) != 0 {
+		t.Fatal("shouldn't return threads from another team")
+	}
+	Client.SetTeamId(th.BasicTeam.Id)
+
+	th.LoginBasic2()
+
+	if _, err := Client.FollowThread(channel2.Id, post2.Id); err == nil {
+		t.Fatal("should not be able to follow a thread in a channel the user is not a member of")
+	}
+
+	if _, err := Client.FollowThread(channel1.Id, post2.Id); err == nil {
+		t.Fatal("should not be able to follow a post through another channel")
+	}
+}
+
+func TestPinPost(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel1 := th.BasicChannel
+
+	post1 := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}
+	post1 = Client.Must(Client.CreatePost(post1)).Data.(*model.Post)
+
+	time.Sleep(10 * time.Millisecond)
+	post2 := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a", IsPinned: true}
+	post2 = Client.Must(Client.CreatePost(post2)).Data.(*model.Post)
+	if post2.IsPinned {
+		t.Fatal("should not be able to create a pinned post")
+	}
+
+	post1.IsPinned = true
+	post1.Message = "a" + model.NewId() + "a"
+	if rpost := Client.Must(Client.UpdatePost(post1)).Data.(*model.Post); rpost.IsPinned {
+		t.Fatal("should not be able to pin a post by editing it")
+	}
+
+	if rpost := Client.Must(Client.PinPost(channel1.Id, post1.Id)).Data.(*model.Post); !rpost.IsPinned {
+		t.Fatal("should have pinned the post")
+	}
+	Client.Must(Client.PinPost(channel1.Id, post2.Id))
+
+	list := Client.Must(Client.GetPinnedPosts(channel1.Id)).Data.(*model.PostList)
+	if len(list.Order) != 2 || list.Order[0] != post2.Id || list.Order[1] != post1.Id {
+		t.Fatal("should have returned the pinned posts")
+	}
+
+	if rpost := Client.Must(Client.UnpinPost(channel1.Id, post2.Id)).Data.(*model.Post); rpost.IsPinned {
+		t.Fatal("should have unpinned the post")
+	}
+
+	list = Client.Must(Client.GetPinnedPosts(channel1.Id)).Data.(*model.PostList)
+	if len(list.Order) != 1 || list.Order[0] != post1.Id {
+		t.Fatal("should only have returned the post that is still pinned")
+	}
+
+	channel2 := th.CreateChannel(Client, th.BasicTeam)
+	if _, err := Client.PinPost(channel2.Id, post2.Id); err == nil {
+		t.Fatal("should not be able to pin a post through another channel")
+	}
+
+	th.LoginBasic2()
+
+	if _, err := Client.PinPost(channel1.Id, post2.Id); err == nil {
+		t.Fatal("should not be able to pin a post in a channel the user is not a member of")
+	}
+
+	if _, err := Client.GetPinnedPosts(channel1.Id); err == nil {
+		t.Fatal("should not be able to see pinned posts in a channel the user is not a member of")
+	}
+}
+
+func TestGetFlaggedPosts(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	user1 := th.BasicUser
+	post1 := th.BasicPost
+
+	channel2 := th.CreateChannel(Client, th.BasicTeam)
+	post2 := th.CreatePost(Client, channel2)
+
+	post3 := th.CreatePost(Client, th.BasicChannel)
+
+	preferences := &model.Preferences{
+		{
+			UserId:   user1.Id,
+			Category: model.PREFERENCE_CATEGORY_FLAGGED_POST,
+			Name:     post1.Id,
+			Value:    "true",
+		},
+		{
+			UserId:   user1.Id,
+			Category: model.PREFERENCE_CATEGORY_FLAGGED_POST,
+			Name:     post2.Id,
+			Value:    "true",
+		},
+		{
+			UserId:   user1.Id,
+			Category: model.PREFERENCE_CATEGORY_FLAGGED_POST,
+			Name:     post3.Id,
+			Value:    "true",
+		},
+	}
+	Client.Must(Client.SetPreferences(preferences))
+
+	r1 := Client.Must(Client.GetFlaggedPosts(0, 10)).Data.(*model.PostList)
+	if len(r1.Order) != 3 {
+		t.Fatal("should have returned all flagged posts")
+	}
+
+	r2 := Client.Must(Client.GetFlaggedPosts(1, 1)).Data.(*model.PostList)
+	if len(r2.Order) != 1 || r2.Order[0] != r1.Order[1] {
+		t.Fatal("should have returned the second page of flagged posts")
+	}
+
+	Client.Must(Client.DeletePost(th.BasicChannel.Id, post3.Id))
+	Client.Must(Client.LeaveChannel(channel2.Id))
+
+	r3 := Client.Must(Client.GetFlaggedPosts(0, 10)).Data.(*model.PostList)
+	if len(r3.Order) != 1 || r3.Order[0] != post1.Id {
+		t.Fatal("should not have returned deleted posts or posts in channels the user left")
+	}
+
+	th.LoginBasic2()
+
+	r4 := Client.Must(Client.GetFlaggedPosts(0, 10)).Data.(*model.PostList)
+	if len(r4.Order) != 0 {
+		t.Fatal("should not have returned posts flagged by another user")
+	}
+}
+
 func TestEmailMention(t *testing.T) {
 	th := Setup().InitBasic()
 	Client := th.BasicClient
@@ -771,3 +1193,119 @@ func TestGetOutOfChannelMentions(t *testing.T) {
 		t.Fatalf("getOutOfChannelMentions returned %v when two users on a different team were mentioned", mentioned)
 	}
 }
+
+func TestDoPostAction(t *testing.T) {
+	th := Setup().InitSystemAdmin()
+	Client := th.SystemAdminClient
+	team := th.SystemAdminTeam
+	channel1 := th.CreateChannel(Client, team)
+	channel2 := th.CreateChannel(Client, team)
+
+	enableIncomingHooks := utils.Cfg.ServiceSettings.EnableIncomingWebhooks
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableIncomingWebhooks = enableIncomingHooks
+	}()
+	utils.Cfg.ServiceSettings.EnableIncomingWebhooks = true
+
+	requests := make(chan *model.PostActionIntegrationRequest, 10)
+	response := ""
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the integration should be able to check that the request came from the server
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get(model.HEADER_WEBHOOK_SIGNATURE) != "sha256="+model.SignOutgoingWebhook(getPostActionSecret(), r.Header.Get(model.HEADER_WEBHOOK_TIMESTAMP), string(body)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		requests <- model.PostActionIntegrationRequestFromJson(strings.NewReader(string(body)))
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	hook := Client.Must(Client.CreateIncomingWebhook(&model.IncomingWebhook{ChannelId: channel1.Id})).Data.(*model.IncomingWebhook)
+
+	payload := fmt.Sprintf(`{"text": "deploy?", "attachments": [{"text": "pick one", "actions": [
+		{"name": "Approve", "type": "button", "integration": {"url": "%v", "context": {"build": "12"}}},
+		{"name": "Target", "type": "select", "options": [{"text": "Staging", "value": "staging"}], "integration": {"url": "%v"}}
+	]}]}`, server.URL, server.URL)
+	if _, err := Client.DoPost("/hooks/"+hook.Id, payload, "application/json"); err != nil {
+		t.Fatal(err)
+	}
+
+	list := Client.Must(Client.GetPosts(channel1.Id, 0, 1, "")).Data.(*model.PostList)
+	post := list.Posts[list.Order[0]]
+	actions := post.Attachments()[0].Actions
+	if len(actions) != 2 || len(actions[0].Id) != 26 {
+		t.Fatal("should have given the actions ids")
+	}
+
+	if actions[0].Integration != nil || actions[1].Integration != nil {
+		t.Fatal("shouldn't have sent the integration of the actions to the client")
+	}
+
+	Client.Must(Client.DoPostAction(channel1.Id, post.Id, actions[0].Id, ""))
+
+	req := <-requests
+	if req.UserId != th.SystemAdminUser.Id || req.PostId != post.Id || req.ChannelId != channel1.Id || req.TeamId != team.Id || req.Context["build"] != "12" {
+		t.Fatal("should have sent the context of the action", req)
+	}
+
+	response = `{"update": {"message": "approved", "props": {"attachments": []}}, "ephemeral_text": "thanks"}`
+	if _, err := Client.DoPostAction(channel1.Id, post.Id, actions[1].Id, "production"); err == nil {
+		t.Fatal("should have failed - not one of the options")
+	}
+
+	rpost := Client.Must(Client.DoPostAction(channel1.Id, post.Id, actions[1].Id, "staging")).Data.(*model.Post)
+	if req := <-requests; req.SelectedOption != "staging" {
+		t.Fatal("should have sent the selected option")
+	}
+
+	if rpost.Message != "approved" || rpost.Props["from_webhook"] != "true" || len(rpost.Attachments()) != 0 {
+		t.Fatal("should have updated the post", rpost)
+	}
+
+	if _, err := Client.DoPostAction(channel1.Id, post.Id, actions[0].Id, ""); err == nil {
+		t.Fatal("should have failed - the update removed the actions")
+	}
+
+	if _, err := Client.DoPostAction(channel2.Id, post.Id, actions[0].Id, ""); err == nil {
+		t.Fatal("should have failed - wrong channel")
+	}
+
+	// users can't make the server send requests by adding actions to their own posts
+	userPost := model.PostFromJson(strings.NewReader(fmt.Sprintf(`{"channel_id": "%v", "message": "click me", "props": {
+		"integration_actions": "true",
+		"attachments": [{"text": "pick one", "actions": [{"id": "forged", "name": "Approve", "type": "button", "integration": {"url": "%v"}}]}]
+	}}`, channel1.Id, server.URL)))
+	userPost = Client.Must(Client.CreatePost(userPost)).Data.(*model.Post)
+
+	if attachments := userPost.Attachments(); len(attachments) != 1 || len(attachments[0].Actions) != 0 || userPost.HasIntegrationActions() {
+		t.Fatal("should have stripped the actions", userPost)
+	}
+
+	if _, err := Client.DoPostAction(channel1.Id, userPost.Id, "forged", ""); err == nil {
+		t.Fatal("should have failed - the post wasn't created by an integration")
+	}
+
+	select {
+	case req := <-requests:
+		t.Fatal("shouldn't have sent a request for a user's post", req)
+	default:
+	}
+
+	if _, err := Client.DoPost("/hooks/"+hook.Id, payload, "application/json"); err != nil {
+		t.Fatal(err)
+	}
+
+	list = Client.Must(Client.GetPosts(channel1.Id, 0, 1, "")).Data.(*model.PostList)
+	post = list.Posts[list.Order[0]]
+	actions = post.Attachments()[0].Actions
+
+	post.Message = "edited"
+	Client.Must(Client.UpdatePost(post))
+
+	if _, err := Client.DoPostAction(channel1.Id, post.Id, actions[0].Id, ""); err == nil {
+		t.Fatal("should have failed - the post was edited by a user")
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func InitReaction() {
+	l4g.Debug(utils.T("api.reaction.init.debug"))
+
+	BaseRoutes.NeedPost.Handle("/reactions", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequired(listReactions))).Methods("GET")
+	BaseRoutes.NeedPost.Handle("/reactions/save", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(saveReaction))).Methods("POST")
+	BaseRoutes.NeedPost.Handle("/reactions/delete", ApiScope(model.SCOPE_WRITE_POSTS, ApiUserRequired(deleteReaction))).Methods("POST")
+}
+
+func saveReaction(c *Context, w http.ResponseWriter, r *http.Request) {
+	reaction := model.ReactionFromJson(r.Body)
+	if reaction == nil {
+		c.SetInvalidParam("saveReaction", "reaction")
+		return
+	}
+
+	channelId, post := getReactionPost(c, r, reaction, "saveReaction")
+	if c.Err != nil {
+		return
+	}
+
+	if result := <-Srv.Store.Reaction().Save(reaction); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		reaction = result.Data.(*model.Reaction)
+
+		sendReactionEvent(c, channelId, post, reaction, model.ACTION_REACTION_ADDED)
+
+		w.Write([]byte(reaction.ToJson()))
+	}
+}
+
+func deleteReaction(c *Context, w http.ResponseWriter, r *http.Request) {
+	reaction := model.ReactionFromJson(r.Body)
+	if reaction == nil {
+		c.SetInvalidParam("deleteReaction", "reaction")
+		return
+	}
+
+	channelId, post := getReactionPost(c, r, reaction, "deleteReaction")
+	if c.Err != nil {
+		return
+	}
+
+	if result := <-Srv.Store.Reaction().Delete(reaction); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		sendReactionEvent(c, channelId, post, reaction, model.ACTION_REACTION_REMOVED)
+
+		w.Write([]byte(reaction.ToJson()))
+	}
+}
+
+// getReactionPost checks that the reaction belongs to the current user and to the
+// post in the request, and that the user can see the channel the post is in.
+func getReactionPost(c *Context, r *http.Request, reaction *model.Reaction, where string) (string, *model.Post) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam(where, "channelId")
+		return "", nil
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 || reaction.PostId != postId {
+		c.SetInvalidParam(where, "postId")
+		return "", nil
+	}
+
+	if reaction.UserId != c.Session.UserId {
+		c.Err = model.NewLocAppError(where, "api.reaction.user_id.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return "", nil
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	pchan := Srv.Store.Post().Get(postId)
+
+	if !c.HasPermissionsToChannel(cchan, where) {
+		return "", nil
+	}
+
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return "", nil
+	} else if post := result.Data.(*model.PostList).Posts[postId]; post.ChannelId != channelId {
+		c.Err = model.NewLocAppError(where, "api.reaction.mismatched_channel_id.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return "", nil
+	} else {
+		return channelId, post
+	}
+}
+
+func sendReactionEvent(c *Context, channelId string, post *model.Post, reaction *model.Reaction, action string) {
+	message := model.NewMessage(c.TeamId, channelId, reaction.UserId, action)
+	message.Add("reaction", reaction.ToJson())
+	message.Add("root_id", post.RootId)
+
+	PublishAndForget(message)
+}
+
+func listReactions(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	channelId := params["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("listReactions", "channelId")
+		return
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("listReactions", "postId")
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, channelId, c.Session.UserId)
+	pchan := Srv.Store.Post().Get(postId)
+
+	if !c.HasPermissionsToChannel(cchan, "listReactions") {
+		return
+	}
+
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if post := result.Data.(*model.PostList).Posts[postId]; post.ChannelId != channelId {
+		c.Err = model.NewLocAppError("listReactions", "api.reaction.mismatched_channel_id.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	if result := <-Srv.Store.Reaction().GetForPost(postId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(model.ReactionsToJson(result.Data.([]*model.Reaction))))
+	}
+}
+
+func addReactionsToPostList(list *model.PostList) *model.AppError {
+	if len(list.Posts) == 0 {
+		return nil
+	}
+
+	postIds := make([]string, 0, len(list.Posts))
+	for id := range list.Posts {
+		postIds = append(postIds, id)
+	}
+
+	if result := <-Srv.Store.Reaction().GetForPosts(postIds); result.Err != nil {
+		return result.Err
+	} else {
+		for _, reaction := range result.Data.([]*model.Reaction) {
+			list.AddReaction(reaction)
+		}
+	}
+
+	return nil
+}
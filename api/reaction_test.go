@@ -0,0 +1,79 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestSaveReaction(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel := th.BasicChannel
+	post := th.BasicPost
+
+	reaction := &model.Reaction{UserId: th.BasicUser.Id, PostId: post.Id, EmojiName: "smile"}
+
+	if saved := Client.Must(Client.SaveReaction(channel.Id, reaction)).Data.(*model.Reaction); saved.EmojiName != reaction.EmojiName {
+		t.Fatal("should have saved the reaction")
+	}
+
+	// saving the same reaction twice should not create a duplicate
+	Client.Must(Client.SaveReaction(channel.Id, reaction))
+
+	if reactions := Client.Must(Client.ListReactions(channel.Id, post.Id)).Data.([]*model.Reaction); len(reactions) != 1 {
+		t.Fatal("should have one reaction")
+	}
+
+	list := Client.Must(Client.GetPosts(channel.Id, 0, 10, "")).Data.(*model.PostList)
+	if len(list.Reactions[post.Id]) != 1 {
+		t.Fatal("post list should include the reaction")
+	}
+
+	if _, err := Client.SaveReaction(channel.Id, &model.Reaction{UserId: th.BasicUser2.Id, PostId: post.Id, EmojiName: "smile"}); err == nil {
+		t.Fatal("should not be able to react as another user")
+	}
+
+	if _, err := Client.SaveReaction(channel.Id, &model.Reaction{UserId: th.BasicUser.Id, PostId: post.Id, EmojiName: "not valid"}); err == nil {
+		t.Fatal("should have failed with an invalid emoji name")
+	}
+
+	th.LoginBasic2()
+
+	if _, err := Client.SaveReaction(channel.Id, &model.Reaction{UserId: th.BasicUser2.Id, PostId: post.Id, EmojiName: "smile"}); err == nil {
+		t.Fatal("should not be able to react in a channel the user is not a member of")
+	}
+
+	if _, err := Client.ListReactions(channel.Id, post.Id); err == nil {
+		t.Fatal("should not be able to list reactions in a channel the user is not a member of")
+	}
+}
+
+func TestDeleteReaction(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+	channel := th.BasicChannel
+	post := th.BasicPost
+
+	reaction1 := &model.Reaction{UserId: th.BasicUser.Id, PostId: post.Id, EmojiName: "smile"}
+	Client.Must(Client.SaveReaction(channel.Id, reaction1))
+
+	th.LoginBasic2()
+	Client.Must(Client.JoinChannel(channel.Id))
+
+	reaction2 := &model.Reaction{UserId: th.BasicUser2.Id, PostId: post.Id, EmojiName: "smile"}
+	Client.Must(Client.SaveReaction(channel.Id, reaction2))
+
+	if _, err := Client.DeleteReaction(channel.Id, reaction1); err == nil {
+		t.Fatal("should not be able to remove another user's reaction")
+	}
+
+	Client.Must(Client.DeleteReaction(channel.Id, reaction2))
+
+	if reactions := Client.Must(Client.ListReactions(channel.Id, post.Id)).Data.([]*model.Reaction); len(reactions) != 1 || reactions[0].UserId != th.BasicUser.Id {
+		t.Fatal("should have removed only the user's own reaction")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	b64 "encoding/base64"
+	"net/http"
+	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+func InitSaml() {
+	l4g.Debug(utils.T("api.saml.init.debug"))
+
+	BaseRoutes.ApiRoot.Handle("/saml/metadata", AppHandlerIndependent(samlMetadata)).Methods("GET")
+
+	BaseRoutes.Root.Handle("/login/sso/saml", AppHandlerIndependent(loginWithSaml)).Methods("GET")
+	BaseRoutes.Root.Handle("/login/sso/saml", AppHandlerIndependent(completeSaml)).Methods("POST")
+}
+
+func getSamlInterface(c *Context, where string) einterfaces.SamlInterface {
+	samlInterface := einterfaces.GetSamlInterface()
+	if samlInterface == nil || !*utils.Cfg.SamlSettings.Enable {
+		c.Err = model.NewLocAppError(where, "api.saml.not_available.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return nil
+	}
+
+	return samlInterface
+}
+
+func samlMetadata(c *Context, w http.ResponseWriter, r *http.Request) {
+	samlInterface := getSamlInterface(c, "samlMetadata")
+	if samlInterface == nil {
+		return
+	}
+
+	if metadata, err := samlInterface.GetMetadata(); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"metadata.xml\"")
+		w.Write([]byte(metadata))
+	}
+}
+
+func loginWithSaml(c *Context, w http.ResponseWriter, r *http.Request) {
+	samlInterface := getSamlInterface(c, "loginWithSaml")
+	if samlInterface == nil {
+		return
+	}
+
+	relayProps := map[string]string{}
+	relayProps["action"] = model.OAUTH_ACTION_LOGIN
+
+	// only redirect within the site once the user is logged in
+	if redirectTo := r.URL.Query().Get("redirect_to"); strings.HasPrefix(redirectTo, "/") && !strings.HasPrefix(redirectTo, "//") {
+		relayProps["redirect_to"] = redirectTo
+	}
+
+	relayState := b64.StdEncoding.EncodeToString([]byte(model.MapToJson(relayProps)))
+
+	if requestUrl, err := samlInterface.BuildRequest(relayState); err != nil {
+		c.Err = err
+		return
+	} else {
+		http.Redirect(w, r, requestUrl, http.StatusFound)
+	}
+}
+
+// updateSamlUser copies the attributes mapped from the assertion to the existing user and
+// returns true if anything changed.
+func updateSamlUser(user *model.User, samlUser *model.User) bool {
+	changed := false
+
+	if user.FirstName != samlUser.FirstName {
+		user.FirstName = samlUser.FirstName
+		changed = true
+	}
+
+	if user.LastName != samlUser.LastName {
+		user.LastName = samlUser.LastName
+		changed = true
+	}
+
+	if len(*utils.Cfg.SamlSettings.NicknameAttribute) > 0 && user.Nickname != samlUser.Nickname {
+		user.Nickname = samlUser.Nickname
+		changed = true
+	}
+
+	if user.Email != samlUser.Email {
+		user.Email = samlUser.Email
+		changed = true
+	}
+
+	return changed
+}
+
+func completeSaml(c *Context, w http.ResponseWriter, r *http.Request) {
+	samlInterface := getSamlInterface(c, "completeSaml")
+	if samlInterface == nil {
+		return
+	}
+
+	samlUser, err := samlInterface.DoLogin(r.FormValue("SAMLResponse"))
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	relayProps := map[string]string{}
+	if b, err := b64.StdEncoding.DecodeString(r.FormValue("RelayState")); err == nil {
+		relayProps = model.MapFromJson(strings.NewReader(string(b)))
+	}
+
+	if result := <-Srv.Store.User().GetByAuth(samlUser.AuthData, model.USER_AUTH_SERVICE_SAML); result.Err != nil {
+		if result.Err.Id != store.MISSING_AUTH_ACCOUNT_ERROR {
+			c.Err = result.Err
+			return
+		}
+
+		if !utils.Cfg.TeamSettings.EnableUserCreation {
+			c.Err = model.NewLocAppError("completeSaml", "web.singup_with_oauth.disabled.app_error", nil, "")
+			c.Err.StatusCode = http.StatusNotImplemented
+			return
+		}
+
+		c.LogAudit("attempt - create user, auth_data=" + samlUser.AuthData)
+
+		CreateOAuthUser(c, w, r, model.USER_AUTH_SERVICE_SAML, strings.NewReader(samlUser.ToJson()), "")
+		if c.Err != nil {
+			return
+		}
+	} else {
+		user := result.Data.(*model.User)
+
+		if err := checkUserNotDisabled(user); err != nil {
+			c.Err = err
+			return
+		}
+
+		if updateSamlUser(user, samlUser) {
+			if result := <-Srv.Store.User().Update(user, true); result.Err != nil {
+				c.Err = result.Err
+				return
+			}
+
+			// the identity provider vouches for the address
+			if result := <-Srv.Store.User().VerifyEmail(user.Id); result.Err != nil {
+				l4g.Error(result.Err.Error())
+			}
+		}
+
+		LoginByOAuth(c, w, r, model.USER_AUTH_SERVICE_SAML, strings.NewReader(samlUser.ToJson()))
+		if c.Err != nil {
+			return
+		}
+	}
+
+	redirectTo := GetProtocol(r) + "://" + r.Host
+	if path := relayProps["redirect_to"]; strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "//") {
+		redirectTo += path
+	}
+
+	http.Redirect(w, r, redirectTo, http.StatusFound)
+}
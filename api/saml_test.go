@@ -0,0 +1,48 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	_ "github.com/mattermost/platform/saml"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestSamlMetadata(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enableSaml := *utils.Cfg.SamlSettings.Enable
+	acs := *utils.Cfg.SamlSettings.AssertionConsumerServiceURL
+	defer func() {
+		*utils.Cfg.SamlSettings.Enable = enableSaml
+		*utils.Cfg.SamlSettings.AssertionConsumerServiceURL = acs
+	}()
+
+	*utils.Cfg.SamlSettings.Enable = false
+	if r, err := http.Get(Client.ApiUrl + "/saml/metadata"); err != nil {
+		t.Fatal(err)
+	} else if r.StatusCode != http.StatusNotImplemented {
+		t.Fatal("should have failed - saml is disabled")
+	}
+
+	*utils.Cfg.SamlSettings.Enable = true
+	*utils.Cfg.SamlSettings.AssertionConsumerServiceURL = Client.Url + "/login/sso/saml"
+
+	if r, err := http.Get(Client.ApiUrl + "/saml/metadata"); err != nil {
+		t.Fatal(err)
+	} else if body, _ := ioutil.ReadAll(r.Body); r.StatusCode != http.StatusOK || !strings.Contains(string(body), `entityID="`+Client.Url+`/login/sso/saml"`) {
+		t.Fatal("should have returned the metadata", string(body))
+	}
+
+	if r, err := http.PostForm(Client.Url+"/login/sso/saml", map[string][]string{"SAMLResponse": {"bm90IGEgcmVzcG9uc2U="}}); err != nil {
+		t.Fatal(err)
+	} else if r.Request.URL.Path != "/error" {
+		t.Fatal("should have shown an error - invalid response")
+	}
+}
@@ -88,6 +88,7 @@ func StopServer() {
 
 	manners.Close()
 	Srv.Store.Close()
+	StopBackplane()
 	hub.Stop()
 
 	l4g.Info(utils.T("api.server.stop_server.stopped.info"))
@@ -50,26 +50,27 @@ func InitUser() {
 	BaseRoutes.Users.Handle("/verify_email", ApiAppHandler(verifyEmail)).Methods("POST")
 	BaseRoutes.Users.Handle("/resend_verification", ApiAppHandler(resendVerification)).Methods("POST")
 	BaseRoutes.Users.Handle("/newimage", ApiUserRequired(uploadProfileImage)).Methods("POST")
-	BaseRoutes.Users.Handle("/me", ApiAppHandler(getMe)).Methods("GET")
+	BaseRoutes.Users.Handle("/me", ApiScope(model.SCOPE_READ_USERS, ApiAppHandler(getMe))).Methods("GET")
 	BaseRoutes.Users.Handle("/initial_load", ApiAppHandler(getInitialLoad)).Methods("GET")
-	BaseRoutes.Users.Handle("/status", ApiUserRequiredActivity(getStatuses, false)).Methods("POST")
-	BaseRoutes.Users.Handle("/direct_profiles", ApiUserRequired(getDirectProfiles)).Methods("GET")
-	BaseRoutes.Users.Handle("/profiles/{id:[A-Za-z0-9]+}", ApiUserRequired(getProfiles)).Methods("GET")
-	BaseRoutes.Users.Handle("/profiles_for_dm_list/{id:[A-Za-z0-9]+}", ApiUserRequired(getProfilesForDirectMessageList)).Methods("GET")
+	BaseRoutes.Users.Handle("/status", ApiScope(model.SCOPE_READ_USERS, ApiUserRequiredActivity(getStatuses, false))).Methods("POST")
+	BaseRoutes.Users.Handle("/direct_profiles", ApiScope(model.SCOPE_READ_USERS, ApiUserRequired(getDirectProfiles))).Methods("GET")
+	BaseRoutes.Users.Handle("/profiles/{id:[A-Za-z0-9]+}", ApiScope(model.SCOPE_READ_USERS, ApiUserRequired(getProfiles))).Methods("GET")
+	BaseRoutes.Users.Handle("/profiles_for_dm_list/{id:[A-Za-z0-9]+}", ApiScope(model.SCOPE_READ_USERS, ApiUserRequired(getProfilesForDirectMessageList))).Methods("GET")
 
 	BaseRoutes.Users.Handle("/mfa", ApiAppHandler(checkMfa)).Methods("POST")
 	BaseRoutes.Users.Handle("/generate_mfa_qr", ApiUserRequiredTrustRequester(generateMfaQrCode)).Methods("GET")
 	BaseRoutes.Users.Handle("/update_mfa", ApiUserRequired(updateMfa)).Methods("POST")
+	BaseRoutes.Users.Handle("/generate_mfa_recovery_codes", ApiUserRequired(generateMfaRecoveryCodes)).Methods("POST")
 
 	BaseRoutes.Users.Handle("/claim/email_to_oauth", ApiAppHandler(emailToOAuth)).Methods("POST")
 	BaseRoutes.Users.Handle("/claim/oauth_to_email", ApiUserRequired(oauthToEmail)).Methods("POST")
 	BaseRoutes.Users.Handle("/claim/email_to_ldap", ApiAppHandler(emailToLdap)).Methods("POST")
 	BaseRoutes.Users.Handle("/claim/ldap_to_email", ApiAppHandler(ldapToEmail)).Methods("POST")
 
-	BaseRoutes.NeedUser.Handle("/get", ApiUserRequired(getUser)).Methods("GET")
+	BaseRoutes.NeedUser.Handle("/get", ApiScope(model.SCOPE_READ_USERS, ApiUserRequired(getUser))).Methods("GET")
 	BaseRoutes.NeedUser.Handle("/sessions", ApiUserRequired(getSessions)).Methods("GET")
 	BaseRoutes.NeedUser.Handle("/audits", ApiUserRequired(getAudits)).Methods("GET")
-	BaseRoutes.NeedUser.Handle("/image", ApiUserRequiredTrustRequester(getProfileImage)).Methods("GET")
+	BaseRoutes.NeedUser.Handle("/image", ApiScope(model.SCOPE_READ_USERS, ApiUserRequiredTrustRequester(getProfileImage))).Methods("GET")
 }
 
 func createUser(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -92,6 +93,11 @@ func createUser(c *Context, w http.ResponseWriter, r *http.Request) {
 	sendWelcomeEmail := true
 	user.EmailVerified = false
 
+	// bots can only be created through createBot
+	user.IsBot = false
+	user.BotOwnerId = ""
+	user.BotOwnerType = ""
+
 	if len(hash) > 0 {
 		data := r.URL.Query().Get("d")
 		props := model.MapFromJson(strings.NewReader(data))
@@ -156,6 +162,13 @@ func createUser(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(user.AuthData) == 0 {
+		if err := utils.IsPasswordValid(user.Password); err != nil {
+			c.Err = err
+			return
+		}
+	}
+
 	ruser, err := CreateUser(user)
 	if err != nil {
 		c.Err = err
@@ -708,6 +721,10 @@ func RevokeSessionById(c *Context, sessionId string) {
 }
 
 func RevokeAllSession(c *Context, userId string) {
+	// the sessions of personal access tokens only live in the cache
+	RemoveAllSessionsForUserId(userId)
+	mfaSatisfiedCache.Remove(userId)
+
 	if result := <-Srv.Store.Session().GetSessions(userId); result.Err != nil {
 		c.Err = result.Err
 		return
@@ -764,7 +781,7 @@ func logout(c *Context, w http.ResponseWriter, r *http.Request) {
 func Logout(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.LogAudit("")
 	c.RemoveSessionCookie(w, r)
-	if c.Session.Id != "" {
+	if c.Session.Id != "" && !c.Session.IsUserAccessToken() {
 		RevokeSessionById(c, c.Session.Id)
 	}
 }
@@ -1311,7 +1328,7 @@ func updatePassword(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	newPassword := props["new_password"]
-	if len(newPassword) < 5 {
+	if len(newPassword) == 0 {
 		c.SetInvalidParam("updatePassword", "new_password")
 		return
 	}
@@ -1337,6 +1354,12 @@ func updatePassword(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	user := result.Data.(*model.User)
 
+	if user.IsBot {
+		c.Err = model.NewLocAppError("updatePassword", "api.user.update_password.bot.app_error", nil, "")
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
 	if user.AuthData != "" {
 		c.LogAudit("failed - tried to update user password who was logged in through oauth")
 		c.Err = model.NewLocAppError("updatePassword", "api.user.update_password.oauth.app_error", nil, "auth_service="+user.AuthService)
@@ -1350,8 +1373,13 @@ func updatePassword(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if uresult := <-Srv.Store.User().UpdatePassword(c.Session.UserId, model.HashPassword(newPassword)); uresult.Err != nil {
-		c.Err = model.NewLocAppError("updatePassword", "api.user.update_password.failed.app_error", nil, uresult.Err.Error())
+	if err := checkPasswordPolicy(user, newPassword); err != nil {
+		c.Err = err
+		return
+	}
+
+	if err := savePassword(user, newPassword); err != nil {
+		c.Err = model.NewLocAppError("updatePassword", "api.user.update_password.failed.app_error", nil, err.Error())
 		return
 	} else {
 		c.LogAudit("completed")
@@ -1359,7 +1387,7 @@ func updatePassword(c *Context, w http.ResponseWriter, r *http.Request) {
 		sendPasswordChangeEmailAndForget(c, user.Email, c.GetSiteURL(), c.T("api.user.update_password.menu"))
 
 		data := make(map[string]string)
-		data["user_id"] = uresult.Data.(string)
+		data["user_id"] = user.Id
 		w.Write([]byte(model.MapToJson(data)))
 	}
 }
@@ -1472,6 +1500,7 @@ func updateRoles(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	RemoveAllSessionsForUserId(user_id)
+	mfaSatisfiedCache.Remove(user_id)
 
 	data := make(map[string]string)
 	data["user_id"] = user_id
@@ -1491,6 +1520,10 @@ func UpdateUserRoles(c *Context, user *model.User, roles string) *model.User {
 		ruser = result.Data.([2]*model.User)[0]
 	}
 
+	// whether MFA is enforced can depend on the user's roles
+	mfaSatisfiedCache.Remove(user.Id)
+	publishToCluster(model.NewClusterMessage(model.CLUSTER_EVENT_INVALIDATE_USER, user.Id, nil))
+
 	return ruser
 }
 
@@ -1577,6 +1610,10 @@ func PermanentDeleteUser(c *Context, user *model.User) *model.AppError {
 		return result.Err
 	}
 
+	if result := <-Srv.Store.UserAccessToken().PermanentDeleteByUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
 	if result := <-Srv.Store.Webhook().PermanentDeleteIncomingByUser(user.Id); result.Err != nil {
 		return result.Err
 	}
@@ -1601,6 +1638,8 @@ func PermanentDeleteUser(c *Context, user *model.User) *model.AppError {
 		return result.Err
 	}
 
+	DeleteUserPostsFromIndexAndForget(user.Id)
+
 	if result := <-Srv.Store.User().PermanentDelete(user.Id); result.Err != nil {
 		return result.Err
 	}
@@ -1617,6 +1656,18 @@ func PermanentDeleteUser(c *Context, user *model.User) *model.AppError {
 		return result.Err
 	}
 
+	if result := <-Srv.Store.NotificationQueue().PermanentDeleteByUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.Thread().PermanentDeleteByUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.Reaction().PermanentDeleteByUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
 	l4g.Warn(utils.T("api.user.permanent_delete_user.deleted.warn"), user.Email, user.Id)
 	c.LogAuditWithUserId("", fmt.Sprintf("success userId=%v", user.Id))
 
@@ -1658,6 +1709,11 @@ func sendPasswordReset(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.IsBot {
+		c.Err = model.NewLocAppError("sendPasswordReset", "api.user.reset_password.bot.app_error", nil, "userId="+user.Id)
+		return
+	}
+
 	recovery := &model.PasswordRecovery{}
 	recovery.UserId = user.Id
 
@@ -1692,7 +1748,7 @@ func resetPassword(c *Context, w http.ResponseWriter, r *http.Request) {
 	props := model.MapFromJson(r.Body)
 
 	newPassword := props["new_password"]
-	if len(newPassword) < model.MIN_PASSWORD_LENGTH {
+	if len(newPassword) == 0 {
 		c.SetInvalidParam("resetPassword", "new_password")
 		return
 	}
@@ -1721,19 +1777,20 @@ func resetPassword(c *Context, w http.ResponseWriter, r *http.Request) {
 			c.Err = model.NewLocAppError("resetPassword", "api.user.reset_password.link_expired.app_error", nil, "")
 			return
 		}
-
-		go func() {
-			if result := <-Srv.Store.PasswordRecovery().Delete(userId); result.Err != nil {
-				l4g.Error("%v", result.Err)
-			}
-		}()
 	}
 
+	// the link stays valid until a password that meets the requirements is chosen
 	if err := ResetPassword(c, userId, newPassword); err != nil {
 		c.Err = err
 		return
 	}
 
+	go func() {
+		if result := <-Srv.Store.PasswordRecovery().Delete(userId); result.Err != nil {
+			l4g.Error("%v", result.Err)
+		}
+	}()
+
 	c.LogAuditWithUserId(userId, "success")
 
 	rdata := map[string]string{}
@@ -1754,8 +1811,16 @@ func ResetPassword(c *Context, userId, newPassword string) *model.AppError {
 
 	}
 
-	if result := <-Srv.Store.User().UpdatePassword(userId, model.HashPassword(newPassword)); result.Err != nil {
-		return result.Err
+	if user.IsBot {
+		return model.NewLocAppError("ResetPassword", "api.user.reset_password.bot.app_error", nil, "userId="+user.Id)
+	}
+
+	if err := checkPasswordPolicy(user, newPassword); err != nil {
+		return err
+	}
+
+	if err := savePassword(user, newPassword); err != nil {
+		return err
 	}
 
 	sendPasswordChangeEmailAndForget(c, user.Email, c.GetSiteURL(), c.T("api.user.reset_password.method"))
@@ -1763,6 +1828,55 @@ func ResetPassword(c *Context, userId, newPassword string) *model.AppError {
 	return nil
 }
 
+// checkPasswordPolicy checks a new password against the password settings and the passwords
+// the user had before.
+func checkPasswordPolicy(user *model.User, newPassword string) *model.AppError {
+	if err := utils.IsPasswordValid(newPassword); err != nil {
+		return err
+	}
+
+	count := *utils.Cfg.PasswordSettings.HistoryCount
+	if count == 0 {
+		return nil
+	}
+
+	previous := append([]string{user.Password}, strings.Fields(user.PasswordHistory)...)
+	for i, hash := range previous {
+		if i >= count {
+			break
+		}
+
+		if len(hash) > 0 && model.ComparePassword(hash, newPassword) {
+			err := model.NewLocAppError("checkPasswordPolicy", "api.user.check_password_policy.reused.app_error", map[string]interface{}{"Count": count}, "user_id="+user.Id)
+			err.StatusCode = http.StatusBadRequest
+			return err
+		}
+	}
+
+	return nil
+}
+
+// savePassword updates the password of the user and, if passwords can't be reused, adds the
+// one it replaces to the front of the user's password history.
+func savePassword(user *model.User, newPassword string) *model.AppError {
+	if result := <-Srv.Store.User().UpdatePassword(user.Id, model.HashPassword(newPassword)); result.Err != nil {
+		return result.Err
+	}
+
+	if count := *utils.Cfg.PasswordSettings.HistoryCount; count > 1 && len(user.Password) > 0 {
+		history := append([]string{user.Password}, strings.Fields(user.PasswordHistory)...)
+		if len(history) > count-1 {
+			history = history[:count-1]
+		}
+
+		if result := <-Srv.Store.User().UpdatePasswordHistory(user.Id, strings.Join(history, " ")); result.Err != nil {
+			return result.Err
+		}
+	}
+
+	return nil
+}
+
 func sendPasswordChangeEmailAndForget(c *Context, email, siteURL, method string) {
 	go func() {
 
@@ -1851,6 +1965,11 @@ func updateUserNotify(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if interval, ok := props["email_interval"]; ok && !model.IsValidEmailInterval(interval) {
+		c.SetInvalidParam("updateUserNotify", "email_interval")
+		return
+	}
+
 	desktop_sound := props["desktop_sound"]
 	if len(desktop_sound) == 0 {
 		c.SetInvalidParam("updateUserNotify", "desktop_sound")
@@ -2019,6 +2138,11 @@ func oauthToEmail(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := utils.IsPasswordValid(password); err != nil {
+		c.Err = err
+		return
+	}
+
 	if result := <-Srv.Store.User().UpdatePassword(c.Session.UserId, model.HashPassword(password)); result.Err != nil {
 		c.LogAudit("fail - database issue")
 		c.Err = result.Err
@@ -2147,6 +2271,11 @@ func ldapToEmail(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := utils.IsPasswordValid(emailPassword); err != nil {
+		c.Err = err
+		return
+	}
+
 	ldapInterface := einterfaces.GetLdapInterface()
 	if ldapInterface == nil {
 		c.Err = model.NewLocAppError("ldapToEmail", "api.user.ldap_to_email.not_available.app_error", nil, "")
@@ -2252,6 +2381,12 @@ func resendVerification(c *Context, w http.ResponseWriter, r *http.Request) {
 }
 
 func generateMfaQrCode(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !*utils.Cfg.ServiceSettings.EnableMultifactorAuthentication {
+		c.Err = model.NewLocAppError("generateMfaQrCode", "api.user.generate_mfa_qr.not_available.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
 	uchan := Srv.Store.User().Get(c.Session.UserId)
 
 	var user *model.User
@@ -2306,6 +2441,15 @@ func updateMfa(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	} else {
+		if result := <-Srv.Store.User().Get(c.Session.UserId); result.Err != nil {
+			c.Err = result.Err
+			return
+		} else if isMfaEnforced(result.Data.(*model.User)) {
+			c.Err = model.NewLocAppError("updateMfa", "api.user.update_mfa.enforced.app_error", nil, "")
+			c.Err.StatusCode = http.StatusForbidden
+			return
+		}
+
 		if err := DeactivateMfa(c.Session.UserId); err != nil {
 			c.Err = err
 			return
@@ -2319,7 +2463,7 @@ func updateMfa(c *Context, w http.ResponseWriter, r *http.Request) {
 
 func ActivateMfa(userId, token string) *model.AppError {
 	mfaInterface := einterfaces.GetMfaInterface()
-	if mfaInterface == nil {
+	if mfaInterface == nil || !*utils.Cfg.ServiceSettings.EnableMultifactorAuthentication {
 		err := model.NewLocAppError("ActivateMfa", "api.user.update_mfa.not_available.app_error", nil, "")
 		err.StatusCode = http.StatusNotImplemented
 		return err
@@ -2351,11 +2495,44 @@ func DeactivateMfa(userId string) *model.AppError {
 		return err
 	}
 
+	mfaSatisfiedCache.Remove(userId)
+	InvalidateCacheForUser(userId)
+
 	return nil
 }
 
+// generateMfaRecoveryCodes replaces the recovery codes of the user. The codes are only ever
+// returned here since they're stored hashed.
+func generateMfaRecoveryCodes(c *Context, w http.ResponseWriter, r *http.Request) {
+	mfaInterface := einterfaces.GetMfaInterface()
+	if mfaInterface == nil || !*utils.Cfg.ServiceSettings.EnableMultifactorAuthentication {
+		c.Err = model.NewLocAppError("generateMfaRecoveryCodes", "api.user.update_mfa.not_available.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	var user *model.User
+	if result := <-Srv.Store.User().Get(c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	codes, err := mfaInterface.GenerateRecoveryCodes(user)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("generated mfa recovery codes")
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(model.ArrayToJson(codes)))
+}
+
 func checkMfa(c *Context, w http.ResponseWriter, r *http.Request) {
-	if !utils.IsLicensed || !*utils.License.Features.MFA || !*utils.Cfg.ServiceSettings.EnableMultifactorAuthentication {
+	if !*utils.Cfg.ServiceSettings.EnableMultifactorAuthentication {
 		rdata := map[string]string{}
 		rdata["mfa_required"] = "false"
 		w.Write([]byte(model.MapToJson(rdata)))
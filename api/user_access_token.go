@@ -0,0 +1,200 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func InitUserAccessToken() {
+	l4g.Debug(utils.T("api.user_access_token.init.debug"))
+
+	BaseRoutes.NeedUser.Handle("/tokens", ApiUserRequired(getUserAccessTokens)).Methods("GET")
+	BaseRoutes.NeedUser.Handle("/tokens/create", ApiUserRequired(createUserAccessToken)).Methods("POST")
+	BaseRoutes.NeedUser.Handle("/tokens/revoke", ApiUserRequired(revokeUserAccessToken)).Methods("POST")
+}
+
+func checkUserAccessTokensEnabled(c *Context, where string) bool {
+	if !*utils.Cfg.ServiceSettings.EnableUserAccessTokens {
+		c.Err = model.NewLocAppError(where, "api.user_access_token.disabled.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return false
+	}
+
+	return true
+}
+
+func createUserAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkUserAccessTokensEnabled(c, "createUserAccessToken") {
+		return
+	}
+
+	userId := mux.Vars(r)["user_id"]
+	if !hasPermissionsToUserOrBot(c, userId, "createUserAccessToken") {
+		return
+	}
+
+	// a leaked token shouldn't be enough to mint new ones
+	if c.Session.IsUserAccessToken() {
+		c.Err = model.NewLocAppError("createUserAccessToken", "api.user_access_token.create.session.app_error", nil, "")
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	props := model.MapFromJson(r.Body)
+
+	c.LogAudit("attempt")
+
+	token := &model.UserAccessToken{UserId: userId, Description: props["description"]}
+
+	if result := <-Srv.Store.User().Get(userId); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	if result := <-Srv.Store.UserAccessToken().Save(token); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		token = result.Data.(*model.UserAccessToken)
+	}
+
+	c.LogAuditWithUserId(userId, "success - token_id="+token.Id)
+
+	w.Write([]byte(token.ToJson()))
+}
+
+func getUserAccessTokens(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkUserAccessTokensEnabled(c, "getUserAccessTokens") {
+		return
+	}
+
+	userId := mux.Vars(r)["user_id"]
+	if !hasPermissionsToUserOrBot(c, userId, "getUserAccessTokens") {
+		return
+	}
+
+	if result := <-Srv.Store.UserAccessToken().GetByUser(userId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(model.UserAccessTokenListToJson(result.Data.([]*model.UserAccessToken))))
+	}
+}
+
+func revokeUserAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !checkUserAccessTokensEnabled(c, "revokeUserAccessToken") {
+		return
+	}
+
+	userId := mux.Vars(r)["user_id"]
+	if !hasPermissionsToUserOrBot(c, userId, "revokeUserAccessToken") {
+		return
+	}
+
+	props := model.MapFromJson(r.Body)
+
+	tokenId := props["token_id"]
+	if len(tokenId) != 26 {
+		c.SetInvalidParam("revokeUserAccessToken", "token_id")
+		return
+	}
+
+	var token *model.UserAccessToken
+	if result := <-Srv.Store.UserAccessToken().Get(tokenId); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusNotFound
+		return
+	} else {
+		token = result.Data.(*model.UserAccessToken)
+	}
+
+	if token.UserId != userId {
+		c.SetInvalidParam("revokeUserAccessToken", "token_id")
+		return
+	}
+
+	if c.Err = RevokeUserAccessToken(token); c.Err != nil {
+		return
+	}
+
+	c.LogAuditWithUserId(userId, "token_id="+token.Id)
+
+	w.Write([]byte(model.MapToJson(props)))
+}
+
+// RevokeUserAccessToken deletes a personal access token and drops the session cached for it.
+func RevokeUserAccessToken(token *model.UserAccessToken) *model.AppError {
+	if result := <-Srv.Store.UserAccessToken().Delete(token.Id); result.Err != nil {
+		return result.Err
+	}
+
+	// only the hash of the token is stored so the cached session has to be found by its id,
+	// the other servers of the cluster may have cached it too
+	RemoveSessionFromCacheById(token.Id)
+	publishToCluster(model.NewClusterMessage(model.CLUSTER_EVENT_REMOVE_SESSION, token.Id, nil))
+
+	return nil
+}
+
+// getSessionForUserAccessToken builds a session for a personal access token. The session is
+// only kept in the cache and its id is the id of the token so audits record which token was used.
+func getSessionForUserAccessToken(tokenString string) *model.Session {
+	if !*utils.Cfg.ServiceSettings.EnableUserAccessTokens {
+		return nil
+	}
+
+	var token *model.UserAccessToken
+	if result := <-Srv.Store.UserAccessToken().GetByToken(tokenString); result.Err != nil {
+		l4g.Error(result.Err.Error())
+		return nil
+	} else if result.Data == nil {
+		return nil
+	} else {
+		token = result.Data.(*model.UserAccessToken)
+	}
+
+	uchan := Srv.Store.User().Get(token.UserId)
+	tchan := Srv.Store.Team().GetTeamsForUser(token.UserId)
+
+	var user *model.User
+	if result := <-uchan; result.Err != nil {
+		l4g.Error(result.Err.Error())
+		return nil
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	if user.DeleteAt != 0 {
+		return nil
+	}
+
+	session := &model.Session{
+		Id:     token.Id,
+		Token:  tokenString,
+		UserId: user.Id,
+		Roles:  user.Roles,
+		Props:  model.StringMap{model.SESSION_PROP_USER_ACCESS_TOKEN_ID: token.Id},
+	}
+
+	if user.IsBot {
+		session.AddProp(model.SESSION_PROP_IS_BOT, "true")
+	}
+
+	if result := <-tchan; result.Err != nil {
+		l4g.Error(result.Err.Error())
+		return nil
+	} else {
+		session.TeamMembers = result.Data.([]*model.TeamMember)
+	}
+
+	AddSessionToCache(session)
+
+	return session
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestUserAccessTokens(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enableUserAccessTokens := *utils.Cfg.ServiceSettings.EnableUserAccessTokens
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableUserAccessTokens = enableUserAccessTokens
+	}()
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = false
+
+	if _, err := Client.CreateUserAccessToken(th.BasicUser.Id, "deploy"); err == nil {
+		t.Fatal("should have failed - personal access tokens are disabled")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = true
+
+	if _, err := Client.CreateUserAccessToken(th.BasicUser2.Id, "deploy"); err == nil {
+		t.Fatal("should have failed - can't create tokens for another user")
+	}
+
+	if _, err := Client.CreateUserAccessToken(th.BasicUser.Id, ""); err == nil {
+		t.Fatal("should have failed - missing description")
+	}
+
+	token := Client.Must(Client.CreateUserAccessToken(th.BasicUser.Id, "deploy")).Data.(*model.UserAccessToken)
+	if len(token.Token) != 26 {
+		t.Fatal("should have returned the token")
+	}
+
+	if tokens := Client.Must(Client.GetUserAccessTokens(th.BasicUser.Id)).Data.([]*model.UserAccessToken); len(tokens) != 1 || tokens[0].Id != token.Id || len(tokens[0].Token) != 0 {
+		t.Fatal("should have listed the token without its value")
+	}
+
+	tokenClient := th.CreateClient()
+	tokenClient.AuthToken = token.Token
+	tokenClient.AuthType = model.HEADER_BEARER
+	tokenClient.SetTeamId(th.BasicTeam.Id)
+
+	if result, err := tokenClient.GetMe(""); err != nil {
+		t.Fatal(err)
+	} else if user, _ := result.Data.(*model.User); user == nil || user.Id != th.BasicUser.Id {
+		t.Fatal("should have authenticated as the owner of the token")
+	}
+
+	tokenClient.Must(tokenClient.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "posted with a token"}))
+
+	if _, err := tokenClient.CreateUserAccessToken(th.BasicUser.Id, "another"); err == nil {
+		t.Fatal("should have failed - tokens can't create tokens")
+	}
+
+	found := false
+	for _, audit := range Client.Must(Client.GetAudits(th.BasicUser.Id, "")).Data.(model.Audits) {
+		if strings.Contains(audit.ExtraInfo, "user_access_token_id="+token.Id) && audit.SessionId == token.Id {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("should have audited the use of the token")
+	}
+
+	th.LoginBasic2()
+	if _, err := Client.RevokeUserAccessToken(th.BasicUser.Id, token.Id); err == nil {
+		t.Fatal("should have failed - can't revoke another user's token")
+	}
+
+	th.LoginBasic()
+	Client.Must(Client.RevokeUserAccessToken(th.BasicUser.Id, token.Id))
+
+	if _, err := tokenClient.GetAllPreferences(); err == nil {
+		t.Fatal("should have failed - the token was revoked")
+	} else if err.StatusCode != http.StatusUnauthorized {
+		t.Fatal("should have been unauthorized", err.StatusCode)
+	}
+
+	token = Client.Must(Client.CreateUserAccessToken(th.BasicUser.Id, "deploy")).Data.(*model.UserAccessToken)
+	tokenClient.AuthToken = token.Token
+	tokenClient.Must(tokenClient.GetAllPreferences())
+
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = false
+
+	if _, err := tokenClient.GetAllPreferences(); err == nil {
+		t.Fatal("should have failed - personal access tokens are disabled")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = true
+	tokenClient.Must(tokenClient.GetAllPreferences())
+
+	th.InitSystemAdmin()
+	th.SystemAdminClient.Must(th.SystemAdminClient.UpdateActive(th.BasicUser.Id, false))
+
+	if _, err := tokenClient.GetAllPreferences(); err == nil {
+		t.Fatal("should have failed - the owner of the token was deactivated")
+	}
+}
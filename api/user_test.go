@@ -19,6 +19,7 @@ import (
 	"github.com/goamz/goamz/aws"
 	"github.com/goamz/goamz/s3"
 
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
@@ -100,7 +101,7 @@ func TestLogin(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Username: "corey" + model.NewId(), Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Username: "corey" + model.NewId(), Password: "passwd"}
 	ruser, _ := Client.CreateUser(&user, "")
 	LinkUserToTeam(ruser.Data.(*model.User), rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Data.(*model.User).Id))
@@ -167,7 +168,7 @@ func TestLogin(t *testing.T) {
 	team2 := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_INVITE}
 	rteam2 := Client.Must(Client.CreateTeam(&team2))
 
-	user2 := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user2 := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 
 	if _, err := Client.CreateUserFromSignup(&user2, "junk", "1231312"); err == nil {
 		t.Fatal("Should have errored, signed up without hashed email")
@@ -193,7 +194,7 @@ func TestLogin(t *testing.T) {
 		Email:       strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com",
 		Nickname:    "Corey Hulen",
 		Username:    "corey" + model.NewId(),
-		Password:    "pwd",
+		Password:    "passwd",
 		AuthService: model.USER_AUTH_SERVICE_LDAP,
 	}
 	user3 = Client.Must(Client.CreateUser(user3, "")).Data.(*model.User)
@@ -211,7 +212,7 @@ func TestLoginByLdap(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Username: "corey" + model.NewId(), Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Username: "corey" + model.NewId(), Password: "passwd"}
 	ruser, _ := Client.CreateUser(&user, "")
 	LinkUserToTeam(ruser.Data.(*model.User), rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Data.(*model.User).Id))
@@ -303,12 +304,12 @@ func TestGetUser(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser, _ := Client.CreateUser(&user, "")
 	LinkUserToTeam(ruser.Data.(*model.User), rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Data.(*model.User).Id))
 
-	user2 := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user2 := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser2, _ := Client.CreateUser(&user2, "")
 	LinkUserToTeam(ruser2.Data.(*model.User), rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser2.Data.(*model.User).Id))
@@ -316,7 +317,7 @@ func TestGetUser(t *testing.T) {
 	team2 := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam2, _ := Client.CreateTeam(&team2)
 
-	user3 := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user3 := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser3, _ := Client.CreateUser(&user3, "")
 	LinkUserToTeam(ruser3.Data.(*model.User), rteam2.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser3.Data.(*model.User).Id))
@@ -385,7 +386,7 @@ func TestGetUser(t *testing.T) {
 	c.IpAddress = "cmd_line"
 	UpdateUserRoles(c, ruser.Data.(*model.User), model.ROLE_SYSTEM_ADMIN)
 
-	Client.Login(user.Email, "pwd")
+	Client.Login(user.Email, "passwd")
 
 	if _, err := Client.GetProfiles(rteam2.Data.(*model.Team).Id, ""); err != nil {
 		t.Fatal(err)
@@ -435,7 +436,7 @@ func TestGetAudits(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser, _ := Client.CreateUser(&user, "")
 	LinkUserToTeam(ruser.Data.(*model.User), rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Data.(*model.User).Id))
@@ -490,12 +491,12 @@ func TestUserCreateImage(t *testing.T) {
 	team := &model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
 
-	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, team)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
 
-	Client.Login(user.Email, "pwd")
+	Client.Login(user.Email, "passwd")
 
 	Client.DoApiGet("/users/"+user.Id+"/image", "", "")
 
@@ -525,7 +526,7 @@ func TestUserUploadProfileImage(t *testing.T) {
 	team := &model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
 
-	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, team)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
@@ -539,7 +540,7 @@ func TestUserUploadProfileImage(t *testing.T) {
 			t.Fatal("Should have errored")
 		}
 
-		Client.Login(user.Email, "pwd")
+		Client.Login(user.Email, "passwd")
 		Client.SetTeamId(team.Id)
 
 		if _, upErr := Client.UploadProfileFile(body.Bytes(), writer.FormDataContentType()); upErr == nil {
@@ -634,7 +635,7 @@ func TestUserUpdate(t *testing.T) {
 
 	time1 := model.GetMillis()
 
-	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd", LastActivityAt: time1, LastPingAt: time1, Roles: ""}
+	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd", LastActivityAt: time1, LastPingAt: time1, Roles: ""}
 	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, team)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
@@ -643,7 +644,7 @@ func TestUserUpdate(t *testing.T) {
 		t.Fatal("Should have errored")
 	}
 
-	Client.Login(user.Email, "pwd")
+	Client.Login(user.Email, "passwd")
 	Client.SetTeamId(team.Id)
 
 	time.Sleep(100 * time.Millisecond)
@@ -678,12 +679,12 @@ func TestUserUpdate(t *testing.T) {
 		}
 	}
 
-	user2 := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user2 := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
 	LinkUserToTeam(user2, team)
 	store.Must(Srv.Store.User().VerifyEmail(user2.Id))
 
-	Client.Login(user2.Email, "pwd")
+	Client.Login(user2.Email, "passwd")
 	Client.SetTeamId(team.Id)
 
 	user.Nickname = "Tim Timmy"
@@ -701,18 +702,18 @@ func TestUserUpdatePassword(t *testing.T) {
 	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
 	Client.SetTeamId(team.Id)
 
-	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, team)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
 
-	if _, err := Client.UpdateUserPassword(user.Id, "pwd", "newpwd"); err == nil {
+	if _, err := Client.UpdateUserPassword(user.Id, "passwd", "newpwd"); err == nil {
 		t.Fatal("Should have errored")
 	}
 
-	Client.Login(user.Email, "pwd")
+	Client.Login(user.Email, "passwd")
 
-	if _, err := Client.UpdateUserPassword("123", "pwd", "newpwd"); err == nil {
+	if _, err := Client.UpdateUserPassword("123", "passwd", "newpwd"); err == nil {
 		t.Fatal("Should have errored")
 	}
 
@@ -720,11 +721,11 @@ func TestUserUpdatePassword(t *testing.T) {
 		t.Fatal("Should have errored")
 	}
 
-	if _, err := Client.UpdateUserPassword(user.Id, "pwd", "npwd"); err == nil {
+	if _, err := Client.UpdateUserPassword(user.Id, "passwd", "npwd"); err == nil {
 		t.Fatal("Should have errored")
 	}
 
-	if _, err := Client.UpdateUserPassword("12345678901234567890123456", "pwd", "newpwd"); err == nil {
+	if _, err := Client.UpdateUserPassword("12345678901234567890123456", "passwd", "newpwd"); err == nil {
 		t.Fatal("Should have errored")
 	}
 
@@ -732,7 +733,7 @@ func TestUserUpdatePassword(t *testing.T) {
 		t.Fatal("Should have errored")
 	}
 
-	if _, err := Client.UpdateUserPassword(user.Id, "pwd", "newpwd"); err != nil {
+	if _, err := Client.UpdateUserPassword(user.Id, "passwd", "newpwd"); err != nil {
 		t.Fatal(err)
 	}
 
@@ -745,13 +746,13 @@ func TestUserUpdatePassword(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	user2 := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user2 := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
 	LinkUserToTeam(user2, team)
 
-	Client.Login(user2.Email, "pwd")
+	Client.Login(user2.Email, "passwd")
 
-	if _, err := Client.UpdateUserPassword(user.Id, "pwd", "newpwd"); err == nil {
+	if _, err := Client.UpdateUserPassword(user.Id, "passwd", "newpwd"); err == nil {
 		t.Fatal("Should have errored")
 	}
 }
@@ -763,12 +764,12 @@ func TestUserUpdateRoles(t *testing.T) {
 	team := &model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
 
-	user := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, team)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
 
-	user2 := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user2 := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
 	LinkUserToTeam(user2, team)
 	store.Must(Srv.Store.User().VerifyEmail(user2.Id))
@@ -781,7 +782,7 @@ func TestUserUpdateRoles(t *testing.T) {
 		t.Fatal("Should have errored, not logged in")
 	}
 
-	Client.Login(user2.Email, "pwd")
+	Client.Login(user2.Email, "passwd")
 	Client.SetTeamId(team.Id)
 
 	if _, err := Client.UpdateUserRoles(data); err == nil {
@@ -791,12 +792,12 @@ func TestUserUpdateRoles(t *testing.T) {
 	team2 := &model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	team2 = Client.Must(Client.CreateTeam(team2)).Data.(*model.Team)
 
-	user3 := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user3 := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user3 = Client.Must(Client.CreateUser(user3, "")).Data.(*model.User)
 	LinkUserToTeam(user3, team2)
 	store.Must(Srv.Store.User().VerifyEmail(user3.Id))
 
-	Client.Login(user3.Email, "pwd")
+	Client.Login(user3.Email, "passwd")
 	Client.SetTeamId(team2.Id)
 
 	data["user_id"] = user2.Id
@@ -805,7 +806,7 @@ func TestUserUpdateRoles(t *testing.T) {
 		t.Fatal("Should have errored, wrong team")
 	}
 
-	Client.Login(user.Email, "pwd")
+	Client.Login(user.Email, "passwd")
 
 	data["user_id"] = "junk"
 
@@ -882,6 +883,8 @@ func TestUserUpdateRolesMoreCases(t *testing.T) {
 
 	th.LoginSystemAdmin()
 
+	mfaSatisfiedCache.Add(th.BasicUser.Id, true)
+
 	// promote user to team admin
 	data["user_id"] = th.BasicUser.Id
 	data["new_roles"] = model.ROLE_TEAM_ADMIN
@@ -890,6 +893,10 @@ func TestUserUpdateRolesMoreCases(t *testing.T) {
 		t.Fatal("Should have succeeded since they are system admin")
 	}
 
+	if _, ok := mfaSatisfiedCache.Get(th.BasicUser.Id); ok {
+		t.Fatal("should have checked MFA again after the roles changed")
+	}
+
 	// demote team admin to basic member
 	data["user_id"] = th.BasicUser.Id
 	data["new_roles"] = ""
@@ -962,12 +969,12 @@ func TestUserUpdateDeviceId(t *testing.T) {
 	team := &model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
 
-	user := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, team)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
 
-	Client.Login(user.Email, "pwd")
+	Client.Login(user.Email, "passwd")
 	Client.SetTeamId(team.Id)
 	deviceId := model.PUSH_NOTIFY_APPLE + ":1234567890"
 
@@ -993,12 +1000,12 @@ func TestUserUpdateActive(t *testing.T) {
 	team := &model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
 
-	user := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, team)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
 
-	user2 := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user2 := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
 	LinkUserToTeam(user2, team)
 	store.Must(Srv.Store.User().VerifyEmail(user2.Id))
@@ -1007,7 +1014,7 @@ func TestUserUpdateActive(t *testing.T) {
 		t.Fatal("Should have errored, not logged in")
 	}
 
-	Client.Login(user2.Email, "pwd")
+	Client.Login(user2.Email, "passwd")
 	Client.SetTeamId(team.Id)
 
 	if _, err := Client.UpdateActive(user.Id, false); err == nil {
@@ -1019,19 +1026,19 @@ func TestUserUpdateActive(t *testing.T) {
 	team2 := &model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	team2 = Client.Must(Client.CreateTeam(team2)).Data.(*model.Team)
 
-	user3 := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user3 := &model.User{Email: "success+" + model.NewId() + "@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user3 = Client.Must(Client.CreateUser(user3, "")).Data.(*model.User)
 	LinkUserToTeam(user2, team2)
 	store.Must(Srv.Store.User().VerifyEmail(user3.Id))
 
-	Client.Login(user3.Email, "pwd")
+	Client.Login(user3.Email, "passwd")
 	Client.SetTeamId(team2.Id)
 
 	if _, err := Client.UpdateActive(user.Id, false); err == nil {
 		t.Fatal("Should have errored, not yourself")
 	}
 
-	Client.Login(user.Email, "pwd")
+	Client.Login(user.Email, "passwd")
 	Client.SetTeamId(team.Id)
 
 	if _, err := Client.UpdateActive("junk", false); err == nil {
@@ -1050,12 +1057,12 @@ func TestUserPermDelete(t *testing.T) {
 	team := &model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
 
-	user1 := &model.User{Email: model.NewId() + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user1 := &model.User{Email: model.NewId() + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
 	LinkUserToTeam(user1, team)
 	store.Must(Srv.Store.User().VerifyEmail(user1.Id))
 
-	Client.Login(user1.Email, "pwd")
+	Client.Login(user1.Email, "passwd")
 	Client.SetTeamId(team.Id)
 
 	channel1 := &model.Channel{DisplayName: "TestGetPosts", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
@@ -1092,7 +1099,7 @@ func TestSendPasswordReset(t *testing.T) {
 	team := &model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
 
-	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, team)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
@@ -1124,7 +1131,7 @@ func TestResetPassword(t *testing.T) {
 	Client := th.SystemAdminClient
 	team := th.SystemAdminTeam
 
-	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, team)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
@@ -1194,7 +1201,7 @@ func TestUserUpdateNotify(t *testing.T) {
 	team := &model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
 
-	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd", Roles: ""}
+	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd", Roles: ""}
 	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
 	LinkUserToTeam(user, team)
 	store.Must(Srv.Store.User().VerifyEmail(user.Id))
@@ -1209,7 +1216,7 @@ func TestUserUpdateNotify(t *testing.T) {
 		t.Fatal("Should have errored - not logged in")
 	}
 
-	Client.Login(user.Email, "pwd")
+	Client.Login(user.Email, "passwd")
 	Client.SetTeamId(team.Id)
 
 	if result, err := Client.UpdateUserNotify(data); err != nil {
@@ -1257,6 +1264,19 @@ func TestUserUpdateNotify(t *testing.T) {
 	if _, err := Client.UpdateUserNotify(data); err == nil {
 		t.Fatal("Should have errored - empty email")
 	}
+
+	data["email"] = "true"
+	data["email_interval"] = "weekly"
+	if _, err := Client.UpdateUserNotify(data); err == nil {
+		t.Fatal("Should have errored - bad email interval")
+	}
+
+	data["email_interval"] = model.EMAIL_INTERVAL_HOUR
+	if result, err := Client.UpdateUserNotify(data); err != nil {
+		t.Fatal(err)
+	} else if result.Data.(*model.User).NotifyProps["email_interval"] != model.EMAIL_INTERVAL_HOUR {
+		t.Fatal("NotifyProps did not update properly - email_interval")
+	}
 }
 
 func TestFuzzyUserCreate(t *testing.T) {
@@ -1295,12 +1315,12 @@ func TestStatuses(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser := Client.Must(Client.CreateUser(&user, "")).Data.(*model.User)
 	LinkUserToTeam(ruser, rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Id))
 
-	user2 := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user2 := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser2 := Client.Must(Client.CreateUser(&user2, "")).Data.(*model.User)
 	LinkUserToTeam(ruser2, rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser2.Id))
@@ -1337,7 +1357,7 @@ func TestEmailToOAuth(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser := Client.Must(Client.CreateUser(&user, "")).Data.(*model.User)
 	LinkUserToTeam(ruser, rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Id))
@@ -1347,7 +1367,7 @@ func TestEmailToOAuth(t *testing.T) {
 		t.Fatal("should have failed - empty data")
 	}
 
-	m["password"] = "pwd"
+	m["password"] = "passwd"
 	_, err := Client.EmailToOAuth(m)
 	if err == nil {
 		t.Fatal("should have failed - missing team_name, service, email")
@@ -1388,12 +1408,12 @@ func TestOAuthToEmail(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser := Client.Must(Client.CreateUser(&user, "")).Data.(*model.User)
 	LinkUserToTeam(ruser, rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Id))
 
-	user2 := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user2 := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser2 := Client.Must(Client.CreateUser(&user2, "")).Data.(*model.User)
 	LinkUserToTeam(ruser2, rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser2.Id))
@@ -1409,7 +1429,7 @@ func TestOAuthToEmail(t *testing.T) {
 		t.Fatal("should have failed - empty data")
 	}
 
-	m["password"] = "pwd"
+	m["password"] = "passwd"
 	_, err := Client.OAuthToEmail(m)
 	if err == nil {
 		t.Fatal("should have failed - missing team_name, service, email")
@@ -1439,7 +1459,7 @@ func TestLDAPToEmail(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser := Client.Must(Client.CreateUser(&user, "")).Data.(*model.User)
 	LinkUserToTeam(ruser, rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Id))
@@ -1451,7 +1471,7 @@ func TestLDAPToEmail(t *testing.T) {
 		t.Fatal("should have failed - empty data")
 	}
 
-	m["email_password"] = "pwd"
+	m["email_password"] = "passwd"
 	_, err := Client.LDAPToEmail(m)
 	if err == nil {
 		t.Fatal("should have failed - missing team_name, ldap_password, email")
@@ -1462,7 +1482,7 @@ func TestLDAPToEmail(t *testing.T) {
 		t.Fatal("should have failed - missing email, ldap_password")
 	}
 
-	m["ldap_password"] = "pwd"
+	m["ldap_password"] = "passwd"
 	if _, err := Client.LDAPToEmail(m); err == nil {
 		t.Fatal("should have failed - missing email")
 	}
@@ -1492,7 +1512,7 @@ func TestEmailToLDAP(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser := Client.Must(Client.CreateUser(&user, "")).Data.(*model.User)
 	LinkUserToTeam(ruser, rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Id))
@@ -1504,7 +1524,7 @@ func TestEmailToLDAP(t *testing.T) {
 		t.Fatal("should have failed - empty data")
 	}
 
-	m["email_password"] = "pwd"
+	m["email_password"] = "passwd"
 	_, err := Client.EmailToLDAP(m)
 	if err == nil {
 		t.Fatal("should have failed - missing team_name, ldap_id, ldap_password, email")
@@ -1520,7 +1540,7 @@ func TestEmailToLDAP(t *testing.T) {
 		t.Fatal("should have failed - missing email, ldap_password")
 	}
 
-	m["ldap_password"] = "pwd"
+	m["ldap_password"] = "passwd"
 	if _, err := Client.EmailToLDAP(m); err == nil {
 		t.Fatal("should have failed - missing email")
 	}
@@ -1543,7 +1563,7 @@ func TestEmailToLDAP(t *testing.T) {
 		t.Fatal("should have failed - bad password")
 	}
 
-	m["email_password"] = "pwd"
+	m["email_password"] = "passwd"
 	if _, err := Client.EmailToLDAP(m); err == nil {
 		t.Fatal("should have failed - missing ldap bits or user")
 	}
@@ -1623,7 +1643,7 @@ func TestGenerateMfaQrCode(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser, _ := Client.CreateUser(&user, "")
 	LinkUserToTeam(ruser.Data.(*model.User), rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Data.(*model.User).Id))
@@ -1661,7 +1681,7 @@ func TestUpdateMfa(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser, _ := Client.CreateUser(&user, "")
 	LinkUserToTeam(ruser.Data.(*model.User), rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Data.(*model.User).Id))
@@ -1693,6 +1713,90 @@ func TestUpdateMfa(t *testing.T) {
 	// need to add more test cases when enterprise bits can be loaded into tests
 }
 
+type testMfaInterface struct {
+}
+
+func (m *testMfaInterface) GenerateQrCode(user *model.User) ([]byte, *model.AppError) {
+	return []byte("qr"), nil
+}
+
+func (m *testMfaInterface) Activate(user *model.User, token string) *model.AppError {
+	if token != "424242" {
+		return model.NewLocAppError("Activate", "mfa.activate.bad_token.app_error", nil, "")
+	}
+
+	return (<-Srv.Store.User().UpdateMfaActive(user.Id, true)).Err
+}
+
+func (m *testMfaInterface) Deactivate(userId string) *model.AppError {
+	return (<-Srv.Store.User().UpdateMfaActive(userId, false)).Err
+}
+
+func (m *testMfaInterface) ValidateToken(user *model.User, token string) (bool, *model.AppError) {
+	return token == "424242", nil
+}
+
+func (m *testMfaInterface) GenerateRecoveryCodes(user *model.User) ([]string, *model.AppError) {
+	return []string{"recovery"}, nil
+}
+
+func (m *testMfaInterface) UseRecoveryCode(user *model.User, code string) (bool, *model.AppError) {
+	return code == "recovery", nil
+}
+
+func TestEnforceMfa(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	enableMfa := *utils.Cfg.ServiceSettings.EnableMultifactorAuthentication
+	enforceMfa := *utils.Cfg.ServiceSettings.EnforceMultifactorAuthentication
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableMultifactorAuthentication = enableMfa
+		*utils.Cfg.ServiceSettings.EnforceMultifactorAuthentication = enforceMfa
+	}()
+	defer einterfaces.RegisterMfaInterface(einterfaces.GetMfaInterface())
+
+	einterfaces.RegisterMfaInterface(&testMfaInterface{})
+	*utils.Cfg.ServiceSettings.EnableMultifactorAuthentication = true
+	*utils.Cfg.ServiceSettings.EnforceMultifactorAuthentication = model.MFA_ENFORCE_SYSTEM_ADMIN
+
+	Client.Must(Client.GetSessions(th.BasicUser.Id))
+
+	*utils.Cfg.ServiceSettings.EnforceMultifactorAuthentication = model.MFA_ENFORCE_ALL
+
+	if _, err := Client.GetSessions(th.BasicUser.Id); err == nil || err.Id != "api.context.mfa_required.app_error" {
+		t.Fatal("should have failed - mfa isn't active", err)
+	}
+
+	if _, err := Client.GenerateMfaRecoveryCodes(); err == nil {
+		t.Fatal("should have failed - mfa isn't active")
+	}
+
+	Client.Must(Client.GenerateMfaQrCode())
+	Client.Must(Client.UpdateMfa(true, "424242"))
+	Client.Must(Client.GetSessions(th.BasicUser.Id))
+
+	if _, err := Client.UpdateMfa(false, ""); err == nil {
+		t.Fatal("should have failed - mfa is enforced")
+	}
+
+	if codes := Client.Must(Client.GenerateMfaRecoveryCodes()).Data.([]string); len(codes) != 1 {
+		t.Fatal("should have returned the recovery codes")
+	}
+
+	Client.Logout()
+
+	if _, err := Client.LoginWithMfa(th.BasicUser.Email, th.BasicUser.Password, "123456"); err == nil {
+		t.Fatal("should have failed - bad token")
+	}
+
+	Client.Must(Client.LoginWithMfa(th.BasicUser.Email, th.BasicUser.Password, "424242"))
+	Client.Must(Client.LoginWithMfa(th.BasicUser.Email, th.BasicUser.Password, "recovery"))
+
+	*utils.Cfg.ServiceSettings.EnforceMultifactorAuthentication = model.MFA_ENFORCE_OFF
+	Client.Must(Client.UpdateMfa(false, ""))
+}
+
 func TestCheckMfa(t *testing.T) {
 	th := Setup()
 	Client := th.CreateClient()
@@ -1700,7 +1804,7 @@ func TestCheckMfa(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := Client.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	ruser, _ := Client.CreateUser(&user, "")
 	LinkUserToTeam(ruser.Data.(*model.User), rteam.Data.(*model.Team))
 	store.Must(Srv.Store.User().VerifyEmail(ruser.Data.(*model.User).Id))
@@ -1716,3 +1820,62 @@ func TestCheckMfa(t *testing.T) {
 
 	// need to add more test cases when enterprise bits can be loaded into tests
 }
+
+func TestPasswordPolicy(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	minimumLength := *utils.Cfg.PasswordSettings.MinimumLength
+	uppercase := *utils.Cfg.PasswordSettings.Uppercase
+	historyCount := *utils.Cfg.PasswordSettings.HistoryCount
+	expiryDays := *utils.Cfg.PasswordSettings.ExpiryDays
+	defer func() {
+		*utils.Cfg.PasswordSettings.MinimumLength = minimumLength
+		*utils.Cfg.PasswordSettings.Uppercase = uppercase
+		*utils.Cfg.PasswordSettings.HistoryCount = historyCount
+		*utils.Cfg.PasswordSettings.ExpiryDays = expiryDays
+	}()
+
+	*utils.Cfg.PasswordSettings.MinimumLength = 8
+	*utils.Cfg.PasswordSettings.Uppercase = true
+	*utils.Cfg.PasswordSettings.HistoryCount = 2
+
+	user := &model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "password"}
+	if _, err := Client.CreateUser(user, ""); err == nil || err.Id != "utils.password.uppercase.app_error" {
+		t.Fatal("should have failed - no uppercase letter", err)
+	}
+
+	user.Password = "Passwd"
+	if _, err := Client.CreateUser(user, ""); err == nil || err.Id != "utils.password.length.app_error" {
+		t.Fatal("should have failed - too short", err)
+	}
+
+	user.Password = "Password1"
+	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
+	LinkUserToTeam(user, th.BasicTeam)
+	store.Must(Srv.Store.User().VerifyEmail(user.Id))
+
+	Client.Must(Client.Login(user.Email, "Password1"))
+
+	if _, err := Client.UpdateUserPassword(user.Id, "Password1", "Password1"); err == nil {
+		t.Fatal("should have failed - reused the current password")
+	}
+
+	Client.Must(Client.UpdateUserPassword(user.Id, "Password1", "Password2"))
+
+	if _, err := Client.UpdateUserPassword(user.Id, "Password2", "Password1"); err == nil {
+		t.Fatal("should have failed - reused a previous password")
+	}
+
+	Client.Must(Client.UpdateUserPassword(user.Id, "Password2", "Password3"))
+	Client.Must(Client.UpdateUserPassword(user.Id, "Password3", "Password1"))
+
+	*utils.Cfg.PasswordSettings.ExpiryDays = 1
+	Client.Must(Client.Login(user.Email, "Password1"))
+
+	Srv.Store.(*store.SqlStore).GetMaster().Exec("UPDATE Users SET LastPasswordUpdate = :Time WHERE Id = :UserId", map[string]interface{}{"Time": model.GetMillis() - 2*24*60*60*1000, "UserId": user.Id})
+
+	if _, err := Client.Login(user.Email, "Password1"); err == nil || err.Id != "api.user.check_user_password.expired.app_error" {
+		t.Fatal("should have failed - the password expired", err)
+	}
+}
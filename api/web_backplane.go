@@ -0,0 +1,148 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"sync"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// A Backplane carries hub events between every app server in a cluster. Each
+// node publishes the events it raises locally and applies the ones it
+// receives from other nodes.
+type Backplane interface {
+	Publish(msg *model.ClusterMessage) *model.AppError
+	Subscribe(handler func(msg *model.ClusterMessage)) *model.AppError
+	Close()
+}
+
+// CLUSTER_PUBLISH_QUEUE_SIZE is how many events can wait to be published before new ones are dropped.
+const CLUSTER_PUBLISH_QUEUE_SIZE = 1000
+
+var backplane Backplane
+var backplaneNodeId = model.NewId()
+
+// Events are published from a queue so that a slow backplane doesn't hold up the requests
+// raising them, a single goroutine publishes them to keep their order.
+var backplaneQueue chan *model.ClusterMessage
+var backplaneStop chan bool
+
+func StartBackplane() {
+	if !*utils.Cfg.ClusterSettings.Enable {
+		return
+	}
+
+	switch *utils.Cfg.ClusterSettings.BackplaneDriver {
+	case model.BACKPLANE_DRIVER_REDIS:
+		backplane = NewRedisBackplane(*utils.Cfg.ClusterSettings.RedisServer, *utils.Cfg.ClusterSettings.RedisPassword, *utils.Cfg.ClusterSettings.RedisChannel)
+	default:
+		backplane = NewMemoryBackplane()
+	}
+
+	l4g.Info(utils.T("api.web_backplane.start.info"), *utils.Cfg.ClusterSettings.BackplaneDriver, backplaneNodeId)
+
+	if err := backplane.Subscribe(handleClusterMessage); err != nil {
+		l4g.Error(utils.T("api.web_backplane.subscribe.error"), err)
+	}
+
+	backplaneQueue = make(chan *model.ClusterMessage, CLUSTER_PUBLISH_QUEUE_SIZE)
+	backplaneStop = make(chan bool)
+	go publishQueuedClusterMessages(backplane, backplaneQueue, backplaneStop)
+}
+
+func StopBackplane() {
+	if backplane != nil {
+		close(backplaneStop)
+		backplane.Close()
+		backplane = nil
+	}
+}
+
+func publishToCluster(msg *model.ClusterMessage) {
+	if backplane == nil {
+		return
+	}
+
+	msg.NodeId = backplaneNodeId
+
+	select {
+	case backplaneQueue <- msg:
+	default:
+		l4g.Error(utils.T("api.web_backplane.publish_queue_full.error"), msg.Event)
+	}
+}
+
+func publishQueuedClusterMessages(b Backplane, queue chan *model.ClusterMessage, stop chan bool) {
+	for {
+		select {
+		case msg := <-queue:
+			if err := b.Publish(msg); err != nil {
+				l4g.Error(utils.T("api.web_backplane.publish.error"), msg.Event, err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func handleClusterMessage(msg *model.ClusterMessage) {
+	// Events raised on this node have already been applied to the local hub
+	if msg.NodeId == backplaneNodeId {
+		return
+	}
+
+	switch msg.Event {
+	case model.CLUSTER_EVENT_PUBLISH:
+		hub.Broadcast(msg.Message)
+	case model.CLUSTER_EVENT_INVALIDATE_USER:
+		mfaSatisfiedCache.Remove(msg.Data)
+		hub.invalidateUser <- msg.Data
+	case model.CLUSTER_EVENT_INVALIDATE_CHANNEL:
+		hub.invalidateChannel <- msg.Data
+	case model.CLUSTER_EVENT_REMOVE_SESSION:
+		RemoveSessionFromCacheById(msg.Data)
+	default:
+		l4g.Warn(utils.T("api.web_backplane.unknown_event.warn"), msg.Event)
+	}
+}
+
+// MemoryBackplane delivers events to every subscriber within the same
+// process. It is mostly useful for a single node and for tests.
+type MemoryBackplane struct {
+	mutex    sync.RWMutex
+	handlers []func(msg *model.ClusterMessage)
+}
+
+func NewMemoryBackplane() *MemoryBackplane {
+	return &MemoryBackplane{}
+}
+
+func (b *MemoryBackplane) Publish(msg *model.ClusterMessage) *model.AppError {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for _, handler := range b.handlers {
+		handler(msg)
+	}
+
+	return nil
+}
+
+func (b *MemoryBackplane) Subscribe(handler func(msg *model.ClusterMessage)) *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+	return nil
+}
+
+func (b *MemoryBackplane) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.handlers = nil
+}
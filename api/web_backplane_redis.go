@@ -0,0 +1,166 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/garyburd/redigo/redis"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// RedisBackplane fans hub events out to every node subscribed to the same
+// Redis pub/sub channel.
+type RedisBackplane struct {
+	pool    *redis.Pool
+	channel string
+	mutex   sync.Mutex
+	psc     *redis.PubSubConn
+	stop    chan bool
+	closed  bool
+}
+
+func NewRedisBackplane(server string, password string, channel string) *RedisBackplane {
+	pool := &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", server,
+				redis.DialPassword(password),
+				redis.DialConnectTimeout(REDIS_TIMEOUT),
+				redis.DialReadTimeout(REDIS_TIMEOUT),
+				redis.DialWriteTimeout(REDIS_TIMEOUT),
+			)
+		},
+	}
+
+	return &RedisBackplane{pool: pool, channel: channel, stop: make(chan bool)}
+}
+
+func (b *RedisBackplane) Publish(msg *model.ClusterMessage) *model.AppError {
+	conn := b.pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("PUBLISH", b.channel, msg.ToJson()); err != nil {
+		return model.NewLocAppError("RedisBackplane.Publish", "api.web_backplane.redis.publish.app_error", nil, err.Error())
+	}
+
+	return nil
+}
+
+func (b *RedisBackplane) Subscribe(handler func(msg *model.ClusterMessage)) *model.AppError {
+	conn := b.pool.Get()
+	if _, err := conn.Do("PING"); err != nil {
+		conn.Close()
+		return model.NewLocAppError("RedisBackplane.Subscribe", "api.web_backplane.redis.connect.app_error", nil, err.Error())
+	}
+	conn.Close()
+
+	go func() {
+		wait := REDIS_MIN_RECONNECT_WAIT
+
+		for {
+			start := time.Now()
+			if err := b.receive(handler); err != nil {
+				// a subscription that held for a while isn't failing repeatedly so it starts over
+				if time.Since(start) > REDIS_MAX_RECONNECT_WAIT {
+					wait = REDIS_MIN_RECONNECT_WAIT
+				}
+
+				l4g.Error(utils.T("api.web_backplane.redis.receive.error"), err, wait)
+			}
+
+			select {
+			case <-b.stop:
+				return
+			case <-time.After(wait):
+			}
+
+			if wait *= 2; wait > REDIS_MAX_RECONNECT_WAIT {
+				wait = REDIS_MAX_RECONNECT_WAIT
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (b *RedisBackplane) receive(handler func(msg *model.ClusterMessage)) error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return nil
+	}
+
+	psc := &redis.PubSubConn{Conn: b.pool.Get()}
+	b.psc = psc
+	b.mutex.Unlock()
+
+	defer psc.Close()
+
+	if err := psc.Subscribe(b.channel); err != nil {
+		return err
+	}
+
+	// the connection has a read timeout so it's kept busy while the channel is quiet
+	done := make(chan bool)
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(REDIS_PING_PERIOD)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := psc.Ping(""); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			if msg := model.ClusterMessageFromJson(bytes.NewReader(v.Data)); msg != nil {
+				handler(msg)
+			}
+		case error:
+			b.mutex.Lock()
+			closed := b.closed
+			b.mutex.Unlock()
+
+			if closed {
+				return nil
+			}
+
+			return v
+		}
+	}
+}
+
+func (b *RedisBackplane) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	close(b.stop)
+
+	if b.psc != nil {
+		b.psc.Close()
+	}
+
+	b.pool.Close()
+}
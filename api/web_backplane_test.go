@@ -0,0 +1,121 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestMemoryBackplane(t *testing.T) {
+	b := NewMemoryBackplane()
+
+	received1 := make(chan *model.ClusterMessage, 1)
+	received2 := make(chan *model.ClusterMessage, 1)
+
+	b.Subscribe(func(msg *model.ClusterMessage) { received1 <- msg })
+	b.Subscribe(func(msg *model.ClusterMessage) { received2 <- msg })
+
+	userId := model.NewId()
+	if err := b.Publish(model.NewClusterMessage(model.CLUSTER_EVENT_INVALIDATE_USER, userId, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg := <-received1; msg.Data != userId {
+		t.Fatal("first subscriber did not get the event")
+	}
+
+	if msg := <-received2; msg.Data != userId {
+		t.Fatal("second subscriber did not get the event")
+	}
+
+	b.Close()
+
+	if err := b.Publish(model.NewClusterMessage(model.CLUSTER_EVENT_INVALIDATE_USER, userId, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-received1:
+		t.Fatal("should not deliver after close")
+	default:
+	}
+}
+
+func TestHandleClusterMessage(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+	mfaSatisfiedCache.Add(userId, true)
+
+	msg := model.NewClusterMessage(model.CLUSTER_EVENT_INVALIDATE_USER, userId, nil)
+	msg.NodeId = backplaneNodeId
+	handleClusterMessage(msg)
+
+	if _, ok := mfaSatisfiedCache.Get(userId); !ok {
+		t.Fatal("should have skipped an event raised by this node")
+	}
+
+	msg = model.NewClusterMessage(model.CLUSTER_EVENT_INVALIDATE_USER, userId, nil)
+	msg.NodeId = model.NewId()
+	handleClusterMessage(msg)
+
+	if _, ok := mfaSatisfiedCache.Get(userId); ok {
+		t.Fatal("should have applied an event raised by another node")
+	}
+
+	session := &model.Session{Id: model.NewId(), Token: model.NewId(), UserId: userId}
+	AddSessionToCache(session)
+
+	msg = model.NewClusterMessage(model.CLUSTER_EVENT_REMOVE_SESSION, session.Id, nil)
+	msg.NodeId = model.NewId()
+	handleClusterMessage(msg)
+
+	if _, ok := sessionCache.Get(session.Token); ok {
+		t.Fatal("should have removed the session revoked on another node")
+	}
+}
+
+func TestHandleClusterMessagePublish(t *testing.T) {
+	th := Setup().InitBasic()
+	Client := th.BasicClient
+
+	url := "ws://localhost" + utils.Cfg.ServiceSettings.ListenAddress + model.API_URL_SUFFIX + "/users/websocket"
+
+	header := http.Header{}
+	header.Set(model.HEADER_AUTH, "BEARER "+Client.AuthToken)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	time.Sleep(300 * time.Millisecond)
+
+	own := model.NewMessage(th.BasicTeam.Id, th.BasicChannel.Id, th.BasicUser2.Id, model.ACTION_TYPING)
+	msg := model.NewClusterMessage(model.CLUSTER_EVENT_PUBLISH, "", own)
+	msg.NodeId = backplaneNodeId
+	handleClusterMessage(msg)
+
+	other := model.NewMessage(th.BasicTeam.Id, th.BasicChannel.Id, th.BasicUser2.Id, model.ACTION_POSTED)
+	msg = model.NewClusterMessage(model.CLUSTER_EVENT_PUBLISH, "", other)
+	msg.NodeId = model.NewId()
+	handleClusterMessage(msg)
+
+	var rmsg model.Message
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&rmsg); err != nil {
+		t.Fatal(err)
+	}
+
+	if rmsg.Action != model.ACTION_POSTED {
+		t.Fatal("should only have broadcast the event raised by another node", rmsg.Action)
+	}
+}
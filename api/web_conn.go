@@ -16,7 +16,11 @@ const (
 	PONG_WAIT   = 60 * time.Second
 	PING_PERIOD = (PONG_WAIT * 9) / 10
 	MAX_SIZE    = 512
-	REDIS_WAIT  = 60 * time.Second
+
+	REDIS_TIMEOUT            = 10 * time.Second
+	REDIS_PING_PERIOD        = (REDIS_TIMEOUT * 9) / 10
+	REDIS_MIN_RECONNECT_WAIT = 1 * time.Second
+	REDIS_MAX_RECONNECT_WAIT = 30 * time.Second
 )
 
 type WebConn struct {
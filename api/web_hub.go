@@ -32,15 +32,18 @@ var hub = &Hub{
 func PublishAndForget(message *model.Message) {
 	go func() {
 		hub.Broadcast(message)
+		publishToCluster(model.NewClusterMessage(model.CLUSTER_EVENT_PUBLISH, "", message))
 	}()
 }
 
 func InvalidateCacheForUser(userId string) {
 	hub.invalidateUser <- userId
+	publishToCluster(model.NewClusterMessage(model.CLUSTER_EVENT_INVALIDATE_USER, userId, nil))
 }
 
 func InvalidateCacheForChannel(channelId string) {
 	hub.invalidateChannel <- channelId
+	publishToCluster(model.NewClusterMessage(model.CLUSTER_EVENT_INVALIDATE_CHANNEL, channelId, nil))
 }
 
 func (h *Hub) Register(webConn *WebConn) {
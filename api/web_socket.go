@@ -13,8 +13,9 @@ import (
 
 func InitWebSocket() {
 	l4g.Debug(utils.T("api.web_socket.init.debug"))
-	BaseRoutes.Users.Handle("/websocket", ApiUserRequiredTrustRequester(connect)).Methods("GET")
+	BaseRoutes.Users.Handle("/websocket", ApiScope(model.SCOPE_READ_POSTS, ApiUserRequiredTrustRequester(connect))).Methods("GET")
 	hub.Start()
+	StartBackplane()
 }
 
 func connect(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -5,6 +5,7 @@ package api
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
 	l4g "github.com/alecthomas/log4go"
@@ -18,6 +19,7 @@ func InitWebhook() {
 	l4g.Debug(utils.T("api.webhook.init.debug"))
 
 	BaseRoutes.Hooks.Handle("/incoming/create", ApiUserRequired(createIncomingHook)).Methods("POST")
+	BaseRoutes.Hooks.Handle("/incoming/update", ApiUserRequired(updateIncomingHook)).Methods("POST")
 	BaseRoutes.Hooks.Handle("/incoming/delete", ApiUserRequired(deleteIncomingHook)).Methods("POST")
 	BaseRoutes.Hooks.Handle("/incoming/list", ApiUserRequired(getIncomingHooks)).Methods("GET")
 
@@ -25,6 +27,8 @@ func InitWebhook() {
 	BaseRoutes.Hooks.Handle("/outgoing/regen_token", ApiUserRequired(regenOutgoingHookToken)).Methods("POST")
 	BaseRoutes.Hooks.Handle("/outgoing/delete", ApiUserRequired(deleteOutgoingHook)).Methods("POST")
 	BaseRoutes.Hooks.Handle("/outgoing/list", ApiUserRequired(getOutgoingHooks)).Methods("GET")
+	BaseRoutes.Hooks.Handle("/outgoing/{id:[A-Za-z0-9]+}/deliveries/{offset:[0-9]+}/{limit:[0-9]+}", ApiUserRequired(getOutgoingHookDeliveries)).Methods("GET")
+	BaseRoutes.Hooks.Handle("/outgoing/deliveries/replay", ApiUserRequired(replayOutgoingHookDelivery)).Methods("POST")
 
 	BaseRoutes.Hooks.Handle("/{id:[A-Za-z0-9]+}", ApiAppHandler(incomingWebhook)).Methods("POST")
 
@@ -88,6 +92,90 @@ func createIncomingHook(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func updateIncomingHook(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableIncomingWebhooks {
+		c.Err = model.NewLocAppError("updateIncomingHook", "api.webhook.update_incoming.disabled.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	if *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations {
+		if !(c.IsSystemAdmin() || c.IsTeamAdmin()) {
+			c.Err = model.NewLocAppError("updateIncomingHook", "api.command.admin_only.app_error", nil, "")
+			c.Err.StatusCode = http.StatusForbidden
+			return
+		}
+	}
+
+	c.LogAudit("attempt")
+
+	updatedHook := model.IncomingWebhookFromJson(r.Body)
+
+	if updatedHook == nil {
+		c.SetInvalidParam("updateIncomingHook", "webhook")
+		return
+	}
+
+	var hook *model.IncomingWebhook
+	if result := <-Srv.Store.Webhook().GetIncoming(updatedHook.Id); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		hook = result.Data.(*model.IncomingWebhook)
+
+		if c.TeamId != hook.TeamId || (c.Session.UserId != hook.UserId && !c.IsTeamAdmin()) {
+			c.LogAudit("fail - inappropriate permissions")
+			c.Err = model.NewLocAppError("updateIncomingHook", "api.webhook.update_incoming.permissions.app_error", nil, "user_id="+c.Session.UserId)
+			c.Err.StatusCode = http.StatusForbidden
+			return
+		}
+	}
+
+	if updatedHook.ChannelId != hook.ChannelId {
+		cchan := Srv.Store.Channel().Get(updatedHook.ChannelId)
+		pchan := Srv.Store.Channel().CheckPermissionsTo(c.TeamId, updatedHook.ChannelId, c.Session.UserId)
+
+		var channel *model.Channel
+		if result := <-cchan; result.Err != nil {
+			c.Err = result.Err
+			return
+		} else {
+			channel = result.Data.(*model.Channel)
+		}
+
+		if !c.HasPermissionsToChannel(pchan, "updateIncomingHook") {
+			if channel.Type != model.CHANNEL_OPEN || channel.TeamId != c.TeamId {
+				c.LogAudit("fail - bad channel permissions")
+				return
+			}
+
+			c.Err = nil
+		}
+
+		// the hook posts as its owner, so an admin can't move it to a channel the owner can't post in
+		if hook.UserId != c.Session.UserId && channel.Type != model.CHANNEL_OPEN {
+			if result := <-Srv.Store.Channel().GetMember(channel.Id, hook.UserId); result.Err != nil {
+				c.LogAudit("fail - bad owner channel permissions")
+				c.Err = model.NewLocAppError("updateIncomingHook", "api.webhook.update_incoming.owner_permissions.app_error", nil, "user_id="+hook.UserId)
+				c.Err.StatusCode = http.StatusForbidden
+				return
+			}
+		}
+	}
+
+	hook.ChannelId = updatedHook.ChannelId
+	hook.DisplayName = updatedHook.DisplayName
+	hook.Description = updatedHook.Description
+
+	if result := <-Srv.Store.Webhook().UpdateIncoming(hook); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		c.LogAudit("success")
+		w.Write([]byte(result.Data.(*model.IncomingWebhook).ToJson()))
+	}
+}
+
 func deleteIncomingHook(c *Context, w http.ResponseWriter, r *http.Request) {
 	if !utils.Cfg.ServiceSettings.EnableIncomingWebhooks {
 		c.Err = model.NewLocAppError("deleteIncomingHook", "api.webhook.delete_incoming.disabled.app_errror", nil, "")
@@ -196,7 +284,7 @@ func createOutgoingHook(c *Context, w http.ResponseWriter, r *http.Request) {
 			channel = result.Data.(*model.Channel)
 		}
 
-		if channel.Type != model.CHANNEL_OPEN {
+		if channel.Type != model.CHANNEL_OPEN && !hook.IncludePrivateChannels {
 			c.LogAudit("fail - not open channel")
 			c.Err = model.NewLocAppError("createOutgoingHook", "api.webhook.create_outgoing.not_open.app_error", nil, "")
 			return
@@ -333,6 +421,7 @@ func regenOutgoingHookToken(c *Context, w http.ResponseWriter, r *http.Request)
 	}
 
 	hook.Token = model.NewId()
+	hook.Secret = model.NewId()
 
 	if result := <-Srv.Store.Webhook().UpdateOutgoing(hook); result.Err != nil {
 		c.Err = result.Err
@@ -342,6 +431,173 @@ func regenOutgoingHookToken(c *Context, w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func getOutgoingHookDeliveries(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOutgoingWebhooks {
+		c.Err = model.NewLocAppError("getOutgoingHookDeliveries", "api.webhook.get_outgoing_deliveries.disabled.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	if !c.IsTeamAdmin() {
+		c.Err = model.NewLocAppError("getOutgoingHookDeliveries", "api.webhook.get_outgoing_deliveries.permissions.app_error", nil, "user_id="+c.Session.UserId)
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	params := mux.Vars(r)
+	id := params["id"]
+
+	offset, err := strconv.Atoi(params["offset"])
+	if err != nil {
+		c.SetInvalidParam("getOutgoingHookDeliveries", "offset")
+		return
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	if err != nil || limit > 200 {
+		c.SetInvalidParam("getOutgoingHookDeliveries", "limit")
+		return
+	}
+
+	var hook *model.OutgoingWebhook
+	if result := <-Srv.Store.Webhook().GetOutgoing(id); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if hook = result.Data.(*model.OutgoingWebhook); hook.TeamId != c.TeamId {
+		c.Err = model.NewLocAppError("getOutgoingHookDeliveries", "api.webhook.get_outgoing_deliveries.permissions.app_error", nil, "user_id="+c.Session.UserId)
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	if result := <-Srv.Store.Webhook().GetDeliveriesByHook(id, offset, limit); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		deliveries := result.Data.([]*model.OutgoingWebhookDelivery)
+
+		readable := map[string]bool{}
+		for _, delivery := range deliveries {
+			if _, ok := readable[delivery.ChannelId]; !ok {
+				readable[delivery.ChannelId] = canReadOutgoingHookDelivery(c, hook, delivery)
+			}
+
+			if !readable[delivery.ChannelId] {
+				delivery.Payload = ""
+			}
+		}
+
+		w.Write([]byte(model.OutgoingWebhookDeliveryListToJson(deliveries)))
+	}
+}
+
+// canReadOutgoingHookDelivery returns true if the user can see the payload of the delivery. The
+// messages of private channels and direct messages are only shown to the creator of the hook and
+// to the members of the channel, other team admins only see the outcome of the delivery.
+func canReadOutgoingHookDelivery(c *Context, hook *model.OutgoingWebhook, delivery *model.OutgoingWebhookDelivery) bool {
+	if hook.CreatorId == c.Session.UserId {
+		return true
+	}
+
+	if result := <-Srv.Store.Channel().Get(delivery.ChannelId); result.Err != nil {
+		return false
+	} else if result.Data.(*model.Channel).Type == model.CHANNEL_OPEN {
+		return true
+	}
+
+	if result := <-Srv.Store.Channel().CheckPermissionsToNoTeam(delivery.ChannelId, c.Session.UserId); result.Err != nil {
+		return false
+	} else {
+		return result.Data.(int64) == 1
+	}
+}
+
+// replayOutgoingHookDelivery sends a logged delivery once more, without retrying, and returns it with
+// the outcome.
+func replayOutgoingHookDelivery(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !utils.Cfg.ServiceSettings.EnableOutgoingWebhooks {
+		c.Err = model.NewLocAppError("replayOutgoingHookDelivery", "api.webhook.replay_outgoing_delivery.disabled.app_error", nil, "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	if !c.IsTeamAdmin() {
+		c.Err = model.NewLocAppError("replayOutgoingHookDelivery", "api.webhook.replay_outgoing_delivery.permissions.app_error", nil, "user_id="+c.Session.UserId)
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	c.LogAudit("attempt")
+
+	props := model.MapFromJson(r.Body)
+
+	id := props["id"]
+	if len(id) == 0 {
+		c.SetInvalidParam("replayOutgoingHookDelivery", "id")
+		return
+	}
+
+	var delivery *model.OutgoingWebhookDelivery
+	if result := <-Srv.Store.Webhook().GetDelivery(id); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		delivery = result.Data.(*model.OutgoingWebhookDelivery)
+	}
+
+	if delivery.TeamId != c.TeamId {
+		c.Err = model.NewLocAppError("replayOutgoingHookDelivery", "api.webhook.replay_outgoing_delivery.permissions.app_error", nil, "user_id="+c.Session.UserId)
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	var hook *model.OutgoingWebhook
+	if result := <-Srv.Store.Webhook().GetOutgoing(delivery.HookId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		hook = result.Data.(*model.OutgoingWebhook)
+	}
+
+	if !canReadOutgoingHookDelivery(c, hook, delivery) {
+		c.Err = model.NewLocAppError("replayOutgoingHookDelivery", "api.webhook.replay_outgoing_delivery.permissions.app_error", nil, "user_id="+c.Session.UserId)
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	// the callback URL may have been removed from the hook since the delivery was made
+	if !hook.HasCallbackURL(delivery.URL) {
+		c.Err = model.NewLocAppError("replayOutgoingHookDelivery", "api.webhook.replay_outgoing_delivery.url.app_error", nil, "id="+delivery.Id)
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	if delivery.IsPayloadTruncated() {
+		c.Err = model.NewLocAppError("replayOutgoingHookDelivery", "api.webhook.replay_outgoing_delivery.truncated.app_error", nil, "id="+delivery.Id)
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	respProps, err := sendOutgoingWebhook(hook, delivery, delivery.Payload)
+	if err != nil {
+		delivery.Status = model.WEBHOOK_DELIVERY_STATUS_FAILED
+	}
+
+	// a replayed delivery isn't retried anymore
+	delivery.NextAttemptAt = 0
+
+	if result := <-Srv.Store.Webhook().UpdateDelivery(delivery); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	if err == nil {
+		go createOutgoingWebhookResponsePost(hook, delivery, respProps)
+	}
+
+	c.LogAudit("success")
+	w.Write([]byte(delivery.ToJson()))
+}
+
 func incomingWebhook(c *Context, w http.ResponseWriter, r *http.Request) {
 	if !utils.Cfg.ServiceSettings.EnableIncomingWebhooks {
 		c.Err = model.NewLocAppError("incomingWebhook", "web.incoming_webhook.disabled.app_error", nil, "")
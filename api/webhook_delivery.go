@@ -0,0 +1,267 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	OUTGOING_WEBHOOK_TIMEOUT = 30 * time.Second
+
+	// OUTGOING_WEBHOOK_ATTEMPT_LEASE is how long a delivery is held by the server sending it, longer
+	// than a request can take so that the retry job doesn't send it at the same time.
+	OUTGOING_WEBHOOK_ATTEMPT_LEASE = 2 * OUTGOING_WEBHOOK_TIMEOUT
+
+	OUTGOING_WEBHOOK_RETRY_BATCH_SIZE = 100
+)
+
+// outgoingWebhookRetryDelay is how long to wait before the first retry of a failed delivery,
+// the delay doubles after each retry.
+var outgoingWebhookRetryDelay = 5 * time.Second
+
+// outgoingWebhookRetryInterval is how often the retry job looks for deliveries to retry.
+var outgoingWebhookRetryInterval = 5 * time.Second
+
+// StartOutgoingWebhookRetryJob retries the pending deliveries once they're due. They're read back
+// from the database so that they're retried by any server of the cluster, even after a restart.
+func StartOutgoingWebhookRetryJob() {
+	go func() {
+		for {
+			time.Sleep(outgoingWebhookRetryInterval)
+			retryOutgoingWebhookDeliveries()
+		}
+	}()
+}
+
+// triggerOutgoingWebhook sends the payload to each of the callback URLs of the hook.
+func triggerOutgoingWebhook(c *Context, hook *model.OutgoingWebhook, payload *model.OutgoingWebhookPayload, channelId string, postId string) {
+	contentType := hook.ContentType
+	body := ""
+
+	if contentType == model.OUTGOING_HOOK_CONTENT_TYPE_JSON {
+		body = payload.ToJson()
+	} else {
+		contentType = model.OUTGOING_HOOK_CONTENT_TYPE_FORM
+		body = payload.ToFormValues()
+	}
+
+	for _, url := range hook.CallbackURLs {
+		delivery := &model.OutgoingWebhookDelivery{
+			HookId:        hook.Id,
+			TeamId:        hook.TeamId,
+			ChannelId:     channelId,
+			PostId:        postId,
+			URL:           url,
+			ContentType:   contentType,
+			Payload:       body,
+			SiteURL:       c.GetSiteURL(),
+			NextAttemptAt: model.GetMillis() + int64(OUTGOING_WEBHOOK_ATTEMPT_LEASE/time.Millisecond),
+		}
+
+		// the delivery is saved before it's sent so that it isn't lost if the server stops, its
+		// payload may be truncated when logged so the first attempt sends the full body
+		saveOutgoingWebhookDelivery(delivery)
+
+		go attemptOutgoingWebhookDelivery(hook, delivery, body)
+	}
+}
+
+// retryOutgoingWebhookDeliveries sends the pending deliveries that are due once more.
+func retryOutgoingWebhookDeliveries() {
+	var deliveries []*model.OutgoingWebhookDelivery
+	if result := <-Srv.Store.Webhook().GetDueDeliveries(model.GetMillis(), OUTGOING_WEBHOOK_RETRY_BATCH_SIZE); result.Err != nil {
+		l4g.Error(utils.T("api.webhook.retry_deliveries.error"), result.Err.Error())
+		return
+	} else {
+		deliveries = result.Data.([]*model.OutgoingWebhookDelivery)
+	}
+
+	for _, delivery := range deliveries {
+		// another server may be retrying it already
+		newNextAttemptAt := model.GetMillis() + int64(OUTGOING_WEBHOOK_ATTEMPT_LEASE/time.Millisecond)
+		if result := <-Srv.Store.Webhook().ClaimDelivery(delivery.Id, delivery.NextAttemptAt, newNextAttemptAt); result.Err != nil {
+			l4g.Error(utils.T("api.webhook.retry_deliveries.error"), result.Err.Error())
+			continue
+		} else if !result.Data.(bool) {
+			continue
+		}
+
+		delivery.NextAttemptAt = newNextAttemptAt
+
+		// the hook may have been deleted or changed since the delivery failed
+		var hook *model.OutgoingWebhook
+		if result := <-Srv.Store.Webhook().GetOutgoing(delivery.HookId); result.Err == nil {
+			hook = result.Data.(*model.OutgoingWebhook)
+		}
+
+		if hook == nil || !hook.HasCallbackURL(delivery.URL) || !utils.Cfg.ServiceSettings.EnableOutgoingWebhooks {
+			delivery.Status = model.WEBHOOK_DELIVERY_STATUS_FAILED
+			delivery.NextAttemptAt = 0
+			saveOutgoingWebhookDelivery(delivery)
+			continue
+		}
+
+		go attemptOutgoingWebhookDelivery(hook, delivery, delivery.Payload)
+	}
+}
+
+// attemptOutgoingWebhookDelivery sends the delivery once and schedules its next attempt if it fails,
+// up to OutgoingWebhookMaxRetries times with an exponential backoff. A delivery that succeeds at the
+// first attempt is removed from the log.
+func attemptOutgoingWebhookDelivery(hook *model.OutgoingWebhook, delivery *model.OutgoingWebhookDelivery, body string) {
+	respProps, err := sendOutgoingWebhook(hook, delivery, body)
+
+	if err != nil {
+		l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.event_post.error"), err.Error())
+
+		if delivery.IsRetryable() && delivery.Attempts <= *utils.Cfg.ServiceSettings.OutgoingWebhookMaxRetries {
+			delay := outgoingWebhookRetryDelay << uint(delivery.Attempts-1)
+			delivery.NextAttemptAt = model.GetMillis() + int64(delay/time.Millisecond)
+		} else {
+			delivery.Status = model.WEBHOOK_DELIVERY_STATUS_FAILED
+			delivery.NextAttemptAt = 0
+		}
+
+		saveOutgoingWebhookDelivery(delivery)
+		return
+	}
+
+	delivery.NextAttemptAt = 0
+
+	if delivery.Attempts == 1 {
+		if len(delivery.Id) > 0 {
+			if result := <-Srv.Store.Webhook().PermanentDeleteDelivery(delivery.Id); result.Err != nil {
+				l4g.Error(utils.T("api.webhook.save_delivery.error"), delivery.HookId, result.Err.Error())
+			}
+		}
+	} else {
+		saveOutgoingWebhookDelivery(delivery)
+	}
+
+	createOutgoingWebhookResponsePost(hook, delivery, respProps)
+}
+
+// sendOutgoingWebhook makes a single request with the body of the delivery and records the outcome on it.
+func sendOutgoingWebhook(hook *model.OutgoingWebhook, delivery *model.OutgoingWebhookDelivery, body string) (map[string]string, error) {
+	delivery.Attempts++
+	delivery.StatusCode = 0
+	delivery.Error = ""
+
+	respProps, err := doOutgoingWebhookRequest(hook, delivery, body)
+	if err != nil {
+		delivery.Status = model.WEBHOOK_DELIVERY_STATUS_PENDING
+		delivery.Error = err.Error()
+	} else {
+		delivery.Status = model.WEBHOOK_DELIVERY_STATUS_SUCCESS
+	}
+
+	return respProps, err
+}
+
+func doOutgoingWebhookRequest(hook *model.OutgoingWebhook, delivery *model.OutgoingWebhookDelivery, body string) (map[string]string, error) {
+	req, err := http.NewRequest("POST", delivery.URL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", delivery.ContentType)
+	req.Header.Set("Accept", "application/json")
+
+	// hooks created before signing was added don't have a secret
+	if len(hook.Secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(model.HEADER_WEBHOOK_TIMESTAMP, timestamp)
+		req.Header.Set(model.HEADER_WEBHOOK_SIGNATURE, "sha256="+model.SignOutgoingWebhook(hook.Secret, timestamp, body))
+	}
+
+	resp, err := newIntegrationHttpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.New(resp.Status)
+	}
+
+	return model.MapFromJson(resp.Body), nil
+}
+
+// newIntegrationHttpClient returns a client for making requests to integrations.
+func newIntegrationHttpClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
+		},
+		Timeout: OUTGOING_WEBHOOK_TIMEOUT,
+	}
+}
+
+func saveOutgoingWebhookDelivery(delivery *model.OutgoingWebhookDelivery) {
+	var result store.StoreResult
+	if len(delivery.Id) == 0 {
+		result = <-Srv.Store.Webhook().SaveDelivery(delivery)
+	} else {
+		result = <-Srv.Store.Webhook().UpdateDelivery(delivery)
+	}
+
+	if result.Err != nil {
+		l4g.Error(utils.T("api.webhook.save_delivery.error"), delivery.HookId, result.Err.Error())
+	}
+}
+
+// createOutgoingWebhookResponsePost posts the text returned by the integration, if any, as the
+// creator of the hook.
+func createOutgoingWebhookResponsePost(hook *model.OutgoingWebhook, delivery *model.OutgoingWebhookDelivery, respProps map[string]string) {
+	text, ok := respProps["text"]
+	if !ok {
+		return
+	}
+
+	// the response is posted with the props of the post that triggered the hook
+	var props model.StringInterface
+	postType := ""
+	if result := <-Srv.Store.Post().Get(delivery.PostId); result.Err == nil {
+		if post, ok := result.Data.(*model.PostList).Posts[delivery.PostId]; ok {
+			props = post.Props
+			postType = post.Type
+		}
+	}
+
+	// create a mock session for posting the message, the delivery may be retried long after the
+	// request that triggered it
+	mockSession := model.Session{
+		UserId:      hook.CreatorId,
+		TeamMembers: []*model.TeamMember{{TeamId: hook.TeamId, UserId: hook.CreatorId}},
+		IsOAuth:     false,
+	}
+
+	newContext := &Context{
+		Session:   mockSession,
+		RequestId: model.NewId(),
+		IpAddress: "",
+		Err:       nil,
+		siteURL:   delivery.SiteURL,
+		T:         utils.T,
+		Locale:    model.DEFAULT_LOCALE,
+		TeamId:    hook.TeamId,
+	}
+
+	if _, err := CreateWebhookPost(newContext, delivery.ChannelId, text, respProps["username"], respProps["icon_url"], props, postType); err != nil {
+		l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.create_post.error"), err)
+	}
+}
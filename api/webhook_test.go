@@ -6,7 +6,12 @@ package api
 import (
 	"fmt"
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -97,6 +102,90 @@ func TestCreateIncomingHook(t *testing.T) {
 	}
 }
 
+func TestUpdateIncomingHook(t *testing.T) {
+	th := Setup().InitSystemAdmin()
+	Client := th.SystemAdminClient
+	team := th.SystemAdminTeam
+	channel1 := th.CreateChannel(Client, team)
+	channel2 := th.CreateChannel(Client, team)
+	channel3 := th.CreatePrivateChannel(Client, team)
+	user2 := th.CreateUser(Client)
+	LinkUserToTeam(user2, team)
+
+	enableIncomingHooks := utils.Cfg.ServiceSettings.EnableIncomingWebhooks
+	enableAdminOnlyHooks := *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableIncomingWebhooks = enableIncomingHooks
+		*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = enableAdminOnlyHooks
+	}()
+	utils.Cfg.ServiceSettings.EnableIncomingWebhooks = true
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = true
+
+	hook := &model.IncomingWebhook{ChannelId: channel1.Id}
+	hook = Client.Must(Client.CreateIncomingWebhook(hook)).Data.(*model.IncomingWebhook)
+
+	hook.ChannelId = channel2.Id
+	hook.DisplayName = "new name"
+	if result, err := Client.UpdateIncomingWebhook(hook); err != nil {
+		t.Fatal(err)
+	} else if rhook := result.Data.(*model.IncomingWebhook); rhook.ChannelId != channel2.Id || rhook.DisplayName != "new name" {
+		t.Fatal("didn't update the hook")
+	}
+
+	hook.ChannelId = "junk"
+	if _, err := Client.UpdateIncomingWebhook(hook); err == nil {
+		t.Fatal("should have failed - bad channel id")
+	}
+
+	hook.ChannelId = channel2.Id
+	hook.Description = strings.Repeat("1", 129)
+	if _, err := Client.UpdateIncomingWebhook(hook); err == nil {
+		t.Fatal("should have failed - description too long")
+	}
+
+	hook.Description = ""
+
+	Client.Logout()
+	Client.Must(Client.LoginById(user2.Id, user2.Password))
+	Client.SetTeamId(team.Id)
+
+	if _, err := Client.UpdateIncomingWebhook(hook); err == nil {
+		t.Fatal("should have failed - not system/team admin")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = false
+
+	if _, err := Client.UpdateIncomingWebhook(hook); err == nil {
+		t.Fatal("should have failed - not the creator")
+	}
+
+	hook2 := Client.Must(Client.CreateIncomingWebhook(&model.IncomingWebhook{ChannelId: channel1.Id})).Data.(*model.IncomingWebhook)
+
+	hook2.ChannelId = channel3.Id
+	if _, err := Client.UpdateIncomingWebhook(hook2); err == nil {
+		t.Fatal("should have failed - private channel the user isn't a member of")
+	}
+
+	th.LoginSystemAdmin()
+
+	if _, err := Client.UpdateIncomingWebhook(hook2); err == nil {
+		t.Fatal("should have failed - private channel the owner isn't a member of")
+	}
+
+	Client.Must(Client.AddChannelMember(channel3.Id, user2.Id))
+
+	if _, err := Client.UpdateIncomingWebhook(hook2); err != nil {
+		t.Fatal(err)
+	}
+
+	utils.Cfg.ServiceSettings.EnableIncomingWebhooks = false
+
+	hook2.ChannelId = channel2.Id
+	if _, err := Client.UpdateIncomingWebhook(hook2); err == nil {
+		t.Fatal("should have errored - webhooks turned off")
+	}
+}
+
 func TestListIncomingHooks(t *testing.T) {
 	th := Setup().InitSystemAdmin()
 	Client := th.SystemAdminClient
@@ -284,6 +373,11 @@ func TestCreateOutgoingHook(t *testing.T) {
 		t.Fatal("should have failed - private channel")
 	}
 
+	hook = &model.OutgoingWebhook{ChannelId: channel2.Id, CallbackURLs: []string{"http://nowhere.com"}, IncludePrivateChannels: true}
+	if _, err := Client.CreateOutgoingWebhook(hook); err != nil {
+		t.Fatal(err)
+	}
+
 	hook = &model.OutgoingWebhook{CallbackURLs: []string{"http://nowhere.com"}}
 	if _, err := Client.CreateOutgoingWebhook(hook); err == nil {
 		t.Fatal("should have failed - blank channel and trigger words")
@@ -511,6 +605,215 @@ func TestRegenOutgoingHookToken(t *testing.T) {
 		t.Fatal("should have errored - webhooks turned off")
 	}
 }
+func TestOutgoingWebhookDeliveries(t *testing.T) {
+	th := Setup().InitSystemAdmin()
+	Client := th.SystemAdminClient
+	team := th.SystemAdminTeam
+	channel1 := th.CreateChannel(Client, team)
+	channel2 := th.CreatePrivateChannel(Client, team)
+	user2 := th.CreateUser(Client)
+	LinkUserToTeam(user2, team)
+
+	enableOutgoingHooks := utils.Cfg.ServiceSettings.EnableOutgoingWebhooks
+	maxRetries := *utils.Cfg.ServiceSettings.OutgoingWebhookMaxRetries
+	retryDelay := outgoingWebhookRetryDelay
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableOutgoingWebhooks = enableOutgoingHooks
+		*utils.Cfg.ServiceSettings.OutgoingWebhookMaxRetries = maxRetries
+		outgoingWebhookRetryDelay = retryDelay
+	}()
+	utils.Cfg.ServiceSettings.EnableOutgoingWebhooks = true
+	*utils.Cfg.ServiceSettings.OutgoingWebhookMaxRetries = 1
+	outgoingWebhookRetryDelay = 10 * time.Millisecond
+
+	type request struct {
+		contentType string
+		body        string
+		timestamp   string
+		signature   string
+	}
+	requests := make(chan request, 10)
+	failWith := http.StatusInternalServerError
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- request{r.Header.Get("Content-Type"), string(body), r.Header.Get(model.HEADER_WEBHOOK_TIMESTAMP), r.Header.Get(model.HEADER_WEBHOOK_SIGNATURE)}
+
+		if failWith != 0 {
+			w.WriteHeader(failWith)
+		} else {
+			w.Write([]byte(`{"text": "got it"}`))
+		}
+	}))
+	defer server.Close()
+
+	hook := &model.OutgoingWebhook{
+		TriggerWords: []string{"deploy"},
+		TriggerWhen:  model.TRIGGER_WORDS_STARTS_WITH,
+		CallbackURLs: []string{server.URL},
+		ContentType:  model.OUTGOING_HOOK_CONTENT_TYPE_JSON,
+	}
+	hook = Client.Must(Client.CreateOutgoingWebhook(hook)).Data.(*model.OutgoingWebhook)
+
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel2.Id, Message: "deploy now"}))
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "please deploy now"}))
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "deployment now"}))
+
+	// the first attempt and its retry, which is sent by the retry job once it's due
+	for i := 0; i < 2; i++ {
+		if i == 1 {
+			time.Sleep(100 * time.Millisecond)
+			retryOutgoingWebhookDeliveries()
+		}
+
+		select {
+		case req := <-requests:
+			if req.contentType != model.OUTGOING_HOOK_CONTENT_TYPE_JSON {
+				t.Fatal("wrong content type", req.contentType)
+			}
+
+			if !strings.Contains(req.body, `"text":"deployment now"`) || !strings.Contains(req.body, `"trigger_word":"deploy"`) {
+				t.Fatal("wrong payload", req.body)
+			}
+
+			if req.signature != "sha256="+model.SignOutgoingWebhook(hook.Secret, req.timestamp, req.body) {
+				t.Fatal("wrong signature")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("should have sent the hook")
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	retryOutgoingWebhookDeliveries()
+
+	select {
+	case req := <-requests:
+		t.Fatal("should only have retried once and ignored the other posts", req.body)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	deliveries := Client.Must(Client.GetOutgoingWebhookDeliveries(hook.Id, 0, 10)).Data.([]*model.OutgoingWebhookDelivery)
+	if len(deliveries) != 1 {
+		t.Fatal("should have logged the failed delivery")
+	}
+
+	delivery := deliveries[0]
+	if delivery.Status != model.WEBHOOK_DELIVERY_STATUS_FAILED || delivery.Attempts != 2 || delivery.StatusCode != http.StatusInternalServerError {
+		t.Fatal("wrong delivery", delivery.ToJson())
+	}
+
+	failWith = 0
+
+	if result, err := Client.ReplayOutgoingWebhookDelivery(delivery.Id); err != nil {
+		t.Fatal(err)
+	} else if replayed := result.Data.(*model.OutgoingWebhookDelivery); replayed.Status != model.WEBHOOK_DELIVERY_STATUS_SUCCESS || replayed.Attempts != 3 {
+		t.Fatal("wrong replayed delivery", replayed.ToJson())
+	}
+
+	if req := <-requests; req.body != delivery.Payload {
+		t.Fatal("should have replayed the same payload")
+	}
+
+	hook.CallbackURLs = []string{"http://nowhere.com/"}
+	store.Must(Srv.Store.Webhook().UpdateOutgoing(hook))
+
+	if _, err := Client.ReplayOutgoingWebhookDelivery(delivery.Id); err == nil {
+		t.Fatal("should have failed - the URL was removed from the hook")
+	}
+
+	hook.CallbackURLs = []string{server.URL}
+	store.Must(Srv.Store.Webhook().UpdateOutgoing(hook))
+
+	// a delivery that succeeds at the first attempt isn't logged
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "deploy again"}))
+	<-requests
+	time.Sleep(100 * time.Millisecond)
+
+	if deliveries := Client.Must(Client.GetOutgoingWebhookDeliveries(hook.Id, 0, 10)).Data.([]*model.OutgoingWebhookDelivery); len(deliveries) != 1 {
+		t.Fatal("shouldn't have logged the successful delivery", len(deliveries))
+	}
+
+	// the integration rejecting the request isn't retried
+	failWith = http.StatusBadRequest
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "deploy the rejected build"}))
+	<-requests
+	time.Sleep(100 * time.Millisecond)
+	retryOutgoingWebhookDeliveries()
+
+	select {
+	case req := <-requests:
+		t.Fatal("shouldn't have retried a rejected request", req.body)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if deliveries := Client.Must(Client.GetOutgoingWebhookDeliveries(hook.Id, 0, 10)).Data.([]*model.OutgoingWebhookDelivery); len(deliveries) != 2 || deliveries[0].Status != model.WEBHOOK_DELIVERY_STATUS_FAILED || deliveries[0].Attempts != 1 {
+		t.Fatal("should have failed the rejected delivery right away")
+	}
+
+	failWith = http.StatusInternalServerError
+
+	privateHook := &model.OutgoingWebhook{
+		TriggerWords:           []string{"release"},
+		TriggerWhen:            model.TRIGGER_WORDS_STARTS_WITH,
+		CallbackURLs:           []string{server.URL},
+		IncludePrivateChannels: true,
+	}
+	privateHook = Client.Must(Client.CreateOutgoingWebhook(privateHook)).Data.(*model.OutgoingWebhook)
+
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel2.Id, Message: "release the secret plans"}))
+
+	for i := 0; i < 2; i++ {
+		if i == 1 {
+			time.Sleep(100 * time.Millisecond)
+			retryOutgoingWebhookDeliveries()
+		}
+
+		select {
+		case <-requests:
+		case <-time.After(5 * time.Second):
+			t.Fatal("should have sent the hook")
+		}
+	}
+
+	// the failed delivery is saved after the last request
+	time.Sleep(100 * time.Millisecond)
+
+	privateDeliveries := Client.Must(Client.GetOutgoingWebhookDeliveries(privateHook.Id, 0, 10)).Data.([]*model.OutgoingWebhookDelivery)
+	if len(privateDeliveries) != 1 || !strings.Contains(privateDeliveries[0].Payload, "secret plans") {
+		t.Fatal("should have shown the payload to the creator of the hook")
+	}
+
+	Client.Logout()
+	Client.Must(Client.LoginById(user2.Id, user2.Password))
+	Client.SetTeamId(team.Id)
+
+	if _, err := Client.GetOutgoingWebhookDeliveries(hook.Id, 0, 10); err == nil {
+		t.Fatal("should have failed - not an admin")
+	}
+
+	if _, err := Client.ReplayOutgoingWebhookDelivery(delivery.Id); err == nil {
+		t.Fatal("should have failed - not an admin")
+	}
+
+	UpdateUserToTeamAdmin(user2, team)
+	Client.Logout()
+	Client.Must(Client.LoginById(user2.Id, user2.Password))
+	Client.SetTeamId(team.Id)
+
+	if deliveries := Client.Must(Client.GetOutgoingWebhookDeliveries(hook.Id, 0, 10)).Data.([]*model.OutgoingWebhookDelivery); len(deliveries) != 2 || len(deliveries[0].Payload) == 0 || len(deliveries[1].Payload) == 0 {
+		t.Fatal("should have shown the payload of a message from an open channel")
+	}
+
+	if deliveries := Client.Must(Client.GetOutgoingWebhookDeliveries(privateHook.Id, 0, 10)).Data.([]*model.OutgoingWebhookDelivery); len(deliveries) != 1 || len(deliveries[0].Payload) != 0 {
+		t.Fatal("shouldn't have shown the payload of a message from a private channel")
+	}
+
+	if _, err := Client.ReplayOutgoingWebhookDelivery(privateDeliveries[0].Id); err == nil {
+		t.Fatal("should have failed - not a member of the private channel")
+	}
+}
+
 func TestIncomingWebhooks(t *testing.T) {
 	th := Setup().InitSystemAdmin()
 	Client := th.SystemAdminClient
@@ -13,6 +13,9 @@ type LdapInterface interface {
 	CheckPassword(id string, password string) *model.AppError
 	SwitchToLdap(userId, ldapId, ldapPassword string) *model.AppError
 	ValidateFilter(filter string) *model.AppError
+	StartLdapSyncJob()
+	SyncNow() *model.AppError
+	GetSyncStatus() *model.LdapSyncStatus
 }
 
 var theLdapInterface LdapInterface
@@ -11,7 +11,9 @@ type MfaInterface interface {
 	GenerateQrCode(user *model.User) ([]byte, *model.AppError)
 	Activate(user *model.User, token string) *model.AppError
 	Deactivate(userId string) *model.AppError
-	ValidateToken(secret, token string) (bool, *model.AppError)
+	ValidateToken(user *model.User, token string) (bool, *model.AppError)
+	GenerateRecoveryCodes(user *model.User) ([]string, *model.AppError)
+	UseRecoveryCode(user *model.User, code string) (bool, *model.AppError)
 }
 
 var theMfaInterface MfaInterface
@@ -14,6 +14,14 @@ type OauthProvider interface {
 	GetAuthDataFromJson(data io.Reader) string
 }
 
+// OpenIdConnectProvider is an OauthProvider that finds its endpoints through discovery and
+// identifies users by the ID token returned along with the access token.
+type OpenIdConnectProvider interface {
+	OauthProvider
+	GetSSOSettings() (*model.SSOSettings, *model.AppError)
+	GetUserDataFromIdToken(idToken string, userInfo io.Reader) ([]byte, *model.AppError)
+}
+
 var oauthProviders = make(map[string]OauthProvider)
 
 func RegisterOauthProvider(name string, newProvider OauthProvider) {
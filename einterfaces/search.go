@@ -0,0 +1,27 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package einterfaces
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SearchEngine interface {
+	IndexPost(post *model.Post) *model.AppError
+	DeletePost(postId string) *model.AppError
+	DeleteChannelPosts(channelId string) *model.AppError
+	DeleteUserPosts(userId string) *model.AppError
+	SearchPosts(channelIds []string, userIds []string, params *model.SearchParams, page int, perPage int) ([]string, *model.AppError)
+	PurgeIndex() *model.AppError
+}
+
+var theSearchEngine SearchEngine
+
+func RegisterSearchEngine(newEngine SearchEngine) {
+	theSearchEngine = newEngine
+}
+
+func GetSearchEngine() SearchEngine {
+	return theSearchEngine
+}
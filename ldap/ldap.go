@@ -0,0 +1,344 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package ldap
+
+import (
+	"crypto/tls"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	goldap "github.com/go-ldap/ldap"
+	"github.com/mattermost/platform/api"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+// LdapProvider authenticates users against an LDAP server and keeps the accounts created for
+// them in sync with the directory. Every operation opens its own connection bound as the
+// BindUsername, users are then looked up by their IdAttribute which is also their AuthData.
+type LdapProvider struct {
+	mutex  sync.Mutex
+	status model.LdapSyncStatus
+}
+
+type ldapConnection struct {
+	*goldap.Conn
+	netConn net.Conn
+	timeout time.Duration
+}
+
+func init() {
+	einterfaces.RegisterLdapInterface(&LdapProvider{})
+}
+
+// extendDeadline gives the next request QueryTimeout seconds to complete, the library
+// doesn't have a timeout of its own and would otherwise wait on an unresponsive server forever.
+func (c *ldapConnection) extendDeadline() {
+	c.netConn.SetDeadline(time.Now().Add(c.timeout))
+}
+
+func connect(settings *model.LdapSettings) (*ldapConnection, *model.AppError) {
+	timeout := time.Duration(*settings.QueryTimeout) * time.Second
+	address := net.JoinHostPort(*settings.LdapServer, strconv.Itoa(*settings.LdapPort))
+	tlsConfig := &tls.Config{
+		ServerName:         *settings.LdapServer,
+		InsecureSkipVerify: *settings.SkipCertificateVerification,
+	}
+
+	netConn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, model.NewLocAppError("connect", "ldap.connect.app_error", nil, "address="+address+", "+err.Error())
+	}
+
+	c := &ldapConnection{netConn: netConn, timeout: timeout}
+	c.extendDeadline()
+
+	if *settings.ConnectionSecurity == model.CONN_SECURITY_TLS {
+		tlsConn := tls.Client(netConn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			netConn.Close()
+			return nil, model.NewLocAppError("connect", "ldap.connect.app_error", nil, "address="+address+", "+err.Error())
+		}
+
+		c.Conn = goldap.NewConn(tlsConn, true)
+	} else {
+		c.Conn = goldap.NewConn(netConn, false)
+	}
+
+	c.Start()
+
+	if *settings.ConnectionSecurity == model.CONN_SECURITY_STARTTLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			c.Close()
+			return nil, model.NewLocAppError("connect", "ldap.connect.app_error", nil, "address="+address+", "+err.Error())
+		}
+	}
+
+	c.extendDeadline()
+	if err := c.Bind(*settings.BindUsername, *settings.BindPassword); err != nil {
+		c.Close()
+		return nil, model.NewLocAppError("connect", "ldap.connect.bind.app_error", nil, err.Error())
+	}
+
+	return c, nil
+}
+
+// normalizeFilter wraps a filter in parentheses when the administrator left them out.
+func normalizeFilter(filter string) string {
+	filter = strings.TrimSpace(filter)
+	if len(filter) > 0 && !strings.HasPrefix(filter, "(") {
+		filter = "(" + filter + ")"
+	}
+
+	return filter
+}
+
+func userSearchFilter(settings *model.LdapSettings, id string) string {
+	filter := "(" + *settings.IdAttribute + "=" + goldap.EscapeFilter(id) + ")"
+
+	if userFilter := normalizeFilter(*settings.UserFilter); len(userFilter) > 0 {
+		filter = "(&" + filter + userFilter + ")"
+	}
+
+	return filter
+}
+
+func userAttributes(settings *model.LdapSettings) []string {
+	attributes := []string{}
+
+	for _, attribute := range []string{
+		*settings.IdAttribute,
+		*settings.EmailAttribute,
+		*settings.UsernameAttribute,
+		*settings.FirstNameAttribute,
+		*settings.LastNameAttribute,
+		*settings.NicknameAttribute,
+	} {
+		if len(attribute) > 0 {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	return attributes
+}
+
+// findUser returns the entry of the user matching both the id and the user filter, or nil if
+// there isn't any.
+func (c *ldapConnection) findUser(settings *model.LdapSettings, id string) (*goldap.Entry, *model.AppError) {
+	request := goldap.NewSearchRequest(
+		*settings.BaseDN,
+		goldap.ScopeWholeSubtree,
+		goldap.NeverDerefAliases,
+		0,
+		*settings.QueryTimeout,
+		false,
+		userSearchFilter(settings, id),
+		userAttributes(settings),
+		nil,
+	)
+
+	c.extendDeadline()
+	result, err := c.Search(request)
+	if err != nil {
+		return nil, model.NewLocAppError("findUser", "ldap.find_user.search.app_error", nil, err.Error())
+	}
+
+	if len(result.Entries) == 0 {
+		return nil, nil
+	} else if len(result.Entries) > 1 {
+		return nil, model.NewLocAppError("findUser", "ldap.find_user.multiple_users.app_error", nil, "id="+id)
+	}
+
+	return result.Entries[0], nil
+}
+
+func (c *ldapConnection) getUser(settings *model.LdapSettings, id string) (*goldap.Entry, *model.AppError) {
+	if entry, err := c.findUser(settings, id); err != nil {
+		return nil, err
+	} else if entry == nil {
+		return nil, model.NewLocAppError("getUser", "ldap.find_user.not_found.app_error", nil, "id="+id)
+	} else {
+		return entry, nil
+	}
+}
+
+func userFromEntry(settings *model.LdapSettings, entry *goldap.Entry) *model.User {
+	user := &model.User{}
+
+	user.AuthService = model.USER_AUTH_SERVICE_LDAP
+	user.AuthData = entry.GetAttributeValue(*settings.IdAttribute)
+	user.Email = strings.ToLower(entry.GetAttributeValue(*settings.EmailAttribute))
+	user.EmailVerified = true
+
+	username := ""
+	if len(*settings.UsernameAttribute) > 0 {
+		username = entry.GetAttributeValue(*settings.UsernameAttribute)
+	}
+	if len(username) == 0 {
+		username = strings.Split(user.Email, "@")[0]
+	}
+	user.Username = model.CleanUsername(username)
+
+	if len(*settings.FirstNameAttribute) > 0 {
+		user.FirstName = entry.GetAttributeValue(*settings.FirstNameAttribute)
+	}
+
+	if len(*settings.LastNameAttribute) > 0 {
+		user.LastName = entry.GetAttributeValue(*settings.LastNameAttribute)
+	}
+
+	if len(*settings.NicknameAttribute) > 0 {
+		user.Nickname = entry.GetAttributeValue(*settings.NicknameAttribute)
+	}
+
+	return user
+}
+
+// updateUser copies the attributes read from the directory to the existing user and returns
+// true if anything changed.
+func updateUser(settings *model.LdapSettings, user *model.User, ldapUser *model.User) bool {
+	changed := false
+
+	if user.Username != ldapUser.Username {
+		user.Username = ldapUser.Username
+		changed = true
+	}
+
+	if user.Email != ldapUser.Email {
+		user.Email = ldapUser.Email
+		changed = true
+	}
+
+	if user.FirstName != ldapUser.FirstName {
+		user.FirstName = ldapUser.FirstName
+		changed = true
+	}
+
+	if user.LastName != ldapUser.LastName {
+		user.LastName = ldapUser.LastName
+		changed = true
+	}
+
+	if len(*settings.NicknameAttribute) > 0 && user.Nickname != ldapUser.Nickname {
+		user.Nickname = ldapUser.Nickname
+		changed = true
+	}
+
+	return changed
+}
+
+// authenticate checks the password by binding as the user and returns what the directory
+// knows about them.
+func authenticate(settings *model.LdapSettings, id string, password string) (*model.User, *model.AppError) {
+	// an empty password would be an unauthenticated bind which most servers accept
+	if len(password) == 0 {
+		return nil, model.NewLocAppError("authenticate", "ldap.authenticate.invalid_password.app_error", nil, "id="+id)
+	}
+
+	c, err := connect(settings)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	entry, err := c.getUser(settings, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.extendDeadline()
+	if err := c.Bind(entry.DN, password); err != nil {
+		return nil, model.NewLocAppError("authenticate", "ldap.authenticate.invalid_password.app_error", nil, "id="+id+", "+err.Error())
+	}
+
+	return userFromEntry(settings, entry), nil
+}
+
+func (l *LdapProvider) DoLogin(id string, password string) (*model.User, *model.AppError) {
+	settings := &utils.Cfg.LdapSettings
+
+	ldapUser, err := authenticate(settings, id, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if result := <-api.Srv.Store.User().GetByAuth(ldapUser.AuthData, model.USER_AUTH_SERVICE_LDAP); result.Err != nil {
+		if result.Err.Id != store.MISSING_AUTH_ACCOUNT_ERROR {
+			return nil, result.Err
+		}
+
+		if !utils.Cfg.TeamSettings.EnableUserCreation {
+			return nil, model.NewLocAppError("DoLogin", "ldap.do_login.user_creation_disabled.app_error", nil, "id="+id)
+		}
+
+		if user, err := api.CreateUser(ldapUser); err != nil {
+			return nil, model.NewLocAppError("DoLogin", "ldap.do_login.create_user.app_error", nil, err.Error())
+		} else {
+			return user, nil
+		}
+	} else {
+		user := result.Data.(*model.User)
+
+		if updateUser(settings, user, ldapUser) {
+			if result := <-api.Srv.Store.User().Update(user, true); result.Err != nil {
+				return nil, result.Err
+			}
+		}
+
+		return user, nil
+	}
+}
+
+func (l *LdapProvider) GetUser(id string) (*model.User, *model.AppError) {
+	settings := &utils.Cfg.LdapSettings
+
+	c, err := connect(settings)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	if entry, err := c.getUser(settings, id); err != nil {
+		return nil, err
+	} else {
+		return userFromEntry(settings, entry), nil
+	}
+}
+
+func (l *LdapProvider) CheckPassword(id string, password string) *model.AppError {
+	_, err := authenticate(&utils.Cfg.LdapSettings, id, password)
+	return err
+}
+
+func (l *LdapProvider) SwitchToLdap(userId, ldapId, ldapPassword string) *model.AppError {
+	ldapUser, err := authenticate(&utils.Cfg.LdapSettings, ldapId, ldapPassword)
+	if err != nil {
+		return err
+	}
+
+	if result := <-api.Srv.Store.User().GetByAuth(ldapUser.AuthData, model.USER_AUTH_SERVICE_LDAP); result.Err == nil {
+		return model.NewLocAppError("SwitchToLdap", "ldap.switch_to_ldap.already_used.app_error", nil, "user_id="+userId)
+	} else if result.Err.Id != store.MISSING_AUTH_ACCOUNT_ERROR {
+		return result.Err
+	}
+
+	if result := <-api.Srv.Store.User().UpdateAuthData(userId, model.USER_AUTH_SERVICE_LDAP, ldapUser.AuthData, ""); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+func (l *LdapProvider) ValidateFilter(filter string) *model.AppError {
+	if _, err := goldap.CompileFilter(normalizeFilter(filter)); err != nil {
+		return model.NewLocAppError("ValidateFilter", "ldap.validate_filter.app_error", nil, err.Error())
+	}
+
+	return nil
+}
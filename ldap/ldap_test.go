@@ -0,0 +1,454 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package ldap
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	goldap "github.com/go-ldap/ldap"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+	"gopkg.in/asn1-ber.v1"
+)
+
+const (
+	TEST_BASE_DN       = "ou=people,dc=example,dc=com"
+	TEST_BIND_DN       = "cn=admin,dc=example,dc=com"
+	TEST_BIND_PASSWORD = "adminpassword"
+)
+
+type testEntry struct {
+	dn         string
+	password   string
+	attributes map[string][]string
+}
+
+// testServer is an in-process LDAP server that only knows enough of the protocol to answer
+// simple binds and searches with equality, presence and boolean filters.
+type testServer struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	entries  []*testEntry
+}
+
+func newTestServer(t *testing.T) *testServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testServer{listener: listener}
+	s.addEntry("jdoe", "Password1", "jane@example.com", "Jane", "Doe")
+	s.addEntry("jsmith", "Password2", "john@example.com", "John", "Smith")
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *testServer) addEntry(uid string, password string, email string, firstName string, lastName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries = append(s.entries, &testEntry{
+		dn:       "uid=" + uid + "," + TEST_BASE_DN,
+		password: password,
+		attributes: map[string][]string{
+			"objectClass": {"person"},
+			"uid":         {uid},
+			"mail":        {email},
+			"givenName":   {firstName},
+			"sn":          {lastName},
+		},
+	})
+}
+
+func (s *testServer) removeEntry(uid string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, entry := range s.entries {
+		if entry.attributes["uid"][0] == uid {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *testServer) setAttribute(uid string, name string, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, entry := range s.entries {
+		if entry.attributes["uid"][0] == uid {
+			entry.attributes[name] = []string{value}
+		}
+	}
+}
+
+func (s *testServer) address() (string, int) {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func (s *testServer) close() {
+	s.listener.Close()
+}
+
+func (s *testServer) checkBind(dn string, password string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if dn == TEST_BIND_DN {
+		return password == TEST_BIND_PASSWORD
+	}
+
+	for _, entry := range s.entries {
+		if entry.dn == dn {
+			return len(password) > 0 && entry.password == password
+		}
+	}
+
+	return false
+}
+
+func (s *testServer) search(baseDN string, filter *ber.Packet) []*testEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entries := []*testEntry{}
+	for _, entry := range s.entries {
+		if strings.HasSuffix(entry.dn, baseDN) && entry.matches(filter) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+func (e *testEntry) values(name string) []string {
+	for attribute, values := range e.attributes {
+		if strings.EqualFold(attribute, name) {
+			return values
+		}
+	}
+
+	return nil
+}
+
+func (e *testEntry) matches(filter *ber.Packet) bool {
+	switch filter.Tag {
+	case goldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !e.matches(child) {
+				return false
+			}
+		}
+		return true
+	case goldap.FilterOr:
+		for _, child := range filter.Children {
+			if e.matches(child) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterNot:
+		return !e.matches(filter.Children[0])
+	case goldap.FilterEqualityMatch:
+		for _, value := range e.values(filter.Children[0].Value.(string)) {
+			if strings.EqualFold(value, filter.Children[1].Value.(string)) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterPresent:
+		return len(e.values(filter.Data.String())) > 0
+	}
+
+	return false
+}
+
+func ldapResult(tag ber.Tag, code int) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return result
+}
+
+func ldapEntry(entry *testEntry) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Entry")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "DN"))
+
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range entry.attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Name"))
+
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+
+	packet.AppendChild(attributes)
+	return packet
+}
+
+func (s *testServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reply := func(messageId int64, response *ber.Packet) bool {
+		packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Response")
+		packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, "MessageID"))
+		packet.AppendChild(response)
+
+		_, err := conn.Write(packet.Bytes())
+		return err == nil
+	}
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		messageId := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+
+		switch request.Tag {
+		case goldap.ApplicationBindRequest:
+			code := goldap.LDAPResultInvalidCredentials
+			if s.checkBind(request.Children[1].Value.(string), request.Children[2].Data.String()) {
+				code = goldap.LDAPResultSuccess
+			}
+
+			if !reply(messageId, ldapResult(goldap.ApplicationBindResponse, code)) {
+				return
+			}
+		case goldap.ApplicationSearchRequest:
+			for _, entry := range s.search(request.Children[0].Value.(string), request.Children[6]) {
+				if !reply(messageId, ldapEntry(entry)) {
+					return
+				}
+			}
+
+			if !reply(messageId, ldapResult(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess)) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func setupLdap(t *testing.T, server *testServer) *model.LdapSettings {
+	utils.LoadConfig("config.json")
+
+	host, port := server.address()
+
+	settings := &utils.Cfg.LdapSettings
+	*settings.Enable = true
+	*settings.LdapServer = host
+	*settings.LdapPort = port
+	*settings.ConnectionSecurity = model.CONN_SECURITY_NONE
+	*settings.BaseDN = TEST_BASE_DN
+	*settings.BindUsername = TEST_BIND_DN
+	*settings.BindPassword = TEST_BIND_PASSWORD
+	*settings.UserFilter = "(objectClass=person)"
+	*settings.IdAttribute = "uid"
+	*settings.UsernameAttribute = "uid"
+	*settings.EmailAttribute = "mail"
+	*settings.FirstNameAttribute = "givenName"
+	*settings.LastNameAttribute = "sn"
+	*settings.NicknameAttribute = ""
+	*settings.QueryTimeout = 5
+
+	return settings
+}
+
+func TestLdapAuthenticate(t *testing.T) {
+	server := newTestServer(t)
+	defer server.close()
+	settings := setupLdap(t, server)
+
+	user, err := authenticate(settings, "jdoe", "Password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.AuthService != model.USER_AUTH_SERVICE_LDAP || user.AuthData != "jdoe" {
+		t.Fatal("should have identified the user by the id attribute", user)
+	}
+
+	if user.Email != "jane@example.com" || user.Username != "jdoe" || user.FirstName != "Jane" || user.LastName != "Doe" {
+		t.Fatal("should have mapped the attributes", user)
+	}
+
+	if _, err := authenticate(settings, "jdoe", "Password2"); err == nil {
+		t.Fatal("should have failed - wrong password")
+	}
+
+	if _, err := authenticate(settings, "jdoe", ""); err == nil {
+		t.Fatal("should have failed - empty password")
+	}
+
+	if _, err := authenticate(settings, "nobody", "Password1"); err == nil {
+		t.Fatal("should have failed - unknown user")
+	}
+
+	if _, err := authenticate(settings, "*", "Password1"); err == nil {
+		t.Fatal("should have failed - the id should have been escaped")
+	}
+
+	server.setAttribute("jsmith", "objectClass", "robot")
+	if _, err := authenticate(settings, "jsmith", "Password2"); err == nil {
+		t.Fatal("should have failed - filtered out by the user filter")
+	}
+
+	*settings.UserFilter = ""
+	if _, err := authenticate(settings, "jsmith", "Password2"); err != nil {
+		t.Fatal(err)
+	}
+
+	*settings.BindPassword = "wrong"
+	if _, err := authenticate(settings, "jdoe", "Password1"); err == nil {
+		t.Fatal("should have failed - wrong bind password")
+	}
+}
+
+func TestLdapGetUserAndCheckPassword(t *testing.T) {
+	server := newTestServer(t)
+	defer server.close()
+	setupLdap(t, server)
+
+	provider := &LdapProvider{}
+
+	if user, err := provider.GetUser("jsmith"); err != nil {
+		t.Fatal(err)
+	} else if user.Email != "john@example.com" {
+		t.Fatal("should have returned the user", user)
+	}
+
+	if _, err := provider.GetUser("nobody"); err == nil {
+		t.Fatal("should have failed - unknown user")
+	}
+
+	if err := provider.CheckPassword("jsmith", "Password2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.CheckPassword("jsmith", "Password1"); err == nil {
+		t.Fatal("should have failed - wrong password")
+	}
+
+	server.close()
+	if err := provider.CheckPassword("jsmith", "Password2"); err == nil {
+		t.Fatal("should have failed - server is down")
+	}
+}
+
+func TestLdapValidateFilter(t *testing.T) {
+	provider := &LdapProvider{}
+
+	for _, filter := range []string{"(objectClass=person)", "objectClass=person", "(&(objectClass=person)(!(uid=admin)))"} {
+		if err := provider.ValidateFilter(filter); err != nil {
+			t.Fatal(filter, err)
+		}
+	}
+
+	for _, filter := range []string{"(objectClass=person", "(&(objectClass=person)"} {
+		if err := provider.ValidateFilter(filter); err == nil {
+			t.Fatal("should have failed - invalid filter", filter)
+		}
+	}
+}
+
+func TestLdapCompareUsers(t *testing.T) {
+	server := newTestServer(t)
+	defer server.close()
+	settings := setupLdap(t, server)
+
+	users := []*model.User{}
+	for _, uid := range []string{"jdoe", "jsmith"} {
+		user, err := authenticate(settings, uid, "Password"+strconv.Itoa(len(users)+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		user.Id = model.NewId()
+		users = append(users, user)
+	}
+
+	if updated, gone, err := compareUsers(settings, users); err != nil {
+		t.Fatal(err)
+	} else if len(updated) != 0 || len(gone) != 0 {
+		t.Fatal("nothing should have changed", updated, gone)
+	}
+
+	server.setAttribute("jdoe", "sn", "Smith")
+	server.removeEntry("jsmith")
+
+	if updated, gone, err := compareUsers(settings, users); err != nil {
+		t.Fatal(err)
+	} else if len(updated) != 1 || updated[0].Id != users[0].Id || updated[0].LastName != "Smith" {
+		t.Fatal("should have updated the last name", updated)
+	} else if len(gone) != 1 || gone[0].Id != users[1].Id {
+		t.Fatal("should have found the removed user", gone)
+	}
+
+	server.close()
+	if updated, gone, err := compareUsers(settings, users); err == nil {
+		t.Fatal("should have failed - server is down")
+	} else if len(updated) != 0 || len(gone) != 0 {
+		t.Fatal("should not have deactivated anybody when the server is down")
+	}
+}
+
+func TestLdapSyncStatus(t *testing.T) {
+	utils.LoadConfig("config.json")
+	utils.InitTranslations()
+	provider := &LdapProvider{}
+
+	*utils.Cfg.LdapSettings.Enable = false
+	if err := provider.SyncNow(); err == nil {
+		t.Fatal("should have failed - ldap is disabled")
+	}
+
+	if !provider.startSync() {
+		t.Fatal("should have started the sync")
+	}
+
+	if provider.startSync() {
+		t.Fatal("should not start a second sync while one is running")
+	}
+
+	if status := provider.GetSyncStatus(); !status.Running || status.LastStartAt == 0 {
+		t.Fatal("should have been running", status)
+	}
+
+	status := provider.GetSyncStatus()
+	status.UsersDeactivated = 1
+	provider.finishSync(status, nil)
+
+	if status := provider.GetSyncStatus(); status.Running || status.LastEndAt == 0 || status.UsersDeactivated != 1 {
+		t.Fatal("should have finished", status)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package ldap
+
+import (
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/api"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func (l *LdapProvider) StartLdapSyncJob() {
+	go func() {
+		for {
+			time.Sleep(time.Duration(*utils.Cfg.LdapSettings.SyncIntervalMinutes) * time.Minute)
+
+			if *utils.Cfg.LdapSettings.Enable && l.startSync() {
+				l.runSync()
+			}
+		}
+	}()
+}
+
+func (l *LdapProvider) SyncNow() *model.AppError {
+	if !*utils.Cfg.LdapSettings.Enable {
+		return model.NewLocAppError("SyncNow", "ldap.sync.disabled.app_error", nil, "")
+	}
+
+	if !l.startSync() {
+		return model.NewLocAppError("SyncNow", "ldap.sync.running.app_error", nil, "")
+	}
+
+	go l.runSync()
+
+	return nil
+}
+
+func (l *LdapProvider) GetSyncStatus() *model.LdapSyncStatus {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	status := l.status
+	return &status
+}
+
+// startSync marks a synchronization as running and returns false if one already was.
+func (l *LdapProvider) startSync() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.status.Running {
+		return false
+	}
+
+	l.status = model.LdapSyncStatus{Running: true, LastStartAt: model.GetMillis()}
+	return true
+}
+
+func (l *LdapProvider) finishSync(status *model.LdapSyncStatus, err *model.AppError) {
+	status.Running = false
+	status.LastEndAt = model.GetMillis()
+
+	if err != nil {
+		l4g.Error(utils.T("ldap.sync.error"), err.Error())
+		status.Error = err.Error()
+	} else {
+		l4g.Info(utils.T("ldap.sync.finished.info"), status.UsersChecked, status.UsersUpdated, status.UsersDeactivated)
+	}
+
+	l.mutex.Lock()
+	l.status = *status
+	l.mutex.Unlock()
+}
+
+// compareUsers looks up each of the users in the directory. It returns the ones whose
+// attributes changed, already updated, and the ones that are no longer in the directory or no
+// longer match the user filter. Nothing is returned at all if the directory can't be searched
+// so users are never deactivated because of a server outage.
+func compareUsers(settings *model.LdapSettings, users []*model.User) ([]*model.User, []*model.User, *model.AppError) {
+	c, err := connect(settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.Close()
+
+	updated := []*model.User{}
+	gone := []*model.User{}
+
+	for _, user := range users {
+		if entry, err := c.findUser(settings, user.AuthData); err != nil {
+			return nil, nil, err
+		} else if entry == nil {
+			gone = append(gone, user)
+		} else if updateUser(settings, user, userFromEntry(settings, entry)) {
+			updated = append(updated, user)
+		}
+	}
+
+	return updated, gone, nil
+}
+
+func (l *LdapProvider) runSync() {
+	settings := &utils.Cfg.LdapSettings
+	status := l.GetSyncStatus()
+
+	var users []*model.User
+	if result := <-api.Srv.Store.User().GetAllUsingAuthService(model.USER_AUTH_SERVICE_LDAP); result.Err != nil {
+		l.finishSync(status, result.Err)
+		return
+	} else {
+		for _, user := range result.Data.([]*model.User) {
+			if user.DeleteAt == 0 {
+				users = append(users, user)
+			}
+		}
+	}
+
+	status.UsersChecked = len(users)
+
+	updated, gone, err := compareUsers(settings, users)
+	if err != nil {
+		l.finishSync(status, err)
+		return
+	}
+
+	for _, user := range updated {
+		if result := <-api.Srv.Store.User().Update(user, true); result.Err != nil {
+			l4g.Error(utils.T("ldap.sync.update_user.error"), user.Id, result.Err.Error())
+		} else {
+			status.UsersUpdated++
+		}
+	}
+
+	c := &api.Context{}
+	c.RequestId = model.NewId()
+	c.IpAddress = "ldap_sync"
+	c.Path = "/ldap/sync"
+	c.T = utils.TfuncWithFallback(model.DEFAULT_LOCALE)
+	c.Locale = model.DEFAULT_LOCALE
+
+	for _, user := range gone {
+		c.Err = nil
+		if api.UpdateActive(c, user, false); c.Err != nil {
+			l4g.Error(utils.T("ldap.sync.deactivate_user.error"), user.Id, c.Err.Error())
+		} else {
+			status.UsersDeactivated++
+		}
+	}
+
+	l.finishSync(status, nil)
+}
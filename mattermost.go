@@ -28,7 +28,12 @@ import (
 	"github.com/mattermost/platform/web"
 
 	// Plugins
+	_ "github.com/mattermost/platform/ldap"
+	_ "github.com/mattermost/platform/mfa"
 	_ "github.com/mattermost/platform/model/gitlab"
+	_ "github.com/mattermost/platform/openid"
+	_ "github.com/mattermost/platform/saml"
+	_ "github.com/mattermost/platform/search"
 
 	// Enterprise Deps
 	_ "github.com/dgryski/dgoogauth"
@@ -50,6 +55,7 @@ var flagCmdPermanentDeleteUser bool
 var flagCmdPermanentDeleteTeam bool
 var flagCmdPermanentDeleteAllUsers bool
 var flagCmdResetDatabase bool
+var flagCmdReindexSearch bool
 var flagUsername string
 var flagCmdUploadLicense bool
 var flagConfigFile string
@@ -119,11 +125,17 @@ func main() {
 
 		setDiagnosticId()
 		runSecurityAndDiagnosticsJobAndForget()
+		api.StartEmailBatchingJob()
+		api.StartOutgoingWebhookRetryJob()
 
 		if einterfaces.GetComplianceInterface() != nil {
 			einterfaces.GetComplianceInterface().StartComplianceDailyJob()
 		}
 
+		if einterfaces.GetLdapInterface() != nil {
+			einterfaces.GetLdapInterface().StartLdapSyncJob()
+		}
+
 		// wait for kill signal before attempting to gracefully shutdown
 		// the running service
 		c := make(chan os.Signal)
@@ -267,6 +279,7 @@ func parseCmds() {
 	flag.BoolVar(&flagCmdPermanentDeleteAllUsers, "permanent_delete_all_users", false, "")
 	flag.BoolVar(&flagCmdResetDatabase, "reset_database", false, "")
 	flag.BoolVar(&flagCmdUploadLicense, "upload_license", false, "")
+	flag.BoolVar(&flagCmdReindexSearch, "reindex_search", false, "")
 
 	flag.Parse()
 
@@ -281,7 +294,8 @@ func parseCmds() {
 		flagCmdPermanentDeleteTeam ||
 		flagCmdPermanentDeleteAllUsers ||
 		flagCmdResetDatabase ||
-		flagCmdUploadLicense)
+		flagCmdUploadLicense ||
+		flagCmdReindexSearch)
 }
 
 func runCmds() {
@@ -297,6 +311,7 @@ func runCmds() {
 	cmdPermDeleteAllUsers()
 	cmdResetDatabase()
 	cmdUploadLicense()
+	cmdReindexSearch()
 }
 
 type TeamForUpgrade struct {
@@ -1013,6 +1028,23 @@ func cmdUploadLicense() {
 	}
 }
 
+func cmdReindexSearch() {
+	if flagCmdReindexSearch {
+		if !api.IsSearchEngineEnabled() {
+			fmt.Fprintln(os.Stderr, "search must be enabled in SearchSettings")
+			os.Exit(1)
+		}
+
+		if count, err := api.ReindexPosts(); err != nil {
+			l4g.Error("%v", err)
+			flushLogAndExit(1)
+		} else {
+			fmt.Printf("SUCCESS: Indexed %v posts.\n", count)
+			flushLogAndExit(0)
+		}
+	}
+}
+
 func flushLogAndExit(code int) {
 	l4g.Close()
 	time.Sleep(time.Second)
@@ -1123,6 +1155,12 @@ COMMANDS:
         Example:
             platform -upload_license -license="/path/to/license/example.mattermost-license"
 
+    -reindex_search                   Rebuilds the search index from all the posts in the database.
+                                      Requires SearchSettings.Enable to be set in the config.
+
+        Example:
+            platform -reindex_search
+
     -upgrade_db_30                   Upgrades the database from a version 2.x schema to version 3 see
                                       http://www.mattermost.org/upgrading-to-mattermost-3-0/
 
@@ -0,0 +1,243 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package mfa
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgryski/dgoogauth"
+	"github.com/mattermost/platform/api"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+	"github.com/mattermost/rsc/qr"
+)
+
+const (
+	// 160 bits as recommended by RFC 4226
+	SECRET_SIZE = 20
+
+	// the codes of the time steps right before and after the current one are also accepted
+	// to allow for clock drift on the user's device
+	TIME_STEP   = 30
+	WINDOW_SIZE = 1
+
+	RECOVERY_CODE_COUNT    = 10
+	RECOVERY_CODE_LENGTH   = 10
+	RECOVERY_CODE_ALPHABET = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// Mfa is a time-based one-time password (RFC 6238) implementation of MFA that works with
+// any authenticator app. Users also get recovery codes in case they lose their device, these
+// are only stored hashed and can each be used once.
+type Mfa struct {
+}
+
+func init() {
+	einterfaces.RegisterMfaInterface(&Mfa{})
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, SECRET_SIZE)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.EncodeToString(b), nil
+}
+
+// validateToken checks a six digit code against the secret at the given time in seconds and
+// returns the time step the code belongs to.
+func validateToken(secret string, token string, now int64) (int64, bool) {
+	token = strings.TrimSpace(token)
+	if len(token) != 6 {
+		return 0, false
+	}
+
+	code, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, false
+	}
+
+	step := now / TIME_STEP
+	for i := step - WINDOW_SIZE; i <= step+WINDOW_SIZE; i++ {
+		if dgoogauth.ComputeCode(secret, i) == code {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// useToken validates the code and records its time step so that neither it nor an older code
+// can be used again, even within the window in which they're still valid.
+func useToken(user *model.User, token string) (bool, *model.AppError) {
+	step, ok := validateToken(user.MfaSecret, token, time.Now().Unix())
+	if !ok {
+		return false, nil
+	}
+
+	if result := <-api.Srv.Store.User().UpdateMfaLastTimeStep(user.Id, step); result.Err != nil {
+		return false, model.NewLocAppError("ValidateToken", "mfa.validate_token.save_time_step.app_error", nil, result.Err.Error())
+	} else {
+		return result.Data.(bool), nil
+	}
+}
+
+func canUseMfa(user *model.User) bool {
+	return !user.IsBot && (len(user.AuthService) == 0 || user.AuthService == model.USER_AUTH_SERVICE_LDAP)
+}
+
+func (m *Mfa) GenerateQrCode(user *model.User) ([]byte, *model.AppError) {
+	if !canUseMfa(user) {
+		return nil, model.NewLocAppError("GenerateQrCode", "mfa.generate_qr_code.sso_user.app_error", nil, "user_id="+user.Id)
+	}
+
+	if user.MfaActive {
+		return nil, model.NewLocAppError("GenerateQrCode", "mfa.generate_qr_code.active.app_error", nil, "user_id="+user.Id)
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, model.NewLocAppError("GenerateQrCode", "mfa.generate_qr_code.create_code.app_error", nil, err.Error())
+	}
+
+	otpConfig := &dgoogauth.OTPConfig{Secret: secret, UTC: true}
+	uri := otpConfig.ProvisionURIWithIssuer(user.Email, utils.Cfg.TeamSettings.SiteName)
+
+	code, err := qr.Encode(uri, qr.M)
+	if err != nil {
+		return nil, model.NewLocAppError("GenerateQrCode", "mfa.generate_qr_code.create_code.app_error", nil, err.Error())
+	}
+
+	if result := <-api.Srv.Store.User().UpdateMfaSecret(user.Id, secret); result.Err != nil {
+		return nil, model.NewLocAppError("GenerateQrCode", "mfa.generate_qr_code.save_secret.app_error", nil, result.Err.Error())
+	}
+
+	return code.PNG(), nil
+}
+
+func (m *Mfa) Activate(user *model.User, token string) *model.AppError {
+	if len(user.MfaSecret) == 0 {
+		return model.NewLocAppError("Activate", "mfa.activate.bad_token.app_error", nil, "user_id="+user.Id)
+	}
+
+	if ok, err := useToken(user, token); err != nil {
+		return err
+	} else if !ok {
+		return model.NewLocAppError("Activate", "mfa.activate.bad_token.app_error", nil, "user_id="+user.Id)
+	}
+
+	if result := <-api.Srv.Store.User().UpdateMfaActive(user.Id, true); result.Err != nil {
+		return model.NewLocAppError("Activate", "mfa.activate.save_active.app_error", nil, result.Err.Error())
+	}
+
+	return nil
+}
+
+func (m *Mfa) Deactivate(userId string) *model.AppError {
+	achan := api.Srv.Store.User().UpdateMfaActive(userId, false)
+	schan := api.Srv.Store.User().UpdateMfaSecret(userId, "")
+	rchan := api.Srv.Store.User().UpdateMfaRecoveryCodes(userId, "")
+
+	if result := <-achan; result.Err != nil {
+		return model.NewLocAppError("Deactivate", "mfa.deactivate.save_active.app_error", nil, result.Err.Error())
+	}
+
+	if result := <-schan; result.Err != nil {
+		return model.NewLocAppError("Deactivate", "mfa.deactivate.save_secret.app_error", nil, result.Err.Error())
+	}
+
+	if result := <-rchan; result.Err != nil {
+		return model.NewLocAppError("Deactivate", "mfa.deactivate.save_recovery_codes.app_error", nil, result.Err.Error())
+	}
+
+	return nil
+}
+
+func (m *Mfa) ValidateToken(user *model.User, token string) (bool, *model.AppError) {
+	if len(user.MfaSecret) == 0 {
+		return false, model.NewLocAppError("ValidateToken", "mfa.validate_token.secret.app_error", nil, "")
+	}
+
+	return useToken(user, token)
+}
+
+// generateRecoveryCodes returns new recovery codes along with their hashes as they're stored.
+func generateRecoveryCodes() ([]string, string, error) {
+	codes := make([]string, RECOVERY_CODE_COUNT)
+	hashes := make([]string, RECOVERY_CODE_COUNT)
+
+	for i := range codes {
+		b := make([]byte, RECOVERY_CODE_LENGTH)
+		if _, err := rand.Read(b); err != nil {
+			return nil, "", err
+		}
+
+		for j := range b {
+			b[j] = RECOVERY_CODE_ALPHABET[int(b[j])%len(RECOVERY_CODE_ALPHABET)]
+		}
+
+		codes[i] = string(b[:RECOVERY_CODE_LENGTH/2]) + "-" + string(b[RECOVERY_CODE_LENGTH/2:])
+		hashes[i] = model.HashPassword(normalizeRecoveryCode(codes[i]))
+	}
+
+	return codes, strings.Join(hashes, " "), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.Replace(strings.ToLower(strings.Join(strings.Fields(code), "")), "-", "", -1)
+}
+
+// useRecoveryCode returns the stored hashes without the one matching the code, if any.
+func useRecoveryCode(hashes string, code string) (string, bool) {
+	code = normalizeRecoveryCode(code)
+	if len(code) != RECOVERY_CODE_LENGTH {
+		return hashes, false
+	}
+
+	remaining := strings.Fields(hashes)
+	for i, hash := range remaining {
+		if model.ComparePassword(hash, code) {
+			return strings.Join(append(remaining[:i], remaining[i+1:]...), " "), true
+		}
+	}
+
+	return hashes, false
+}
+
+func (m *Mfa) GenerateRecoveryCodes(user *model.User) ([]string, *model.AppError) {
+	if !user.MfaActive {
+		return nil, model.NewLocAppError("GenerateRecoveryCodes", "mfa.generate_recovery_codes.not_active.app_error", nil, "user_id="+user.Id)
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, model.NewLocAppError("GenerateRecoveryCodes", "mfa.generate_recovery_codes.app_error", nil, err.Error())
+	}
+
+	if result := <-api.Srv.Store.User().UpdateMfaRecoveryCodes(user.Id, hashes); result.Err != nil {
+		return nil, model.NewLocAppError("GenerateRecoveryCodes", "mfa.generate_recovery_codes.app_error", nil, result.Err.Error())
+	}
+
+	return codes, nil
+}
+
+func (m *Mfa) UseRecoveryCode(user *model.User, code string) (bool, *model.AppError) {
+	remaining, ok := useRecoveryCode(user.MfaRecoveryCodes, code)
+	if !ok {
+		return false, nil
+	}
+
+	// the codes are only replaced if nobody else used one since they were read
+	if result := <-api.Srv.Store.User().ReplaceMfaRecoveryCodes(user.Id, user.MfaRecoveryCodes, remaining); result.Err != nil {
+		return false, model.NewLocAppError("UseRecoveryCode", "mfa.use_recovery_code.app_error", nil, result.Err.Error())
+	} else {
+		return result.Data.(bool), nil
+	}
+}
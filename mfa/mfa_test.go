@@ -0,0 +1,85 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package mfa
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestValidateToken(t *testing.T) {
+	// the SHA1 test vectors of RFC 6238 truncated to six digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	if step, ok := validateToken(secret, "287082", 59); !ok || step != 1 {
+		t.Fatal("should have accepted the code")
+	}
+
+	if _, ok := validateToken(secret, "081804", 1111111109); !ok {
+		t.Fatal("should have accepted the code")
+	}
+
+	if _, ok := validateToken(secret, " 050471 ", 1111111111); !ok {
+		t.Fatal("should have accepted the code")
+	}
+
+	if step, ok := validateToken(secret, "287082", 59+TIME_STEP); !ok || step != 1 {
+		t.Fatal("should have accepted the code of the previous step")
+	}
+
+	if _, ok := validateToken(secret, "287082", 59+2*TIME_STEP); ok {
+		t.Fatal("should have failed - the code has expired")
+	}
+
+	for _, token := range []string{"287083", "94287082", "abcdef"} {
+		if _, ok := validateToken(secret, token, 59); ok {
+			t.Fatal("should have failed - wrong code", token)
+		}
+	}
+
+	if s, err := newSecret(); err != nil {
+		t.Fatal(err)
+	} else if b, err := base32.StdEncoding.DecodeString(s); err != nil || len(b) != SECRET_SIZE {
+		t.Fatal("should have generated a valid secret")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(codes) != RECOVERY_CODE_COUNT || len(strings.Fields(hashes)) != RECOVERY_CODE_COUNT {
+		t.Fatal("should have generated the codes")
+	}
+
+	if strings.Contains(hashes, strings.Replace(codes[0], "-", "", -1)) {
+		t.Fatal("should have hashed the codes")
+	}
+
+	remaining, ok := useRecoveryCode(hashes, strings.ToUpper(codes[3]))
+	if !ok || len(strings.Fields(remaining)) != RECOVERY_CODE_COUNT-1 {
+		t.Fatal("should have used the code")
+	}
+
+	if _, ok := useRecoveryCode(remaining, codes[3]); ok {
+		t.Fatal("should have failed - the code was already used")
+	}
+
+	if _, ok := useRecoveryCode(remaining, strings.Replace(codes[4], "-", " ", 1)); !ok {
+		t.Fatal("should have accepted the code without the dash")
+	}
+
+	if _, ok := useRecoveryCode(remaining, "123456"); ok {
+		t.Fatal("should have failed - not a recovery code")
+	}
+
+	if canUseMfa(&model.User{AuthService: model.USER_AUTH_SERVICE_GITLAB}) || !canUseMfa(&model.User{AuthService: model.USER_AUTH_SERVICE_LDAP}) {
+		t.Fatal("should only let email and LDAP users use MFA")
+	}
+}
@@ -15,10 +15,14 @@ const (
 )
 
 type AccessData struct {
+	ClientId     string `json:"client_id"`
+	UserId       string `json:"user_id"`
 	AuthCode     string `json:"auth_code"`
 	Token        string `json:"token"`
 	RefreshToken string `json:"refresh_token"`
 	RedirectUri  string `json:"redirect_uri"`
+	Scope        string `json:"scope"`
+	RotatedAt    int64  `json:"rotated_at"`
 }
 
 type AccessResponse struct {
@@ -27,12 +31,21 @@ type AccessResponse struct {
 	ExpiresIn    int32  `json:"expires_in"`
 	Scope        string `json:"scope"`
 	RefreshToken string `json:"refresh_token"`
+	IdToken      string `json:"id_token,omitempty"`
 }
 
 // IsValid validates the AccessData and returns an error if it isn't configured
 // correctly.
 func (ad *AccessData) IsValid() *AppError {
 
+	if len(ad.ClientId) > 26 {
+		return NewLocAppError("AccessData.IsValid", "model.access.is_valid.client_id.app_error", nil, "")
+	}
+
+	if len(ad.UserId) > 26 {
+		return NewLocAppError("AccessData.IsValid", "model.access.is_valid.user_id.app_error", nil, "")
+	}
+
 	if len(ad.AuthCode) == 0 || len(ad.AuthCode) > 128 {
 		return NewLocAppError("AccessData.IsValid", "model.access.is_valid.auth_code.app_error", nil, "")
 	}
@@ -49,9 +62,18 @@ func (ad *AccessData) IsValid() *AppError {
 		return NewLocAppError("AccessData.IsValid", "model.access.is_valid.redirect_uri.app_error", nil, "")
 	}
 
+	if len(ad.Scope) > 128 {
+		return NewLocAppError("AccessData.IsValid", "model.access.is_valid.scope.app_error", nil, "")
+	}
+
 	return nil
 }
 
+// IsRotated returns true if the refresh token has already been exchanged for a new access token.
+func (ad *AccessData) IsRotated() bool {
+	return ad.RotatedAt != 0
+}
+
 func (ad *AccessData) ToJson() string {
 	b, err := json.Marshal(ad)
 	if err != nil {
@@ -38,4 +38,20 @@ func TestAccessIsValid(t *testing.T) {
 	if err := ad.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	ad.ClientId = NewId() + "a"
+	if err := ad.IsValid(); err == nil {
+		t.Fatal("should have failed")
+	}
+
+	ad.ClientId = NewId()
+	ad.UserId = NewId() + "a"
+	if err := ad.IsValid(); err == nil {
+		t.Fatal("should have failed")
+	}
+
+	ad.UserId = NewId()
+	if err := ad.IsValid(); err != nil {
+		t.Fatal(err)
+	}
 }
@@ -362,6 +362,14 @@ func (c *Client) Login(loginId string, password string) (*Result, *AppError) {
 	return c.login(m)
 }
 
+func (c *Client) LoginWithMfa(loginId string, password string, mfaToken string) (*Result, *AppError) {
+	m := make(map[string]string)
+	m["login_id"] = loginId
+	m["password"] = password
+	m["token"] = mfaToken
+	return c.login(m)
+}
+
 func (c *Client) LoginByLdap(loginId string, password string) (*Result, *AppError) {
 	m := make(map[string]string)
 	m["login_id"] = loginId
@@ -442,6 +450,17 @@ func (c *Client) UpdateMfa(activate bool, token string) (*Result, *AppError) {
 	}
 }
 
+// GenerateMfaRecoveryCodes replaces the MFA recovery codes of the logged in user and returns
+// the new ones. On success it returns an array of codes.
+func (c *Client) GenerateMfaRecoveryCodes() (*Result, *AppError) {
+	if r, err := c.DoApiPost("/users/generate_mfa_recovery_codes", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ArrayFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) AdminResetMfa(userId string) (*Result, *AppError) {
 	m := make(map[string]string)
 	m["user_id"] = userId
@@ -475,6 +494,70 @@ func (c *Client) GetSessions(id string) (*Result, *AppError) {
 	}
 }
 
+// CreateUserAccessToken creates a personal access token for a user. The token itself is
+// only returned by this call.
+func (c *Client) CreateUserAccessToken(userId string, description string) (*Result, *AppError) {
+	m := make(map[string]string)
+	m["description"] = description
+
+	if r, err := c.DoApiPost("/users/"+userId+"/tokens/create", MapToJson(m)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), UserAccessTokenFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetUserAccessTokens(userId string) (*Result, *AppError) {
+	if r, err := c.DoApiGet("/users/"+userId+"/tokens", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), UserAccessTokenListFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) RevokeUserAccessToken(userId string, tokenId string) (*Result, *AppError) {
+	m := make(map[string]string)
+	m["token_id"] = tokenId
+
+	if r, err := c.DoApiPost("/users/"+userId+"/tokens/revoke", MapToJson(m)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+// CreateBot creates a bot account owned by a user or a team. Bots can't log in, they
+// authenticate with the personal access tokens created for them by their owners. A bot
+// owned by a user can be added to the team given by teamId.
+func (c *Client) CreateBot(username string, displayName string, ownerType string, ownerId string, teamId string) (*Result, *AppError) {
+	m := make(map[string]string)
+	m["username"] = username
+	m["display_name"] = displayName
+	m["owner_type"] = ownerType
+	m["owner_id"] = ownerId
+	m["team_id"] = teamId
+
+	if r, err := c.DoApiPost("/users/bots/create", MapToJson(m)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), UserFromJson(r.Body)}, nil
+	}
+}
+
+// GetBots returns the bots owned by the current user and by the teams they administer.
+func (c *Client) GetBots() (*Result, *AppError) {
+	if r, err := c.DoApiGet("/users/bots", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), UserMapFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) EmailToOAuth(m map[string]string) (*Result, *AppError) {
 	if r, err := c.DoApiPost("/users/claim/email_to_sso", MapToJson(m)); err != nil {
 		return nil, err
@@ -533,6 +616,19 @@ func (c *Client) ListCommands() (*Result, *AppError) {
 	}
 }
 
+// SuggestCommands returns the suggestions for completing the last word of a partially typed command.
+func (c *Client) SuggestCommands(command string, channelId string) (*Result, *AppError) {
+	m := make(map[string]string)
+	m["command"] = command
+	m["channelId"] = channelId
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/commands/suggest", MapToJson(m)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), SuggestCommandListFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) ListTeamCommands() (*Result, *AppError) {
 	if r, err := c.DoApiGet(c.GetTeamRoute()+"/commands/list_team_commands", "", ""); err != nil {
 		return nil, err
@@ -551,6 +647,15 @@ func (c *Client) CreateCommand(cmd *Command) (*Result, *AppError) {
 	}
 }
 
+func (c *Client) UpdateCommand(cmd *Command) (*Result, *AppError) {
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/commands/update", cmd.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), CommandFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) RegenCommandToken(data map[string]string) (*Result, *AppError) {
 	if r, err := c.DoApiPost(c.GetTeamRoute()+"/commands/regen_token", MapToJson(data)); err != nil {
 		return nil, err
@@ -769,6 +874,16 @@ func (c *Client) GetMoreChannels(etag string) (*Result, *AppError) {
 	}
 }
 
+// GetDeletedChannels returns the archived open channels of the current team.
+func (c *Client) GetDeletedChannels(etag string) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+"/channels/deleted", "", etag); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ChannelListFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) GetChannelCounts(etag string) (*Result, *AppError) {
 	if r, err := c.DoApiGet(c.GetTeamRoute()+"/channels/counts", "", etag); err != nil {
 		return nil, err
@@ -814,6 +929,19 @@ func (c *Client) DeleteChannel(id string) (*Result, *AppError) {
 	}
 }
 
+// RestoreChannel undoes the deletion of a channel. The webhooks that were deleted along with the
+// channel are re-enabled if restoreHooks is true.
+func (c *Client) RestoreChannel(id string, restoreHooks bool) (*Result, *AppError) {
+	data := make(map[string]string)
+	data["restore_hooks"] = strconv.FormatBool(restoreHooks)
+	if r, err := c.DoApiPost(c.GetChannelRoute(id)+"/restore", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ChannelFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) AddChannelMember(id, user_id string) (*Result, *AppError) {
 	data := make(map[string]string)
 	data["user_id"] = user_id
@@ -908,6 +1036,41 @@ func (c *Client) GetPostsAfter(channelId string, postid string, offset int, limi
 	}
 }
 
+// GetLatestPostsCursor returns the newest page of posts in a channel. The oldest post id
+// in the returned order can be passed to GetPostsBeforeCursor to fetch the next page.
+func (c *Client) GetLatestPostsCursor(channelId string, limit int, etag string) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/cursor/%v", limit), "", etag); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
+// GetPostsBeforeCursor returns up to limit posts older than the given post.
+func (c *Client) GetPostsBeforeCursor(channelId string, postId string, limit int, etag string) (*Result, *AppError) {
+	return c.getPostsByCursor(channelId, postId, "before", limit, etag)
+}
+
+// GetPostsAfterCursor returns up to limit posts newer than the given post.
+func (c *Client) GetPostsAfterCursor(channelId string, postId string, limit int, etag string) (*Result, *AppError) {
+	return c.getPostsByCursor(channelId, postId, "after", limit, etag)
+}
+
+// GetPostsAroundCursor returns the given post with up to limit posts on either side of it.
+func (c *Client) GetPostsAroundCursor(channelId string, postId string, limit int, etag string) (*Result, *AppError) {
+	return c.getPostsByCursor(channelId, postId, "around", limit, etag)
+}
+
+func (c *Client) getPostsByCursor(channelId string, postId string, direction string, limit int, etag string) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/cursor/%v/%v", postId, direction, limit), "", etag); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) GetPost(channelId string, postId string, etag string) (*Result, *AppError) {
 	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/get", postId), "", etag); err != nil {
 		return nil, err
@@ -926,6 +1089,138 @@ func (c *Client) DeletePost(channelId string, postId string) (*Result, *AppError
 	}
 }
 
+// GetPostThread returns the root post and all replies of the thread the given post belongs to.
+func (c *Client) GetPostThread(postId string, etag string) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+fmt.Sprintf("/posts/%v/thread", postId), "", etag); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
+// GetPostEditHistory returns the previous revisions of the post, most recent edit first.
+func (c *Client) GetPostEditHistory(postId string) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+fmt.Sprintf("/posts/%v/history", postId), "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetFollowedThreads() (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+"/threads/followed", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ThreadFollowerListFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) FollowThread(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/follow", postId), ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ThreadFollowerFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) UnfollowThread(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/unfollow", postId), ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) ViewThread(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/thread/viewed", postId), ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+// GetFlaggedPosts returns a page of the posts the current user has flagged.
+func (c *Client) GetFlaggedPosts(offset int, limit int) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+fmt.Sprintf("/posts/flagged/%v/%v", offset, limit), "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) PinPost(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/pin", postId), ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) UnpinPost(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/unpin", postId), ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostFromJson(r.Body)}, nil
+	}
+}
+
+// DoPostAction runs an action of one of the attachments of the post. selectedOption is only used
+// by select menus.
+func (c *Client) DoPostAction(channelId string, postId string, actionId string, selectedOption string) (*Result, *AppError) {
+	data := map[string]string{"selected_option": selectedOption}
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/actions/%v", postId, actionId), MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetPinnedPosts(channelId string) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+"/pinned", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) SaveReaction(channelId string, reaction *Reaction) (*Result, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/reactions/save", reaction.PostId), reaction.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ReactionFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) DeleteReaction(channelId string, reaction *Reaction) (*Result, *AppError) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/reactions/delete", reaction.PostId), reaction.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ReactionFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) ListReactions(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetChannelRoute(channelId)+fmt.Sprintf("/posts/%v/reactions", postId), "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ReactionsFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) SearchPosts(terms string, isOrSearch bool) (*Result, *AppError) {
 	data := map[string]interface{}{}
 	data["terms"] = terms
@@ -938,6 +1233,22 @@ func (c *Client) SearchPosts(terms string, isOrSearch bool) (*Result, *AppError)
 	}
 }
 
+// SearchPostsWithPaging returns a single page of search results. Paging is only honoured
+// when the server has a search engine enabled, otherwise the first 100 results are returned.
+func (c *Client) SearchPostsWithPaging(terms string, isOrSearch bool, page int, perPage int) (*Result, *AppError) {
+	data := map[string]interface{}{}
+	data["terms"] = terms
+	data["is_or_search"] = isOrSearch
+	data["page"] = page
+	data["per_page"] = perPage
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/posts/search", StringInterfaceToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) UploadProfileFile(data []byte, contentType string) (*Result, *AppError) {
 	return c.uploadFile(c.ApiUrl+"/users/newimage", data, contentType)
 }
@@ -1112,6 +1423,26 @@ func (c *Client) AdminResetPassword(userId, newPassword string) (*Result, *AppEr
 	}
 }
 
+// LdapSyncNow starts synchronizing the LDAP users with the directory, the progress can be
+// followed with GetLdapSyncStatus.
+func (c *Client) LdapSyncNow() (*Result, *AppError) {
+	if r, err := c.DoApiPost("/admin/ldap_sync_now", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetLdapSyncStatus() (*Result, *AppError) {
+	if r, err := c.DoApiGet("/admin/ldap_sync_status", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), LdapSyncStatusFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) GetStatuses(data []string) (*Result, *AppError) {
 	if r, err := c.DoApiPost("/users/status", ArrayToJson(data)); err != nil {
 		return nil, err
@@ -1148,8 +1479,85 @@ func (c *Client) RegisterApp(app *OAuthApp) (*Result, *AppError) {
 	}
 }
 
+// ListOAuthApps returns the OAuth apps registered by the current user, or every app
+// when the user is a system admin. Client secrets are not included.
+func (c *Client) ListOAuthApps() (*Result, *AppError) {
+	if r, err := c.DoApiGet("/oauth/list", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OAuthAppListFromJson(r.Body)}, nil
+	}
+}
+
+// GetOAuthAppInfo returns the public information of an OAuth app.
+func (c *Client) GetOAuthAppInfo(clientId string) (*Result, *AppError) {
+	if r, err := c.DoApiGet("/oauth/app/"+clientId, "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OAuthAppFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) UpdateOAuthApp(app *OAuthApp) (*Result, *AppError) {
+	if r, err := c.DoApiPost("/oauth/update", app.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OAuthAppFromJson(r.Body)}, nil
+	}
+}
+
+// RegenerateOAuthAppSecret replaces the client secret of an app. The returned app holds
+// the new secret, it can't be retrieved again later.
+func (c *Client) RegenerateOAuthAppSecret(clientId string) (*Result, *AppError) {
+	data := make(map[string]string)
+	data["id"] = clientId
+	if r, err := c.DoApiPost("/oauth/regen_secret", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OAuthAppFromJson(r.Body)}, nil
+	}
+}
+
+// DeleteOAuthApp deletes an app and revokes every access token issued to it.
+func (c *Client) DeleteOAuthApp(clientId string) (*Result, *AppError) {
+	data := make(map[string]string)
+	data["id"] = clientId
+	if r, err := c.DoApiPost("/oauth/delete", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+// GetAuthorizedOAuthApps returns the apps the current user has granted access to.
+func (c *Client) GetAuthorizedOAuthApps() (*Result, *AppError) {
+	if r, err := c.DoApiGet("/oauth/authorized", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OAuthAppListFromJson(r.Body)}, nil
+	}
+}
+
+// DeauthorizeOAuthApp revokes every access token the current user granted to an app.
+func (c *Client) DeauthorizeOAuthApp(clientId string) (*Result, *AppError) {
+	data := make(map[string]string)
+	data["id"] = clientId
+	if r, err := c.DoApiPost("/oauth/deauthorize", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) AllowOAuth(rspType, clientId, redirect, scope, state string) (*Result, *AppError) {
-	if r, err := c.DoApiGet("/oauth/allow?response_type="+rspType+"&client_id="+clientId+"&redirect_uri="+url.QueryEscape(redirect)+"&scope="+scope+"&state="+url.QueryEscape(state), "", ""); err != nil {
+	if r, err := c.DoApiGet("/oauth/allow?response_type="+rspType+"&client_id="+clientId+"&redirect_uri="+url.QueryEscape(redirect)+"&scope="+url.QueryEscape(scope)+"&state="+url.QueryEscape(state), "", ""); err != nil {
 		return nil, err
 	} else {
 		return &Result{r.Header.Get(HEADER_REQUEST_ID),
@@ -1175,6 +1583,15 @@ func (c *Client) CreateIncomingWebhook(hook *IncomingWebhook) (*Result, *AppErro
 	}
 }
 
+func (c *Client) UpdateIncomingWebhook(hook *IncomingWebhook) (*Result, *AppError) {
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/hooks/incoming/update", hook.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), IncomingWebhookFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) PostToWebhook(id, payload string) (*Result, *AppError) {
 	if r, err := c.DoPost("/hooks/"+id, payload, "application/x-www-form-urlencoded"); err != nil {
 		return nil, err
@@ -1279,6 +1696,29 @@ func (c *Client) RegenOutgoingWebhookToken(id string) (*Result, *AppError) {
 	}
 }
 
+// GetOutgoingWebhookDeliveries returns the deliveries of the outgoing webhook that failed at least
+// once, most recent first.
+func (c *Client) GetOutgoingWebhookDeliveries(hookId string, offset int, limit int) (*Result, *AppError) {
+	if r, err := c.DoApiGet(c.GetTeamRoute()+fmt.Sprintf("/hooks/outgoing/%v/deliveries/%v/%v", hookId, offset, limit), "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OutgoingWebhookDeliveryListFromJson(r.Body)}, nil
+	}
+}
+
+// ReplayOutgoingWebhookDelivery sends a logged delivery again and returns its updated status.
+func (c *Client) ReplayOutgoingWebhookDelivery(id string) (*Result, *AppError) {
+	data := make(map[string]string)
+	data["id"] = id
+	if r, err := c.DoApiPost(c.GetTeamRoute()+"/hooks/outgoing/deliveries/replay", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), OutgoingWebhookDeliveryFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) MockSession(sessionToken string) {
 	c.AuthToken = sessionToken
 	c.AuthType = HEADER_BEARER
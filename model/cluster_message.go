@@ -0,0 +1,47 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	CLUSTER_EVENT_PUBLISH            = "publish"
+	CLUSTER_EVENT_INVALIDATE_USER    = "invalidate_user"
+	CLUSTER_EVENT_INVALIDATE_CHANNEL = "invalidate_channel"
+	CLUSTER_EVENT_REMOVE_SESSION     = "remove_session"
+)
+
+type ClusterMessage struct {
+	NodeId  string   `json:"node_id"`
+	Event   string   `json:"event"`
+	Data    string   `json:"data"`
+	Message *Message `json:"message,omitempty"`
+}
+
+func NewClusterMessage(event string, data string, message *Message) *ClusterMessage {
+	return &ClusterMessage{Event: event, Data: data, Message: message}
+}
+
+func (o *ClusterMessage) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ClusterMessageFromJson(data io.Reader) *ClusterMessage {
+	decoder := json.NewDecoder(data)
+	var o ClusterMessage
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
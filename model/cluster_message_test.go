@@ -0,0 +1,42 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterMessageJson(t *testing.T) {
+	m := NewMessage(NewId(), NewId(), NewId(), ACTION_POSTED)
+	m.Add("post", "{}")
+
+	o := NewClusterMessage(CLUSTER_EVENT_PUBLISH, "", m)
+	o.NodeId = NewId()
+	json := o.ToJson()
+	result := ClusterMessageFromJson(strings.NewReader(json))
+
+	if o.NodeId != result.NodeId {
+		t.Fatal("Ids do not match")
+	}
+
+	if result.Event != CLUSTER_EVENT_PUBLISH {
+		t.Fatal("events do not match")
+	}
+
+	if result.Message == nil || m.ChannelId != result.Message.ChannelId || m.Props["post"] != result.Message.Props["post"] {
+		t.Fatal("messages do not match")
+	}
+
+	o = NewClusterMessage(CLUSTER_EVENT_INVALIDATE_USER, NewId(), nil)
+	result = ClusterMessageFromJson(strings.NewReader(o.ToJson()))
+
+	if o.Data != result.Data {
+		t.Fatal("data does not match")
+	}
+
+	if result.Message != nil {
+		t.Fatal("message should be empty")
+	}
+}
@@ -14,23 +14,24 @@ const (
 )
 
 type Command struct {
-	Id               string `json:"id"`
-	Token            string `json:"token"`
-	CreateAt         int64  `json:"create_at"`
-	UpdateAt         int64  `json:"update_at"`
-	DeleteAt         int64  `json:"delete_at"`
-	CreatorId        string `json:"creator_id"`
-	TeamId           string `json:"team_id"`
-	Trigger          string `json:"trigger"`
-	Method           string `json:"method"`
-	Username         string `json:"username"`
-	IconURL          string `json:"icon_url"`
-	AutoComplete     bool   `json:"auto_complete"`
-	AutoCompleteDesc string `json:"auto_complete_desc"`
-	AutoCompleteHint string `json:"auto_complete_hint"`
-	DisplayName      string `json:"display_name"`
-	Description      string `json:"description"`
-	URL              string `json:"url"`
+	Id               string            `json:"id"`
+	Token            string            `json:"token"`
+	CreateAt         int64             `json:"create_at"`
+	UpdateAt         int64             `json:"update_at"`
+	DeleteAt         int64             `json:"delete_at"`
+	CreatorId        string            `json:"creator_id"`
+	TeamId           string            `json:"team_id"`
+	Trigger          string            `json:"trigger"`
+	Method           string            `json:"method"`
+	Username         string            `json:"username"`
+	IconURL          string            `json:"icon_url"`
+	AutoComplete     bool              `json:"auto_complete"`
+	AutoCompleteDesc string            `json:"auto_complete_desc"`
+	AutoCompleteHint string            `json:"auto_complete_hint"`
+	AutocompleteData *AutocompleteData `json:"autocomplete_data,omitempty"`
+	DisplayName      string            `json:"display_name"`
+	Description      string            `json:"description"`
+	URL              string            `json:"url"`
 }
 
 func (o *Command) ToJson() string {
@@ -123,6 +124,20 @@ func (o *Command) IsValid() *AppError {
 		return NewLocAppError("Command.IsValid", "model.command.is_valid.description.app_error", nil, "")
 	}
 
+	if o.AutocompleteData != nil {
+		if o.AutocompleteData.Trigger != o.Trigger {
+			return NewLocAppError("Command.IsValid", "model.command.is_valid.autocomplete_data.app_error", nil, "")
+		}
+
+		if err := o.AutocompleteData.IsValid(); err != nil {
+			return err
+		}
+
+		if len(o.AutocompleteData.ToJson()) > AUTOCOMPLETE_DATA_MAX_LENGTH {
+			return NewLocAppError("Command.IsValid", "model.command.is_valid.autocomplete_data.app_error", nil, "")
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,171 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+const (
+	AUTOCOMPLETE_ARG_TYPE_TEXT         = "text"
+	AUTOCOMPLETE_ARG_TYPE_USER         = "user"
+	AUTOCOMPLETE_ARG_TYPE_CHANNEL      = "channel"
+	AUTOCOMPLETE_ARG_TYPE_STATIC_LIST  = "static_list"
+	AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST = "dynamic_list"
+
+	AUTOCOMPLETE_DATA_MAX_LENGTH = 4000
+)
+
+// AutocompleteData describes a command, or one of its subcommands, for autocompletion. A command
+// either has subcommands or arguments, the arguments are expected in the order they're listed.
+type AutocompleteData struct {
+	Trigger     string              `json:"trigger"`
+	Hint        string              `json:"hint"`
+	HelpText    string              `json:"help_text"`
+	Arguments   []*AutocompleteArg  `json:"arguments,omitempty"`
+	SubCommands []*AutocompleteData `json:"sub_commands,omitempty"`
+}
+
+type AutocompleteArg struct {
+	Name     string `json:"name"`
+	Hint     string `json:"hint"`
+	HelpText string `json:"help_text"`
+	Type     string `json:"type"`
+
+	// Items are the values of a static list
+	Items []*AutocompleteListItem `json:"items,omitempty"`
+}
+
+type AutocompleteListItem struct {
+	Item     string `json:"item"`
+	Hint     string `json:"hint"`
+	HelpText string `json:"help_text"`
+}
+
+func NewAutocompleteData(trigger, hint, helpText string) *AutocompleteData {
+	return &AutocompleteData{
+		Trigger:  trigger,
+		Hint:     hint,
+		HelpText: helpText,
+	}
+}
+
+func (o *AutocompleteData) AddCommand(command *AutocompleteData) {
+	o.SubCommands = append(o.SubCommands, command)
+}
+
+func (o *AutocompleteData) AddArgument(name, hint, helpText, argType string) {
+	o.Arguments = append(o.Arguments, &AutocompleteArg{
+		Name:     name,
+		Hint:     hint,
+		HelpText: helpText,
+		Type:     argType,
+	})
+}
+
+func (o *AutocompleteData) AddStaticListArgument(name, helpText string, items []*AutocompleteListItem) {
+	o.Arguments = append(o.Arguments, &AutocompleteArg{
+		Name:     name,
+		HelpText: helpText,
+		Type:     AUTOCOMPLETE_ARG_TYPE_STATIC_LIST,
+		Items:    items,
+	})
+}
+
+// GetSubCommand returns the subcommand with the given trigger, or nil.
+func (o *AutocompleteData) GetSubCommand(trigger string) *AutocompleteData {
+	for _, command := range o.SubCommands {
+		if command.Trigger == trigger {
+			return command
+		}
+	}
+
+	return nil
+}
+
+func (o *AutocompleteData) IsValid() *AppError {
+	if len(o.Trigger) == 0 || strings.ContainsAny(o.Trigger, " \t\n") {
+		return NewLocAppError("AutocompleteData.IsValid", "model.autocomplete_data.is_valid.trigger.app_error", nil, "trigger="+o.Trigger)
+	}
+
+	if len(o.SubCommands) > 0 && len(o.Arguments) > 0 {
+		return NewLocAppError("AutocompleteData.IsValid", "model.autocomplete_data.is_valid.sub_commands.app_error", nil, "trigger="+o.Trigger)
+	}
+
+	for _, arg := range o.Arguments {
+		if arg == nil {
+			return NewLocAppError("AutocompleteData.IsValid", "model.autocomplete_data.is_valid.arg.app_error", nil, "trigger="+o.Trigger)
+		}
+
+		for _, item := range arg.Items {
+			if item == nil {
+				return NewLocAppError("AutocompleteData.IsValid", "model.autocomplete_data.is_valid.item.app_error", nil, "trigger="+o.Trigger+", name="+arg.Name)
+			}
+		}
+
+		switch arg.Type {
+		case AUTOCOMPLETE_ARG_TYPE_TEXT, AUTOCOMPLETE_ARG_TYPE_USER, AUTOCOMPLETE_ARG_TYPE_CHANNEL, AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST:
+		case AUTOCOMPLETE_ARG_TYPE_STATIC_LIST:
+			if len(arg.Items) == 0 {
+				return NewLocAppError("AutocompleteData.IsValid", "model.autocomplete_data.is_valid.static_list.app_error", nil, "trigger="+o.Trigger+", name="+arg.Name)
+			}
+		default:
+			return NewLocAppError("AutocompleteData.IsValid", "model.autocomplete_data.is_valid.arg_type.app_error", nil, "trigger="+o.Trigger+", type="+arg.Type)
+		}
+	}
+
+	for _, command := range o.SubCommands {
+		if command == nil {
+			return NewLocAppError("AutocompleteData.IsValid", "model.autocomplete_data.is_valid.sub_command.app_error", nil, "trigger="+o.Trigger)
+		}
+
+		if err := command.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *AutocompleteData) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func AutocompleteDataFromJson(data io.Reader) *AutocompleteData {
+	decoder := json.NewDecoder(data)
+	var o AutocompleteData
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func AutocompleteListItemsToJson(l []*AutocompleteListItem) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func AutocompleteListItemsFromJson(data io.Reader) []*AutocompleteListItem {
+	decoder := json.NewDecoder(data)
+	var o []*AutocompleteListItem
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAutocompleteDataJson(t *testing.T) {
+	data := NewAutocompleteData("jira", "[command]", "Available commands")
+	create := NewAutocompleteData("create", "[project]", "Create an issue")
+	create.AddStaticListArgument("project", "The project", []*AutocompleteListItem{{Item: "MM", HelpText: "Mattermost"}})
+	data.AddCommand(create)
+
+	rdata := AutocompleteDataFromJson(strings.NewReader(data.ToJson()))
+	if rdata.Trigger != data.Trigger || rdata.GetSubCommand("create") == nil || rdata.GetSubCommand("create").Arguments[0].Items[0].Item != "MM" {
+		t.Fatal("data does not match")
+	}
+
+	if rdata.GetSubCommand("delete") != nil {
+		t.Fatal("shouldn't have found the subcommand")
+	}
+
+	items := AutocompleteListItemsFromJson(strings.NewReader(AutocompleteListItemsToJson(create.Arguments[0].Items)))
+	if len(items) != 1 || items[0].HelpText != "Mattermost" {
+		t.Fatal("items do not match")
+	}
+}
+
+func TestAutocompleteDataIsValid(t *testing.T) {
+	data := NewAutocompleteData("jira", "", "")
+	if err := data.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	data.Trigger = "ji ra"
+	if err := data.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	data.Trigger = "jira"
+	create := NewAutocompleteData("create", "", "")
+	data.AddCommand(create)
+	if err := data.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	create.AddArgument("summary", "", "", "number")
+	if err := data.IsValid(); err == nil {
+		t.Fatal("should be invalid - unknown type")
+	}
+
+	create.Arguments[0].Type = AUTOCOMPLETE_ARG_TYPE_STATIC_LIST
+	if err := data.IsValid(); err == nil {
+		t.Fatal("should be invalid - static list without items")
+	}
+
+	create.Arguments[0].Type = AUTOCOMPLETE_ARG_TYPE_TEXT
+	if err := data.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	data.AddArgument("user", "", "", AUTOCOMPLETE_ARG_TYPE_USER)
+	if err := data.IsValid(); err == nil {
+		t.Fatal("should be invalid - both subcommands and arguments")
+	}
+
+	for _, json := range []string{
+		`{"trigger": "x", "arguments": [null]}`,
+		`{"trigger": "x", "sub_commands": [null]}`,
+		`{"trigger": "x", "arguments": [{"name": "y", "type": "static_list", "items": [null]}]}`,
+	} {
+		if err := AutocompleteDataFromJson(strings.NewReader(json)).IsValid(); err == nil {
+			t.Fatal("should be invalid - empty entry", json)
+		}
+	}
+}
@@ -161,6 +161,22 @@ func TestCommandIsValid(t *testing.T) {
 	if err := o.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	o.AutocompleteData = NewAutocompleteData("other", "", "")
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.AutocompleteData = NewAutocompleteData(o.Trigger, "", "")
+	o.AutocompleteData.AddArgument("text", "", strings.Repeat("1", AUTOCOMPLETE_DATA_MAX_LENGTH), AUTOCOMPLETE_ARG_TYPE_TEXT)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.AutocompleteData = NewAutocompleteData(o.Trigger, "", "")
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestCommandPreSave(t *testing.T) {
@@ -35,6 +35,7 @@ type CompliancePost struct {
 	PostProps      string
 	PostHashtags   string
 	PostFilenames  string
+	PostReactions  string
 }
 
 func CompliancePostHeader() []string {
@@ -61,6 +62,7 @@ func CompliancePostHeader() []string {
 		"PostProps",
 		"PostHashtags",
 		"PostFilenames",
+		"PostReactions",
 	}
 }
 
@@ -100,5 +102,6 @@ func (me *CompliancePost) Row() []string {
 		me.PostProps,
 		me.PostHashtags,
 		me.PostFilenames,
+		me.PostReactions,
 	}
 }
@@ -14,14 +14,22 @@ func TestCompliancePostHeader(t *testing.T) {
 }
 
 func TestCompliancePost(t *testing.T) {
-	o := CompliancePost{TeamName: "test", PostFilenames: "files", PostCreateAt: GetMillis()}
+	o := CompliancePost{TeamName: "test", PostFilenames: "files", PostReactions: "reactions", PostCreateAt: GetMillis()}
 	r := o.Row()
 
 	if r[0] != "test" {
 		t.Fatal()
 	}
 
-	if r[len(r)-1] != "files" {
+	if r[len(r)-2] != "files" {
+		t.Fatal()
+	}
+
+	if r[len(r)-1] != "reactions" {
+		t.Fatal()
+	}
+
+	if len(r) != len(CompliancePostHeader()) {
 		t.Fatal()
 	}
 }
@@ -21,6 +21,7 @@ const (
 
 	SERVICE_GITLAB = "gitlab"
 	SERVICE_GOOGLE = "google"
+	SERVICE_OPENID = "openid"
 
 	WEBSERVER_MODE_REGULAR  = "regular"
 	WEBSERVER_MODE_GZIP     = "gzip"
@@ -32,6 +33,15 @@ const (
 	DIRECT_MESSAGE_ANY  = "any"
 	DIRECT_MESSAGE_TEAM = "team"
 
+	MFA_ENFORCE_OFF          = "off"
+	MFA_ENFORCE_ALL          = "all"
+	MFA_ENFORCE_SYSTEM_ADMIN = "system_admin"
+
+	PASSWORD_MAXIMUM_HISTORY_COUNT = 10
+
+	BACKPLANE_DRIVER_MEMORY = "memory"
+	BACKPLANE_DRIVER_REDIS  = "redis"
+
 	FAKE_SETTING = "********************************"
 )
 
@@ -45,6 +55,9 @@ type ServiceSettings struct {
 	EnableOutgoingWebhooks            bool
 	EnableCommands                    *bool
 	EnableOnlyAdminIntegrations       *bool
+	OutgoingWebhookMaxRetries         *int
+	PostActionSecret                  string
+	EnableUserAccessTokens            *bool
 	EnablePostUsernameOverride        bool
 	EnablePostIconOverride            bool
 	EnableTesting                     bool
@@ -52,6 +65,7 @@ type ServiceSettings struct {
 	EnableSecurityFixAlert            *bool
 	EnableInsecureOutgoingConnections *bool
 	EnableMultifactorAuthentication   *bool
+	EnforceMultifactorAuthentication  *string
 	AllowCorsFrom                     *string
 	SessionLengthWebInDays            *int
 	SessionLengthMobileInDays         *int
@@ -72,6 +86,25 @@ type SSOSettings struct {
 	UserApiEndpoint string
 }
 
+// OpenIdSettings configure a generic OpenID Connect provider. The endpoints of the embedded
+// SSOSettings can be left blank, they're then read from the discovery document.
+type OpenIdSettings struct {
+	SSOSettings
+	DiscoveryEndpoint *string
+	ButtonText        *string
+
+	// Claim Mapping
+	IdClaim        *string
+	UsernameClaim  *string
+	EmailClaim     *string
+	FirstNameClaim *string
+	LastNameClaim  *string
+	NicknameClaim  *string
+
+	// RequireVerifiedEmail rejects logins unless the provider says the email has been verified
+	RequireVerifiedEmail *bool
+}
+
 type SqlSettings struct {
 	DriverName         string
 	DataSource         string
@@ -192,6 +225,7 @@ type LdapSettings struct {
 	// Advanced
 	SkipCertificateVerification *bool
 	QueryTimeout                *int
+	SyncIntervalMinutes         *int
 
 	// Customization
 	LoginFieldName *string
@@ -203,6 +237,55 @@ type ComplianceSettings struct {
 	EnableDaily *bool
 }
 
+type ClusterSettings struct {
+	Enable          *bool
+	BackplaneDriver *string
+	RedisServer     *string
+	RedisPassword   *string
+	RedisChannel    *string
+}
+
+type SearchSettings struct {
+	Enable    *bool
+	Directory *string
+}
+
+// SamlSettings configure the SAML 2.0 service provider. The assertion consumer service url is
+// also used as the entity id of the service provider.
+type SamlSettings struct {
+	// Basic
+	Enable                      *bool
+	IdpUrl                      *string
+	IdpDescriptorUrl            *string
+	AssertionConsumerServiceURL *string
+	IdpCertificateFile          *string
+
+	// User Mapping
+	FirstNameAttribute *string
+	LastNameAttribute  *string
+	EmailAttribute     *string
+	UsernameAttribute  *string
+	NicknameAttribute  *string
+	IdAttribute        *string
+
+	// Customization
+	LoginButtonText *string
+}
+
+// PasswordSettings apply to the passwords of users signing in with their email or username.
+// The banned passwords file lists the SHA-1 hashes of passwords that may never be used, one per
+// line, optionally followed by a colon and a count as in the Pwned Passwords lists.
+type PasswordSettings struct {
+	MinimumLength       *int
+	Lowercase           *bool
+	Uppercase           *bool
+	Number              *bool
+	Symbol              *bool
+	ExpiryDays          *int
+	HistoryCount        *int
+	BannedPasswordsFile *string
+}
+
 type Config struct {
 	ServiceSettings    ServiceSettings
 	TeamSettings       TeamSettings
@@ -215,8 +298,13 @@ type Config struct {
 	SupportSettings    SupportSettings
 	GitLabSettings     SSOSettings
 	GoogleSettings     SSOSettings
+	OpenIdSettings     OpenIdSettings
 	LdapSettings       LdapSettings
 	ComplianceSettings ComplianceSettings
+	ClusterSettings    ClusterSettings
+	SearchSettings     SearchSettings
+	SamlSettings       SamlSettings
+	PasswordSettings   PasswordSettings
 }
 
 func (o *Config) ToJson() string {
@@ -234,6 +322,8 @@ func (o *Config) GetSSOService(service string) *SSOSettings {
 		return &o.GitLabSettings
 	case SERVICE_GOOGLE:
 		return &o.GoogleSettings
+	case SERVICE_OPENID:
+		return &o.OpenIdSettings.SSOSettings
 	}
 
 	return nil
@@ -298,6 +388,11 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.EnableMultifactorAuthentication = false
 	}
 
+	if o.ServiceSettings.EnforceMultifactorAuthentication == nil {
+		o.ServiceSettings.EnforceMultifactorAuthentication = new(string)
+		*o.ServiceSettings.EnforceMultifactorAuthentication = MFA_ENFORCE_OFF
+	}
+
 	if o.TeamSettings.RestrictTeamNames == nil {
 		o.TeamSettings.RestrictTeamNames = new(bool)
 		*o.TeamSettings.RestrictTeamNames = true
@@ -413,6 +508,11 @@ func (o *Config) SetDefaults() {
 		*o.LdapSettings.QueryTimeout = 60
 	}
 
+	if o.LdapSettings.SyncIntervalMinutes == nil {
+		o.LdapSettings.SyncIntervalMinutes = new(int)
+		*o.LdapSettings.SyncIntervalMinutes = 60
+	}
+
 	if o.LdapSettings.Enable == nil {
 		o.LdapSettings.Enable = new(bool)
 		*o.LdapSettings.Enable = false
@@ -453,11 +553,21 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.EnableCommands = false
 	}
 
+	if o.ServiceSettings.EnableUserAccessTokens == nil {
+		o.ServiceSettings.EnableUserAccessTokens = new(bool)
+		*o.ServiceSettings.EnableUserAccessTokens = false
+	}
+
 	if o.ServiceSettings.EnableOnlyAdminIntegrations == nil {
 		o.ServiceSettings.EnableOnlyAdminIntegrations = new(bool)
 		*o.ServiceSettings.EnableOnlyAdminIntegrations = true
 	}
 
+	if o.ServiceSettings.OutgoingWebhookMaxRetries == nil {
+		o.ServiceSettings.OutgoingWebhookMaxRetries = new(int)
+		*o.ServiceSettings.OutgoingWebhookMaxRetries = 3
+	}
+
 	if o.ServiceSettings.WebsocketPort == nil {
 		o.ServiceSettings.WebsocketPort = new(int)
 		*o.ServiceSettings.WebsocketPort = 80
@@ -507,6 +617,186 @@ func (o *Config) SetDefaults() {
 		o.LdapSettings.NicknameAttribute = new(string)
 		*o.LdapSettings.NicknameAttribute = ""
 	}
+
+	if o.ClusterSettings.Enable == nil {
+		o.ClusterSettings.Enable = new(bool)
+		*o.ClusterSettings.Enable = false
+	}
+
+	if o.ClusterSettings.BackplaneDriver == nil {
+		o.ClusterSettings.BackplaneDriver = new(string)
+		*o.ClusterSettings.BackplaneDriver = BACKPLANE_DRIVER_MEMORY
+	}
+
+	if o.ClusterSettings.RedisServer == nil {
+		o.ClusterSettings.RedisServer = new(string)
+		*o.ClusterSettings.RedisServer = "localhost:6379"
+	}
+
+	if o.ClusterSettings.RedisPassword == nil {
+		o.ClusterSettings.RedisPassword = new(string)
+		*o.ClusterSettings.RedisPassword = ""
+	}
+
+	if o.ClusterSettings.RedisChannel == nil {
+		o.ClusterSettings.RedisChannel = new(string)
+		*o.ClusterSettings.RedisChannel = "mattermost"
+	}
+
+	if o.SearchSettings.Enable == nil {
+		o.SearchSettings.Enable = new(bool)
+		*o.SearchSettings.Enable = false
+	}
+
+	if o.SearchSettings.Directory == nil {
+		o.SearchSettings.Directory = new(string)
+		*o.SearchSettings.Directory = "./data/search/"
+	}
+
+	if o.SamlSettings.Enable == nil {
+		o.SamlSettings.Enable = new(bool)
+		*o.SamlSettings.Enable = false
+	}
+
+	if o.SamlSettings.IdpUrl == nil {
+		o.SamlSettings.IdpUrl = new(string)
+		*o.SamlSettings.IdpUrl = ""
+	}
+
+	if o.SamlSettings.IdpDescriptorUrl == nil {
+		o.SamlSettings.IdpDescriptorUrl = new(string)
+		*o.SamlSettings.IdpDescriptorUrl = ""
+	}
+
+	if o.SamlSettings.AssertionConsumerServiceURL == nil {
+		o.SamlSettings.AssertionConsumerServiceURL = new(string)
+		*o.SamlSettings.AssertionConsumerServiceURL = ""
+	}
+
+	if o.SamlSettings.IdpCertificateFile == nil {
+		o.SamlSettings.IdpCertificateFile = new(string)
+		*o.SamlSettings.IdpCertificateFile = ""
+	}
+
+	if o.SamlSettings.FirstNameAttribute == nil {
+		o.SamlSettings.FirstNameAttribute = new(string)
+		*o.SamlSettings.FirstNameAttribute = ""
+	}
+
+	if o.SamlSettings.LastNameAttribute == nil {
+		o.SamlSettings.LastNameAttribute = new(string)
+		*o.SamlSettings.LastNameAttribute = ""
+	}
+
+	if o.SamlSettings.EmailAttribute == nil {
+		o.SamlSettings.EmailAttribute = new(string)
+		*o.SamlSettings.EmailAttribute = ""
+	}
+
+	if o.SamlSettings.UsernameAttribute == nil {
+		o.SamlSettings.UsernameAttribute = new(string)
+		*o.SamlSettings.UsernameAttribute = ""
+	}
+
+	if o.SamlSettings.NicknameAttribute == nil {
+		o.SamlSettings.NicknameAttribute = new(string)
+		*o.SamlSettings.NicknameAttribute = ""
+	}
+
+	if o.SamlSettings.IdAttribute == nil {
+		o.SamlSettings.IdAttribute = new(string)
+		*o.SamlSettings.IdAttribute = ""
+	}
+
+	if o.SamlSettings.LoginButtonText == nil {
+		o.SamlSettings.LoginButtonText = new(string)
+		*o.SamlSettings.LoginButtonText = ""
+	}
+
+	if o.PasswordSettings.MinimumLength == nil {
+		o.PasswordSettings.MinimumLength = new(int)
+		*o.PasswordSettings.MinimumLength = MIN_PASSWORD_LENGTH
+	}
+
+	if o.PasswordSettings.Lowercase == nil {
+		o.PasswordSettings.Lowercase = new(bool)
+		*o.PasswordSettings.Lowercase = false
+	}
+
+	if o.PasswordSettings.Uppercase == nil {
+		o.PasswordSettings.Uppercase = new(bool)
+		*o.PasswordSettings.Uppercase = false
+	}
+
+	if o.PasswordSettings.Number == nil {
+		o.PasswordSettings.Number = new(bool)
+		*o.PasswordSettings.Number = false
+	}
+
+	if o.PasswordSettings.Symbol == nil {
+		o.PasswordSettings.Symbol = new(bool)
+		*o.PasswordSettings.Symbol = false
+	}
+
+	if o.PasswordSettings.ExpiryDays == nil {
+		o.PasswordSettings.ExpiryDays = new(int)
+		*o.PasswordSettings.ExpiryDays = 0
+	}
+
+	if o.PasswordSettings.HistoryCount == nil {
+		o.PasswordSettings.HistoryCount = new(int)
+		*o.PasswordSettings.HistoryCount = 0
+	}
+
+	if o.PasswordSettings.BannedPasswordsFile == nil {
+		o.PasswordSettings.BannedPasswordsFile = new(string)
+		*o.PasswordSettings.BannedPasswordsFile = ""
+	}
+
+	if o.OpenIdSettings.DiscoveryEndpoint == nil {
+		o.OpenIdSettings.DiscoveryEndpoint = new(string)
+		*o.OpenIdSettings.DiscoveryEndpoint = ""
+	}
+
+	if o.OpenIdSettings.ButtonText == nil {
+		o.OpenIdSettings.ButtonText = new(string)
+		*o.OpenIdSettings.ButtonText = ""
+	}
+
+	if o.OpenIdSettings.IdClaim == nil {
+		o.OpenIdSettings.IdClaim = new(string)
+		*o.OpenIdSettings.IdClaim = "sub"
+	}
+
+	if o.OpenIdSettings.UsernameClaim == nil {
+		o.OpenIdSettings.UsernameClaim = new(string)
+		*o.OpenIdSettings.UsernameClaim = "preferred_username"
+	}
+
+	if o.OpenIdSettings.EmailClaim == nil {
+		o.OpenIdSettings.EmailClaim = new(string)
+		*o.OpenIdSettings.EmailClaim = "email"
+	}
+
+	if o.OpenIdSettings.FirstNameClaim == nil {
+		o.OpenIdSettings.FirstNameClaim = new(string)
+		*o.OpenIdSettings.FirstNameClaim = "given_name"
+	}
+
+	if o.OpenIdSettings.LastNameClaim == nil {
+		o.OpenIdSettings.LastNameClaim = new(string)
+		*o.OpenIdSettings.LastNameClaim = "family_name"
+	}
+
+	if o.OpenIdSettings.NicknameClaim == nil {
+		o.OpenIdSettings.NicknameClaim = new(string)
+		*o.OpenIdSettings.NicknameClaim = "nickname"
+	}
+
+	if o.OpenIdSettings.RequireVerifiedEmail == nil {
+		o.OpenIdSettings.RequireVerifiedEmail = new(bool)
+		*o.OpenIdSettings.RequireVerifiedEmail = true
+	}
 }
 
 func (o *Config) IsValid() *AppError {
@@ -519,6 +809,18 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.listen_address.app_error", nil, "")
 	}
 
+	if *o.ServiceSettings.OutgoingWebhookMaxRetries < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.outgoing_webhook_retries.app_error", nil, "")
+	}
+
+	if len(o.ServiceSettings.PostActionSecret) > 0 && len(o.ServiceSettings.PostActionSecret) < 32 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.post_action_secret.app_error", nil, "")
+	}
+
+	if !(*o.ServiceSettings.EnforceMultifactorAuthentication == MFA_ENFORCE_OFF || *o.ServiceSettings.EnforceMultifactorAuthentication == MFA_ENFORCE_ALL || *o.ServiceSettings.EnforceMultifactorAuthentication == MFA_ENFORCE_SYSTEM_ADMIN) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.enforce_mfa.app_error", nil, "")
+	}
+
 	if o.TeamSettings.MaxUsersPerTeam <= 0 {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_users.app_error", nil, "")
 	}
@@ -575,6 +877,27 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_thumb_width.app_error", nil, "")
 	}
 
+	if *o.SamlSettings.Enable && (len(*o.SamlSettings.IdpUrl) == 0 || len(*o.SamlSettings.IdpDescriptorUrl) == 0 ||
+		len(*o.SamlSettings.AssertionConsumerServiceURL) == 0 || len(*o.SamlSettings.IdpCertificateFile) == 0 || len(*o.SamlSettings.EmailAttribute) == 0) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.saml.app_error", nil, "")
+	}
+
+	if *o.PasswordSettings.MinimumLength < MIN_PASSWORD_LENGTH {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.password_length.app_error", map[string]interface{}{"MinLength": MIN_PASSWORD_LENGTH}, "")
+	}
+
+	if *o.PasswordSettings.ExpiryDays < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.password_expiry.app_error", nil, "")
+	}
+
+	if *o.PasswordSettings.HistoryCount < 0 || *o.PasswordSettings.HistoryCount > PASSWORD_MAXIMUM_HISTORY_COUNT {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.password_history.app_error", map[string]interface{}{"MaxCount": PASSWORD_MAXIMUM_HISTORY_COUNT}, "")
+	}
+
+	if o.OpenIdSettings.Enable && (len(*o.OpenIdSettings.DiscoveryEndpoint) == 0 || len(*o.OpenIdSettings.IdClaim) == 0 || len(*o.OpenIdSettings.EmailClaim) == 0) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.openid.app_error", nil, "")
+	}
+
 	if len(o.FileSettings.PublicLinkSalt) < 32 {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_salt.app_error", nil, "")
 	}
@@ -603,6 +926,23 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.ldap_security.app_error", nil, "")
 	}
 
+	if *o.LdapSettings.SyncIntervalMinutes <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.ldap_sync_interval.app_error", nil, "")
+	}
+
+	if !(*o.ClusterSettings.BackplaneDriver == BACKPLANE_DRIVER_MEMORY || *o.ClusterSettings.BackplaneDriver == BACKPLANE_DRIVER_REDIS) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.cluster_backplane.app_error", nil, "")
+	}
+
+	if *o.ClusterSettings.Enable && *o.ClusterSettings.BackplaneDriver == BACKPLANE_DRIVER_REDIS && len(*o.ClusterSettings.RedisServer) == 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.cluster_redis_server.app_error", nil, "")
+	}
+
+	// the search index is kept on the local disk of each server so it can't be shared by a cluster
+	if *o.SearchSettings.Enable && *o.ClusterSettings.Enable {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.search_cluster.app_error", nil, "")
+	}
+
 	return nil
 }
 
@@ -619,6 +959,8 @@ func (o *Config) Sanitize() {
 		*o.LdapSettings.BindPassword = FAKE_SETTING
 	}
 
+	o.ServiceSettings.PostActionSecret = FAKE_SETTING
+
 	o.FileSettings.PublicLinkSalt = FAKE_SETTING
 	if len(o.FileSettings.AmazonS3SecretAccessKey) > 0 {
 		o.FileSettings.AmazonS3SecretAccessKey = FAKE_SETTING
@@ -634,6 +976,14 @@ func (o *Config) Sanitize() {
 		o.GitLabSettings.Secret = FAKE_SETTING
 	}
 
+	if len(o.OpenIdSettings.Secret) > 0 {
+		o.OpenIdSettings.Secret = FAKE_SETTING
+	}
+
+	if len(*o.ClusterSettings.RedisPassword) > 0 {
+		*o.ClusterSettings.RedisPassword = FAKE_SETTING
+	}
+
 	o.SqlSettings.DataSource = FAKE_SETTING
 	o.SqlSettings.AtRestEncryptKey = FAKE_SETTING
 
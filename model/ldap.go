@@ -3,6 +3,41 @@
 
 package model
 
+import (
+	"encoding/json"
+	"io"
+)
+
 const (
 	USER_AUTH_SERVICE_LDAP = "ldap"
 )
+
+type LdapSyncStatus struct {
+	Running          bool   `json:"running"`
+	LastStartAt      int64  `json:"last_start_at"`
+	LastEndAt        int64  `json:"last_end_at"`
+	UsersChecked     int    `json:"users_checked"`
+	UsersUpdated     int    `json:"users_updated"`
+	UsersDeactivated int    `json:"users_deactivated"`
+	Error            string `json:"error"`
+}
+
+func (o *LdapSyncStatus) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func LdapSyncStatusFromJson(data io.Reader) *LdapSyncStatus {
+	decoder := json.NewDecoder(data)
+	var o LdapSyncStatus
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
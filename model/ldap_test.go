@@ -0,0 +1,19 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLdapSyncStatusJson(t *testing.T) {
+	o := LdapSyncStatus{LastStartAt: GetMillis(), UsersDeactivated: 2, Error: "error"}
+	json := o.ToJson()
+	result := LdapSyncStatusFromJson(strings.NewReader(json))
+
+	if result.LastStartAt != o.LastStartAt || result.UsersDeactivated != 2 || result.Error != "error" {
+		t.Fatal("status should have matched")
+	}
+}
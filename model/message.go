@@ -14,6 +14,7 @@ const (
 	ACTION_POST_EDITED        = "post_edited"
 	ACTION_POST_DELETED       = "post_deleted"
 	ACTION_CHANNEL_DELETED    = "channel_deleted"
+	ACTION_CHANNEL_RESTORED   = "channel_restored"
 	ACTION_CHANNEL_VIEWED     = "channel_viewed"
 	ACTION_DIRECT_ADDED       = "direct_added"
 	ACTION_NEW_USER           = "new_user"
@@ -21,6 +22,8 @@ const (
 	ACTION_USER_REMOVED       = "user_removed"
 	ACTION_PREFERENCE_CHANGED = "preference_changed"
 	ACTION_EPHEMERAL_MESSAGE  = "ephemeral_message"
+	ACTION_REACTION_ADDED     = "reaction_added"
+	ACTION_REACTION_REMOVED   = "reaction_removed"
 )
 
 type Message struct {
@@ -0,0 +1,72 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	SCOPE_READ_POSTS  = "read:posts"
+	SCOPE_WRITE_POSTS = "write:posts"
+	SCOPE_READ_USERS  = "read:users"
+	SCOPE_ADMIN       = "admin"
+
+	// SCOPE_ALL is what apps requested before scopes existed, it grants full access
+	SCOPE_ALL = "all"
+)
+
+var knownScopes = map[string]bool{
+	SCOPE_READ_POSTS:  true,
+	SCOPE_WRITE_POSTS: true,
+	SCOPE_READ_USERS:  true,
+	SCOPE_ADMIN:       true,
+	SCOPE_ALL:         true,
+}
+
+// NormalizeScope parses a space separated list of scopes as requested by an OAuth app. It
+// returns the scopes sorted and without duplicates, or false if any of them is unknown. An
+// empty scope is treated as a request for full access so that existing apps keep working.
+func NormalizeScope(scope string) (string, bool) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return SCOPE_ALL, true
+	}
+
+	seen := make(map[string]bool)
+	scopes := []string{}
+
+	for _, s := range requested {
+		if !knownScopes[s] {
+			return "", false
+		}
+
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+
+	sort.Strings(scopes)
+
+	return strings.Join(scopes, " "), true
+}
+
+// ScopeAllows returns true if a token granted the given scopes can be used for something
+// that requires the required scope. The admin scope and full access allow everything, an
+// empty required scope is only allowed by them.
+func ScopeAllows(granted string, required string) bool {
+	for _, s := range strings.Fields(granted) {
+		if s == SCOPE_ALL || s == SCOPE_ADMIN {
+			return true
+		}
+
+		if len(required) > 0 && s == required {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"testing"
+)
+
+func TestNormalizeScope(t *testing.T) {
+	if scope, ok := NormalizeScope(""); !ok || scope != SCOPE_ALL {
+		t.Fatal("should have granted full access for an empty scope")
+	}
+
+	if scope, ok := NormalizeScope("write:posts read:posts  write:posts"); !ok || scope != "read:posts write:posts" {
+		t.Fatal("should have sorted and removed duplicates", scope)
+	}
+
+	if _, ok := NormalizeScope("read:posts junk"); ok {
+		t.Fatal("should have rejected an unknown scope")
+	}
+}
+
+func TestScopeAllows(t *testing.T) {
+	if !ScopeAllows("read:posts read:users", SCOPE_READ_USERS) {
+		t.Fatal("should have allowed a granted scope")
+	}
+
+	if ScopeAllows("read:posts", SCOPE_WRITE_POSTS) {
+		t.Fatal("should not have allowed a scope that wasn't granted")
+	}
+
+	if ScopeAllows("read:posts", "") {
+		t.Fatal("should not have allowed a route without a scope")
+	}
+
+	if !ScopeAllows(SCOPE_ADMIN, "") || !ScopeAllows(SCOPE_ALL, SCOPE_WRITE_POSTS) {
+		t.Fatal("should have allowed everything")
+	}
+}
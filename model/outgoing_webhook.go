@@ -4,24 +4,88 @@
 package model
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	TRIGGER_WORDS_EXACT_MATCH = 0
+	TRIGGER_WORDS_STARTS_WITH = 1
+	TRIGGER_WORDS_CONTAINS    = 2
+
+	OUTGOING_HOOK_CONTENT_TYPE_FORM = "application/x-www-form-urlencoded"
+	OUTGOING_HOOK_CONTENT_TYPE_JSON = "application/json"
+
+	HEADER_WEBHOOK_SIGNATURE = "X-Mattermost-Signature"
+	HEADER_WEBHOOK_TIMESTAMP = "X-Mattermost-Timestamp"
 )
 
 type OutgoingWebhook struct {
-	Id           string      `json:"id"`
-	Token        string      `json:"token"`
-	CreateAt     int64       `json:"create_at"`
-	UpdateAt     int64       `json:"update_at"`
-	DeleteAt     int64       `json:"delete_at"`
-	CreatorId    string      `json:"creator_id"`
-	ChannelId    string      `json:"channel_id"`
-	TeamId       string      `json:"team_id"`
-	TriggerWords StringArray `json:"trigger_words"`
-	CallbackURLs StringArray `json:"callback_urls"`
-	DisplayName  string      `json:"display_name"`
-	Description  string      `json:"description"`
+	Id                     string      `json:"id"`
+	Token                  string      `json:"token"`
+	Secret                 string      `json:"secret"`
+	CreateAt               int64       `json:"create_at"`
+	UpdateAt               int64       `json:"update_at"`
+	DeleteAt               int64       `json:"delete_at"`
+	CreatorId              string      `json:"creator_id"`
+	ChannelId              string      `json:"channel_id"`
+	TeamId                 string      `json:"team_id"`
+	TriggerWords           StringArray `json:"trigger_words"`
+	TriggerWhen            int         `json:"trigger_when"`
+	CallbackURLs           StringArray `json:"callback_urls"`
+	ContentType            string      `json:"content_type"`
+	IncludePrivateChannels bool        `json:"include_private_channels"`
+	DisplayName            string      `json:"display_name"`
+	Description            string      `json:"description"`
+}
+
+// OutgoingWebhookPayload is what gets sent to the callback URLs, either as a form or as JSON
+// depending on the ContentType of the hook.
+type OutgoingWebhookPayload struct {
+	Token       string `json:"token"`
+	TeamId      string `json:"team_id"`
+	TeamDomain  string `json:"team_domain"`
+	ChannelId   string `json:"channel_id"`
+	ChannelName string `json:"channel_name"`
+	Timestamp   int64  `json:"timestamp"`
+	UserId      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	PostId      string `json:"post_id"`
+	Text        string `json:"text"`
+	TriggerWord string `json:"trigger_word"`
+}
+
+func (o *OutgoingWebhookPayload) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func (o *OutgoingWebhookPayload) ToFormValues() string {
+	v := url.Values{}
+	v.Set("token", o.Token)
+	v.Set("team_id", o.TeamId)
+	v.Set("team_domain", o.TeamDomain)
+	v.Set("channel_id", o.ChannelId)
+	v.Set("channel_name", o.ChannelName)
+	v.Set("timestamp", strconv.FormatInt(o.Timestamp, 10))
+	v.Set("user_id", o.UserId)
+	v.Set("user_name", o.UserName)
+	v.Set("post_id", o.PostId)
+	v.Set("text", o.Text)
+	v.Set("trigger_word", o.TriggerWord)
+
+	return v.Encode()
 }
 
 func (o *OutgoingWebhook) ToJson() string {
@@ -94,6 +158,10 @@ func (o *OutgoingWebhook) IsValid() *AppError {
 		return NewLocAppError("OutgoingWebhook.IsValid", "model.outgoing_hook.is_valid.team_id.app_error", nil, "")
 	}
 
+	if o.TriggerWhen < TRIGGER_WORDS_EXACT_MATCH || o.TriggerWhen > TRIGGER_WORDS_CONTAINS {
+		return NewLocAppError("OutgoingWebhook.IsValid", "model.outgoing_hook.is_valid.trigger_when.app_error", nil, "")
+	}
+
 	if len(fmt.Sprintf("%s", o.TriggerWords)) > 1024 {
 		return NewLocAppError("OutgoingWebhook.IsValid", "model.outgoing_hook.is_valid.words.app_error", nil, "")
 	}
@@ -116,6 +184,10 @@ func (o *OutgoingWebhook) IsValid() *AppError {
 		}
 	}
 
+	if len(o.ContentType) != 0 && o.ContentType != OUTGOING_HOOK_CONTENT_TYPE_FORM && o.ContentType != OUTGOING_HOOK_CONTENT_TYPE_JSON {
+		return NewLocAppError("OutgoingWebhook.IsValid", "model.outgoing_hook.is_valid.content_type.app_error", nil, "")
+	}
+
 	if len(o.DisplayName) > 64 {
 		return NewLocAppError("OutgoingWebhook.IsValid", "model.outgoing_hook.is_valid.display_name.app_error", nil, "")
 	}
@@ -136,6 +208,10 @@ func (o *OutgoingWebhook) PreSave() {
 		o.Token = NewId()
 	}
 
+	if o.Secret == "" {
+		o.Secret = NewId()
+	}
+
 	o.CreateAt = GetMillis()
 	o.UpdateAt = o.CreateAt
 }
@@ -144,6 +220,17 @@ func (o *OutgoingWebhook) PreUpdate() {
 	o.UpdateAt = GetMillis()
 }
 
+// HasCallbackURL returns true if url is one of the callback URLs of the hook.
+func (o *OutgoingWebhook) HasCallbackURL(url string) bool {
+	for _, callbackURL := range o.CallbackURLs {
+		if callbackURL == url {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (o *OutgoingWebhook) HasTriggerWord(word string) bool {
 	if len(o.TriggerWords) == 0 || len(word) == 0 {
 		return false
@@ -157,3 +244,44 @@ func (o *OutgoingWebhook) HasTriggerWord(word string) bool {
 
 	return false
 }
+
+// GetTriggerWord returns the trigger word that the message matches, or an empty string if it doesn't
+// match any of them. Depending on TriggerWhen the first word of the message must either be a trigger
+// word or start with one, or the message must contain one anywhere.
+func (o *OutgoingWebhook) GetTriggerWord(message string) string {
+	if len(o.TriggerWords) == 0 {
+		return ""
+	}
+
+	if o.TriggerWhen == TRIGGER_WORDS_CONTAINS {
+		for _, trigger := range o.TriggerWords {
+			if strings.Contains(message, trigger) {
+				return trigger
+			}
+		}
+
+		return ""
+	}
+
+	words := strings.Fields(message)
+	if len(words) == 0 {
+		return ""
+	}
+
+	for _, trigger := range o.TriggerWords {
+		if trigger == words[0] || (o.TriggerWhen == TRIGGER_WORDS_STARTS_WITH && strings.HasPrefix(words[0], trigger)) {
+			return trigger
+		}
+	}
+
+	return ""
+}
+
+// SignOutgoingWebhook returns the hex encoded HMAC-SHA256 of the timestamp and the body keyed by the
+// secret of the hook. It's sent in the HEADER_WEBHOOK_SIGNATURE header so that integrations can check
+// that the request came from this server and wasn't replayed later.
+func SignOutgoingWebhook(secret string, timestamp string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
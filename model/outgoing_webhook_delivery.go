@@ -0,0 +1,180 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	WEBHOOK_DELIVERY_STATUS_PENDING = "pending"
+	WEBHOOK_DELIVERY_STATUS_SUCCESS = "success"
+	WEBHOOK_DELIVERY_STATUS_FAILED  = "failed"
+
+	// a message can be 4000 characters long, which can take several times as many bytes once
+	// encoded, longer payloads are truncated and can't be replayed anymore
+	WEBHOOK_DELIVERY_PAYLOAD_MAX_LENGTH = 64000
+	WEBHOOK_DELIVERY_PAYLOAD_TRUNCATED  = "...[truncated]"
+	WEBHOOK_DELIVERY_ERROR_MAX_LENGTH   = 1024
+)
+
+// OutgoingWebhookDelivery records a request to a callback URL along with everything needed to send
+// it again. Pending deliveries are retried once NextAttemptAt is reached, deliveries that succeed at
+// the first attempt aren't kept.
+type OutgoingWebhookDelivery struct {
+	Id            string `json:"id"`
+	CreateAt      int64  `json:"create_at"`
+	UpdateAt      int64  `json:"update_at"`
+	HookId        string `json:"hook_id"`
+	TeamId        string `json:"team_id"`
+	ChannelId     string `json:"channel_id"`
+	PostId        string `json:"post_id"`
+	URL           string `json:"url"`
+	ContentType   string `json:"content_type"`
+	Payload       string `json:"payload"`
+	Attempts      int    `json:"attempts"`
+	Status        string `json:"status"`
+	StatusCode    int    `json:"status_code"`
+	Error         string `json:"error"`
+	NextAttemptAt int64  `json:"next_attempt_at"`
+	SiteURL       string `json:"-"`
+}
+
+func (o *OutgoingWebhookDelivery) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func OutgoingWebhookDeliveryFromJson(data io.Reader) *OutgoingWebhookDelivery {
+	decoder := json.NewDecoder(data)
+	var o OutgoingWebhookDelivery
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func OutgoingWebhookDeliveryListToJson(l []*OutgoingWebhookDelivery) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func OutgoingWebhookDeliveryListFromJson(data io.Reader) []*OutgoingWebhookDelivery {
+	decoder := json.NewDecoder(data)
+	var o []*OutgoingWebhookDelivery
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
+
+func (o *OutgoingWebhookDelivery) IsValid() *AppError {
+
+	if len(o.Id) != 26 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.id.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	if o.UpdateAt == 0 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.update_at.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.HookId) != 26 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.hook_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.TeamId) != 26 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.team_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.ChannelId) != 26 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.channel_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.PostId) != 0 && len(o.PostId) != 26 {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.post_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.URL) > 1024 || !IsValidHttpUrl(o.URL) {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.url.app_error", nil, "id="+o.Id)
+	}
+
+	if o.ContentType != OUTGOING_HOOK_CONTENT_TYPE_FORM && o.ContentType != OUTGOING_HOOK_CONTENT_TYPE_JSON {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.content_type.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.Payload) > WEBHOOK_DELIVERY_PAYLOAD_MAX_LENGTH {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.payload.app_error", nil, "id="+o.Id)
+	}
+
+	if o.Status != WEBHOOK_DELIVERY_STATUS_PENDING && o.Status != WEBHOOK_DELIVERY_STATUS_SUCCESS && o.Status != WEBHOOK_DELIVERY_STATUS_FAILED {
+		return NewLocAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.status.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *OutgoingWebhookDelivery) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.Status == "" {
+		o.Status = WEBHOOK_DELIVERY_STATUS_PENDING
+	}
+
+	o.CreateAt = GetMillis()
+	o.UpdateAt = o.CreateAt
+
+	o.truncate()
+}
+
+func (o *OutgoingWebhookDelivery) PreUpdate() {
+	o.UpdateAt = GetMillis()
+
+	o.truncate()
+}
+
+func (o *OutgoingWebhookDelivery) truncate() {
+	if len(o.Payload) > WEBHOOK_DELIVERY_PAYLOAD_MAX_LENGTH {
+		o.Payload = o.Payload[:WEBHOOK_DELIVERY_PAYLOAD_MAX_LENGTH-len(WEBHOOK_DELIVERY_PAYLOAD_TRUNCATED)] + WEBHOOK_DELIVERY_PAYLOAD_TRUNCATED
+	}
+
+	if len(o.Error) > WEBHOOK_DELIVERY_ERROR_MAX_LENGTH {
+		o.Error = o.Error[:WEBHOOK_DELIVERY_ERROR_MAX_LENGTH]
+	}
+}
+
+// IsRetryable returns true if the last attempt failed in a way that sending the request again may
+// fix, the integration rejecting the request itself isn't retried unless it was rate limited.
+func (o *OutgoingWebhookDelivery) IsRetryable() bool {
+	if o.Status != WEBHOOK_DELIVERY_STATUS_PENDING || o.IsPayloadTruncated() {
+		return false
+	}
+
+	return o.StatusCode == 0 || o.StatusCode == http.StatusTooManyRequests || o.StatusCode >= 500
+}
+
+// IsPayloadTruncated returns true if the payload was too long to be logged in full.
+func (o *OutgoingWebhookDelivery) IsPayloadTruncated() bool {
+	return len(o.Payload) == WEBHOOK_DELIVERY_PAYLOAD_MAX_LENGTH && strings.HasSuffix(o.Payload, WEBHOOK_DELIVERY_PAYLOAD_TRUNCATED)
+}
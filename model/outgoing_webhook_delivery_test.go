@@ -0,0 +1,129 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOutgoingWebhookDeliveryJson(t *testing.T) {
+	o := OutgoingWebhookDelivery{Id: NewId()}
+	json := o.ToJson()
+	ro := OutgoingWebhookDeliveryFromJson(strings.NewReader(json))
+
+	if o.Id != ro.Id {
+		t.Fatal("Ids do not match")
+	}
+
+	l := []*OutgoingWebhookDelivery{&o}
+	if rl := OutgoingWebhookDeliveryListFromJson(strings.NewReader(OutgoingWebhookDeliveryListToJson(l))); len(rl) != 1 || rl[0].Id != o.Id {
+		t.Fatal("lists do not match")
+	}
+}
+
+func TestOutgoingWebhookDeliveryIsValid(t *testing.T) {
+	o := OutgoingWebhookDelivery{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.HookId = NewId()
+	o.TeamId = NewId()
+	o.ChannelId = NewId()
+	o.URL = "http://nowhere.com/"
+	o.ContentType = OUTGOING_HOOK_CONTENT_TYPE_FORM
+	o.Error = strings.Repeat("1", WEBHOOK_DELIVERY_ERROR_MAX_LENGTH+1)
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Status != WEBHOOK_DELIVERY_STATUS_PENDING {
+		t.Fatal("should have defaulted to pending")
+	}
+
+	if len(o.Error) != WEBHOOK_DELIVERY_ERROR_MAX_LENGTH {
+		t.Fatal("should have truncated the error")
+	}
+
+	o.URL = "nowhere.com/"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.URL = "http://nowhere.com/"
+	o.ContentType = "text/plain"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ContentType = OUTGOING_HOOK_CONTENT_TYPE_JSON
+	o.Payload = strings.Repeat("1", WEBHOOK_DELIVERY_PAYLOAD_MAX_LENGTH+1)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreUpdate()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !o.IsPayloadTruncated() || len(o.Payload) != WEBHOOK_DELIVERY_PAYLOAD_MAX_LENGTH {
+		t.Fatal("should have truncated the payload")
+	}
+
+	o.Payload = `{"text": "` + strings.Repeat("é", 4000) + `"}`
+	o.PreUpdate()
+	if o.IsPayloadTruncated() {
+		t.Fatal("shouldn't have truncated the payload of a long message")
+	}
+
+	o.Payload = ""
+	o.Status = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Status = WEBHOOK_DELIVERY_STATUS_FAILED
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOutgoingWebhookDeliveryIsRetryable(t *testing.T) {
+	o := OutgoingWebhookDelivery{Status: WEBHOOK_DELIVERY_STATUS_PENDING}
+
+	if !o.IsRetryable() {
+		t.Fatal("should retry a connection error")
+	}
+
+	for _, statusCode := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway} {
+		if o.StatusCode = statusCode; !o.IsRetryable() {
+			t.Fatal("should retry", statusCode)
+		}
+	}
+
+	for _, statusCode := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		if o.StatusCode = statusCode; o.IsRetryable() {
+			t.Fatal("shouldn't retry", statusCode)
+		}
+	}
+
+	o.StatusCode = http.StatusInternalServerError
+	o.Status = WEBHOOK_DELIVERY_STATUS_FAILED
+	if o.IsRetryable() {
+		t.Fatal("shouldn't retry a failed delivery")
+	}
+
+	o.Status = WEBHOOK_DELIVERY_STATUS_PENDING
+	o.Payload = strings.Repeat("1", WEBHOOK_DELIVERY_PAYLOAD_MAX_LENGTH+1)
+	o.truncate()
+	if o.IsRetryable() {
+		t.Fatal("shouldn't retry a truncated payload")
+	}
+}
@@ -4,6 +4,7 @@
 package model
 
 import (
+	"net/url"
 	"strings"
 	"testing"
 )
@@ -109,6 +110,26 @@ func TestOutgoingWebhookIsValid(t *testing.T) {
 	if err := o.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	o.TriggerWhen = 3
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.TriggerWhen = TRIGGER_WORDS_CONTAINS
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.ContentType = "text/plain"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ContentType = OUTGOING_HOOK_CONTENT_TYPE_JSON
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestOutgoingWebhookPreSave(t *testing.T) {
@@ -120,3 +141,74 @@ func TestOutgoingWebhookPreUpdate(t *testing.T) {
 	o := OutgoingWebhook{}
 	o.PreUpdate()
 }
+
+func TestOutgoingWebhookHasCallbackURL(t *testing.T) {
+	o := OutgoingWebhook{CallbackURLs: []string{"http://nowhere.com/", "http://somewhere.com/"}}
+
+	if !o.HasCallbackURL("http://somewhere.com/") {
+		t.Fatal("should have the callback URL")
+	}
+
+	if o.HasCallbackURL("http://elsewhere.com/") {
+		t.Fatal("shouldn't have the callback URL")
+	}
+}
+
+func TestOutgoingWebhookGetTriggerWord(t *testing.T) {
+	o := OutgoingWebhook{TriggerWords: []string{"foo", "bar"}}
+
+	tests := []struct {
+		when    int
+		message string
+		trigger string
+	}{
+		{TRIGGER_WORDS_EXACT_MATCH, "foo something", "foo"},
+		{TRIGGER_WORDS_EXACT_MATCH, "bar", "bar"},
+		{TRIGGER_WORDS_EXACT_MATCH, "foobar something", ""},
+		{TRIGGER_WORDS_EXACT_MATCH, "something foo", ""},
+		{TRIGGER_WORDS_STARTS_WITH, "foobar something", "foo"},
+		{TRIGGER_WORDS_STARTS_WITH, "barfoo", "bar"},
+		{TRIGGER_WORDS_STARTS_WITH, "something foo", ""},
+		{TRIGGER_WORDS_CONTAINS, "something foo", "foo"},
+		{TRIGGER_WORDS_CONTAINS, "xbarx", "bar"},
+		{TRIGGER_WORDS_CONTAINS, "something", ""},
+		{TRIGGER_WORDS_CONTAINS, "", ""},
+	}
+
+	for _, test := range tests {
+		o.TriggerWhen = test.when
+		if trigger := o.GetTriggerWord(test.message); trigger != test.trigger {
+			t.Fatal("wrong trigger word", test.when, test.message, trigger)
+		}
+	}
+
+	o.TriggerWords = nil
+	if trigger := o.GetTriggerWord("foo"); trigger != "" {
+		t.Fatal("shouldn't have matched without trigger words")
+	}
+}
+
+func TestOutgoingWebhookPayload(t *testing.T) {
+	o := OutgoingWebhookPayload{Token: NewId(), TeamId: NewId(), Timestamp: 12345, Text: "some text & more"}
+
+	if values, err := url.ParseQuery(o.ToFormValues()); err != nil {
+		t.Fatal(err)
+	} else if values.Get("token") != o.Token || values.Get("timestamp") != "12345" || values.Get("text") != o.Text {
+		t.Fatal("form values didn't match")
+	}
+
+	if !strings.Contains(o.ToJson(), `"team_id":"`+o.TeamId+`"`) {
+		t.Fatal("json didn't match")
+	}
+}
+
+func TestSignOutgoingWebhook(t *testing.T) {
+	// computed with: echo -n "1234.text=hello" | openssl dgst -sha256 -hmac secret
+	if signature := SignOutgoingWebhook("secret", "1234", "text=hello"); signature != "3d496c31b004c284186c7694ab8d1b9feff9eba074e170a8db715e0b003d7950" {
+		t.Fatal("wrong signature", signature)
+	}
+
+	if SignOutgoingWebhook("secret", "1235", "text=hello") == SignOutgoingWebhook("secret", "1234", "text=hello") {
+		t.Fatal("the timestamp should be part of the signature")
+	}
+}
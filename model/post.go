@@ -17,7 +17,9 @@ const (
 	POST_JOIN_LEAVE            = "system_join_leave"
 	POST_HEADER_CHANGE         = "system_header_change"
 	POST_CHANNEL_DELETED       = "system_channel_deleted"
+	POST_CHANNEL_RESTORED      = "system_channel_restored"
 	POST_EPHEMERAL             = "system_ephemeral"
+	POST_PROP_FROM_BOT         = "from_bot"
 )
 
 type Post struct {
@@ -35,6 +37,7 @@ type Post struct {
 	Props         StringInterface `json:"props"`
 	Hashtags      string          `json:"hashtags"`
 	Filenames     StringArray     `json:"filenames"`
+	IsPinned      bool            `json:"is_pinned"`
 	PendingPostId string          `json:"pending_post_id" db:"-"`
 }
 
@@ -131,6 +134,9 @@ func (o *Post) PreSave() {
 
 	o.OriginalId = ""
 
+	// posts are only pinned through UpdatePinned
+	o.IsPinned = false
+
 	if o.CreateAt == 0 {
 		o.CreateAt = GetMillis()
 	}
@@ -165,6 +171,11 @@ func (o *Post) AddProp(key string, value interface{}) {
 func (o *Post) PreExport() {
 }
 
+// IsFromBot returns true if the post was made by a bot account.
+func (o *Post) IsFromBot() bool {
+	return o.Props[POST_PROP_FROM_BOT] == "true"
+}
+
 func (o *Post) IsSystemMessage() bool {
 	return len(o.Type) >= len(POST_SYSTEM_MESSAGE_PREFIX) && o.Type[:len(POST_SYSTEM_MESSAGE_PREFIX)] == POST_SYSTEM_MESSAGE_PREFIX
 }
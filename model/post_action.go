@@ -0,0 +1,299 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	POST_ACTION_TYPE_BUTTON = "button"
+	POST_ACTION_TYPE_SELECT = "select"
+
+	POST_PROP_ATTACHMENTS = "attachments"
+
+	// POST_PROP_INTEGRATION_ACTIONS is set by the server on the posts whose actions were prepared
+	// for an integration, it's stripped from the posts sent by clients along with their actions.
+	POST_PROP_INTEGRATION_ACTIONS = "integration_actions"
+)
+
+type SlackAttachment struct {
+	Fallback   string                  `json:"fallback"`
+	Color      string                  `json:"color"`
+	Pretext    string                  `json:"pretext"`
+	AuthorName string                  `json:"author_name"`
+	AuthorLink string                  `json:"author_link"`
+	AuthorIcon string                  `json:"author_icon"`
+	Title      string                  `json:"title"`
+	TitleLink  string                  `json:"title_link"`
+	Text       string                  `json:"text"`
+	Fields     []*SlackAttachmentField `json:"fields"`
+	ImageURL   string                  `json:"image_url"`
+	ThumbURL   string                  `json:"thumb_url"`
+	Footer     string                  `json:"footer"`
+	FooterIcon string                  `json:"footer_icon"`
+	Timestamp  interface{}             `json:"ts,omitempty"`
+	Actions    []*PostAction           `json:"actions,omitempty"`
+}
+
+type SlackAttachmentField struct {
+	Title string      `json:"title"`
+	Value interface{} `json:"value"`
+	Short bool        `json:"short"`
+}
+
+// PostAction is a button or a select menu shown on an attachment. When a user uses it, the
+// server sends a PostActionIntegrationRequest to the URL of its integration.
+type PostAction struct {
+	Id          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Type        string                 `json:"type"`
+	Options     []*PostActionOption    `json:"options,omitempty"`
+	Integration *PostActionIntegration `json:"integration,omitempty"`
+}
+
+type PostActionOption struct {
+	Text  string `json:"text"`
+	Value string `json:"value"`
+}
+
+type PostActionIntegration struct {
+	URL     string          `json:"url"`
+	Context StringInterface `json:"context,omitempty"`
+}
+
+type PostActionIntegrationRequest struct {
+	UserId         string          `json:"user_id"`
+	ChannelId      string          `json:"channel_id"`
+	TeamId         string          `json:"team_id"`
+	PostId         string          `json:"post_id"`
+	ActionId       string          `json:"action_id"`
+	SelectedOption string          `json:"selected_option,omitempty"`
+	Context        StringInterface `json:"context,omitempty"`
+}
+
+// PostActionIntegrationResponse is what the integration can answer with. Update replaces the
+// message and attachments of the post and EphemeralText is shown only to the user who acted.
+type PostActionIntegrationResponse struct {
+	Update        *Post  `json:"update"`
+	EphemeralText string `json:"ephemeral_text"`
+}
+
+func (o *PostAction) IsValid() bool {
+	if o.Type != POST_ACTION_TYPE_BUTTON && o.Type != POST_ACTION_TYPE_SELECT {
+		return false
+	}
+
+	if len(o.Name) == 0 {
+		return false
+	}
+
+	return o.Integration != nil && IsValidHttpUrl(o.Integration.URL)
+}
+
+// HasOption returns true if value is one of the options of a select menu.
+func (o *PostAction) HasOption(value string) bool {
+	for _, option := range o.Options {
+		if option != nil && option.Value == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *PostActionIntegrationRequest) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func PostActionIntegrationRequestFromJson(data io.Reader) *PostActionIntegrationRequest {
+	decoder := json.NewDecoder(data)
+	var o PostActionIntegrationRequest
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *PostActionIntegrationResponse) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func PostActionIntegrationResponseFromJson(data io.Reader) *PostActionIntegrationResponse {
+	decoder := json.NewDecoder(data)
+	var o PostActionIntegrationResponse
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+// Attachments returns the attachments of the post, or nil if it doesn't have any.
+func (o *Post) Attachments() []*SlackAttachment {
+	val, ok := o.Props[POST_PROP_ATTACHMENTS]
+	if !ok {
+		return nil
+	}
+
+	// the props are decoded into plain maps when the post is read back from the database
+	if attachments, ok := val.([]*SlackAttachment); ok {
+		return attachments
+	}
+
+	b, err := json.Marshal(val)
+	if err != nil {
+		return nil
+	}
+
+	var attachments []*SlackAttachment
+	if err := json.Unmarshal(b, &attachments); err != nil {
+		return nil
+	}
+
+	return attachments
+}
+
+func hasActions(attachments []*SlackAttachment) bool {
+	for _, attachment := range attachments {
+		if attachment != nil && len(attachment.Actions) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PrepareActions gives an id to each action of the attachments and drops the invalid ones so
+// that the actions can be looked up when a user clicks on them. It must only be called for
+// posts created by an integration since it marks the actions as usable.
+func (o *Post) PrepareActions() {
+	attachments := o.Attachments()
+	if !hasActions(attachments) {
+		return
+	}
+
+	for _, attachment := range attachments {
+		if attachment == nil {
+			continue
+		}
+
+		actions := make([]*PostAction, 0, len(attachment.Actions))
+		for _, action := range attachment.Actions {
+			if action == nil || !action.IsValid() {
+				continue
+			}
+
+			if len(action.Id) == 0 {
+				action.Id = NewId()
+			}
+
+			actions = append(actions, action)
+		}
+		attachment.Actions = actions
+	}
+
+	o.AddProp(POST_PROP_ATTACHMENTS, attachments)
+	o.AddProp(POST_PROP_INTEGRATION_ACTIONS, "true")
+}
+
+// StripActions removes the actions of the attachments, users aren't allowed to make the server
+// send requests on their behalf by adding actions to their own posts.
+func (o *Post) StripActions() {
+	delete(o.Props, POST_PROP_INTEGRATION_ACTIONS)
+
+	attachments := o.Attachments()
+	if !hasActions(attachments) {
+		return
+	}
+
+	for _, attachment := range attachments {
+		if attachment != nil {
+			attachment.Actions = nil
+		}
+	}
+
+	o.AddProp(POST_PROP_ATTACHMENTS, attachments)
+}
+
+// WithoutActionIntegrations returns a copy of the post without the integration of its actions, their
+// URL and context are only meant for the server while the post is sent to the channel members. The
+// post itself is returned as is when it has no actions.
+func (o *Post) WithoutActionIntegrations() *Post {
+	attachments := o.Attachments()
+	if !hasActions(attachments) {
+		return o
+	}
+
+	stripped := make([]*SlackAttachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		if attachment == nil {
+			continue
+		}
+
+		attachmentCopy := *attachment
+		attachmentCopy.Actions = make([]*PostAction, 0, len(attachment.Actions))
+		for _, action := range attachment.Actions {
+			if action == nil {
+				continue
+			}
+
+			actionCopy := *action
+			actionCopy.Integration = nil
+			attachmentCopy.Actions = append(attachmentCopy.Actions, &actionCopy)
+		}
+
+		stripped = append(stripped, &attachmentCopy)
+	}
+
+	postCopy := *o
+	postCopy.Props = make(StringInterface, len(o.Props))
+	for key, value := range o.Props {
+		postCopy.Props[key] = value
+	}
+	postCopy.Props[POST_PROP_ATTACHMENTS] = stripped
+
+	return &postCopy
+}
+
+// HasIntegrationActions returns true if the actions of the post were prepared by the server.
+func (o *Post) HasIntegrationActions() bool {
+	return o.Props[POST_PROP_INTEGRATION_ACTIONS] == "true"
+}
+
+// GetAction returns the action of the attachments with the given id, or nil. Actions of posts that
+// weren't prepared by the server are ignored.
+func (o *Post) GetAction(id string) *PostAction {
+	if !o.HasIntegrationActions() {
+		return nil
+	}
+
+	for _, attachment := range o.Attachments() {
+		if attachment == nil {
+			continue
+		}
+
+		for _, action := range attachment.Actions {
+			if action != nil && action.Id == id {
+				return action
+			}
+		}
+	}
+
+	return nil
+}
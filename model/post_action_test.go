@@ -0,0 +1,145 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostActionIntegrationJson(t *testing.T) {
+	o := PostActionIntegrationRequest{UserId: NewId(), PostId: NewId(), Context: StringInterface{"key": "value"}}
+	ro := PostActionIntegrationRequestFromJson(strings.NewReader(o.ToJson()))
+
+	if o.UserId != ro.UserId || o.PostId != ro.PostId || ro.Context["key"] != "value" {
+		t.Fatal("requests do not match")
+	}
+
+	resp := PostActionIntegrationResponseFromJson(strings.NewReader(`{"update": {"message": "updated"}, "ephemeral_text": "done"}`))
+	if resp.Update.Message != "updated" || resp.EphemeralText != "done" {
+		t.Fatal("failed to decode response")
+	}
+}
+
+func TestPostPrepareActions(t *testing.T) {
+	post := PostFromJson(strings.NewReader(`{
+		"props": {
+			"attachments": [{
+				"text": "pick one",
+				"actions": [
+					{"name": "Approve", "type": "button", "integration": {"url": "http://localhost/approve", "context": {"id": "1"}}},
+					{"name": "Colour", "type": "select", "options": [{"text": "Red", "value": "red"}], "integration": {"url": "http://localhost/colour"}},
+					{"name": "Broken", "type": "button"},
+					{"name": "Unknown", "type": "link", "integration": {"url": "http://localhost/unknown"}}
+				]
+			}]
+		}
+	}`))
+
+	post.PrepareActions()
+
+	attachments := post.Attachments()
+	if len(attachments) != 1 || attachments[0].Text != "pick one" {
+		t.Fatal("should have kept the attachment")
+	}
+
+	actions := attachments[0].Actions
+	if len(actions) != 2 {
+		t.Fatal("should have dropped the invalid actions")
+	}
+
+	for _, action := range actions {
+		if len(action.Id) != 26 {
+			t.Fatal("should have generated an id")
+		}
+	}
+
+	// make sure the actions survive a round trip through the database
+	post = PostFromJson(strings.NewReader(post.ToJson()))
+
+	if action := post.GetAction(actions[0].Id); action == nil || action.Integration.URL != "http://localhost/approve" || action.Integration.Context["id"] != "1" {
+		t.Fatal("should have found the button")
+	}
+
+	if action := post.GetAction(actions[1].Id); action == nil || !action.HasOption("red") || action.HasOption("blue") {
+		t.Fatal("should have found the select menu")
+	}
+
+	if post.GetAction(NewId()) != nil {
+		t.Fatal("shouldn't have found an action")
+	}
+
+	list := &PostList{Posts: map[string]*Post{"id": post}}
+	list.StripActionIntegrations()
+
+	if stripped := list.Posts["id"].Attachments()[0].Actions; len(stripped) != 2 || stripped[0].Id != actions[0].Id || stripped[0].Integration != nil {
+		t.Fatal("should have removed the integration of the actions")
+	}
+
+	if action := post.GetAction(actions[0].Id); action == nil || action.Integration == nil {
+		t.Fatal("shouldn't have modified the original post")
+	}
+
+	post.StripActions()
+
+	if len(post.Attachments()) != 1 || len(post.Attachments()[0].Actions) != 0 || post.HasIntegrationActions() {
+		t.Fatal("should have removed the actions")
+	}
+
+	if post.GetAction(actions[0].Id) != nil {
+		t.Fatal("shouldn't have found a removed action")
+	}
+}
+
+func TestPostActionsWithoutServerMarker(t *testing.T) {
+	post := PostFromJson(strings.NewReader(`{
+		"props": {
+			"attachments": [{
+				"actions": [{"id": "action1", "name": "Approve", "type": "button", "integration": {"url": "http://localhost/approve"}}]
+			}]
+		}
+	}`))
+
+	if post.GetAction("action1") != nil {
+		t.Fatal("shouldn't have found an action the server didn't prepare")
+	}
+}
+
+func TestPostPrepareActionsWithoutActions(t *testing.T) {
+	attachments := []interface{}{map[string]interface{}{"text": "text", "custom": "value"}}
+	post := &Post{Props: StringInterface{"attachments": attachments}}
+
+	post.PrepareActions()
+
+	if _, ok := post.Props["attachments"].([]interface{}); !ok {
+		t.Fatal("shouldn't have touched attachments without actions")
+	}
+}
+
+func TestPostActionsWithNullEntries(t *testing.T) {
+	post := PostFromJson(strings.NewReader(`{
+		"props": {
+			"attachments": [null, {
+				"actions": [null, {"name": "Colour", "type": "select", "options": [null, {"text": "Red", "value": "red"}], "integration": {"url": "http://localhost/colour"}}]
+			}]
+		}
+	}`))
+
+	post.PrepareActions()
+
+	actions := post.Attachments()[1].Actions
+	if len(actions) != 1 {
+		t.Fatal("should have dropped the null action")
+	}
+
+	if action := post.GetAction(actions[0].Id); action == nil || !action.HasOption("red") || action.HasOption("") {
+		t.Fatal("should have found the select menu")
+	}
+
+	post.StripActions()
+
+	if post.HasIntegrationActions() || len(post.Attachments()[1].Actions) != 0 {
+		t.Fatal("should have removed the actions")
+	}
+}
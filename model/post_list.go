@@ -9,8 +9,9 @@ import (
 )
 
 type PostList struct {
-	Order []string         `json:"order"`
-	Posts map[string]*Post `json:"posts"`
+	Order     []string               `json:"order"`
+	Posts     map[string]*Post       `json:"posts"`
+	Reactions map[string][]*Reaction `json:"reactions,omitempty"`
 }
 
 func (o *PostList) ToJson() string {
@@ -54,11 +55,33 @@ func (o *PostList) AddPost(post *Post) {
 	o.Posts[post.Id] = post
 }
 
+// AddReaction attaches the reaction to the post it was made on.
+func (o *PostList) AddReaction(reaction *Reaction) {
+
+	if o.Reactions == nil {
+		o.Reactions = make(map[string][]*Reaction)
+	}
+
+	o.Reactions[reaction.PostId] = append(o.Reactions[reaction.PostId], reaction)
+}
+
+// StripActionIntegrations replaces the posts of the list by copies without the integration of
+// their actions before the list is sent to a client.
+func (o *PostList) StripActionIntegrations() {
+	for id, post := range o.Posts {
+		o.Posts[id] = post.WithoutActionIntegrations()
+	}
+}
+
 func (o *PostList) Extend(other *PostList) {
 	for _, postId := range other.Order {
 		if _, ok := o.Posts[postId]; !ok {
 			o.AddPost(other.Posts[postId])
 			o.AddOrder(postId)
+
+			for _, reaction := range other.Reactions[postId] {
+				o.AddReaction(reaction)
+			}
 		}
 	}
 }
@@ -68,3 +68,25 @@ func TestPostListExtend(t *testing.T) {
 		t.Fatal("extending l2 again changed l2")
 	}
 }
+
+func TestPostListReactions(t *testing.T) {
+	l1 := PostList{}
+
+	p1 := &Post{Id: NewId(), Message: NewId()}
+	l1.AddPost(p1)
+	l1.AddOrder(p1.Id)
+	l1.AddReaction(&Reaction{UserId: NewId(), PostId: p1.Id, EmojiName: "smile"})
+	l1.AddReaction(&Reaction{UserId: NewId(), PostId: p1.Id, EmojiName: "frown"})
+
+	rpl := PostListFromJson(strings.NewReader(l1.ToJson()))
+	if len(rpl.Reactions[p1.Id]) != 2 {
+		t.Fatal("failed to serialize reactions")
+	}
+
+	l2 := PostList{}
+	l2.Extend(&l1)
+
+	if len(l2.Reactions[p1.Id]) != 2 {
+		t.Fatal("failed to extend reactions")
+	}
+}
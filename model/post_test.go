@@ -96,6 +96,13 @@ func TestPostPreSave(t *testing.T) {
 		t.Fatal("should not be updated")
 	}
 
+	o = Post{Message: "test", IsPinned: true}
+	o.PreSave()
+
+	if o.IsPinned {
+		t.Fatal("should not be pinned")
+	}
+
 	o.Etag()
 }
 
@@ -113,3 +120,15 @@ func TestPostIsSystemMessage(t *testing.T) {
 		t.Fatalf("TestPostIsSystemMessage failed, expected post2.IsSystemMessage() to be true")
 	}
 }
+
+func TestPostIsFromBot(t *testing.T) {
+	post := &Post{Message: "test"}
+	if post.IsFromBot() {
+		t.Fatal("post should not be from a bot")
+	}
+
+	post.AddProp(POST_PROP_FROM_BOT, "true")
+	if !post.IsFromBot() {
+		t.Fatal("post should be from a bot")
+	}
+}
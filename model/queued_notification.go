@@ -0,0 +1,86 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+const (
+	EMAIL_INTERVAL_IMMEDIATE = "immediate"
+	EMAIL_INTERVAL_FIFTEEN   = "fifteen"
+	EMAIL_INTERVAL_HOUR      = "hour"
+)
+
+type QueuedNotification struct {
+	Id        string `json:"id"`
+	UserId    string `json:"user_id"`
+	TeamId    string `json:"team_id"`
+	ChannelId string `json:"channel_id"`
+	PostId    string `json:"post_id"`
+	SiteURL   string `json:"site_url"`
+	CreateAt  int64  `json:"create_at"`
+	SendAt    int64  `json:"send_at"`
+	Attempts  int    `json:"attempts"`
+}
+
+func IsValidEmailInterval(interval string) bool {
+	return interval == EMAIL_INTERVAL_IMMEDIATE || interval == EMAIL_INTERVAL_FIFTEEN || interval == EMAIL_INTERVAL_HOUR
+}
+
+// GetEmailIntervalMillis returns how long an email notification should wait
+// in the queue for the given interval. Immediate emails are never queued.
+func GetEmailIntervalMillis(interval string) int64 {
+	switch interval {
+	case EMAIL_INTERVAL_FIFTEEN:
+		return 15 * 60 * 1000
+	case EMAIL_INTERVAL_HOUR:
+		return 60 * 60 * 1000
+	}
+
+	return 0
+}
+
+func (o *QueuedNotification) IsValid() *AppError {
+
+	if len(o.Id) != 26 {
+		return NewLocAppError("QueuedNotification.IsValid", "model.queued_notification.is_valid.id.app_error", nil, "")
+	}
+
+	if len(o.UserId) != 26 {
+		return NewLocAppError("QueuedNotification.IsValid", "model.queued_notification.is_valid.user_id.app_error", nil, "")
+	}
+
+	if len(o.TeamId) != 26 {
+		return NewLocAppError("QueuedNotification.IsValid", "model.queued_notification.is_valid.team_id.app_error", nil, "")
+	}
+
+	if len(o.ChannelId) != 26 {
+		return NewLocAppError("QueuedNotification.IsValid", "model.queued_notification.is_valid.channel_id.app_error", nil, "")
+	}
+
+	if len(o.PostId) != 26 {
+		return NewLocAppError("QueuedNotification.IsValid", "model.queued_notification.is_valid.post_id.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("QueuedNotification.IsValid", "model.queued_notification.is_valid.create_at.app_error", nil, "")
+	}
+
+	if o.Attempts < 0 {
+		return NewLocAppError("QueuedNotification.IsValid", "model.queued_notification.is_valid.attempts.app_error", nil, "")
+	}
+
+	if o.SendAt < o.CreateAt {
+		return NewLocAppError("QueuedNotification.IsValid", "model.queued_notification.is_valid.send_at.app_error", nil, "")
+	}
+
+	return nil
+}
+
+func (o *QueuedNotification) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
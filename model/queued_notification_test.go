@@ -0,0 +1,56 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"testing"
+)
+
+func TestQueuedNotificationIsValid(t *testing.T) {
+	o := QueuedNotification{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	o.TeamId = NewId()
+	o.ChannelId = NewId()
+	o.PostId = NewId()
+	o.PreSave()
+	o.SendAt = o.CreateAt + GetEmailIntervalMillis(EMAIL_INTERVAL_FIFTEEN)
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Attempts = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Attempts = 0
+	o.SendAt = o.CreateAt - 1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestEmailInterval(t *testing.T) {
+	if !IsValidEmailInterval(EMAIL_INTERVAL_IMMEDIATE) || !IsValidEmailInterval(EMAIL_INTERVAL_FIFTEEN) || !IsValidEmailInterval(EMAIL_INTERVAL_HOUR) {
+		t.Fatal("should be valid")
+	}
+
+	if IsValidEmailInterval("weekly") {
+		t.Fatal("should be invalid")
+	}
+
+	if GetEmailIntervalMillis(EMAIL_INTERVAL_IMMEDIATE) != 0 {
+		t.Fatal("immediate emails should not be delayed")
+	}
+
+	if GetEmailIntervalMillis(EMAIL_INTERVAL_HOUR) != 60*60*1000 {
+		t.Fatal("hourly emails should be delayed by an hour")
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReactionJson(t *testing.T) {
+	o := Reaction{UserId: NewId(), PostId: NewId(), EmojiName: "smile"}
+	json := o.ToJson()
+	ro := ReactionFromJson(strings.NewReader(json))
+
+	if o.UserId != ro.UserId || o.PostId != ro.PostId || o.EmojiName != ro.EmojiName {
+		t.Fatal("reactions do not match")
+	}
+
+	rl := ReactionsFromJson(strings.NewReader(ReactionsToJson([]*Reaction{&o})))
+	if len(rl) != 1 || rl[0].EmojiName != o.EmojiName {
+		t.Fatal("reaction lists do not match")
+	}
+}
+
+func TestReactionIsValid(t *testing.T) {
+	o := Reaction{UserId: NewId(), PostId: NewId(), EmojiName: "+1"}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.UserId = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("user id should be invalid")
+	}
+
+	o.UserId = NewId()
+	o.PostId = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("post id should be invalid")
+	}
+
+	o.PostId = NewId()
+	o.EmojiName = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("empty emoji name should be invalid")
+	}
+
+	o.EmojiName = "no spaces"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("emoji name with spaces should be invalid")
+	}
+
+	o.EmojiName = strings.Repeat("a", 65)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("long emoji name should be invalid")
+	}
+
+	o.EmojiName = "thumbs_up-2"
+	o.CreateAt = 0
+	if err := o.IsValid(); err == nil {
+		t.Fatal("create at should be invalid")
+	}
+}
@@ -5,17 +5,29 @@ package model
 
 import (
 	"strings"
+	"time"
 )
 
 type SearchParams struct {
-	Terms      string
-	IsHashtag  bool
-	InChannels []string
-	FromUsers  []string
-	OrTerms    bool
+	Terms         string
+	IsHashtag     bool
+	InChannels    []string
+	FromUsers     []string
+	OrTerms       bool
+	CreatedAfter  int64
+	CreatedBefore int64
+	ExcludedTerms []string
+	HasFile       bool
+	HasLink       bool
 }
 
-var searchFlags = [...]string{"from", "channel", "in"}
+const (
+	SEARCH_DATE_FORMAT      = "2006-01-02"
+	SEARCH_FLAG_EXCLUDE     = "exclude"
+	SEARCH_DAY_MILLISECONDS = 24 * 60 * 60 * 1000
+)
+
+var searchFlags = [...]string{"from", "channel", "in", "before", "after", "on", "has"}
 
 func splitWordsNoQuotes(text string) []string {
 	words := []string{}
@@ -66,6 +78,18 @@ func parseSearchFlags(input []string) ([]string, [][2]string) {
 
 		isFlag := false
 
+		// a leading dash excludes the word from the results
+		if len(word) > 1 && word[0] == '-' {
+			excluded := puncStart.ReplaceAllString(word[1:], "")
+			excluded = puncEndWildcard.ReplaceAllString(excluded, "")
+
+			if len(excluded) != 0 {
+				flags = append(flags, [2]string{SEARCH_FLAG_EXCLUDE, excluded})
+			}
+
+			continue
+		}
+
 		if colon := strings.Index(word, ":"); colon != -1 {
 			flag := word[:colon]
 			value := word[colon+1:]
@@ -125,6 +149,10 @@ func ParseSearchParams(text string) []*SearchParams {
 
 	inChannels := []string{}
 	fromUsers := []string{}
+	excludedTerms := []string{}
+	hasFile := false
+	hasLink := false
+	var createdAfter, createdBefore int64
 
 	for _, flagPair := range flags {
 		flag := flagPair[0]
@@ -134,9 +162,29 @@ func ParseSearchParams(text string) []*SearchParams {
 			inChannels = append(inChannels, value)
 		} else if flag == "from" {
 			fromUsers = append(fromUsers, value)
+		} else if flag == SEARCH_FLAG_EXCLUDE {
+			excludedTerms = append(excludedTerms, value)
+		} else if flag == "has" {
+			if strings.EqualFold(value, "file") || strings.EqualFold(value, "files") {
+				hasFile = true
+			} else if strings.EqualFold(value, "link") || strings.EqualFold(value, "links") {
+				hasLink = true
+			}
+		} else if day, ok := parseSearchDate(value); ok {
+			// dates are whole days so after: and on: include everything up to the end of the day
+			if flag == "before" {
+				createdBefore = day
+			} else if flag == "after" {
+				createdAfter = day + SEARCH_DAY_MILLISECONDS - 1
+			} else if flag == "on" {
+				createdAfter = day - 1
+				createdBefore = day + SEARCH_DAY_MILLISECONDS
+			}
 		}
 	}
 
+	hasFilters := len(inChannels) != 0 || len(fromUsers) != 0 || createdAfter != 0 || createdBefore != 0 || hasFile || hasLink
+
 	paramsList := []*SearchParams{}
 
 	if len(plainTerms) > 0 {
@@ -158,7 +206,7 @@ func ParseSearchParams(text string) []*SearchParams {
 	}
 
 	// special case for when no terms are specified but we still have a filter
-	if len(plainTerms) == 0 && len(hashtagTerms) == 0 && (len(inChannels) != 0 || len(fromUsers) != 0) {
+	if len(plainTerms) == 0 && len(hashtagTerms) == 0 && hasFilters {
 		paramsList = append(paramsList, &SearchParams{
 			Terms:      "",
 			IsHashtag:  true,
@@ -167,5 +215,22 @@ func ParseSearchParams(text string) []*SearchParams {
 		})
 	}
 
+	for _, params := range paramsList {
+		params.CreatedAfter = createdAfter
+		params.CreatedBefore = createdBefore
+		params.ExcludedTerms = excludedTerms
+		params.HasFile = hasFile
+		params.HasLink = hasLink
+	}
+
 	return paramsList
 }
+
+// parseSearchDate returns the start of the given YYYY-MM-DD day in UTC, in milliseconds.
+func parseSearchDate(value string) (int64, bool) {
+	if day, err := time.Parse(SEARCH_DATE_FORMAT, value); err != nil {
+		return 0, false
+	} else {
+		return day.UnixNano() / int64(time.Millisecond), true
+	}
+}
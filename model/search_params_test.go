@@ -221,3 +221,69 @@ func TestParseSearchParams(t *testing.T) {
 		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
 	}
 }
+
+func TestParseSearchParamsDates(t *testing.T) {
+	day := int64(1467331200000) // 2016-07-01 UTC
+
+	if sp := ParseSearchParams("testing before:2016-07-01"); len(sp) != 1 || sp[0].Terms != "testing" || sp[0].CreatedBefore != day || sp[0].CreatedAfter != 0 {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("testing after: 2016-07-01"); len(sp) != 1 || sp[0].Terms != "testing" || sp[0].CreatedAfter != day+SEARCH_DAY_MILLISECONDS-1 || sp[0].CreatedBefore != 0 {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("testing on:2016-07-01"); len(sp) != 1 || sp[0].Terms != "testing" || sp[0].CreatedAfter != day-1 || sp[0].CreatedBefore != day+SEARCH_DAY_MILLISECONDS {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("on:2016-07-01"); len(sp) != 1 || sp[0].Terms != "" || sp[0].CreatedAfter != day-1 {
+		t.Fatalf("Incorrect output from parse search params: %v", sp)
+	}
+
+	if sp := ParseSearchParams("testing before:yesterday"); len(sp) != 1 || sp[0].Terms != "testing" || sp[0].CreatedBefore != 0 {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+}
+
+func TestParseSearchParamsExcludedTerms(t *testing.T) {
+	if words, flags := parseSearchFlags(splitWords("apple -banana")); len(words) != 1 || words[0] != "apple" {
+		t.Fatalf("got incorrect words %v", words)
+	} else if len(flags) != 1 || flags[0][0] != SEARCH_FLAG_EXCLUDE || flags[0][1] != "banana" {
+		t.Fatalf("got incorrect flags %v", flags)
+	}
+
+	if sp := ParseSearchParams("apple -banana -cherr*"); len(sp) != 1 || sp[0].Terms != "apple" || len(sp[0].ExcludedTerms) != 2 || sp[0].ExcludedTerms[0] != "banana" || sp[0].ExcludedTerms[1] != "cherr*" {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("#apple -banana"); len(sp) != 1 || !sp[0].IsHashtag || len(sp[0].ExcludedTerms) != 1 {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("-banana"); len(sp) != 0 {
+		t.Fatalf("excluded terms on their own shouldn't search for everything: %v", sp)
+	}
+
+	if sp := ParseSearchParams("apple - banana"); len(sp) != 1 || sp[0].Terms != "apple banana" || len(sp[0].ExcludedTerms) != 0 {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+}
+
+func TestParseSearchParamsHas(t *testing.T) {
+	if sp := ParseSearchParams("apple has:file"); len(sp) != 1 || sp[0].Terms != "apple" || !sp[0].HasFile || sp[0].HasLink {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("apple has:Link"); len(sp) != 1 || sp[0].Terms != "apple" || sp[0].HasFile || !sp[0].HasLink {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+
+	if sp := ParseSearchParams("has:files"); len(sp) != 1 || sp[0].Terms != "" || !sp[0].HasFile {
+		t.Fatalf("Incorrect output from parse search params: %v", sp)
+	}
+
+	if sp := ParseSearchParams("apple has:nothing"); len(sp) != 1 || sp[0].HasFile || sp[0].HasLink {
+		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
+	}
+}
@@ -14,6 +14,7 @@ const (
 	SESSION_PROP_PLATFORM = "platform"
 	SESSION_PROP_OS       = "os"
 	SESSION_PROP_BROWSER  = "browser"
+	SESSION_PROP_IS_BOT   = "is_bot"
 )
 
 type Session struct {
@@ -26,6 +27,7 @@ type Session struct {
 	DeviceId       string        `json:"device_id"`
 	Roles          string        `json:"roles"`
 	IsOAuth        bool          `json:"is_oauth"`
+	Scope          string        `json:"scope"`
 	Props          StringMap     `json:"props"`
 	TeamMembers    []*TeamMember `json:"team_members" db:"-"`
 }
@@ -95,6 +97,27 @@ func (me *Session) AddProp(key string, value string) {
 	me.Props[key] = value
 }
 
+// HasScope returns true if the session can be used for something that requires the given
+// OAuth scope. Only OAuth sessions are limited, and the ones created before scopes existed
+// have full access.
+func (me *Session) HasScope(scope string) bool {
+	if !me.IsOAuth || len(me.Scope) == 0 {
+		return true
+	}
+
+	return ScopeAllows(me.Scope, scope)
+}
+
+// IsUserAccessToken returns true if the session was created for a personal access token.
+func (me *Session) IsUserAccessToken() bool {
+	return len(me.Props[SESSION_PROP_USER_ACCESS_TOKEN_ID]) > 0
+}
+
+// IsBot returns true if the session belongs to a bot account.
+func (me *Session) IsBot() bool {
+	return me.Props[SESSION_PROP_IS_BOT] == "true"
+}
+
 func (me *Session) GetTeamByTeamId(teamId string) *TeamMember {
 	for _, team := range me.TeamMembers {
 		if team.TeamId == teamId {
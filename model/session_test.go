@@ -33,3 +33,20 @@ func TestSessionJson(t *testing.T) {
 
 	session.SetExpireInDays(10)
 }
+
+func TestSessionHasScope(t *testing.T) {
+	session := Session{}
+	if !session.HasScope(SCOPE_WRITE_POSTS) {
+		t.Fatal("should not have limited a regular session")
+	}
+
+	session.IsOAuth = true
+	if !session.HasScope(SCOPE_WRITE_POSTS) {
+		t.Fatal("should not have limited an OAuth session from before scopes")
+	}
+
+	session.Scope = SCOPE_READ_POSTS
+	if !session.HasScope(SCOPE_READ_POSTS) || session.HasScope(SCOPE_WRITE_POSTS) {
+		t.Fatal("should have limited the session to its scope")
+	}
+}
@@ -8,8 +8,12 @@ import (
 	"io"
 )
 
+// SuggestCommand is a suggestion for completing the word being typed in a command. Complete is
+// the whole command with the suggestion applied.
 type SuggestCommand struct {
+	Complete    string `json:"complete"`
 	Suggestion  string `json:"suggestion"`
+	Hint        string `json:"hint"`
 	Description string `json:"description"`
 }
 
@@ -32,3 +36,23 @@ func SuggestCommandFromJson(data io.Reader) *SuggestCommand {
 		return nil
 	}
 }
+
+func SuggestCommandListToJson(l []*SuggestCommand) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func SuggestCommandListFromJson(data io.Reader) []*SuggestCommand {
+	decoder := json.NewDecoder(data)
+	var o []*SuggestCommand
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
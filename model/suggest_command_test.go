@@ -17,3 +17,12 @@ func TestSuggestCommandJson(t *testing.T) {
 		t.Fatal("Ids do not match")
 	}
 }
+
+func TestSuggestCommandListJson(t *testing.T) {
+	commands := []*SuggestCommand{{Complete: "/msg @" + NewId(), Suggestion: NewId()}}
+	result := SuggestCommandListFromJson(strings.NewReader(SuggestCommandListToJson(commands)))
+
+	if len(result) != 1 || result[0].Complete != commands[0].Complete {
+		t.Fatal("Suggestions do not match")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type ThreadFollower struct {
+	RootId       string `json:"root_id"`
+	UserId       string `json:"user_id"`
+	ChannelId    string `json:"channel_id"`
+	MentionCount int64  `json:"mention_count"`
+	LastViewedAt int64  `json:"last_viewed_at"`
+	CreateAt     int64  `json:"create_at"`
+}
+
+func (o *ThreadFollower) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ThreadFollowerFromJson(data io.Reader) *ThreadFollower {
+	decoder := json.NewDecoder(data)
+	var o ThreadFollower
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func ThreadFollowerListToJson(l []*ThreadFollower) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ThreadFollowerListFromJson(data io.Reader) []*ThreadFollower {
+	decoder := json.NewDecoder(data)
+	var o []*ThreadFollower
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
+
+func (o *ThreadFollower) IsValid() *AppError {
+
+	if len(o.RootId) != 26 {
+		return NewLocAppError("ThreadFollower.IsValid", "model.thread_follower.is_valid.root_id.app_error", nil, "")
+	}
+
+	if len(o.UserId) != 26 {
+		return NewLocAppError("ThreadFollower.IsValid", "model.thread_follower.is_valid.user_id.app_error", nil, "")
+	}
+
+	if len(o.ChannelId) != 26 {
+		return NewLocAppError("ThreadFollower.IsValid", "model.thread_follower.is_valid.channel_id.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("ThreadFollower.IsValid", "model.thread_follower.is_valid.create_at.app_error", nil, "")
+	}
+
+	return nil
+}
+
+func (o *ThreadFollower) PreSave() {
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+
+	if o.LastViewedAt == 0 {
+		o.LastViewedAt = o.CreateAt
+	}
+}
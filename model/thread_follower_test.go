@@ -0,0 +1,58 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThreadFollowerJson(t *testing.T) {
+	o := ThreadFollower{RootId: NewId(), UserId: NewId()}
+	json := o.ToJson()
+	ro := ThreadFollowerFromJson(strings.NewReader(json))
+
+	if o.RootId != ro.RootId || o.UserId != ro.UserId {
+		t.Fatal("Ids do not match")
+	}
+
+	l := []*ThreadFollower{&o}
+	rl := ThreadFollowerListFromJson(strings.NewReader(ThreadFollowerListToJson(l)))
+
+	if len(rl) != 1 || rl[0].RootId != o.RootId {
+		t.Fatal("list does not match")
+	}
+}
+
+func TestThreadFollowerIsValid(t *testing.T) {
+	o := ThreadFollower{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.RootId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ChannelId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreSave()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.LastViewedAt != o.CreateAt {
+		t.Fatal("last viewed should default to create at")
+	}
+}
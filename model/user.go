@@ -28,6 +28,8 @@ const (
 	USER_AUTH_SERVICE_EMAIL    = "email"
 	USER_AUTH_SERVICE_USERNAME = "username"
 	MIN_PASSWORD_LENGTH        = 5
+	BOT_OWNER_TYPE_USER        = "user"
+	BOT_OWNER_TYPE_TEAM        = "team"
 )
 
 type User struct {
@@ -57,6 +59,12 @@ type User struct {
 	Locale             string    `json:"locale"`
 	MfaActive          bool      `json:"mfa_active,omitempty"`
 	MfaSecret          string    `json:"mfa_secret,omitempty"`
+	MfaRecoveryCodes   string    `json:"mfa_recovery_codes,omitempty"`
+	MfaLastTimeStep    int64     `json:"mfa_last_time_step,omitempty"`
+	PasswordHistory    string    `json:"password_history,omitempty"`
+	IsBot              bool      `json:"is_bot,omitempty"`
+	BotOwnerId         string    `json:"bot_owner_id,omitempty"`
+	BotOwnerType       string    `json:"bot_owner_type,omitempty"`
 }
 
 // IsValid validates the user and returns an error if it isn't configured
@@ -115,6 +123,18 @@ func (u *User) IsValid() *AppError {
 		return NewLocAppError("User.IsValid", "model.user.is_valid.theme.app_error", nil, "user_id="+u.Id)
 	}
 
+	if u.IsBot {
+		if len(u.BotOwnerId) != 26 || (u.BotOwnerType != BOT_OWNER_TYPE_USER && u.BotOwnerType != BOT_OWNER_TYPE_TEAM) {
+			return NewLocAppError("User.IsValid", "model.user.is_valid.bot_owner.app_error", nil, "user_id="+u.Id)
+		}
+
+		if len(u.Password) > 0 || len(u.AuthData) > 0 {
+			return NewLocAppError("User.IsValid", "model.user.is_valid.bot_pwd.app_error", nil, "user_id="+u.Id)
+		}
+	} else if len(u.BotOwnerId) > 0 || len(u.BotOwnerType) > 0 {
+		return NewLocAppError("User.IsValid", "model.user.is_valid.bot_owner.app_error", nil, "user_id="+u.Id)
+	}
+
 	return nil
 }
 
@@ -183,6 +203,7 @@ func (u *User) PreUpdate() {
 func (u *User) SetDefaultNotifications() {
 	u.NotifyProps = make(map[string]string)
 	u.NotifyProps["email"] = "true"
+	u.NotifyProps["email_interval"] = EMAIL_INTERVAL_IMMEDIATE
 	u.NotifyProps["desktop"] = USER_NOTIFY_ALL
 	u.NotifyProps["desktop_sound"] = "true"
 	u.NotifyProps["mention_keys"] = u.Username + ",@" + u.Username
@@ -239,6 +260,8 @@ func (u *User) Sanitize(options map[string]bool) {
 	u.Password = ""
 	u.AuthData = ""
 	u.MfaSecret = ""
+	u.MfaRecoveryCodes = ""
+	u.PasswordHistory = ""
 
 	if len(options) != 0 && !options["email"] {
 		u.Email = ""
@@ -259,6 +282,9 @@ func (u *User) ClearNonProfileFields() {
 	u.AuthService = ""
 	u.MfaActive = false
 	u.MfaSecret = ""
+	u.MfaRecoveryCodes = ""
+	u.MfaLastTimeStep = 0
+	u.PasswordHistory = ""
 	u.EmailVerified = false
 	u.LastPingAt = 0
 	u.AllowMarketing = false
@@ -361,7 +387,7 @@ func IsInRole(userRoles string, inRole string) bool {
 }
 
 func (u *User) IsOAuthUser() bool {
-	if u.AuthService == USER_AUTH_SERVICE_GITLAB {
+	if u.AuthService == USER_AUTH_SERVICE_GITLAB || u.AuthService == USER_AUTH_SERVICE_OPENID {
 		return true
 	}
 	return false
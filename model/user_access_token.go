@@ -0,0 +1,109 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"unicode/utf8"
+)
+
+const (
+	SESSION_PROP_USER_ACCESS_TOKEN_ID = "user_access_token_id"
+)
+
+// UserAccessToken is a long-lived personal access token. Only a hash of the token is
+// stored, the token itself is returned once when it is created.
+type UserAccessToken struct {
+	Id          string `json:"id"`
+	Token       string `json:"token,omitempty" db:"-"`
+	TokenHash   string `json:"-"`
+	UserId      string `json:"user_id"`
+	Description string `json:"description"`
+	CreateAt    int64  `json:"create_at"`
+}
+
+// HashUserAccessToken returns the hash a personal access token is stored and looked up by.
+// Tokens are random so a fast unsalted hash is enough.
+func HashUserAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *UserAccessToken) IsValid() *AppError {
+	if len(t.Id) != 26 {
+		return NewLocAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.id.app_error", nil, "")
+	}
+
+	if len(t.TokenHash) != 64 {
+		return NewLocAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.token.app_error", nil, "id="+t.Id)
+	}
+
+	if len(t.UserId) != 26 {
+		return NewLocAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.user_id.app_error", nil, "id="+t.Id)
+	}
+
+	if len(t.Description) == 0 || utf8.RuneCountInString(t.Description) > 255 {
+		return NewLocAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.description.app_error", nil, "id="+t.Id)
+	}
+
+	if t.CreateAt == 0 {
+		return NewLocAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.create_at.app_error", nil, "id="+t.Id)
+	}
+
+	return nil
+}
+
+// PreSave generates the token and keeps its hash. It should be run before saving the token to the db.
+func (t *UserAccessToken) PreSave() {
+	if t.Id == "" {
+		t.Id = NewId()
+	}
+
+	t.Token = NewId()
+	t.TokenHash = HashUserAccessToken(t.Token)
+	t.CreateAt = GetMillis()
+}
+
+func (t *UserAccessToken) ToJson() string {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func UserAccessTokenFromJson(data io.Reader) *UserAccessToken {
+	decoder := json.NewDecoder(data)
+	var t UserAccessToken
+	err := decoder.Decode(&t)
+	if err == nil {
+		return &t
+	} else {
+		return nil
+	}
+}
+
+func UserAccessTokenListToJson(l []*UserAccessToken) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func UserAccessTokenListFromJson(data io.Reader) []*UserAccessToken {
+	decoder := json.NewDecoder(data)
+	var o []*UserAccessToken
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUserAccessTokenJson(t *testing.T) {
+	token := UserAccessToken{UserId: NewId(), Description: "deploy bot"}
+	token.PreSave()
+
+	json := token.ToJson()
+	if strings.Contains(json, token.TokenHash) {
+		t.Fatal("should not have serialized the token hash")
+	}
+
+	rtoken := UserAccessTokenFromJson(strings.NewReader(json))
+	if rtoken.Id != token.Id || rtoken.Token != token.Token {
+		t.Fatal("ids do not match")
+	}
+}
+
+func TestUserAccessTokenIsValid(t *testing.T) {
+	token := UserAccessToken{UserId: NewId(), Description: "deploy bot"}
+	token.PreSave()
+
+	if err := token.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if token.TokenHash != HashUserAccessToken(token.Token) || token.TokenHash == token.Token {
+		t.Fatal("should have stored the hash of the token")
+	}
+
+	token.Description = ""
+	if err := token.IsValid(); err == nil {
+		t.Fatal("should have required a description")
+	}
+
+	token.Description = strings.Repeat("a", 256)
+	if err := token.IsValid(); err == nil {
+		t.Fatal("description should be limited")
+	}
+
+	token.Description = "deploy bot"
+	token.UserId = "junk"
+	if err := token.IsValid(); err == nil {
+		t.Fatal("should have required a user id")
+	}
+}
@@ -128,6 +128,28 @@ func TestUserIsValid(t *testing.T) {
 	if err := user.IsValid(); err == nil {
 		t.Fatal(err)
 	}
+
+	user.LastName = ""
+	user.BotOwnerId = NewId()
+	if err := user.IsValid(); err == nil {
+		t.Fatal("should require a bot to have an owner")
+	}
+
+	user.IsBot = true
+	user.BotOwnerType = "channel"
+	if err := user.IsValid(); err == nil {
+		t.Fatal("should have failed on the owner type")
+	}
+
+	user.BotOwnerType = BOT_OWNER_TYPE_TEAM
+	if err := user.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	user.Password = "passwd1"
+	if err := user.IsValid(); err == nil {
+		t.Fatal("should not allow a bot to have a password")
+	}
 }
 
 func TestUserGetFullName(t *testing.T) {
@@ -0,0 +1,256 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package openid
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/mattermost/platform/model"
+)
+
+var signingAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	if b, err := decodeSegment(value); err != nil {
+		return nil, err
+	} else if len(b) == 0 {
+		return nil, errors.New("empty value")
+	} else {
+		return new(big.Int).SetBytes(b), nil
+	}
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %v", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %v", k.Kty)
+}
+
+// publicKeys returns the signing keys of the set by id, skipping the ones that can't be used.
+func (s *jsonWebKeySet) publicKeys() map[string]crypto.PublicKey {
+	keys := make(map[string]crypto.PublicKey)
+
+	for _, k := range s.Keys {
+		if len(k.Use) > 0 && k.Use != "sig" {
+			continue
+		}
+
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	return keys
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed []byte, signature []byte) error {
+	hash, ok := signingAlgorithms[alg]
+	if !ok {
+		return fmt.Errorf("unsupported signing algorithm %v", alg)
+	}
+
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("the key doesn't match the signing algorithm")
+		}
+
+		if alg[:2] == "PS" {
+			return rsa.VerifyPSS(pub, hash, digest, signature, nil)
+		}
+
+		return rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+	default:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("the key doesn't match the signing algorithm")
+		}
+
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid signature length")
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+
+		return nil
+	}
+}
+
+// checkClaims makes sure that the token was issued by the provider for this client and
+// that it is valid at the given time in seconds.
+func checkClaims(claims map[string]interface{}, issuer string, clientId string, now int64) error {
+	if iss, _ := claims["iss"].(string); iss != issuer {
+		return fmt.Errorf("unexpected issuer %v", iss)
+	}
+
+	audiences := []string{}
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = append(audiences, aud)
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+	}
+
+	found := false
+	for _, aud := range audiences {
+		if aud == clientId {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return errors.New("the token wasn't issued for this client")
+	}
+
+	if azp, ok := claims["azp"].(string); ok && len(audiences) > 1 && azp != clientId {
+		return errors.New("the token wasn't issued for this client")
+	}
+
+	if exp, ok := claims["exp"].(float64); !ok || int64(exp)+CLOCK_SKEW < now {
+		return errors.New("the token has expired")
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && int64(nbf)-CLOCK_SKEW > now {
+		return errors.New("the token isn't valid yet")
+	}
+
+	return nil
+}
+
+// validateIdToken checks the signature of an ID token against the provider's keys and
+// returns its claims if they're valid at the given time in seconds. The caller must hold the mutex.
+func (p *OpenIdProvider) validateIdToken(doc *discoveryDocument, clientId string, idToken string, now int64) (map[string]interface{}, *model.AppError) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, model.NewLocAppError("OpenIdProvider.validateIdToken", "openid.validate_id_token.app_error", nil, "malformed token")
+	}
+
+	var header tokenHeader
+	if b, err := decodeSegment(parts[0]); err != nil {
+		return nil, model.NewLocAppError("OpenIdProvider.validateIdToken", "openid.validate_id_token.app_error", nil, err.Error())
+	} else if err := json.Unmarshal(b, &header); err != nil {
+		return nil, model.NewLocAppError("OpenIdProvider.validateIdToken", "openid.validate_id_token.app_error", nil, err.Error())
+	}
+
+	key, appErr := p.getSigningKey(doc, header.Kid)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if signature, err := decodeSegment(parts[2]); err != nil {
+		return nil, model.NewLocAppError("OpenIdProvider.validateIdToken", "openid.validate_id_token.app_error", nil, err.Error())
+	} else if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, model.NewLocAppError("OpenIdProvider.validateIdToken", "openid.validate_id_token.signature.app_error", nil, err.Error())
+	}
+
+	var claims map[string]interface{}
+	if b, err := decodeSegment(parts[1]); err != nil {
+		return nil, model.NewLocAppError("OpenIdProvider.validateIdToken", "openid.validate_id_token.app_error", nil, err.Error())
+	} else if err := json.Unmarshal(b, &claims); err != nil {
+		return nil, model.NewLocAppError("OpenIdProvider.validateIdToken", "openid.validate_id_token.app_error", nil, err.Error())
+	}
+
+	if err := checkClaims(claims, doc.Issuer, clientId, now); err != nil {
+		return nil, model.NewLocAppError("OpenIdProvider.validateIdToken", "openid.validate_id_token.claims.app_error", nil, err.Error())
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package openid
+
+import (
+	"crypto"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	DISCOVERY_PATH = "/.well-known/openid-configuration"
+	DEFAULT_SCOPE  = "openid profile email"
+
+	// discovery documents and signing keys are fetched again once they're this old
+	CACHE_EXPIRY = 60 * 60 * 1000
+
+	// an ID token signed with an unknown key only triggers a refresh of the keys this often
+	KEY_REFRESH_INTERVAL = 60 * 1000
+
+	// the clock skew in seconds allowed when checking that an ID token is still valid
+	CLOCK_SKEW = 60
+)
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+// OpenIdProvider signs users in with any OpenID Connect provider configured in OpenIdSettings.
+// The discovery document and the signing keys of the provider are cached between logins.
+type OpenIdProvider struct {
+	mutex         sync.Mutex
+	discoveryUrl  string
+	discovery     *discoveryDocument
+	discoveredAt  int64
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt int64
+}
+
+func init() {
+	einterfaces.RegisterOauthProvider(model.USER_AUTH_SERVICE_OPENID, &OpenIdProvider{})
+}
+
+func getJson(url string, v interface{}) error {
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
+	}
+	client := &http.Client{Transport: tr, Timeout: 30 * time.Second}
+
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v returned status %v", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func getDiscoveryUrl(endpoint string) string {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if strings.HasSuffix(endpoint, DISCOVERY_PATH) {
+		return endpoint
+	}
+
+	return endpoint + DISCOVERY_PATH
+}
+
+// discover returns the discovery document found at the endpoint, which can either be the
+// issuer or the full url of the document. The caller must hold the mutex.
+func (p *OpenIdProvider) discover(endpoint string) (*discoveryDocument, *model.AppError) {
+	url := getDiscoveryUrl(endpoint)
+
+	if p.discovery != nil && p.discoveryUrl == url && model.GetMillis()-p.discoveredAt < CACHE_EXPIRY {
+		return p.discovery, nil
+	}
+
+	var doc discoveryDocument
+	if err := getJson(url, &doc); err != nil {
+		return nil, model.NewLocAppError("OpenIdProvider.discover", "openid.discover.app_error", nil, err.Error())
+	}
+
+	if len(doc.Issuer) == 0 || len(doc.AuthorizationEndpoint) == 0 || len(doc.TokenEndpoint) == 0 || len(doc.JwksUri) == 0 {
+		return nil, model.NewLocAppError("OpenIdProvider.discover", "openid.discover.invalid.app_error", nil, "url="+url)
+	}
+
+	if p.discoveryUrl != url {
+		p.keys = nil
+	}
+
+	p.discovery = &doc
+	p.discoveryUrl = url
+	p.discoveredAt = model.GetMillis()
+
+	return p.discovery, nil
+}
+
+func (p *OpenIdProvider) findKey(kid string) crypto.PublicKey {
+	if len(kid) == 0 && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+
+	return p.keys[kid]
+}
+
+// getSigningKey returns the key with the given id from the provider's JWKS. The keys are
+// fetched again when they've expired or when the id isn't known, in case they were rotated.
+// The caller must hold the mutex.
+func (p *OpenIdProvider) getSigningKey(doc *discoveryDocument, kid string) (crypto.PublicKey, *model.AppError) {
+	now := model.GetMillis()
+
+	key := p.findKey(kid)
+	if key == nil || now-p.keysFetchedAt > CACHE_EXPIRY {
+		if p.keys == nil || now-p.keysFetchedAt > KEY_REFRESH_INTERVAL {
+			var set jsonWebKeySet
+			if err := getJson(doc.JwksUri, &set); err != nil {
+				return nil, model.NewLocAppError("OpenIdProvider.getSigningKey", "openid.signing_keys.app_error", nil, err.Error())
+			}
+
+			p.keys = set.publicKeys()
+			p.keysFetchedAt = now
+
+			key = p.findKey(kid)
+		}
+	}
+
+	if key == nil {
+		return nil, model.NewLocAppError("OpenIdProvider.getSigningKey", "openid.signing_keys.unknown.app_error", nil, "kid="+kid)
+	}
+
+	return key, nil
+}
+
+func (p *OpenIdProvider) GetIdentifier() string {
+	return model.USER_AUTH_SERVICE_OPENID
+}
+
+// GetSSOSettings returns the configured settings with the endpoints that were left blank
+// filled in from the discovery document. The openid scope is always requested.
+func (p *OpenIdProvider) GetSSOSettings() (*model.SSOSettings, *model.AppError) {
+	settings := utils.Cfg.OpenIdSettings
+	sso := settings.SSOSettings
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	doc, err := p.discover(*settings.DiscoveryEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sso.AuthEndpoint) == 0 {
+		sso.AuthEndpoint = doc.AuthorizationEndpoint
+	}
+
+	if len(sso.TokenEndpoint) == 0 {
+		sso.TokenEndpoint = doc.TokenEndpoint
+	}
+
+	if len(sso.UserApiEndpoint) == 0 {
+		sso.UserApiEndpoint = doc.UserinfoEndpoint
+	}
+
+	if len(strings.TrimSpace(sso.Scope)) == 0 {
+		sso.Scope = DEFAULT_SCOPE
+	} else if !hasScope(sso.Scope, "openid") {
+		sso.Scope = "openid " + sso.Scope
+	}
+
+	return &sso, nil
+}
+
+func hasScope(scopes string, scope string) bool {
+	for _, s := range strings.Fields(scopes) {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetUserDataFromIdToken validates the ID token and returns its claims as json. Claims that
+// are only returned by the userinfo endpoint are added when the response is for the same subject.
+func (p *OpenIdProvider) GetUserDataFromIdToken(idToken string, userInfo io.Reader) ([]byte, *model.AppError) {
+	settings := utils.Cfg.OpenIdSettings
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	doc, err := p.discover(*settings.DiscoveryEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := p.validateIdToken(doc, settings.Id, idToken, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	if userInfo != nil {
+		var info map[string]interface{}
+		if json.NewDecoder(userInfo).Decode(&info) == nil && info["sub"] == claims["sub"] {
+			for name, value := range info {
+				if _, ok := claims[name]; !ok {
+					claims[name] = value
+				}
+			}
+		}
+	}
+
+	if b, err := json.Marshal(claims); err != nil {
+		return nil, model.NewLocAppError("OpenIdProvider.GetUserDataFromIdToken", "openid.validate_id_token.app_error", nil, err.Error())
+	} else {
+		return b, nil
+	}
+}
+
+func claimsFromJson(data io.Reader) map[string]interface{} {
+	var claims map[string]interface{}
+	if err := json.NewDecoder(data).Decode(&claims); err != nil {
+		return nil
+	}
+
+	return claims
+}
+
+func getClaim(claims map[string]interface{}, name string) string {
+	switch value := claims[name].(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	return ""
+}
+
+// isEmailVerified checks the standard email_verified claim, some providers send it as a string.
+func isEmailVerified(claims map[string]interface{}) bool {
+	switch value := claims["email_verified"].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+
+	return false
+}
+
+func userFromClaims(settings *model.OpenIdSettings, claims map[string]interface{}) *model.User {
+	user := &model.User{}
+
+	authData := getClaim(claims, *settings.IdClaim)
+	email := getClaim(claims, *settings.EmailClaim)
+	if len(authData) == 0 || len(email) == 0 {
+		return user
+	}
+
+	// anyone can claim an unverified email, which would let them take over the matching account
+	if *settings.RequireVerifiedEmail && !isEmailVerified(claims) {
+		return user
+	}
+
+	username := getClaim(claims, *settings.UsernameClaim)
+	if len(username) == 0 {
+		username = strings.Split(email, "@")[0]
+	}
+
+	user.Username = model.CleanUsername(username)
+	user.Email = email
+	user.FirstName = getClaim(claims, *settings.FirstNameClaim)
+	user.LastName = getClaim(claims, *settings.LastNameClaim)
+	user.Nickname = getClaim(claims, *settings.NicknameClaim)
+	user.AuthData = authData
+	user.AuthService = model.USER_AUTH_SERVICE_OPENID
+
+	return user
+}
+
+func (p *OpenIdProvider) GetUserFromJson(data io.Reader) *model.User {
+	return userFromClaims(&utils.Cfg.OpenIdSettings, claimsFromJson(data))
+}
+
+func (p *OpenIdProvider) GetAuthDataFromJson(data io.Reader) string {
+	return userFromClaims(&utils.Cfg.OpenIdSettings, claimsFromJson(data)).AuthData
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package openid
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+type testIdentityProvider struct {
+	server *httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func newTestIdentityProvider(t *testing.T) *testIdentityProvider {
+	idp := &testIdentityProvider{}
+
+	var err error
+	if idp.rsaKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatal(err)
+	}
+
+	if idp.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(DISCOVERY_PATH, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/auth",
+			"token_endpoint":         idp.server.URL + "/token",
+			"userinfo_endpoint":      idp.server.URL + "/userinfo",
+			"jwks_uri":               idp.server.URL + "/certs",
+		})
+	})
+	mux.HandleFunc("/certs", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{
+					"kty": "RSA",
+					"kid": "rsa1",
+					"use": "sig",
+					"n":   encodeSegment(idp.rsaKey.N.Bytes()),
+					"e":   encodeSegment(big.NewInt(int64(idp.rsaKey.E)).Bytes()),
+				},
+				{
+					"kty": "EC",
+					"kid": "ec1",
+					"crv": "P-256",
+					"x":   encodeSegment(idp.ecKey.X.Bytes()),
+					"y":   encodeSegment(idp.ecKey.Y.Bytes()),
+				},
+			},
+		})
+	})
+
+	idp.server = httptest.NewServer(mux)
+
+	return idp
+}
+
+func (idp *testIdentityProvider) sign(t *testing.T, alg string, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := encodeSegment(header) + "." + encodeSegment(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	if alg == "ES256" {
+		r, s, err := ecdsa.Sign(rand.Reader, idp.ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signature = make([]byte, 64)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(signature[32-len(rb):32], rb)
+		copy(signature[64-len(sb):], sb)
+	} else {
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, idp.rsaKey, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return signed + "." + encodeSegment(signature)
+}
+
+func (idp *testIdentityProvider) claims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":                idp.server.URL,
+		"aud":                "mattermost",
+		"sub":                "248289761001",
+		"exp":                time.Now().Unix() + 300,
+		"email":              "jane@example.com",
+		"email_verified":     true,
+		"preferred_username": "Jane.Doe",
+		"given_name":         "Jane",
+		"family_name":        "Doe",
+	}
+}
+
+func setupOpenId(idp *testIdentityProvider) {
+	utils.LoadConfig("config.json")
+
+	utils.Cfg.OpenIdSettings.Enable = true
+	utils.Cfg.OpenIdSettings.Id = "mattermost"
+	utils.Cfg.OpenIdSettings.Scope = ""
+	utils.Cfg.OpenIdSettings.AuthEndpoint = ""
+	utils.Cfg.OpenIdSettings.TokenEndpoint = ""
+	utils.Cfg.OpenIdSettings.UserApiEndpoint = ""
+	*utils.Cfg.OpenIdSettings.DiscoveryEndpoint = idp.server.URL
+}
+
+func TestOpenIdDiscovery(t *testing.T) {
+	idp := newTestIdentityProvider(t)
+	defer idp.server.Close()
+
+	setupOpenId(idp)
+	provider := &OpenIdProvider{}
+
+	if sso, err := provider.GetSSOSettings(); err != nil {
+		t.Fatal(err)
+	} else if sso.AuthEndpoint != idp.server.URL+"/auth" || sso.TokenEndpoint != idp.server.URL+"/token" || sso.UserApiEndpoint != idp.server.URL+"/userinfo" {
+		t.Fatal("should have filled in the endpoints from discovery", sso)
+	} else if sso.Scope != DEFAULT_SCOPE {
+		t.Fatal("should have requested the default scope", sso.Scope)
+	}
+
+	utils.Cfg.OpenIdSettings.Scope = "email"
+	utils.Cfg.OpenIdSettings.AuthEndpoint = "https://example.com/authorize"
+
+	if sso, err := provider.GetSSOSettings(); err != nil {
+		t.Fatal(err)
+	} else if sso.AuthEndpoint != "https://example.com/authorize" {
+		t.Fatal("should have kept the configured endpoint")
+	} else if sso.Scope != "openid email" {
+		t.Fatal("should have added the openid scope", sso.Scope)
+	}
+
+	*utils.Cfg.OpenIdSettings.DiscoveryEndpoint = idp.server.URL + "/missing"
+	if _, err := provider.GetSSOSettings(); err == nil {
+		t.Fatal("should have failed to discover the provider")
+	}
+}
+
+func TestOpenIdValidateIdToken(t *testing.T) {
+	idp := newTestIdentityProvider(t)
+	defer idp.server.Close()
+
+	setupOpenId(idp)
+	provider := &OpenIdProvider{}
+
+	idToken := idp.sign(t, "RS256", "rsa1", idp.claims())
+
+	data, err := provider.GetUserDataFromIdToken(idToken, strings.NewReader(`{"sub": "248289761001", "nickname": "jd", "email": "other@example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := provider.GetUserFromJson(strings.NewReader(string(data)))
+	if user.AuthService != model.USER_AUTH_SERVICE_OPENID || user.AuthData != "248289761001" {
+		t.Fatal("should have identified the user by the sub claim")
+	}
+
+	if user.Email != "jane@example.com" || user.Username != "jane.doe" || user.FirstName != "Jane" || user.LastName != "Doe" {
+		t.Fatal("should have mapped the claims", user)
+	}
+
+	if user.Nickname != "jd" {
+		t.Fatal("should have added the claims from the userinfo endpoint")
+	}
+
+	if authData := provider.GetAuthDataFromJson(strings.NewReader(string(data))); authData != "248289761001" {
+		t.Fatal("should have returned the auth data")
+	}
+
+	if _, err := provider.GetUserDataFromIdToken(idp.sign(t, "ES256", "ec1", idp.claims()), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(idToken, ".")
+	tampered := idp.claims()
+	tampered["sub"] = "1"
+	payload, _ := json.Marshal(tampered)
+	if _, err := provider.GetUserDataFromIdToken(parts[0]+"."+encodeSegment(payload)+"."+parts[2], nil); err == nil {
+		t.Fatal("should have failed - the signature doesn't match")
+	}
+
+	if _, err := provider.GetUserDataFromIdToken(idp.sign(t, "RS256", "unknown", idp.claims()), nil); err == nil {
+		t.Fatal("should have failed - unknown key")
+	}
+
+	if _, err := provider.GetUserDataFromIdToken(idp.sign(t, "none", "rsa1", idp.claims()), nil); err == nil {
+		t.Fatal("should have failed - unsigned token")
+	}
+
+	claims := idp.claims()
+	claims["aud"] = []string{"another", "client"}
+	if _, err := provider.GetUserDataFromIdToken(idp.sign(t, "RS256", "rsa1", claims), nil); err == nil {
+		t.Fatal("should have failed - wrong audience")
+	}
+
+	claims = idp.claims()
+	claims["iss"] = "https://evil.example.com"
+	if _, err := provider.GetUserDataFromIdToken(idp.sign(t, "RS256", "rsa1", claims), nil); err == nil {
+		t.Fatal("should have failed - wrong issuer")
+	}
+
+	claims = idp.claims()
+	claims["exp"] = time.Now().Unix() - 600
+	if _, err := provider.GetUserDataFromIdToken(idp.sign(t, "RS256", "rsa1", claims), nil); err == nil {
+		t.Fatal("should have failed - expired token")
+	}
+}
+
+func TestOpenIdClaimMapping(t *testing.T) {
+	config := &model.Config{}
+	config.SetDefaults()
+	settings := &config.OpenIdSettings
+
+	*settings.IdClaim = "oid"
+	*settings.UsernameClaim = "upn"
+
+	user := userFromClaims(settings, map[string]interface{}{"oid": float64(42), "email": "bob@example.com", "email_verified": "true"})
+	if user.AuthData != "42" || user.Username != "bob" {
+		t.Fatal("should have used the configured claims and the email for the username", user)
+	}
+
+	if user := userFromClaims(settings, map[string]interface{}{"sub": "42", "email": "bob@example.com"}); len(user.AuthData) != 0 {
+		t.Fatal("should have required the id claim")
+	}
+
+	if user := userFromClaims(settings, map[string]interface{}{"oid": "42", "email": "bob@example.com"}); len(user.AuthData) != 0 {
+		t.Fatal("should have required a verified email")
+	}
+
+	if user := userFromClaims(settings, map[string]interface{}{"oid": "42", "email": "bob@example.com", "email_verified": false}); len(user.AuthData) != 0 {
+		t.Fatal("should have required a verified email")
+	}
+
+	*settings.RequireVerifiedEmail = false
+
+	if user := userFromClaims(settings, map[string]interface{}{"oid": "42", "email": "bob@example.com"}); user.AuthData != "42" {
+		t.Fatal("shouldn't have required a verified email")
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	NS_ASSERTION = "urn:oasis:names:tc:SAML:2.0:assertion"
+	NS_PROTOCOL  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	NS_METADATA  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	BINDING_HTTP_POST    = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	STATUS_SUCCESS       = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	CONFIRMATION_BEARER  = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	NAMEID_FORMAT_UNSPEC = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+
+	// the clock skew allowed when checking the validity period of an assertion
+	CLOCK_SKEW = 60 * time.Second
+)
+
+// SamlProvider is the service provider side of SAML 2.0 web browser SSO. Requests are sent with
+// the HTTP-Redirect binding and signed responses are received with the HTTP-POST binding.
+//
+// The provider is also registered as an OauthProvider for the saml service so that the users it
+// returns can go through CreateOAuthUser and LoginByOAuth.
+type SamlProvider struct {
+	mutex sync.Mutex
+
+	// the ids of the assertions that were already used, until they expire
+	usedAssertions map[string]time.Time
+}
+
+func init() {
+	provider := &SamlProvider{usedAssertions: make(map[string]time.Time)}
+
+	einterfaces.RegisterSamlInterface(provider)
+	einterfaces.RegisterOauthProvider(model.USER_AUTH_SERVICE_SAML, provider)
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func loadCertificate(fileName string) (*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(utils.FindConfigFile(fileName))
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// BuildRequest returns the url that sends the user to the identity provider with an
+// AuthnRequest. The relay state is posted back unchanged with the response.
+func (p *SamlProvider) BuildRequest(relayState string) (string, *model.AppError) {
+	settings := utils.Cfg.SamlSettings
+
+	request := `<samlp:AuthnRequest xmlns:samlp="` + NS_PROTOCOL + `" xmlns:saml="` + NS_ASSERTION + `"` +
+		` ID="_` + model.NewId() + `" Version="2.0" IssueInstant="` + time.Now().UTC().Format(time.RFC3339) + `"` +
+		` Destination="` + xmlEscape(*settings.IdpUrl) + `"` +
+		` AssertionConsumerServiceURL="` + xmlEscape(*settings.AssertionConsumerServiceURL) + `"` +
+		` ProtocolBinding="` + BINDING_HTTP_POST + `">` +
+		`<saml:Issuer>` + xmlEscape(*settings.AssertionConsumerServiceURL) + `</saml:Issuer>` +
+		`<samlp:NameIDPolicy Format="` + NAMEID_FORMAT_UNSPEC + `" AllowCreate="true"/>` +
+		`</samlp:AuthnRequest>`
+
+	var buf bytes.Buffer
+	writer, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	writer.Write([]byte(request))
+	writer.Close()
+
+	query := url.Values{}
+	query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if len(relayState) > 0 {
+		query.Set("RelayState", relayState)
+	}
+
+	separator := "?"
+	if strings.Contains(*settings.IdpUrl, "?") {
+		separator = "&"
+	}
+
+	return *settings.IdpUrl + separator + query.Encode(), nil
+}
+
+// GetMetadata returns the metadata of the service provider to be uploaded to the identity provider.
+func (p *SamlProvider) GetMetadata() (string, *model.AppError) {
+	acs := xmlEscape(*utils.Cfg.SamlSettings.AssertionConsumerServiceURL)
+
+	return xml.Header +
+		`<md:EntityDescriptor xmlns:md="` + NS_METADATA + `" entityID="` + acs + `">` + "\n" +
+		`  <md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="` + NS_PROTOCOL + `">` + "\n" +
+		`    <md:NameIDFormat>` + NAMEID_FORMAT_UNSPEC + `</md:NameIDFormat>` + "\n" +
+		`    <md:AssertionConsumerService Binding="` + BINDING_HTTP_POST + `" Location="` + acs + `" index="0"/>` + "\n" +
+		`  </md:SPSSODescriptor>` + "\n" +
+		`</md:EntityDescriptor>` + "\n", nil
+}
+
+// DoLogin validates the base64 encoded response posted by the identity provider and returns the
+// user described by its assertion.
+func (p *SamlProvider) DoLogin(encodedXML string) (*model.User, *model.AppError) {
+	settings := &utils.Cfg.SamlSettings
+
+	cert, err := loadCertificate(*settings.IdpCertificateFile)
+	if err != nil {
+		return nil, model.NewLocAppError("SamlProvider.DoLogin", "saml.certificate.app_error", nil, err.Error())
+	}
+
+	data, err := decodeBase64(encodedXML)
+	if err != nil {
+		return nil, model.NewLocAppError("SamlProvider.DoLogin", "saml.response.app_error", nil, err.Error())
+	}
+
+	return p.parseResponse(settings, cert, data, time.Now())
+}
+
+// parseResponse checks the response against the settings at the given time. Everything that is
+// returned is read from the signed assertion, never from the rest of the document.
+func (p *SamlProvider) parseResponse(settings *model.SamlSettings, cert *x509.Certificate, data []byte, now time.Time) (*model.User, *model.AppError) {
+	assertion, err := getSignedAssertion(cert, data)
+	if err != nil {
+		return nil, model.NewLocAppError("SamlProvider.parseResponse", "saml.response.app_error", nil, err.Error())
+	}
+
+	nameId, expiry, err := checkAssertion(settings, assertion, now)
+	if err != nil {
+		return nil, model.NewLocAppError("SamlProvider.parseResponse", "saml.assertion.app_error", nil, err.Error())
+	}
+
+	user := userFromAssertion(settings, assertion, nameId)
+	if len(user.AuthData) == 0 || len(user.Email) == 0 {
+		return nil, model.NewLocAppError("SamlProvider.parseResponse", "saml.attributes.app_error", nil, "")
+	}
+
+	if !p.useAssertion(assertion.attr("ID"), expiry, now) {
+		return nil, model.NewLocAppError("SamlProvider.parseResponse", "saml.assertion.replayed.app_error", nil, "id="+assertion.attr("ID"))
+	}
+
+	return user, nil
+}
+
+// useAssertion marks the assertion as used and returns false if it already was.
+func (p *SamlProvider) useAssertion(id string, expiry time.Time, now time.Time) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for usedId, usedExpiry := range p.usedAssertions {
+		if usedExpiry.Add(CLOCK_SKEW).Before(now) {
+			delete(p.usedAssertions, usedId)
+		}
+	}
+
+	if _, ok := p.usedAssertions[id]; ok {
+		return false
+	}
+
+	p.usedAssertions[id] = expiry
+	return true
+}
+
+// getSignedAssertion returns the only assertion of a successful response, making sure that it's
+// signed itself or covered by the signature of the response.
+func getSignedAssertion(cert *x509.Certificate, data []byte) (*xmlNode, error) {
+	root, err := parseXml(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !root.is(NS_PROTOCOL, "Response") {
+		return nil, errors.New("the document isn't a SAML response")
+	}
+
+	if err := checkUniqueIds(root); err != nil {
+		return nil, err
+	}
+
+	status := root.child(NS_PROTOCOL, "Status")
+	if status == nil || status.child(NS_PROTOCOL, "StatusCode") == nil || status.child(NS_PROTOCOL, "StatusCode").attr("Value") != STATUS_SUCCESS {
+		return nil, errors.New("the identity provider didn't return a successful response")
+	}
+
+	if root.child(NS_ASSERTION, "EncryptedAssertion") != nil {
+		return nil, errors.New("encrypted assertions aren't supported")
+	}
+
+	assertions := root.childrenNamed(NS_ASSERTION, "Assertion")
+	if len(assertions) != 1 {
+		return nil, errors.New("the response must contain exactly one assertion")
+	}
+	assertion := assertions[0]
+
+	if root.child(NS_DSIG, "Signature") != nil {
+		if err := verifySignature(root, cert); err != nil {
+			return nil, err
+		}
+	} else if err := verifySignature(assertion, cert); err != nil {
+		return nil, err
+	}
+
+	return assertion, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339, value)
+}
+
+// checkAssertion makes sure that the assertion was issued by the identity provider for this
+// service provider and that it's valid at the given time. The subject's NameID is returned along
+// with the time the assertion expires at.
+func checkAssertion(settings *model.SamlSettings, assertion *xmlNode, now time.Time) (string, time.Time, error) {
+	var expiry time.Time
+
+	if issuer := assertion.child(NS_ASSERTION, "Issuer"); issuer == nil || issuer.text() != *settings.IdpDescriptorUrl {
+		return "", expiry, errors.New("unexpected issuer")
+	}
+
+	conditions := assertion.child(NS_ASSERTION, "Conditions")
+	if conditions == nil {
+		return "", expiry, errors.New("missing conditions")
+	}
+
+	if value := conditions.attr("NotBefore"); len(value) > 0 {
+		if notBefore, err := parseTime(value); err != nil {
+			return "", expiry, err
+		} else if now.Add(CLOCK_SKEW).Before(notBefore) {
+			return "", expiry, errors.New("the assertion isn't valid yet")
+		}
+	}
+
+	if notOnOrAfter, err := parseTime(conditions.attr("NotOnOrAfter")); err != nil {
+		return "", expiry, err
+	} else if !now.Add(-CLOCK_SKEW).Before(notOnOrAfter) {
+		return "", expiry, errors.New("the assertion has expired")
+	} else {
+		expiry = notOnOrAfter
+	}
+
+	found := false
+	for _, restriction := range conditions.childrenNamed(NS_ASSERTION, "AudienceRestriction") {
+		for _, audience := range restriction.childrenNamed(NS_ASSERTION, "Audience") {
+			if audience.text() == *settings.AssertionConsumerServiceURL {
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		return "", expiry, errors.New("the assertion wasn't issued for this service provider")
+	}
+
+	subject := assertion.child(NS_ASSERTION, "Subject")
+	if subject == nil || subject.child(NS_ASSERTION, "NameID") == nil {
+		return "", expiry, errors.New("missing subject")
+	}
+
+	confirmed := false
+	for _, confirmation := range subject.childrenNamed(NS_ASSERTION, "SubjectConfirmation") {
+		data := confirmation.child(NS_ASSERTION, "SubjectConfirmationData")
+		if confirmation.attr("Method") != CONFIRMATION_BEARER || data == nil {
+			continue
+		}
+
+		if recipient := data.attr("Recipient"); len(recipient) > 0 && recipient != *settings.AssertionConsumerServiceURL {
+			continue
+		}
+
+		if notOnOrAfter, err := parseTime(data.attr("NotOnOrAfter")); err != nil || !now.Add(-CLOCK_SKEW).Before(notOnOrAfter) {
+			continue
+		}
+
+		confirmed = true
+	}
+
+	if !confirmed {
+		return "", expiry, errors.New("the subject couldn't be confirmed")
+	}
+
+	return subject.child(NS_ASSERTION, "NameID").text(), expiry, nil
+}
+
+// getAttributes returns the values of the assertion's attributes by name. Attributes can be
+// mapped by their name or by their friendly name.
+func getAttributes(assertion *xmlNode) map[string]string {
+	attributes := make(map[string]string)
+
+	for _, statement := range assertion.childrenNamed(NS_ASSERTION, "AttributeStatement") {
+		for _, attribute := range statement.childrenNamed(NS_ASSERTION, "Attribute") {
+			value := ""
+			if values := attribute.childrenNamed(NS_ASSERTION, "AttributeValue"); len(values) > 0 {
+				value = values[0].text()
+			}
+
+			if name := attribute.attr("FriendlyName"); len(name) > 0 {
+				attributes[name] = value
+			}
+
+			attributes[attribute.attr("Name")] = value
+		}
+	}
+
+	return attributes
+}
+
+func userFromAssertion(settings *model.SamlSettings, assertion *xmlNode, nameId string) *model.User {
+	attributes := getAttributes(assertion)
+
+	user := &model.User{}
+
+	authData := nameId
+	if len(*settings.IdAttribute) > 0 {
+		authData = attributes[*settings.IdAttribute]
+	}
+
+	email := attributes[*settings.EmailAttribute]
+	if len(authData) == 0 || len(email) == 0 {
+		return user
+	}
+
+	username := ""
+	if len(*settings.UsernameAttribute) > 0 {
+		username = attributes[*settings.UsernameAttribute]
+	}
+	if len(username) == 0 {
+		username = strings.Split(email, "@")[0]
+	}
+
+	user.Username = model.CleanUsername(username)
+	user.Email = email
+	user.AuthData = authData
+	user.AuthService = model.USER_AUTH_SERVICE_SAML
+
+	if len(*settings.FirstNameAttribute) > 0 {
+		user.FirstName = attributes[*settings.FirstNameAttribute]
+	}
+
+	if len(*settings.LastNameAttribute) > 0 {
+		user.LastName = attributes[*settings.LastNameAttribute]
+	}
+
+	if len(*settings.NicknameAttribute) > 0 {
+		user.Nickname = attributes[*settings.NicknameAttribute]
+	}
+
+	return user
+}
+
+func (p *SamlProvider) GetIdentifier() string {
+	return model.USER_AUTH_SERVICE_SAML
+}
+
+// GetUserFromJson reads back a user returned by DoLogin.
+func (p *SamlProvider) GetUserFromJson(data io.Reader) *model.User {
+	if user := model.UserFromJson(data); user != nil && user.AuthService == model.USER_AUTH_SERVICE_SAML {
+		return user
+	}
+
+	return nil
+}
+
+func (p *SamlProvider) GetAuthDataFromJson(data io.Reader) string {
+	if user := p.GetUserFromJson(data); user != nil {
+		return user.AuthData
+	}
+
+	return ""
+}
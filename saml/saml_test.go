@@ -0,0 +1,208 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// testdata/response.xml is a response signed by the key of testdata/idp.crt and
+// testdata/assertion.c14n is the canonical form of its assertion that was signed.
+var fixtureTime = time.Date(2016, 10, 1, 10, 1, 0, 0, time.UTC)
+
+func setupSaml(t *testing.T) *model.SamlSettings {
+	utils.LoadConfig("config.json")
+
+	settings := &utils.Cfg.SamlSettings
+	*settings.Enable = true
+	*settings.IdpUrl = "https://idp.example.com/sso"
+	*settings.IdpDescriptorUrl = "https://idp.example.com/metadata"
+	*settings.AssertionConsumerServiceURL = "https://mattermost.example.com/login/sso/saml"
+	*settings.IdpCertificateFile = "testdata/idp.crt"
+	*settings.EmailAttribute = "mail"
+	*settings.FirstNameAttribute = "givenName"
+	*settings.LastNameAttribute = "urn:oid:2.5.4.4"
+	*settings.UsernameAttribute = "uid"
+	*settings.NicknameAttribute = ""
+	*settings.IdAttribute = ""
+
+	return settings
+}
+
+func readFixture(t *testing.T) string {
+	data, err := ioutil.ReadFile("testdata/response.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func newTestProvider() *SamlProvider {
+	return &SamlProvider{usedAssertions: make(map[string]time.Time)}
+}
+
+func TestSamlCanonicalize(t *testing.T) {
+	expected, err := ioutil.ReadFile("testdata/assertion.c14n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := parseXml([]byte(readFixture(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertion := root.child(NS_ASSERTION, "Assertion")
+	if canonical := canonicalize(assertion, assertion.child(NS_DSIG, "Signature"), []string{"xs"}); string(canonical) != string(expected) {
+		t.Fatal("should have matched the canonical form", string(canonical))
+	}
+
+	root, _ = parseXml([]byte(`<a:b xmlns:a="urn:a" xmlns="urn:default" z="1" a:y="&#x9;2"><c attr='"'/><a:d>&lt;&amp;&gt;&#xD;</a:d></a:b>`))
+	if canonical := string(canonicalize(root, nil, nil)); canonical != `<a:b xmlns:a="urn:a" z="1" a:y="&#x9;2"><c xmlns="urn:default" attr="&quot;"></c><a:d>&lt;&amp;&gt;&#xD;</a:d></a:b>` {
+		t.Fatal("should have canonicalized the document", canonical)
+	}
+
+	if _, err := parseXml([]byte(`<!DOCTYPE a [<!ENTITY b "c">]><a>&b;</a>`)); err == nil {
+		t.Fatal("should have refused the DTD")
+	}
+}
+
+func TestSamlDoLogin(t *testing.T) {
+	settings := setupSaml(t)
+	cert, err := loadCertificate(*settings.IdpCertificateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixture := readFixture(t)
+	provider := newTestProvider()
+
+	user, appErr := provider.parseResponse(settings, cert, []byte(fixture), fixtureTime)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	if user.AuthService != model.USER_AUTH_SERVICE_SAML || user.AuthData != "jdoe" {
+		t.Fatal("should have identified the user by the NameID", user)
+	}
+
+	if user.Email != "jane@example.com" || user.Username != "jane.doe" || user.FirstName != "Jane" || user.LastName != "O'Brien & Doe" {
+		t.Fatal("should have mapped the attributes", user)
+	}
+
+	if _, err := provider.parseResponse(settings, cert, []byte(fixture), fixtureTime); err == nil {
+		t.Fatal("should have failed - the assertion was already used")
+	}
+
+	if authData := provider.GetAuthDataFromJson(strings.NewReader(user.ToJson())); authData != "jdoe" {
+		t.Fatal("should have read the user back")
+	}
+
+	*settings.IdAttribute = "employeeNumber"
+	if user, err := newTestProvider().parseResponse(settings, cert, []byte(fixture), fixtureTime); err != nil {
+		t.Fatal(err)
+	} else if user.AuthData != "4242" {
+		t.Fatal("should have used the id attribute")
+	}
+	*settings.IdAttribute = ""
+
+	if _, err := newTestProvider().DoLogin(base64.StdEncoding.EncodeToString([]byte(fixture))); err == nil {
+		t.Fatal("should have failed - the fixture has expired")
+	}
+
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		message string
+	}{
+		{"tampered", "<saml:AttributeValue xsi:type=\"xs:string\">jane@example.com", "<saml:AttributeValue xsi:type=\"xs:string\">admin@example.com", "the digest doesn't match"},
+		{"reference", `URI="#_assertion1"`, `URI="#_response1"`, "the reference doesn't point at the assertion"},
+		{"wrapped", "</samlp:Response>", `<saml:Assertion ID="_evil" Version="2.0"><saml:Issuer>https://idp.example.com/metadata</saml:Issuer></saml:Assertion></samlp:Response>`, "the response has two assertions"},
+		{"duplicate", `ID="_response1"`, `ID="_assertion1"`, "the ID isn't unique"},
+		{"status", "status:Success", "status:Requester", "the status isn't successful"},
+	}
+
+	for _, test := range tests {
+		data := strings.Replace(fixture, test.old, test.new, 1)
+		if _, err := newTestProvider().parseResponse(settings, cert, []byte(data), fixtureTime); err == nil {
+			t.Fatal("should have failed - "+test.message, test.name)
+		}
+	}
+
+	unsigned := fixture[:strings.Index(fixture, "<ds:Signature")] + fixture[strings.Index(fixture, "</ds:Signature>")+len("</ds:Signature>"):]
+	if _, err := newTestProvider().parseResponse(settings, cert, []byte(unsigned), fixtureTime); err == nil {
+		t.Fatal("should have failed - the assertion isn't signed")
+	}
+
+	if _, err := newTestProvider().parseResponse(settings, cert, []byte(fixture), fixtureTime.Add(10*time.Minute)); err == nil {
+		t.Fatal("should have failed - expired assertion")
+	}
+
+	if _, err := newTestProvider().parseResponse(settings, cert, []byte(fixture), fixtureTime.Add(-10*time.Minute)); err == nil {
+		t.Fatal("should have failed - assertion not valid yet")
+	}
+
+	*settings.AssertionConsumerServiceURL = "https://other.example.com/login/sso/saml"
+	if _, err := newTestProvider().parseResponse(settings, cert, []byte(fixture), fixtureTime); err == nil {
+		t.Fatal("should have failed - wrong audience")
+	}
+
+	settings = setupSaml(t)
+	*settings.IdpDescriptorUrl = "https://evil.example.com/metadata"
+	if _, err := newTestProvider().parseResponse(settings, cert, []byte(fixture), fixtureTime); err == nil {
+		t.Fatal("should have failed - wrong issuer")
+	}
+
+	settings = setupSaml(t)
+	*settings.EmailAttribute = "email"
+	if _, err := newTestProvider().parseResponse(settings, cert, []byte(fixture), fixtureTime); err == nil {
+		t.Fatal("should have failed - missing email attribute")
+	}
+}
+
+func TestSamlRequestAndMetadata(t *testing.T) {
+	setupSaml(t)
+	provider := newTestProvider()
+
+	redirect, err := provider.BuildRequest("state")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := url.Parse(redirect)
+	if u.Host != "idp.example.com" || u.Query().Get("RelayState") != "state" {
+		t.Fatal("should have redirected to the identity provider", redirect)
+	}
+
+	compressed, _ := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+	request, _ := ioutil.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if root, err := parseXml(request); err != nil {
+		t.Fatal(err)
+	} else if !root.is(NS_PROTOCOL, "AuthnRequest") || root.attr("AssertionConsumerServiceURL") != *utils.Cfg.SamlSettings.AssertionConsumerServiceURL {
+		t.Fatal("should have built an AuthnRequest", string(request))
+	}
+
+	metadata, err := provider.GetMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if root, err := parseXml([]byte(metadata)); err != nil {
+		t.Fatal(err)
+	} else if !root.is(NS_METADATA, "EntityDescriptor") || root.attr("entityID") != *utils.Cfg.SamlSettings.AssertionConsumerServiceURL {
+		t.Fatal("should have described the service provider", metadata)
+	}
+}
@@ -0,0 +1,437 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	NS_XML       = "http://www.w3.org/XML/1998/namespace"
+	NS_DSIG      = "http://www.w3.org/2000/09/xmldsig#"
+	NS_EXC_C14N  = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	ALG_ENVELOPE = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+)
+
+var digestAlgorithms = map[string]crypto.Hash{
+	"http://www.w3.org/2001/04/xmlenc#sha256": crypto.SHA256,
+	"http://www.w3.org/2001/04/xmlenc#sha512": crypto.SHA512,
+}
+
+var signatureAlgorithms = map[string]crypto.Hash{
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha256": crypto.SHA256,
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha512": crypto.SHA512,
+}
+
+type xmlAttr struct {
+	prefix string
+	local  string
+	value  string
+}
+
+// xmlNode is an element of a parsed document. Prefixes are kept as they were written so that
+// the element can be canonicalized again to check its signature.
+type xmlNode struct {
+	parent     *xmlNode
+	prefix     string
+	local      string
+	attrs      []xmlAttr
+	namespaces map[string]string
+
+	// either *xmlNode or string for character data
+	children []interface{}
+}
+
+func parseXml(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var root, current *xmlNode
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root != nil && current == nil {
+				return nil, errors.New("more than one root element")
+			}
+
+			node := &xmlNode{parent: current, prefix: t.Name.Space, local: t.Name.Local, namespaces: make(map[string]string)}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" {
+					node.namespaces[a.Name.Local] = a.Value
+				} else if a.Name.Space == "" && a.Name.Local == "xmlns" {
+					node.namespaces[""] = a.Value
+				} else {
+					node.attrs = append(node.attrs, xmlAttr{prefix: a.Name.Space, local: a.Name.Local, value: a.Value})
+				}
+			}
+
+			if current == nil {
+				root = node
+			} else {
+				current.children = append(current.children, node)
+			}
+			current = node
+		case xml.EndElement:
+			if current == nil || current.prefix != t.Name.Space || current.local != t.Name.Local {
+				return nil, fmt.Errorf("unexpected end element %v", t.Name.Local)
+			}
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, string(t))
+			}
+		case xml.Directive:
+			// a DTD could be used to expand entities inside the signed content
+			return nil, errors.New("directives aren't allowed")
+		}
+	}
+
+	if root == nil || current != nil {
+		return nil, errors.New("incomplete document")
+	}
+
+	return root, nil
+}
+
+// lookupNamespace returns the namespace bound to the prefix in the scope of the node.
+func (n *xmlNode) lookupNamespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return NS_XML, true
+	}
+
+	for node := n; node != nil; node = node.parent {
+		if uri, ok := node.namespaces[prefix]; ok {
+			return uri, true
+		}
+	}
+
+	return "", false
+}
+
+func (n *xmlNode) namespace() string {
+	uri, _ := n.lookupNamespace(n.prefix)
+	return uri
+}
+
+func (n *xmlNode) is(namespace string, local string) bool {
+	return n.local == local && n.namespace() == namespace
+}
+
+func (n *xmlNode) attr(local string) string {
+	for _, a := range n.attrs {
+		if a.prefix == "" && a.local == local {
+			return a.value
+		}
+	}
+
+	return ""
+}
+
+func (n *xmlNode) childrenNamed(namespace string, local string) []*xmlNode {
+	nodes := []*xmlNode{}
+	for _, child := range n.children {
+		if node, ok := child.(*xmlNode); ok && node.is(namespace, local) {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes
+}
+
+// child returns the first child element with the given name or nil.
+func (n *xmlNode) child(namespace string, local string) *xmlNode {
+	if nodes := n.childrenNamed(namespace, local); len(nodes) > 0 {
+		return nodes[0]
+	}
+
+	return nil
+}
+
+// text returns the character data directly inside the element.
+func (n *xmlNode) text() string {
+	text := ""
+	for _, child := range n.children {
+		if s, ok := child.(string); ok {
+			text += s
+		}
+	}
+
+	return strings.TrimSpace(text)
+}
+
+func (n *xmlNode) walk(f func(*xmlNode)) {
+	f(n)
+	for _, child := range n.children {
+		if node, ok := child.(*xmlNode); ok {
+			node.walk(f)
+		}
+	}
+}
+
+// checkUniqueIds makes sure that a reference to an ID can't point at two different elements.
+func checkUniqueIds(root *xmlNode) error {
+	ids := make(map[string]bool)
+
+	var err error
+	root.walk(func(n *xmlNode) {
+		if id := n.attr("ID"); len(id) > 0 {
+			if ids[id] {
+				err = fmt.Errorf("duplicate ID %v", id)
+			}
+			ids[id] = true
+		}
+	})
+
+	return err
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+var attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", "\"", "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+
+type canonicalAttr struct {
+	namespace string
+	name      string
+	value     string
+	local     string
+}
+
+// canonicalAttrs sort by namespace uri first and then by local name.
+type canonicalAttrs []canonicalAttr
+
+func (a canonicalAttrs) Len() int      { return len(a) }
+func (a canonicalAttrs) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a canonicalAttrs) Less(i, j int) bool {
+	if a[i].namespace != a[j].namespace {
+		return a[i].namespace < a[j].namespace
+	}
+	return a[i].local < a[j].local
+}
+
+func qualifiedName(prefix string, local string) string {
+	if len(prefix) == 0 {
+		return local
+	}
+
+	return prefix + ":" + local
+}
+
+// canonicalize returns the node in exclusive canonical form without comments, leaving out the
+// excluded descendant. The inclusive prefixes are the PrefixList of the transform.
+func canonicalize(n *xmlNode, excluded *xmlNode, inclusive []string) []byte {
+	prefixes := make(map[string]bool)
+	for _, p := range inclusive {
+		if p == "#default" {
+			p = ""
+		}
+		prefixes[p] = true
+	}
+
+	var buf bytes.Buffer
+	writeCanonical(&buf, n, excluded, map[string]string{}, prefixes)
+	return buf.Bytes()
+}
+
+func writeCanonical(buf *bytes.Buffer, n *xmlNode, excluded *xmlNode, rendered map[string]string, inclusive map[string]bool) {
+	// only the namespaces that are visibly utilized are output, unless they're listed as inclusive
+	used := map[string]bool{n.prefix: true}
+	for _, a := range n.attrs {
+		if len(a.prefix) > 0 {
+			used[a.prefix] = true
+		}
+	}
+	for p := range inclusive {
+		if _, ok := n.lookupNamespace(p); ok {
+			used[p] = true
+		}
+	}
+
+	declared := []string{}
+	scope := make(map[string]string)
+	for p, uri := range rendered {
+		scope[p] = uri
+	}
+
+	for p := range used {
+		if p == "xml" {
+			continue
+		}
+
+		uri, ok := n.lookupNamespace(p)
+		if !ok && len(p) > 0 {
+			continue
+		}
+
+		if previous, ok := rendered[p]; previous == uri && (ok || len(p) == 0) {
+			continue
+		}
+
+		declared = append(declared, p)
+		scope[p] = uri
+	}
+	sort.Strings(declared)
+
+	attrs := make([]canonicalAttr, 0, len(n.attrs))
+	for _, a := range n.attrs {
+		namespace := ""
+		if len(a.prefix) > 0 {
+			namespace, _ = n.lookupNamespace(a.prefix)
+		}
+		attrs = append(attrs, canonicalAttr{namespace: namespace, name: qualifiedName(a.prefix, a.local), value: a.value, local: a.local})
+	}
+	sort.Sort(canonicalAttrs(attrs))
+
+	name := qualifiedName(n.prefix, n.local)
+
+	buf.WriteString("<" + name)
+	for _, p := range declared {
+		if len(p) == 0 {
+			buf.WriteString(" xmlns=\"" + attrEscaper.Replace(scope[p]) + "\"")
+		} else {
+			buf.WriteString(" xmlns:" + p + "=\"" + attrEscaper.Replace(scope[p]) + "\"")
+		}
+	}
+	for _, a := range attrs {
+		buf.WriteString(" " + a.name + "=\"" + attrEscaper.Replace(a.value) + "\"")
+	}
+	buf.WriteString(">")
+
+	for _, child := range n.children {
+		switch c := child.(type) {
+		case string:
+			buf.WriteString(textEscaper.Replace(c))
+		case *xmlNode:
+			if c != excluded {
+				writeCanonical(buf, c, excluded, scope, inclusive)
+			}
+		}
+	}
+
+	buf.WriteString("</" + name + ">")
+}
+
+func decodeBase64(value string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+}
+
+func inclusivePrefixes(n *xmlNode) []string {
+	if list := n.child(NS_EXC_C14N, "InclusiveNamespaces"); list != nil {
+		return strings.Fields(list.attr("PrefixList"))
+	}
+
+	return nil
+}
+
+// verifySignature checks the enveloped signature of the element against the certificate. Only
+// a single reference to the element itself is accepted, so the signature can't cover anything else.
+func verifySignature(n *xmlNode, cert *x509.Certificate) error {
+	signature := n.child(NS_DSIG, "Signature")
+	if signature == nil {
+		return errors.New("the element isn't signed")
+	}
+
+	signedInfo := signature.child(NS_DSIG, "SignedInfo")
+	if signedInfo == nil {
+		return errors.New("missing SignedInfo")
+	}
+
+	method := signedInfo.child(NS_DSIG, "CanonicalizationMethod")
+	if method == nil || method.attr("Algorithm") != NS_EXC_C14N {
+		return errors.New("unsupported canonicalization method")
+	}
+
+	signatureMethod := signedInfo.child(NS_DSIG, "SignatureMethod")
+	if signatureMethod == nil {
+		return errors.New("missing SignatureMethod")
+	}
+
+	signatureHash, ok := signatureAlgorithms[signatureMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("unsupported signature method %v", signatureMethod.attr("Algorithm"))
+	}
+
+	references := signedInfo.childrenNamed(NS_DSIG, "Reference")
+	if len(references) != 1 {
+		return errors.New("the signature must have exactly one reference")
+	}
+	reference := references[0]
+
+	if id := n.attr("ID"); len(id) == 0 || reference.attr("URI") != "#"+id {
+		return errors.New("the signature doesn't reference the element")
+	}
+
+	var prefixes []string
+	if transforms := reference.child(NS_DSIG, "Transforms"); transforms != nil {
+		for _, transform := range transforms.childrenNamed(NS_DSIG, "Transform") {
+			switch transform.attr("Algorithm") {
+			case ALG_ENVELOPE:
+			case NS_EXC_C14N:
+				prefixes = inclusivePrefixes(transform)
+			default:
+				return fmt.Errorf("unsupported transform %v", transform.attr("Algorithm"))
+			}
+		}
+	}
+
+	digestMethod := reference.child(NS_DSIG, "DigestMethod")
+	digestValue := reference.child(NS_DSIG, "DigestValue")
+	if digestMethod == nil || digestValue == nil {
+		return errors.New("missing digest")
+	}
+
+	digestHash, ok := digestAlgorithms[digestMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("unsupported digest method %v", digestMethod.attr("Algorithm"))
+	}
+
+	expected, err := decodeBase64(digestValue.text())
+	if err != nil {
+		return err
+	}
+
+	h := digestHash.New()
+	h.Write(canonicalize(n, signature, prefixes))
+	if subtle.ConstantTimeCompare(h.Sum(nil), expected) != 1 {
+		return errors.New("the digest doesn't match")
+	}
+
+	signatureValue := signature.child(NS_DSIG, "SignatureValue")
+	if signatureValue == nil {
+		return errors.New("missing SignatureValue")
+	}
+
+	value, err := decodeBase64(signatureValue.text())
+	if err != nil {
+		return err
+	}
+
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("the certificate doesn't have an RSA key")
+	}
+
+	h = signatureHash.New()
+	h.Write(canonicalize(signedInfo, nil, inclusivePrefixes(method)))
+
+	return rsa.VerifyPKCS1v15(key, signatureHash, h.Sum(nil), value)
+}
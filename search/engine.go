@@ -0,0 +1,382 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package search
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	INDEX_FILE_NAME = "posts.idx"
+	LOCK_FILE_NAME  = "posts.lock"
+
+	// the log is rewritten on open once it holds this many more entries than live documents
+	INDEX_COMPACT_THRESHOLD = 1000
+)
+
+// logEntry is a single line of the on disk index. The index is an append only log of
+// indexed and deleted posts that is replayed into memory when the engine is opened.
+type logEntry struct {
+	Post    *document `json:"post,omitempty"`
+	Deleted string    `json:"deleted,omitempty"`
+}
+
+// LocalSearchEngine is the default SearchEngine. It keeps an inverted index of posts in
+// memory and persists it to a log file in SearchSettings.Directory. The index only sees the
+// posts made through the server that owns it, so it can only be used by a single server
+// and not by a cluster. Only one process can have the index open at a time.
+type LocalSearchEngine struct {
+	mutex     sync.Mutex
+	directory string
+	index     *index
+	lock      *os.File
+	file      *os.File
+	writer    *bufio.Writer
+}
+
+func init() {
+	einterfaces.RegisterSearchEngine(&LocalSearchEngine{})
+}
+
+// NewLocalSearchEngine returns an engine that stores its index in the given directory
+// instead of the one from the config.
+func NewLocalSearchEngine(directory string) *LocalSearchEngine {
+	return &LocalSearchEngine{directory: directory}
+}
+
+// open loads the index from disk the first time it is needed. The caller must hold the mutex.
+func (e *LocalSearchEngine) open() *model.AppError {
+	if e.index != nil {
+		return nil
+	}
+
+	directory := e.directory
+	if len(directory) == 0 {
+		directory = *utils.Cfg.SearchSettings.Directory
+	}
+
+	if err := os.MkdirAll(directory, 0774); err != nil {
+		return model.NewLocAppError("LocalSearchEngine.open", "search.local.open.app_error", nil, err.Error())
+	}
+
+	lock, err := os.OpenFile(filepath.Join(directory, LOCK_FILE_NAME), os.O_CREATE|os.O_RDWR, 0664)
+	if err != nil {
+		return model.NewLocAppError("LocalSearchEngine.open", "search.local.open.app_error", nil, err.Error())
+	}
+
+	// keep the reindex command from rewriting the index while a server is using it
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return model.NewLocAppError("LocalSearchEngine.open", "search.local.open.locked.app_error", nil, err.Error())
+	}
+
+	if err := e.load(directory); err != nil {
+		lock.Close()
+		return err
+	}
+
+	e.lock = lock
+
+	return nil
+}
+
+// load replays the log into memory and opens it for appending. The caller must hold the lock file.
+func (e *LocalSearchEngine) load(directory string) *model.AppError {
+	path := filepath.Join(directory, INDEX_FILE_NAME)
+	idx := newIndex()
+	entries := 0
+
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+		for scanner.Scan() {
+			var entry logEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				l4g.Warn(utils.T("search.local.open.skip_entry.warn"), err.Error())
+				continue
+			}
+
+			if entry.Post != nil {
+				idx.add(entry.Post)
+			} else if len(entry.Deleted) > 0 {
+				idx.remove(entry.Deleted)
+			}
+			entries++
+		}
+
+		f.Close()
+
+		if err := scanner.Err(); err != nil {
+			return model.NewLocAppError("LocalSearchEngine.open", "search.local.open.app_error", nil, err.Error())
+		}
+	} else if !os.IsNotExist(err) {
+		return model.NewLocAppError("LocalSearchEngine.open", "search.local.open.app_error", nil, err.Error())
+	}
+
+	if entries > len(idx.docs)+INDEX_COMPACT_THRESHOLD {
+		if err := compact(path, idx); err != nil {
+			return model.NewLocAppError("LocalSearchEngine.open", "search.local.open.app_error", nil, err.Error())
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0664)
+	if err != nil {
+		return model.NewLocAppError("LocalSearchEngine.open", "search.local.open.app_error", nil, err.Error())
+	}
+
+	e.index = idx
+	e.file = f
+	e.writer = bufio.NewWriter(f)
+
+	return nil
+}
+
+// compact rewrites the log so that it only holds the live documents.
+func compact(path string, idx *index) error {
+	tmpPath := path + ".tmp"
+
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(f)
+	for _, doc := range idx.docs {
+		if err := writeEntry(writer, &logEntry{Post: doc}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func writeEntry(writer *bufio.Writer, entry *logEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.Write(append(b, '\n')); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (e *LocalSearchEngine) append(entry *logEntry) *model.AppError {
+	if err := writeEntry(e.writer, entry); err != nil {
+		return model.NewLocAppError("LocalSearchEngine.append", "search.local.write.app_error", nil, err.Error())
+	}
+
+	if err := e.writer.Flush(); err != nil {
+		return model.NewLocAppError("LocalSearchEngine.append", "search.local.write.app_error", nil, err.Error())
+	}
+
+	return nil
+}
+
+func (e *LocalSearchEngine) IndexPost(post *model.Post) *model.AppError {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if err := e.open(); err != nil {
+		return err
+	}
+
+	// system messages are never returned by search so there's no point in indexing them
+	if strings.HasPrefix(post.Type, model.POST_SYSTEM_MESSAGE_PREFIX) || post.DeleteAt != 0 {
+		return nil
+	}
+
+	doc := &document{
+		Id:        post.Id,
+		ChannelId: post.ChannelId,
+		UserId:    post.UserId,
+		CreateAt:  post.CreateAt,
+		Message:   post.Message,
+		Hashtags:  post.Hashtags,
+		HasFiles:  len(post.Filenames) > 0,
+	}
+
+	if err := e.append(&logEntry{Post: doc}); err != nil {
+		return err
+	}
+
+	e.index.add(doc)
+
+	return nil
+}
+
+func (e *LocalSearchEngine) DeletePost(postId string) *model.AppError {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if err := e.open(); err != nil {
+		return err
+	}
+
+	if _, ok := e.index.docs[postId]; !ok {
+		return nil
+	}
+
+	if err := e.append(&logEntry{Deleted: postId}); err != nil {
+		return err
+	}
+
+	e.index.remove(postId)
+
+	return nil
+}
+
+// SearchPosts returns the ids of the posts in the given channels that match the search
+// params, best match first. When userIds is empty posts from any user are returned.
+func (e *LocalSearchEngine) SearchPosts(channelIds []string, userIds []string, params *model.SearchParams, page int, perPage int) ([]string, *model.AppError) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if err := e.open(); err != nil {
+		return nil, err
+	}
+
+	f := &filter{
+		channelIds: make(map[string]bool),
+		userIds:    make(map[string]bool),
+		after:      params.CreatedAfter,
+		before:     params.CreatedBefore,
+		excluded:   parseClauses(strings.Join(params.ExcludedTerms, " ")),
+		hasFile:    params.HasFile,
+		hasLink:    params.HasLink,
+	}
+
+	for _, channelId := range channelIds {
+		f.channelIds[channelId] = true
+	}
+
+	for _, userId := range userIds {
+		f.userIds[userId] = true
+	}
+
+	var hits []*hit
+	if params.IsHashtag {
+		hits = e.index.search(nil, strings.Fields(params.Terms), params.OrTerms, f)
+	} else {
+		hits = e.index.search(parseClauses(params.Terms), nil, params.OrTerms, f)
+	}
+
+	postIds := []string{}
+
+	for i := page * perPage; i < len(hits) && i < (page+1)*perPage; i++ {
+		postIds = append(postIds, hits[i].doc.Id)
+	}
+
+	return postIds, nil
+}
+
+func (e *LocalSearchEngine) PurgeIndex() *model.AppError {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if err := e.open(); err != nil {
+		return err
+	}
+
+	if err := e.file.Truncate(0); err != nil {
+		return model.NewLocAppError("LocalSearchEngine.PurgeIndex", "search.local.write.app_error", nil, err.Error())
+	}
+
+	e.writer.Reset(e.file)
+	e.index = newIndex()
+
+	return nil
+}
+
+// DeleteChannelPosts removes every post in the given channel from the index.
+func (e *LocalSearchEngine) DeleteChannelPosts(channelId string) *model.AppError {
+	return e.deleteWhere(func(doc *document) bool {
+		return doc.ChannelId == channelId
+	})
+}
+
+// DeleteUserPosts removes every post made by the given user from the index.
+func (e *LocalSearchEngine) DeleteUserPosts(userId string) *model.AppError {
+	return e.deleteWhere(func(doc *document) bool {
+		return doc.UserId == userId
+	})
+}
+
+func (e *LocalSearchEngine) deleteWhere(match func(doc *document) bool) *model.AppError {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if err := e.open(); err != nil {
+		return err
+	}
+
+	postIds := []string{}
+	for postId, doc := range e.index.docs {
+		if match(doc) {
+			postIds = append(postIds, postId)
+		}
+	}
+
+	for _, postId := range postIds {
+		if err := writeEntry(e.writer, &logEntry{Deleted: postId}); err != nil {
+			return model.NewLocAppError("LocalSearchEngine.deleteWhere", "search.local.write.app_error", nil, err.Error())
+		}
+
+		e.index.remove(postId)
+	}
+
+	if err := e.writer.Flush(); err != nil {
+		return model.NewLocAppError("LocalSearchEngine.deleteWhere", "search.local.write.app_error", nil, err.Error())
+	}
+
+	return nil
+}
+
+// Close writes out the index and releases it so that another process can open it.
+func (e *LocalSearchEngine) Close() *model.AppError {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.index == nil {
+		return nil
+	}
+
+	err := e.writer.Flush()
+	e.file.Close()
+	e.lock.Close()
+
+	e.index = nil
+	e.file = nil
+	e.writer = nil
+	e.lock = nil
+
+	if err != nil {
+		return model.NewLocAppError("LocalSearchEngine.Close", "search.local.write.app_error", nil, err.Error())
+	}
+
+	return nil
+}
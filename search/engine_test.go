@@ -0,0 +1,290 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package search
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func newTestEngine(t *testing.T) (*LocalSearchEngine, string) {
+	dir, err := ioutil.TempDir("", "searchindex")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewLocalSearchEngine(dir), dir
+}
+
+func indexTestPost(t *testing.T, engine *LocalSearchEngine, channelId string, userId string, message string, createAt int64) *model.Post {
+	post := &model.Post{Id: model.NewId(), ChannelId: channelId, UserId: userId, Message: message, CreateAt: createAt}
+	post.Hashtags, _ = model.ParseHashtags(message)
+
+	if err := engine.IndexPost(post); err != nil {
+		t.Fatal(err)
+	}
+
+	return post
+}
+
+func runSearch(t *testing.T, engine *LocalSearchEngine, channelIds []string, params *model.SearchParams) []string {
+	if postIds, err := engine.SearchPosts(channelIds, nil, params, 0, 100); err != nil {
+		t.Fatal(err)
+		return nil
+	} else {
+		return postIds
+	}
+}
+
+func TestLocalSearchEngineRanking(t *testing.T) {
+	engine, dir := newTestEngine(t)
+	defer os.RemoveAll(dir)
+
+	channelId := model.NewId()
+	userId := model.NewId()
+
+	p1 := indexTestPost(t, engine, channelId, userId, "the deploy went out this morning, nothing else to report", 1000)
+	p2 := indexTestPost(t, engine, channelId, userId, "deploy deploy deploy", 2000)
+	p3 := indexTestPost(t, engine, channelId, userId, "lunch anyone?", 3000)
+
+	postIds := runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "deploy"})
+	if len(postIds) != 2 || postIds[0] != p2.Id || postIds[1] != p1.Id {
+		t.Fatal("should have ranked the post with more matches first", postIds)
+	}
+
+	postIds = runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "deploy lunch"})
+	if len(postIds) != 0 {
+		t.Fatal("should have required every term")
+	}
+
+	postIds = runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "deploy lunch", OrTerms: true})
+	if len(postIds) != 3 {
+		t.Fatal("should have matched any term")
+	}
+
+	postIds = runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "lun*"})
+	if len(postIds) != 1 || postIds[0] != p3.Id {
+		t.Fatal("should have matched the prefix")
+	}
+
+	if postIds = runSearch(t, engine, []string{model.NewId()}, &model.SearchParams{Terms: "deploy"}); len(postIds) != 0 {
+		t.Fatal("should only have searched the given channels")
+	}
+
+	if postIds, err := engine.SearchPosts([]string{channelId}, []string{model.NewId()}, &model.SearchParams{Terms: "deploy"}, 0, 100); err != nil {
+		t.Fatal(err)
+	} else if len(postIds) != 0 {
+		t.Fatal("should only have searched posts from the given users")
+	}
+}
+
+func TestLocalSearchEnginePhrase(t *testing.T) {
+	engine, dir := newTestEngine(t)
+	defer os.RemoveAll(dir)
+
+	channelId := model.NewId()
+	userId := model.NewId()
+
+	p1 := indexTestPost(t, engine, channelId, userId, "the build server is down again", 1000)
+	indexTestPost(t, engine, channelId, userId, "the server build is down again", 2000)
+
+	postIds := runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "\"build server\""})
+	if len(postIds) != 1 || postIds[0] != p1.Id {
+		t.Fatal("should only have matched the exact phrase", postIds)
+	}
+
+	if postIds = runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "build server"}); len(postIds) != 2 {
+		t.Fatal("should have matched both posts without quotes")
+	}
+}
+
+func TestLocalSearchEngineHashtags(t *testing.T) {
+	engine, dir := newTestEngine(t)
+	defer os.RemoveAll(dir)
+
+	channelId := model.NewId()
+	userId := model.NewId()
+
+	p1 := indexTestPost(t, engine, channelId, userId, "release notes #Release", 1000)
+	indexTestPost(t, engine, channelId, userId, "release day", 2000)
+
+	postIds := runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "#release", IsHashtag: true})
+	if len(postIds) != 1 || postIds[0] != p1.Id {
+		t.Fatal("should have matched the hashtag case insensitively")
+	}
+}
+
+func TestLocalSearchEnginePagingAndDates(t *testing.T) {
+	engine, dir := newTestEngine(t)
+	defer os.RemoveAll(dir)
+
+	channelId := model.NewId()
+	userId := model.NewId()
+
+	var posts []*model.Post
+	for i := 0; i < 5; i++ {
+		posts = append(posts, indexTestPost(t, engine, channelId, userId, "standup notes", int64(1000*(i+1))))
+	}
+
+	if postIds, err := engine.SearchPosts([]string{channelId}, nil, &model.SearchParams{Terms: "standup"}, 1, 2); err != nil {
+		t.Fatal(err)
+	} else if len(postIds) != 2 || postIds[0] != posts[2].Id || postIds[1] != posts[1].Id {
+		t.Fatal("should have returned the second page newest first", postIds)
+	}
+
+	if postIds, err := engine.SearchPosts([]string{channelId}, nil, &model.SearchParams{Terms: "standup"}, 3, 2); err != nil {
+		t.Fatal(err)
+	} else if len(postIds) != 0 {
+		t.Fatal("should have returned an empty page")
+	}
+
+	postIds := runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "standup", CreatedAfter: 2000, CreatedBefore: 5000})
+	if len(postIds) != 2 || postIds[0] != posts[3].Id || postIds[1] != posts[2].Id {
+		t.Fatal("should have filtered on the dates", postIds)
+	}
+
+	if postIds = runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "", CreatedAfter: 4000}); len(postIds) != 1 {
+		t.Fatal("should have returned every post in range without any terms")
+	}
+}
+
+func TestLocalSearchEnginePersistence(t *testing.T) {
+	engine, dir := newTestEngine(t)
+	defer os.RemoveAll(dir)
+
+	channelId := model.NewId()
+	userId := model.NewId()
+
+	p1 := indexTestPost(t, engine, channelId, userId, "first draft", 1000)
+	p2 := indexTestPost(t, engine, channelId, userId, "second draft", 2000)
+
+	p1.Message = "final version"
+	if err := engine.IndexPost(p1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := engine.DeletePost(p2.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := engine.IndexPost(&model.Post{Id: model.NewId(), ChannelId: channelId, UserId: userId, Message: "draft joined", Type: model.POST_JOIN_LEAVE, CreateAt: 3000}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := engine.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := NewLocalSearchEngine(dir)
+
+	if postIds := runSearch(t, reopened, []string{channelId}, &model.SearchParams{Terms: "draft"}); len(postIds) != 0 {
+		t.Fatal("should have replayed the update and delete", postIds)
+	}
+
+	if postIds := runSearch(t, reopened, []string{channelId}, &model.SearchParams{Terms: "final"}); len(postIds) != 1 || postIds[0] != p1.Id {
+		t.Fatal("should have kept the updated post")
+	}
+
+	if err := reopened.PurgeIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened.Close()
+
+	if postIds := runSearch(t, NewLocalSearchEngine(dir), []string{channelId}, &model.SearchParams{Terms: "final"}); len(postIds) != 0 {
+		t.Fatal("should have purged the index")
+	}
+}
+
+func TestLocalSearchEngineLock(t *testing.T) {
+	engine, dir := newTestEngine(t)
+	defer os.RemoveAll(dir)
+
+	channelId := model.NewId()
+	indexTestPost(t, engine, channelId, model.NewId(), "locked", 1000)
+
+	other := NewLocalSearchEngine(dir)
+	if err := other.PurgeIndex(); err == nil || err.Id != "search.local.open.locked.app_error" {
+		t.Fatal("shouldn't have opened an index that is in use", err)
+	}
+
+	engine.Close()
+
+	if postIds := runSearch(t, other, []string{channelId}, &model.SearchParams{Terms: "locked"}); len(postIds) != 1 {
+		t.Fatal("should have opened the index once it was released")
+	}
+
+	other.Close()
+}
+
+func TestLocalSearchEngineDeleteChannelAndUserPosts(t *testing.T) {
+	engine, dir := newTestEngine(t)
+	defer os.RemoveAll(dir)
+
+	channelId1 := model.NewId()
+	channelId2 := model.NewId()
+	userId1 := model.NewId()
+	userId2 := model.NewId()
+
+	indexTestPost(t, engine, channelId1, userId1, "cleanup one", 1000)
+	p2 := indexTestPost(t, engine, channelId1, userId2, "cleanup two", 2000)
+	indexTestPost(t, engine, channelId2, userId1, "cleanup three", 3000)
+	p4 := indexTestPost(t, engine, channelId2, userId2, "cleanup four", 4000)
+
+	if err := engine.DeleteUserPosts(userId1); err != nil {
+		t.Fatal(err)
+	}
+
+	if postIds := runSearch(t, engine, []string{channelId1, channelId2}, &model.SearchParams{Terms: "cleanup"}); len(postIds) != 2 || postIds[0] != p4.Id || postIds[1] != p2.Id {
+		t.Fatal("should have removed the user's posts", postIds)
+	}
+
+	if err := engine.DeleteChannelPosts(channelId1); err != nil {
+		t.Fatal(err)
+	}
+
+	engine.Close()
+
+	reopened := NewLocalSearchEngine(dir)
+	defer reopened.Close()
+
+	if postIds := runSearch(t, reopened, []string{channelId1, channelId2}, &model.SearchParams{Terms: "cleanup"}); len(postIds) != 1 || postIds[0] != p4.Id {
+		t.Fatal("should have removed the channel's posts", postIds)
+	}
+}
+
+func TestLocalSearchEngineModifiers(t *testing.T) {
+	engine, dir := newTestEngine(t)
+	defer os.RemoveAll(dir)
+
+	channelId := model.NewId()
+	userId := model.NewId()
+
+	p1 := indexTestPost(t, engine, channelId, userId, "quarterly report draft", 1000)
+	p2 := indexTestPost(t, engine, channelId, userId, "quarterly report final http://example.com", 2000)
+
+	p3 := &model.Post{Id: model.NewId(), ChannelId: channelId, UserId: userId, Message: "quarterly report attached", CreateAt: 3000, Filenames: []string{"report.pdf"}}
+	if err := engine.IndexPost(p3); err != nil {
+		t.Fatal(err)
+	}
+
+	if postIds := runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "quarterly", ExcludedTerms: []string{"draft", "fin*"}}); len(postIds) != 1 || postIds[0] != p3.Id {
+		t.Fatal("should have excluded the terms", postIds)
+	}
+
+	if postIds := runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "quarterly", HasFile: true}); len(postIds) != 1 || postIds[0] != p3.Id {
+		t.Fatal("should have only found posts with files")
+	}
+
+	if postIds := runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "quarterly", HasLink: true}); len(postIds) != 1 || postIds[0] != p2.Id {
+		t.Fatal("should have only found posts with links")
+	}
+
+	if postIds := runSearch(t, engine, []string{channelId}, &model.SearchParams{Terms: "quarterly", CreatedBefore: 2000}); len(postIds) != 1 || postIds[0] != p1.Id {
+		t.Fatal("should have only found posts before the date")
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// document is the part of a post that is kept in the index. The message is stored so
+// that the index can be rebuilt from its log without going back to the database.
+type document struct {
+	Id        string `json:"id"`
+	ChannelId string `json:"channel_id"`
+	UserId    string `json:"user_id"`
+	CreateAt  int64  `json:"create_at"`
+	Message   string `json:"message"`
+	Hashtags  string `json:"hashtags"`
+	HasFiles  bool   `json:"has_files,omitempty"`
+}
+
+type queryTerm struct {
+	text   string
+	prefix bool
+}
+
+// a clause is a single word or a quoted phrase, all of whose words have to appear next to each other
+type clause []queryTerm
+
+type filter struct {
+	channelIds map[string]bool
+	userIds    map[string]bool
+	after      int64
+	before     int64
+	excluded   []clause
+	hasFile    bool
+	hasLink    bool
+}
+
+type hit struct {
+	doc   *document
+	score float64
+}
+
+// index is an in-memory inverted index of post messages and hashtags. It is not safe
+// for concurrent use, the engine guards it with a mutex.
+type index struct {
+	docs     map[string]*document
+	terms    map[string]map[string][]int
+	hashtags map[string]map[string]bool
+}
+
+func newIndex() *index {
+	return &index{
+		docs:     make(map[string]*document),
+		terms:    make(map[string]map[string][]int),
+		hashtags: make(map[string]map[string]bool),
+	}
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+func splitHashtags(hashtags string) []string {
+	return strings.Fields(strings.ToLower(hashtags))
+}
+
+func (idx *index) add(doc *document) {
+	idx.remove(doc.Id)
+
+	idx.docs[doc.Id] = doc
+
+	for position, term := range tokenize(doc.Message) {
+		postings, ok := idx.terms[term]
+		if !ok {
+			postings = make(map[string][]int)
+			idx.terms[term] = postings
+		}
+		postings[doc.Id] = append(postings[doc.Id], position)
+	}
+
+	for _, tag := range splitHashtags(doc.Hashtags) {
+		tagged, ok := idx.hashtags[tag]
+		if !ok {
+			tagged = make(map[string]bool)
+			idx.hashtags[tag] = tagged
+		}
+		tagged[doc.Id] = true
+	}
+}
+
+func (idx *index) remove(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for _, term := range tokenize(doc.Message) {
+		if postings, ok := idx.terms[term]; ok {
+			delete(postings, id)
+			if len(postings) == 0 {
+				delete(idx.terms, term)
+			}
+		}
+	}
+
+	for _, tag := range splitHashtags(doc.Hashtags) {
+		if tagged, ok := idx.hashtags[tag]; ok {
+			delete(tagged, id)
+			if len(tagged) == 0 {
+				delete(idx.hashtags, tag)
+			}
+		}
+	}
+
+	delete(idx.docs, id)
+}
+
+// parseClauses splits search terms into words and quoted phrases. A trailing asterisk
+// on a word turns it into a prefix match.
+func parseClauses(terms string) []clause {
+	clauses := []clause{}
+
+	for _, word := range splitQuoted(terms) {
+		quoted := len(word) > 1 && strings.HasPrefix(word, "\"") && strings.HasSuffix(word, "\"")
+		if quoted {
+			word = word[1 : len(word)-1]
+		}
+
+		c := clause{}
+		if quoted {
+			for _, token := range tokenize(word) {
+				c = append(c, queryTerm{text: token})
+			}
+		} else {
+			prefix := strings.HasSuffix(word, "*")
+			tokens := tokenize(strings.TrimRight(word, "*"))
+			for i, token := range tokens {
+				c = append(c, queryTerm{text: token, prefix: prefix && i == len(tokens)-1})
+			}
+		}
+
+		if len(c) > 0 {
+			clauses = append(clauses, c)
+		}
+	}
+
+	return clauses
+}
+
+func splitQuoted(text string) []string {
+	words := []string{}
+
+	for {
+		start := strings.Index(text, "\"")
+		if start == -1 {
+			break
+		}
+
+		end := strings.Index(text[start+1:], "\"")
+		if end == -1 {
+			break
+		}
+		end += start + 1
+
+		words = append(words, strings.Fields(text[:start])...)
+		words = append(words, text[start:end+1])
+		text = text[end+1:]
+	}
+
+	return append(words, strings.Fields(text)...)
+}
+
+// postings returns the positions of a query term in every document it appears in,
+// expanding prefix terms to every matching term in the index.
+func (idx *index) postings(qt queryTerm) map[string][]int {
+	if !qt.prefix {
+		return idx.terms[qt.text]
+	}
+
+	merged := make(map[string][]int)
+	for term, postings := range idx.terms {
+		if strings.HasPrefix(term, qt.text) {
+			for id, positions := range postings {
+				merged[id] = append(merged[id], positions...)
+			}
+		}
+	}
+
+	return merged
+}
+
+// matchClause returns the number of times the clause occurs in each document that contains it.
+func (idx *index) matchClause(c clause) map[string]int {
+	matches := make(map[string]int)
+
+	first := idx.postings(c[0])
+	if len(c) == 1 {
+		for id, positions := range first {
+			matches[id] = len(positions)
+		}
+		return matches
+	}
+
+	rest := make([]map[string]map[int]bool, len(c)-1)
+	for i, qt := range c[1:] {
+		rest[i] = make(map[string]map[int]bool)
+		for id, positions := range idx.postings(qt) {
+			set := make(map[int]bool, len(positions))
+			for _, p := range positions {
+				set[p] = true
+			}
+			rest[i][id] = set
+		}
+	}
+
+	for id, positions := range first {
+		count := 0
+		for _, p := range positions {
+			found := true
+			for i := range rest {
+				if !rest[i][id][p+i+1] {
+					found = false
+					break
+				}
+			}
+			if found {
+				count++
+			}
+		}
+
+		if count > 0 {
+			matches[id] = count
+		}
+	}
+
+	return matches
+}
+
+func (f *filter) accepts(doc *document) bool {
+	if !f.channelIds[doc.ChannelId] {
+		return false
+	}
+
+	if len(f.userIds) > 0 && !f.userIds[doc.UserId] {
+		return false
+	}
+
+	if f.after != 0 && doc.CreateAt <= f.after {
+		return false
+	}
+
+	if f.before != 0 && doc.CreateAt >= f.before {
+		return false
+	}
+
+	if f.hasFile && !doc.HasFiles {
+		return false
+	}
+
+	if f.hasLink && !strings.Contains(doc.Message, "http://") && !strings.Contains(doc.Message, "https://") {
+		return false
+	}
+
+	return true
+}
+
+// search scores every document matching the clauses and hashtags with a tf-idf style
+// ranking and returns them best match first, newest first for equal scores. When
+// there is nothing to match on, every document accepted by the filter is returned.
+func (idx *index) search(clauses []clause, hashtags []string, orTerms bool, f *filter) []*hit {
+	scores := make(map[string]float64)
+	matched := make(map[string]int)
+	required := len(clauses) + len(hashtags)
+	total := float64(len(idx.docs))
+
+	for _, c := range clauses {
+		for id, count := range idx.matchClause(c) {
+			docLength := float64(len(tokenize(idx.docs[id].Message)))
+			tf := float64(count) / (float64(count) + 0.5 + docLength/20)
+			idf := math.Log(1 + total/float64(len(idx.postings(c[0]))))
+			scores[id] += tf * idf * float64(len(c))
+			matched[id]++
+		}
+	}
+
+	for _, tag := range hashtags {
+		tagged := idx.hashtags[strings.ToLower(tag)]
+		for id := range tagged {
+			scores[id] += math.Log(1 + total/float64(len(tagged)))
+			matched[id]++
+		}
+	}
+
+	excluded := make(map[string]bool)
+	for _, c := range f.excluded {
+		for id := range idx.matchClause(c) {
+			excluded[id] = true
+		}
+	}
+
+	hits := []*hit{}
+
+	if required == 0 {
+		for _, doc := range idx.docs {
+			if !excluded[doc.Id] && f.accepts(doc) {
+				hits = append(hits, &hit{doc: doc})
+			}
+		}
+	} else {
+		for id, count := range matched {
+			if !orTerms && count < required {
+				continue
+			}
+
+			if doc := idx.docs[id]; !excluded[id] && f.accepts(doc) {
+				hits = append(hits, &hit{doc: doc, score: scores[id]})
+			}
+		}
+	}
+
+	sort.Sort(byRelevance(hits))
+
+	return hits
+}
+
+type byRelevance []*hit
+
+func (h byRelevance) Len() int      { return len(h) }
+func (h byRelevance) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h byRelevance) Less(i, j int) bool {
+	if h[i].score != h[j].score {
+		return h[i].score > h[j].score
+	}
+
+	if h[i].doc.CreateAt != h[j].doc.CreateAt {
+		return h[i].doc.CreateAt > h[j].doc.CreateAt
+	}
+
+	return h[i].doc.Id > h[j].doc.Id
+}
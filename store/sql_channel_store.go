@@ -306,6 +306,24 @@ func (s SqlChannelStore) Delete(channelId string, time int64) StoreChannel {
 	return storeChannel
 }
 
+func (s SqlChannelStore) Restore(channelId string, time int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		_, err := s.GetMaster().Exec("Update Channels SET DeleteAt = 0, UpdateAt = :Time WHERE Id = :ChannelId", map[string]interface{}{"Time": time, "ChannelId": channelId})
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlChannelStore.Restore", "store.sql_channel.restore.app_error", nil, "id="+channelId+", err="+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlChannelStore) PermanentDeleteByTeam(teamId string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -403,6 +421,30 @@ func (s SqlChannelStore) GetMoreChannels(teamId string, userId string) StoreChan
 	return storeChannel
 }
 
+// GetDeletedChannels returns the archived open channels of the team. Private channels are left
+// out since there's no membership to check them against.
+func (s SqlChannelStore) GetDeletedChannels(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var data []*model.Channel
+		_, err := s.GetReplica().Select(&data, "SELECT * FROM Channels WHERE TeamId = :TeamId AND Type = 'O' AND DeleteAt != 0 ORDER BY DisplayName", map[string]interface{}{"TeamId": teamId})
+
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlChannelStore.GetDeletedChannels", "store.sql_channel.get_deleted_channels.get.app_error", nil, "teamId="+teamId+", err="+err.Error())
+		} else {
+			result.Data = &model.ChannelList{Channels: data, Members: make(map[string]*model.ChannelMember)}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 type channelIdWithCountAndUpdateAt struct {
 	Id            string
 	TotalMsgCount int64
@@ -791,6 +833,39 @@ func (s SqlChannelStore) CheckPermissionsTo(teamId string, channelId string, use
 	return storeChannel
 }
 
+// CheckReadPermissionsTo is CheckPermissionsTo for the routes that only read the channel, the
+// archived open channels of the team can be read by anyone since they can't be joined anymore.
+func (s SqlChannelStore) CheckReadPermissionsTo(teamId string, channelId string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		count, err := s.GetReplica().SelectInt(
+			`SELECT
+			    COUNT(0)
+			FROM
+			    Channels
+			WHERE
+			    Channels.Id = :ChannelId
+			        AND (Channels.TeamId = :TeamId OR Channels.TeamId = '')
+			        AND ((Channels.DeleteAt = 0
+			            AND EXISTS (SELECT 1 FROM ChannelMembers WHERE ChannelMembers.ChannelId = :ChannelId AND ChannelMembers.UserId = :UserId))
+			        OR (Channels.DeleteAt != 0 AND Channels.Type = :Type))`,
+			map[string]interface{}{"TeamId": teamId, "ChannelId": channelId, "UserId": userId, "Type": model.CHANNEL_OPEN})
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlChannelStore.CheckReadPermissionsTo", "store.sql_channel.check_permissions.app_error", nil, "channel_id="+channelId+", user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlChannelStore) CheckPermissionsToByName(teamId string, channelName string, userId string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -272,6 +272,54 @@ func TestChannelStoreDelete(t *testing.T) {
 	}
 }
 
+func TestChannelStoreRestore(t *testing.T) {
+	Setup()
+
+	o1 := model.Channel{}
+	o1.TeamId = model.NewId()
+	o1.DisplayName = "Channel1"
+	o1.Name = "a" + model.NewId() + "b"
+	o1.Type = model.CHANNEL_OPEN
+	Must(store.Channel().Save(&o1))
+
+	o2 := model.Channel{}
+	o2.TeamId = o1.TeamId
+	o2.DisplayName = "Channel2"
+	o2.Name = "a" + model.NewId() + "b"
+	o2.Type = model.CHANNEL_PRIVATE
+	Must(store.Channel().Save(&o2))
+
+	o3 := model.Channel{}
+	o3.TeamId = o1.TeamId
+	o3.DisplayName = "Channel3"
+	o3.Name = "a" + model.NewId() + "b"
+	o3.Type = model.CHANNEL_OPEN
+	Must(store.Channel().Save(&o3))
+
+	Must(store.Channel().Delete(o1.Id, model.GetMillis()))
+	Must(store.Channel().Delete(o2.Id, model.GetMillis()))
+
+	if r := <-store.Channel().GetDeletedChannels(o1.TeamId); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if list := r.Data.(*model.ChannelList); len(list.Channels) != 1 || list.Channels[0].Id != o1.Id {
+		t.Fatal("should only have returned the deleted open channel")
+	}
+
+	if r := <-store.Channel().Restore(o1.Id, model.GetMillis()); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	if r := <-store.Channel().Get(o1.Id); r.Data.(*model.Channel).DeleteAt != 0 {
+		t.Fatal("should have been restored")
+	}
+
+	if r := <-store.Channel().GetDeletedChannels(o1.TeamId); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if len(r.Data.(*model.ChannelList).Channels) != 0 {
+		t.Fatal("should have no deleted open channels left")
+	}
+}
+
 func TestChannelStoreGetByName(t *testing.T) {
 	Setup()
 
@@ -510,6 +558,65 @@ func TestChannelStorePermissionsTo(t *testing.T) {
 	}
 }
 
+func TestChannelStoreReadPermissionsTo(t *testing.T) {
+	Setup()
+
+	o1 := model.Channel{}
+	o1.TeamId = model.NewId()
+	o1.DisplayName = "Channel1"
+	o1.Name = "a" + model.NewId() + "b"
+	o1.Type = model.CHANNEL_OPEN
+	Must(store.Channel().Save(&o1))
+
+	o2 := model.Channel{}
+	o2.TeamId = o1.TeamId
+	o2.DisplayName = "Channel2"
+	o2.Name = "a" + model.NewId() + "b"
+	o2.Type = model.CHANNEL_PRIVATE
+	Must(store.Channel().Save(&o2))
+
+	m1 := model.ChannelMember{}
+	m1.ChannelId = o1.Id
+	m1.UserId = model.NewId()
+	m1.NotifyProps = model.GetDefaultChannelNotifyProps()
+	Must(store.Channel().SaveMember(&m1))
+
+	m2 := model.ChannelMember{}
+	m2.ChannelId = o2.Id
+	m2.UserId = m1.UserId
+	m2.NotifyProps = model.GetDefaultChannelNotifyProps()
+	Must(store.Channel().SaveMember(&m2))
+
+	otherUserId := model.NewId()
+
+	if count := Must(store.Channel().CheckReadPermissionsTo(o1.TeamId, o1.Id, m1.UserId)).(int64); count != 1 {
+		t.Fatal("should have permissions")
+	}
+
+	if count := Must(store.Channel().CheckReadPermissionsTo(o1.TeamId, o1.Id, otherUserId)).(int64); count != 0 {
+		t.Fatal("shouldn't have permissions to a channel that can still be joined")
+	}
+
+	Must(store.Channel().Delete(o1.Id, model.GetMillis()))
+	Must(store.Channel().Delete(o2.Id, model.GetMillis()))
+
+	if count := Must(store.Channel().CheckReadPermissionsTo(o1.TeamId, o1.Id, otherUserId)).(int64); count != 1 {
+		t.Fatal("should have permissions to an archived open channel")
+	}
+
+	if count := Must(store.Channel().CheckReadPermissionsTo("junk", o1.Id, otherUserId)).(int64); count != 0 {
+		t.Fatal("shouldn't have permissions to a channel of another team")
+	}
+
+	if count := Must(store.Channel().CheckReadPermissionsTo(o2.TeamId, o2.Id, m1.UserId)).(int64); count != 0 {
+		t.Fatal("shouldn't have permissions to an archived private channel")
+	}
+
+	if count := Must(store.Channel().CheckPermissionsTo(o1.TeamId, o1.Id, m1.UserId)).(int64); count != 0 {
+		t.Fatal("shouldn't have write permissions to an archived channel")
+	}
+}
+
 func TestChannelStoreOpenChannelPermissionsTo(t *testing.T) {
 	Setup()
 
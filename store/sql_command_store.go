@@ -27,6 +27,7 @@ func NewSqlCommandStore(sqlStore *SqlStore) CommandStore {
 		tableo.ColMap("IconURL").SetMaxSize(1024)
 		tableo.ColMap("AutoCompleteDesc").SetMaxSize(1024)
 		tableo.ColMap("AutoCompleteHint").SetMaxSize(1024)
+		tableo.ColMap("AutocompleteData").SetMaxSize(model.AUTOCOMPLETE_DATA_MAX_LENGTH)
 		tableo.ColMap("DisplayName").SetMaxSize(64)
 		tableo.ColMap("Description").SetMaxSize(128)
 	}
@@ -36,6 +37,7 @@ func NewSqlCommandStore(sqlStore *SqlStore) CommandStore {
 
 func (s SqlCommandStore) UpgradeSchemaIfNeeded() {
 	s.CreateColumnIfNotExists("Commands", "Description", "varchar(128)", "varchar(128)", "")
+	s.CreateColumnIfNotExists("Commands", "AutocompleteData", "varchar(4000)", "varchar(4000)", "")
 }
 
 func (s SqlCommandStore) CreateIndexesIfNotExists() {
@@ -159,7 +161,12 @@ func (s SqlCommandStore) Update(cmd *model.Command) StoreChannel {
 	go func() {
 		result := StoreResult{}
 
-		cmd.UpdateAt = model.GetMillis()
+		cmd.PreUpdate()
+		if result.Err = cmd.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
 
 		if _, err := s.GetMaster().Update(cmd); err != nil {
 			result.Err = model.NewLocAppError("SqlCommandStore.Update", "store.sql_command.save.update.app_error", nil, "id="+cmd.Id+", "+err.Error())
@@ -158,6 +158,37 @@ func TestCommandStoreUpdate(t *testing.T) {
 	if r2 := <-store.Command().Update(o1); r2.Err != nil {
 		t.Fatal(r2.Err)
 	}
+
+	o1.URL = "junk"
+
+	if r3 := <-store.Command().Update(o1); r3.Err == nil {
+		t.Fatal("should have failed - invalid url")
+	}
+}
+
+func TestCommandStoreAutocompleteData(t *testing.T) {
+	Setup()
+
+	o1 := &model.Command{}
+	o1.CreatorId = model.NewId()
+	o1.Method = model.COMMAND_METHOD_POST
+	o1.TeamId = model.NewId()
+	o1.URL = "http://nowhere.com/"
+	o1.Trigger = "trigger"
+
+	o1 = Must(store.Command().Save(o1)).(*model.Command)
+
+	if r1 := Must(store.Command().Get(o1.Id)).(*model.Command); r1.AutocompleteData != nil {
+		t.Fatal("shouldn't have autocomplete data")
+	}
+
+	o1.AutocompleteData = model.NewAutocompleteData("trigger", "[user]", "")
+	o1.AutocompleteData.AddArgument("user", "@username", "", model.AUTOCOMPLETE_ARG_TYPE_USER)
+	Must(store.Command().Update(o1))
+
+	if r1 := Must(store.Command().Get(o1.Id)).(*model.Command); r1.AutocompleteData == nil || r1.AutocompleteData.Arguments[0].Type != model.AUTOCOMPLETE_ARG_TYPE_USER {
+		t.Fatal("should have saved the autocomplete data")
+	}
 }
 
 func TestCommandCount(t *testing.T) {
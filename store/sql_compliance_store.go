@@ -211,8 +211,11 @@ func (s SqlComplianceStore) ComplianceExport(job *model.Compliance) StoreChannel
 			    Teams.Id = Channels.TeamId
 			        AND Posts.ChannelId = Channels.Id
 			        AND Posts.UserId = Users.Id
-			        AND Posts.CreateAt > :StartTime
-			        AND Posts.CreateAt <= :EndTime
+			        AND ((Posts.CreateAt > :StartTime
+			            AND Posts.CreateAt <= :EndTime)
+			        OR (Posts.OriginalId != ''
+			            AND Posts.DeleteAt > :StartTime
+			            AND Posts.DeleteAt <= :EndTime))
 			        ` + emailQuery + `
 			        ` + keywordQuery + `
 			ORDER BY Posts.CreateAt
@@ -222,6 +225,8 @@ func (s SqlComplianceStore) ComplianceExport(job *model.Compliance) StoreChannel
 
 		if _, err := s.GetReplica().Select(&cposts, query, props); err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.ComplianceExport", "store.sql_post.compliance_export.app_error", nil, err.Error())
+		} else if err := s.addComplianceReactions(cposts); err != nil {
+			result.Err = err
 		} else {
 			result.Data = cposts
 		}
@@ -232,3 +237,68 @@ func (s SqlComplianceStore) ComplianceExport(job *model.Compliance) StoreChannel
 
 	return storeChannel
 }
+
+const (
+	COMPLIANCE_REACTIONS_BATCH_SIZE = 500
+)
+
+type complianceReaction struct {
+	PostId       string
+	UserUsername string
+	EmojiName    string
+}
+
+// addComplianceReactions fills in the reactions made on the exported posts as a
+// list of username:emoji_name pairs. The posts are looked up in batches to keep
+// the IN clause of the query to a reasonable size.
+func (s SqlComplianceStore) addComplianceReactions(cposts []*model.CompliancePost) *model.AppError {
+	var reactions []*complianceReaction
+
+	for start := 0; start < len(cposts); start += COMPLIANCE_REACTIONS_BATCH_SIZE {
+		end := start + COMPLIANCE_REACTIONS_BATCH_SIZE
+		if end > len(cposts) {
+			end = len(cposts)
+		}
+
+		inClause := ""
+		params := make(map[string]interface{})
+		for i, cpost := range cposts[start:end] {
+			paramName := "PostId" + strconv.Itoa(i)
+			if i > 0 {
+				inClause += ", "
+			}
+			inClause += ":" + paramName
+			params[paramName] = cpost.PostId
+		}
+
+		var batch []*complianceReaction
+		if _, err := s.GetReplica().Select(&batch,
+			`SELECT
+			    Reactions.PostId AS PostId,
+			    Users.Username AS UserUsername,
+			    Reactions.EmojiName AS EmojiName
+			FROM
+			    Reactions,
+			    Users
+			WHERE
+			    Reactions.UserId = Users.Id
+			        AND Reactions.PostId IN (`+inClause+`)
+			ORDER BY Reactions.CreateAt`,
+			params); err != nil {
+			return model.NewLocAppError("SqlPostStore.ComplianceExport", "store.sql_post.compliance_export.reactions.app_error", nil, err.Error())
+		}
+
+		reactions = append(reactions, batch...)
+	}
+
+	reactionMap := make(map[string][]string)
+	for _, reaction := range reactions {
+		reactionMap[reaction.PostId] = append(reactionMap[reaction.PostId], reaction.UserUsername+":"+reaction.EmojiName)
+	}
+
+	for _, cpost := range cposts {
+		cpost.PostReactions = strings.Join(reactionMap[cpost.PostId], ",")
+	}
+
+	return nil
+}
@@ -104,6 +104,9 @@ func TestComplianceExport(t *testing.T) {
 	o2a.Message = "a" + model.NewId() + "b"
 	o2a = Must(store.Post().Save(o2a)).(*model.Post)
 
+	Must(store.Reaction().Save(&model.Reaction{UserId: u2.Id, PostId: o1.Id, EmojiName: "smile"}))
+	Must(store.Reaction().Save(&model.Reaction{UserId: u1.Id, PostId: o2a.Id, EmojiName: "tada"}))
+
 	time.Sleep(100 * time.Millisecond)
 
 	cr1 := &model.Compliance{Desc: "test" + model.NewId(), StartAt: o1.CreateAt - 1, EndAt: o2a.CreateAt + 1}
@@ -138,6 +141,10 @@ func TestComplianceExport(t *testing.T) {
 		if cposts[0].PostId != o2a.Id {
 			t.Fatal("Wrong sort")
 		}
+
+		if cposts[0].PostReactions != u1.Username+":tada" {
+			t.Fatal("should only have exported the reactions of the post", cposts[0].PostReactions)
+		}
 	}
 
 	cr3 := &model.Compliance{Desc: "test" + model.NewId(), StartAt: o1.CreateAt - 1, EndAt: o2a.CreateAt + 1, Emails: u2.Email + ", " + u1.Email}
@@ -208,3 +215,58 @@ func TestComplianceExport(t *testing.T) {
 		}
 	}
 }
+
+func TestComplianceExportEditHistory(t *testing.T) {
+	Setup()
+
+	t1 := &model.Team{}
+	t1.DisplayName = "DisplayName"
+	t1.Name = "a" + model.NewId() + "b"
+	t1.Email = model.NewId() + "@nowhere.com"
+	t1.Type = model.TEAM_OPEN
+	t1 = Must(store.Team().Save(t1)).(*model.Team)
+
+	u1 := &model.User{}
+	u1.Email = model.NewId()
+	u1.Username = model.NewId()
+	u1 = Must(store.User().Save(u1)).(*model.User)
+	Must(store.Team().SaveMember(&model.TeamMember{TeamId: t1.Id, UserId: u1.Id}))
+
+	c1 := &model.Channel{}
+	c1.TeamId = t1.Id
+	c1.DisplayName = "Channel2"
+	c1.Name = "a" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	c1 = Must(store.Channel().Save(c1)).(*model.Channel)
+
+	o1 := &model.Post{}
+	o1.ChannelId = c1.Id
+	o1.UserId = u1.Id
+	o1.CreateAt = model.GetMillis() - 100000
+	o1.Message = "a" + model.NewId() + "b"
+	o1 = Must(store.Post().Save(o1)).(*model.Post)
+
+	startAt := model.GetMillis()
+	time.Sleep(10 * time.Millisecond)
+
+	liveId := o1.Id
+	original := o1.Message
+	Must(store.Post().Update(o1, "a"+model.NewId()+"b", ""))
+
+	time.Sleep(10 * time.Millisecond)
+
+	cr1 := &model.Compliance{Desc: "test" + model.NewId(), StartAt: startAt, EndAt: model.GetMillis(), Emails: u1.Email}
+	if r1 := <-store.Compliance().ComplianceExport(cr1); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else {
+		cposts := r1.Data.([]*model.CompliancePost)
+
+		if len(cposts) != 1 {
+			t.Fatal("should have returned the revision replaced during the export period")
+		}
+
+		if cposts[0].PostOriginalId != liveId || cposts[0].PostMessage != original {
+			t.Fatal("should have returned the original message linked to the live post")
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlNotificationQueueStore struct {
+	*SqlStore
+}
+
+func NewSqlNotificationQueueStore(sqlStore *SqlStore) NotificationQueueStore {
+	s := &SqlNotificationQueueStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.QueuedNotification{}, "NotificationQueue").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("TeamId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+		table.ColMap("PostId").SetMaxSize(26)
+		table.ColMap("SiteURL").SetMaxSize(512)
+	}
+
+	return s
+}
+
+func (s SqlNotificationQueueStore) UpgradeSchemaIfNeeded() {
+	s.CreateColumnIfNotExists("NotificationQueue", "Attempts", "int(11)", "integer", "0")
+}
+
+func (s SqlNotificationQueueStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_notification_queue_user_id", "NotificationQueue", "UserId")
+	s.CreateIndexIfNotExists("idx_notification_queue_send_at", "NotificationQueue", "SendAt")
+}
+
+func (s SqlNotificationQueueStore) Save(notification *model.QueuedNotification) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		notification.PreSave()
+		if result.Err = notification.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(notification); err != nil {
+			result.Err = model.NewLocAppError("SqlNotificationQueueStore.Save", "store.sql_notification_queue.save.app_error", nil, "user_id="+notification.UserId+", "+err.Error())
+		} else {
+			result.Data = notification
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetDueUserIds returns the users whose oldest queued notification is
+// scheduled to be sent at or before the given time.
+func (s SqlNotificationQueueStore) GetDueUserIds(time int64) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var userIds []string
+		if _, err := s.GetMaster().Select(&userIds,
+			`SELECT
+				UserId
+			FROM
+				NotificationQueue
+			GROUP BY UserId
+			HAVING MIN(SendAt) <= :Time`, map[string]interface{}{"Time": time}); err != nil {
+			result.Err = model.NewLocAppError("SqlNotificationQueueStore.GetDueUserIds", "store.sql_notification_queue.get_due_user_ids.app_error", nil, err.Error())
+		} else {
+			result.Data = userIds
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlNotificationQueueStore) GetForUser(userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var notifications []*model.QueuedNotification
+		if _, err := s.GetMaster().Select(&notifications, "SELECT * FROM NotificationQueue WHERE UserId = :UserId ORDER BY CreateAt", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlNotificationQueueStore.GetForUser", "store.sql_notification_queue.get_for_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = notifications
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteForUser removes the user's queued notifications created at or before
+// the given time and returns how many were removed.
+func (s SqlNotificationQueueStore) DeleteForUser(userId string, time int64) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("DELETE FROM NotificationQueue WHERE UserId = :UserId AND CreateAt <= :Time", map[string]interface{}{"UserId": userId, "Time": time}); err != nil {
+			result.Err = model.NewLocAppError("SqlNotificationQueueStore.DeleteForUser", "store.sql_notification_queue.delete_for_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			rows, _ := sqlResult.RowsAffected()
+			result.Data = rows
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlNotificationQueueStore) PermanentDeleteByUser(userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM NotificationQueue WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlNotificationQueueStore.PermanentDeleteByUser", "store.sql_notification_queue.permanent_delete_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
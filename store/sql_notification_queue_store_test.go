@@ -0,0 +1,70 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestNotificationQueueStore(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+
+	n1 := &model.QueuedNotification{UserId: userId, TeamId: model.NewId(), ChannelId: model.NewId(), PostId: model.NewId()}
+	n1.CreateAt = model.GetMillis()
+	n1.SendAt = n1.CreateAt + model.GetEmailIntervalMillis(model.EMAIL_INTERVAL_FIFTEEN)
+	Must(store.NotificationQueue().Save(n1))
+
+	n2 := &model.QueuedNotification{UserId: userId, TeamId: n1.TeamId, ChannelId: n1.ChannelId, PostId: model.NewId()}
+	n2.CreateAt = n1.CreateAt + 1
+	n2.SendAt = n2.CreateAt + model.GetEmailIntervalMillis(model.EMAIL_INTERVAL_FIFTEEN)
+	Must(store.NotificationQueue().Save(n2))
+
+	if err := (<-store.NotificationQueue().Save(&model.QueuedNotification{UserId: userId})).Err; err == nil {
+		t.Fatal("should have failed validation")
+	}
+
+	due := Must(store.NotificationQueue().GetDueUserIds(n1.CreateAt)).([]string)
+	for _, id := range due {
+		if id == userId {
+			t.Fatal("notifications should not be due yet")
+		}
+	}
+
+	due = Must(store.NotificationQueue().GetDueUserIds(n1.SendAt)).([]string)
+	found := false
+	for _, id := range due {
+		if id == userId {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("notifications should be due")
+	}
+
+	queued := Must(store.NotificationQueue().GetForUser(userId)).([]*model.QueuedNotification)
+	if len(queued) != 2 || queued[0].Id != n1.Id || queued[1].Id != n2.Id {
+		t.Fatal("should have returned both notifications in order")
+	}
+
+	if deleted := Must(store.NotificationQueue().DeleteForUser(userId, n1.CreateAt)).(int64); deleted != 1 {
+		t.Fatal("should have deleted the first notification only")
+	}
+
+	queued = Must(store.NotificationQueue().GetForUser(userId)).([]*model.QueuedNotification)
+	if len(queued) != 1 || queued[0].Id != n2.Id {
+		t.Fatal("second notification should still be queued")
+	}
+
+	Must(store.NotificationQueue().PermanentDeleteByUser(userId))
+
+	queued = Must(store.NotificationQueue().GetForUser(userId)).([]*model.QueuedNotification)
+	if len(queued) != 0 {
+		t.Fatal("should have deleted all notifications")
+	}
+}
@@ -4,8 +4,11 @@
 package store
 
 import (
-	"github.com/mattermost/platform/model"
 	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
 )
 
 type SqlOAuthStore struct {
@@ -34,21 +37,41 @@ func NewSqlOAuthStore(sqlStore *SqlStore) OAuthStore {
 		tableAuth.ColMap("Scope").SetMaxSize(128)
 
 		tableAccess := db.AddTableWithName(model.AccessData{}, "OAuthAccessData").SetKeys(false, "Token")
+		tableAccess.ColMap("ClientId").SetMaxSize(26)
+		tableAccess.ColMap("UserId").SetMaxSize(26)
 		tableAccess.ColMap("AuthCode").SetMaxSize(128)
 		tableAccess.ColMap("Token").SetMaxSize(26)
 		tableAccess.ColMap("RefreshToken").SetMaxSize(26)
 		tableAccess.ColMap("RedirectUri").SetMaxSize(256)
+		tableAccess.ColMap("Scope").SetMaxSize(128)
 	}
 
 	return as
 }
 
 func (as SqlOAuthStore) UpgradeSchemaIfNeeded() {
+	as.CreateColumnIfNotExists("OAuthAccessData", "ClientId", "varchar(26)", "varchar(26)", "")
+	as.CreateColumnIfNotExists("OAuthAccessData", "UserId", "varchar(26)", "varchar(26)", "")
+	as.CreateColumnIfNotExists("OAuthAccessData", "RotatedAt", "bigint(20)", "bigint", "0")
+	as.CreateColumnIfNotExists("OAuthAccessData", "Scope", "varchar(128)", "varchar(128)", "")
+
+	// access data saved before it held the client and user gets them from its authorization code
+	if _, err := as.GetMaster().Exec(`UPDATE OAuthAccessData
+		SET
+			ClientId = COALESCE((SELECT ClientId FROM OAuthAuthData WHERE Code = OAuthAccessData.AuthCode), ''),
+			UserId = COALESCE((SELECT UserId FROM OAuthAuthData WHERE Code = OAuthAccessData.AuthCode), '')
+		WHERE
+			ClientId = ''`); err != nil {
+		l4g.Error(utils.T("store.sql_oauth.upgrade_access_data.error"), err.Error())
+	}
 }
 
 func (as SqlOAuthStore) CreateIndexesIfNotExists() {
 	as.CreateIndexIfNotExists("idx_oauthapps_creator_id", "OAuthApps", "CreatorId")
 	as.CreateIndexIfNotExists("idx_oauthaccessdata_auth_code", "OAuthAccessData", "AuthCode")
+	as.CreateIndexIfNotExists("idx_oauthaccessdata_refresh_token", "OAuthAccessData", "RefreshToken")
+	as.CreateIndexIfNotExists("idx_oauthaccessdata_client_id", "OAuthAccessData", "ClientId")
+	as.CreateIndexIfNotExists("idx_oauthaccessdata_user_id", "OAuthAccessData", "UserId")
 	as.CreateIndexIfNotExists("idx_oauthauthdata_client_id", "OAuthAuthData", "Code")
 }
 
@@ -172,6 +195,122 @@ func (as SqlOAuthStore) GetAppByUser(userId string) StoreChannel {
 	return storeChannel
 }
 
+func (as SqlOAuthStore) GetApps() StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var apps []*model.OAuthApp
+
+		if _, err := as.GetReplica().Select(&apps, "SELECT * FROM OAuthApps ORDER BY Name"); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.GetApps", "store.sql_oauth.get_apps.find.app_error", nil, err.Error())
+		}
+
+		result.Data = apps
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetAuthorizedApps returns the apps that hold an access token for the user.
+func (as SqlOAuthStore) GetAuthorizedApps(userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var apps []*model.OAuthApp
+
+		if _, err := as.GetReplica().Select(&apps,
+			`SELECT
+				*
+			FROM
+				OAuthApps
+			WHERE
+				Id IN (SELECT ClientId FROM OAuthAccessData WHERE UserId = :UserId AND RotatedAt = 0)
+			ORDER BY Name`, map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.GetAuthorizedApps", "store.sql_oauth.get_authorized_apps.app_error", nil, "user_id="+userId+", "+err.Error())
+		}
+
+		result.Data = apps
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateAppSecret replaces the client secret of an app. The secret must already be hashed.
+func (as SqlOAuthStore) UpdateAppSecret(appId string, clientSecret string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := as.GetMaster().Exec("UPDATE OAuthApps SET ClientSecret = :ClientSecret, UpdateAt = :UpdateAt WHERE Id = :Id",
+			map[string]interface{}{"ClientSecret": clientSecret, "UpdateAt": model.GetMillis(), "Id": appId}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.UpdateAppSecret", "store.sql_oauth.update_app_secret.app_error", nil, "app_id="+appId+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows != 1 {
+			result.Err = model.NewLocAppError("SqlOAuthStore.UpdateAppSecret", "store.sql_oauth.update_app.find.app_error", nil, "app_id="+appId)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteApp removes an app along with its authorization codes and access data. The sessions
+// created for its access tokens have to be removed separately.
+func (as SqlOAuthStore) DeleteApp(appId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		transaction, err := as.GetMaster().Begin()
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.DeleteApp", "store.sql_oauth.delete_app.open_transaction.app_error", nil, err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		params := map[string]interface{}{"AppId": appId}
+
+		if _, err := transaction.Exec("DELETE FROM OAuthAccessData WHERE ClientId = :AppId", params); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.DeleteApp", "store.sql_oauth.delete_app.app_error", nil, "app_id="+appId+", "+err.Error())
+		} else if _, err := transaction.Exec("DELETE FROM OAuthAuthData WHERE ClientId = :AppId", params); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.DeleteApp", "store.sql_oauth.delete_app.app_error", nil, "app_id="+appId+", "+err.Error())
+		} else if _, err := transaction.Exec("DELETE FROM OAuthApps WHERE Id = :AppId", params); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.DeleteApp", "store.sql_oauth.delete_app.app_error", nil, "app_id="+appId+", "+err.Error())
+		}
+
+		if result.Err != nil {
+			transaction.Rollback()
+		} else if err := transaction.Commit(); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.DeleteApp", "store.sql_oauth.delete_app.commit_transaction.app_error", nil, err.Error())
+		} else {
+			result.Data = appId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (as SqlOAuthStore) SaveAccessData(accessData *model.AccessData) StoreChannel {
 
 	storeChannel := make(StoreChannel)
@@ -230,7 +369,7 @@ func (as SqlOAuthStore) GetAccessDataByAuthCode(authCode string) StoreChannel {
 
 		accessData := model.AccessData{}
 
-		if err := as.GetReplica().SelectOne(&accessData, "SELECT * FROM OAuthAccessData WHERE AuthCode = :AuthCode", map[string]interface{}{"AuthCode": authCode}); err != nil {
+		if err := as.GetReplica().SelectOne(&accessData, "SELECT * FROM OAuthAccessData WHERE AuthCode = :AuthCode AND RotatedAt = 0", map[string]interface{}{"AuthCode": authCode}); err != nil {
 			if strings.Contains(err.Error(), "no rows") {
 				result.Data = nil
 			} else {
@@ -248,6 +387,152 @@ func (as SqlOAuthStore) GetAccessDataByAuthCode(authCode string) StoreChannel {
 	return storeChannel
 }
 
+func (as SqlOAuthStore) GetAccessDataByRefreshToken(refreshToken string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		accessData := model.AccessData{}
+
+		if err := as.GetReplica().SelectOne(&accessData, "SELECT * FROM OAuthAccessData WHERE RefreshToken = :RefreshToken", map[string]interface{}{"RefreshToken": refreshToken}); err != nil {
+			if strings.Contains(err.Error(), "no rows") {
+				result.Data = nil
+			} else {
+				result.Err = model.NewLocAppError("SqlOAuthStore.GetAccessDataByRefreshToken", "store.sql_oauth.get_access_data_by_refresh_token.app_error", nil, err.Error())
+			}
+		} else {
+			result.Data = &accessData
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+
+	}()
+
+	return storeChannel
+}
+
+// GetAccessDataByApp returns the access tokens of an app that are still in use, only the ones
+// issued to the given user when userId isn't empty.
+func (as SqlOAuthStore) GetAccessDataByApp(clientId string, userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var accessData []*model.AccessData
+
+		query := "SELECT * FROM OAuthAccessData WHERE ClientId = :ClientId AND RotatedAt = 0"
+		if len(userId) > 0 {
+			query += " AND UserId = :UserId"
+		}
+
+		if _, err := as.GetReplica().Select(&accessData, query, map[string]interface{}{"ClientId": clientId, "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.GetAccessDataByApp", "store.sql_oauth.get_access_data_by_app.app_error", nil, "client_id="+clientId+", "+err.Error())
+		} else {
+			result.Data = accessData
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+
+	}()
+
+	return storeChannel
+}
+
+// GetAccessDataFamily returns every access token issued for an authorization code, including
+// the ones whose refresh tokens have already been rotated.
+func (as SqlOAuthStore) GetAccessDataFamily(authCode string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var family []*model.AccessData
+
+		if _, err := as.GetReplica().Select(&family, "SELECT * FROM OAuthAccessData WHERE AuthCode = :AuthCode", map[string]interface{}{"AuthCode": authCode}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.GetAccessDataFamily", "store.sql_oauth.get_access_data_family.app_error", nil, err.Error())
+		} else {
+			result.Data = family
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+
+	}()
+
+	return storeChannel
+}
+
+// RotateAccessData marks the access data for oldToken as rotated and saves its replacement. It fails
+// if the old access data was already rotated so that a refresh token can only be exchanged once.
+func (as SqlOAuthStore) RotateAccessData(oldToken string, accessData *model.AccessData) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if result.Err = accessData.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		transaction, err := as.GetMaster().Begin()
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.RotateAccessData", "store.sql_oauth.rotate_access_data.open_transaction.app_error", nil, err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if sqlResult, err := transaction.Exec("UPDATE OAuthAccessData SET RotatedAt = :RotatedAt WHERE Token = :Token AND RotatedAt = 0",
+			map[string]interface{}{"RotatedAt": model.GetMillis(), "Token": oldToken}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.RotateAccessData", "store.sql_oauth.rotate_access_data.app_error", nil, err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows != 1 {
+			result.Err = model.NewLocAppError("SqlOAuthStore.RotateAccessData", "store.sql_oauth.rotate_access_data.rotated.app_error", nil, "")
+		} else if err := transaction.Insert(accessData); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.RotateAccessData", "store.sql_oauth.save_access_data.app_error", nil, err.Error())
+		}
+
+		if result.Err != nil {
+			transaction.Rollback()
+		} else if err := transaction.Commit(); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.RotateAccessData", "store.sql_oauth.rotate_access_data.commit_transaction.app_error", nil, err.Error())
+		} else {
+			result.Data = accessData
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (as SqlOAuthStore) RemoveAccessDataFamily(authCode string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := as.GetMaster().Exec("DELETE FROM OAuthAccessData WHERE AuthCode = :AuthCode", map[string]interface{}{"AuthCode": authCode}); err != nil {
+			result.Err = model.NewLocAppError("SqlOAuthStore.RemoveAccessDataFamily", "store.sql_oauth.remove_access_data_family.app_error", nil, "err="+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (as SqlOAuthStore) RemoveAccessData(token string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
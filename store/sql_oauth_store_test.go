@@ -74,6 +74,57 @@ func TestOAuthStoreUpdateApp(t *testing.T) {
 	}
 }
 
+func TestOAuthStoreDeleteApp(t *testing.T) {
+	Setup()
+
+	a1 := model.OAuthApp{}
+	a1.CreatorId = model.NewId()
+	a1.Name = "TestApp" + model.NewId()
+	a1.CallbackUrls = []string{"https://nowhere.com"}
+	a1.Homepage = "https://nowhere.com"
+	Must(store.OAuth().SaveApp(&a1))
+
+	userId := model.NewId()
+
+	ad1 := model.AccessData{ClientId: a1.Id, UserId: userId, AuthCode: model.NewId(), Token: model.NewId(), RefreshToken: model.NewId()}
+	Must(store.OAuth().SaveAccessData(&ad1))
+
+	ad2 := model.AccessData{ClientId: a1.Id, UserId: model.NewId(), AuthCode: model.NewId(), Token: model.NewId(), RefreshToken: model.NewId()}
+	Must(store.OAuth().SaveAccessData(&ad2))
+
+	if apps := Must(store.OAuth().GetAuthorizedApps(userId)).([]*model.OAuthApp); len(apps) != 1 || apps[0].Id != a1.Id {
+		t.Fatal("should have returned the authorized app")
+	}
+
+	if accessData := Must(store.OAuth().GetAccessDataByApp(a1.Id, "")).([]*model.AccessData); len(accessData) != 2 {
+		t.Fatal("should have returned every token of the app")
+	}
+
+	if accessData := Must(store.OAuth().GetAccessDataByApp(a1.Id, userId)).([]*model.AccessData); len(accessData) != 1 || accessData[0].Token != ad1.Token {
+		t.Fatal("should have returned the user's token")
+	}
+
+	if err := (<-store.OAuth().UpdateAppSecret(a1.Id, model.HashPassword("secret"))).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if app := Must(store.OAuth().GetApp(a1.Id)).(*model.OAuthApp); !model.ComparePassword(app.ClientSecret, "secret") {
+		t.Fatal("should have updated the secret")
+	}
+
+	if err := (<-store.OAuth().DeleteApp(a1.Id)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (<-store.OAuth().GetApp(a1.Id)).Err; err == nil {
+		t.Fatal("should have deleted the app")
+	}
+
+	if accessData := Must(store.OAuth().GetAccessDataByApp(a1.Id, "")).([]*model.AccessData); len(accessData) != 0 {
+		t.Fatal("should have deleted the app's access data")
+	}
+}
+
 func TestOAuthStoreSaveAccessData(t *testing.T) {
 	Setup()
 
@@ -136,6 +187,48 @@ func TestOAuthStoreRemoveAccessData(t *testing.T) {
 	}
 }
 
+func TestOAuthStoreRotateAccessData(t *testing.T) {
+	Setup()
+
+	a1 := model.AccessData{}
+	a1.ClientId = model.NewId()
+	a1.UserId = model.NewId()
+	a1.AuthCode = model.NewId()
+	a1.Token = model.NewId()
+	a1.RefreshToken = model.NewId()
+	Must(store.OAuth().SaveAccessData(&a1))
+
+	a2 := model.AccessData{ClientId: a1.ClientId, UserId: a1.UserId, AuthCode: a1.AuthCode, Token: model.NewId(), RefreshToken: model.NewId()}
+	Must(store.OAuth().RotateAccessData(a1.Token, &a2))
+
+	if ra1 := Must(store.OAuth().GetAccessDataByRefreshToken(a1.RefreshToken)).(*model.AccessData); !ra1.IsRotated() {
+		t.Fatal("should have marked the old refresh token as rotated")
+	}
+
+	if ra2 := Must(store.OAuth().GetAccessDataByAuthCode(a1.AuthCode)).(*model.AccessData); ra2.Token != a2.Token {
+		t.Fatal("should have returned the live access token for the auth code")
+	}
+
+	a3 := model.AccessData{ClientId: a1.ClientId, UserId: a1.UserId, AuthCode: a1.AuthCode, Token: model.NewId(), RefreshToken: model.NewId()}
+	if err := (<-store.OAuth().RotateAccessData(a1.Token, &a3)).Err; err == nil {
+		t.Fatal("should not be able to rotate a refresh token twice")
+	}
+
+	if data := Must(store.OAuth().GetAccessDataByRefreshToken(model.NewId())); data != nil {
+		t.Fatal("should not have found an unknown refresh token")
+	}
+
+	if family := Must(store.OAuth().GetAccessDataFamily(a1.AuthCode)).([]*model.AccessData); len(family) != 2 {
+		t.Fatal("should have returned both tokens in the family")
+	}
+
+	Must(store.OAuth().RemoveAccessDataFamily(a1.AuthCode))
+
+	if family := Must(store.OAuth().GetAccessDataFamily(a1.AuthCode)).([]*model.AccessData); len(family) != 0 {
+		t.Fatal("should have removed the family")
+	}
+}
+
 func TestOAuthStoreSaveAuthData(t *testing.T) {
 	Setup()
 
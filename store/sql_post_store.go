@@ -38,6 +38,7 @@ func NewSqlPostStore(sqlStore *SqlStore) PostStore {
 }
 
 func (s SqlPostStore) UpgradeSchemaIfNeeded() {
+	s.CreateColumnIfNotExists("Posts", "IsPinned", "tinyint(1)", "boolean", "0")
 }
 
 func (s SqlPostStore) CreateIndexesIfNotExists() {
@@ -45,6 +46,7 @@ func (s SqlPostStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_posts_create_at", "Posts", "CreateAt")
 	s.CreateIndexIfNotExists("idx_posts_channel_id", "Posts", "ChannelId")
 	s.CreateIndexIfNotExists("idx_posts_root_id", "Posts", "RootId")
+	s.CreateIndexIfNotExists("idx_posts_channel_id_create_at", "Posts", "ChannelId, CreateAt")
 
 	s.CreateFullTextIndexIfNotExists("idx_posts_message_txt", "Posts", "Message")
 	s.CreateFullTextIndexIfNotExists("idx_posts_hashtags_txt", "Posts", "Hashtags")
@@ -97,6 +99,9 @@ func (s SqlPostStore) Save(post *model.Post) StoreChannel {
 	return storeChannel
 }
 
+// Update replaces the message of the post and keeps what it said before as a
+// revision row, marked as deleted at the time of the edit and linked to the live
+// post through OriginalId.
 func (s SqlPostStore) Update(oldPost *model.Post, newMessage string, newHashtags string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -112,6 +117,7 @@ func (s SqlPostStore) Update(oldPost *model.Post, newMessage string, newHashtags
 		oldPost.UpdateAt = editPost.UpdateAt
 		oldPost.OriginalId = oldPost.Id
 		oldPost.Id = model.NewId()
+		oldPost.IsPinned = false
 
 		if result.Err = editPost.IsValid(); result.Err != nil {
 			storeChannel <- result
@@ -119,20 +125,95 @@ func (s SqlPostStore) Update(oldPost *model.Post, newMessage string, newHashtags
 			return
 		}
 
-		if _, err := s.GetMaster().Update(&editPost); err != nil {
-			result.Err = model.NewLocAppError("SqlPostStore.Update", "store.sql_post.update.app_error", nil, "id="+editPost.Id+", "+err.Error())
+		if transaction, err := s.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.Update", "store.sql_post.update.open_transaction.app_error", nil, err.Error())
 		} else {
-			time := model.GetMillis()
-			s.GetMaster().Exec("UPDATE Channels SET LastPostAt = :LastPostAt  WHERE Id = :ChannelId", map[string]interface{}{"LastPostAt": time, "ChannelId": editPost.ChannelId})
+			if _, err := transaction.Update(&editPost); err != nil {
+				result.Err = model.NewLocAppError("SqlPostStore.Update", "store.sql_post.update.app_error", nil, "id="+editPost.Id+", "+err.Error())
+			} else if err := transaction.Insert(oldPost); err != nil {
+				// keep the previous revision of the post
+				result.Err = model.NewLocAppError("SqlPostStore.Update", "store.sql_post.update.revision.app_error", nil, "id="+editPost.Id+", "+err.Error())
+			}
+
+			if result.Err != nil {
+				transaction.Rollback()
+			} else if err := transaction.Commit(); err != nil {
+				result.Err = model.NewLocAppError("SqlPostStore.Update", "store.sql_post.update.commit_transaction.app_error", nil, err.Error())
+			} else {
+				time := model.GetMillis()
+				s.GetMaster().Exec("UPDATE Channels SET LastPostAt = :LastPostAt  WHERE Id = :ChannelId", map[string]interface{}{"LastPostAt": time, "ChannelId": editPost.ChannelId})
+
+				if len(editPost.RootId) > 0 {
+					s.GetMaster().Exec("UPDATE Posts SET UpdateAt = :UpdateAt WHERE Id = :RootId", map[string]interface{}{"UpdateAt": time, "RootId": editPost.RootId})
+				}
 
-			if len(editPost.RootId) > 0 {
-				s.GetMaster().Exec("UPDATE Posts SET UpdateAt = :UpdateAt WHERE Id = :RootId", map[string]interface{}{"UpdateAt": time, "RootId": editPost.RootId})
+				result.Data = &editPost
 			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Overwrite replaces the message and props of the post in place without keeping a revision, it's
+// used when an integration updates one of its own posts.
+func (s SqlPostStore) Overwrite(post *model.Post) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		post.UpdateAt = model.GetMillis()
+
+		if result.Err = post.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Update(post); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.Overwrite", "store.sql_post.overwrite.app_error", nil, "id="+post.Id+", "+err.Error())
+		} else {
+			result.Data = post
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetEditHistory returns the previous revisions of the post, most recent edit first.
+func (s SqlPostStore) GetEditHistory(postId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
 
-			// mark the old post as deleted
-			s.GetMaster().Insert(oldPost)
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts,
+			`SELECT
+				*
+			FROM
+				Posts
+			WHERE
+				OriginalId = :OriginalId
+			ORDER BY DeleteAt DESC`,
+			map[string]interface{}{"OriginalId": postId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetEditHistory", "store.sql_post.get_edit_history.app_error", nil, "id="+postId+", "+err.Error())
+		} else {
+			pl := &model.PostList{}
+
+			for _, p := range posts {
+				pl.AddPost(p)
+				pl.AddOrder(p.Id)
+			}
 
-			result.Data = &editPost
+			result.Data = pl
 		}
 
 		storeChannel <- result
@@ -183,6 +264,181 @@ func (s SqlPostStore) Get(id string) StoreChannel {
 	return storeChannel
 }
 
+// GetPostThread returns the root post and all of its live replies, newest first.
+func (s SqlPostStore) GetPostThread(rootId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var posts []*model.Post
+		_, err := s.GetReplica().Select(&posts,
+			`SELECT
+				*
+			FROM
+				Posts
+			WHERE
+				(Id = :Id OR RootId = :RootId)
+					AND DeleteAt = 0
+			ORDER BY CreateAt DESC`,
+			map[string]interface{}{"Id": rootId, "RootId": rootId})
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPostThread", "store.sql_post.get_post_thread.app_error", nil, "root_id="+rootId+", "+err.Error())
+		} else {
+			pl := &model.PostList{}
+			foundRoot := false
+
+			for _, p := range posts {
+				if p.Id == rootId {
+					foundRoot = true
+				}
+				pl.AddPost(p)
+				pl.AddOrder(p.Id)
+			}
+
+			if !foundRoot {
+				result.Err = model.NewLocAppError("SqlPostStore.GetPostThread", "store.sql_post.get_post_thread.missing_root.app_error", nil, "root_id="+rootId)
+			} else {
+				result.Data = pl
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdatePinned pins or unpins the post without creating a new revision of it.
+func (s SqlPostStore) UpdatePinned(postId string, isPinned bool) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var post model.Post
+		if err := s.GetMaster().SelectOne(&post, "SELECT * FROM Posts WHERE Id = :Id AND DeleteAt = 0", map[string]interface{}{"Id": postId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.UpdatePinned", "store.sql_post.update_pinned.app_error", nil, "id="+postId+", "+err.Error())
+		} else {
+			post.IsPinned = isPinned
+			post.UpdateAt = model.GetMillis()
+
+			if _, err := s.GetMaster().Exec("UPDATE Posts SET IsPinned = :IsPinned, UpdateAt = :UpdateAt WHERE Id = :Id",
+				map[string]interface{}{"IsPinned": post.IsPinned, "UpdateAt": post.UpdateAt, "Id": post.Id}); err != nil {
+				result.Err = model.NewLocAppError("SqlPostStore.UpdatePinned", "store.sql_post.update_pinned.app_error", nil, "id="+postId+", "+err.Error())
+			} else {
+				result.Data = &post
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetPinnedPosts returns the live pinned posts of the channel, newest first.
+func (s SqlPostStore) GetPinnedPosts(channelId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts,
+			`SELECT
+				*
+			FROM
+				Posts
+			WHERE
+				ChannelId = :ChannelId
+					AND IsPinned = :IsPinned
+					AND DeleteAt = 0
+			ORDER BY CreateAt DESC`,
+			map[string]interface{}{"ChannelId": channelId, "IsPinned": true}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPinnedPosts", "store.sql_post.get_pinned_posts.app_error", nil, "channel_id="+channelId+", "+err.Error())
+		} else {
+			pl := &model.PostList{}
+
+			for _, p := range posts {
+				pl.AddPost(p)
+				pl.AddOrder(p.Id)
+			}
+
+			result.Data = pl
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetFlaggedPosts returns a page of the live posts the user has flagged, newest
+// first, skipping posts in channels the user is no longer a member of.
+func (s SqlPostStore) GetFlaggedPosts(userId string, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if limit > 1000 {
+			result.Err = model.NewLocAppError("SqlPostStore.GetFlaggedPosts", "store.sql_post.get_flagged_posts.limit.app_error", nil, "user_id="+userId)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts,
+			`SELECT
+				*
+			FROM
+				Posts
+			WHERE
+				Id IN (SELECT
+						Name
+					FROM
+						Preferences
+					WHERE
+						UserId = :UserId
+							AND Category = :Category
+							AND Value = 'true')
+					AND ChannelId IN (SELECT
+						ChannelMembers.ChannelId
+					FROM
+						Channels,
+						ChannelMembers
+					WHERE
+						Channels.Id = ChannelMembers.ChannelId
+							AND Channels.DeleteAt = 0
+							AND ChannelMembers.UserId = :UserId)
+					AND DeleteAt = 0
+			ORDER BY CreateAt DESC
+			LIMIT :Limit OFFSET :Offset`,
+			map[string]interface{}{"UserId": userId, "Category": model.PREFERENCE_CATEGORY_FLAGGED_POST, "Offset": offset, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetFlaggedPosts", "store.sql_post.get_flagged_posts.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			pl := &model.PostList{}
+
+			for _, p := range posts {
+				pl.AddPost(p)
+				pl.AddOrder(p.Id)
+			}
+
+			result.Data = pl
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 type etagPosts struct {
 	Id       string
 	UpdateAt int64
@@ -215,7 +471,8 @@ func (s SqlPostStore) Delete(postId string, time int64) StoreChannel {
 	go func() {
 		result := StoreResult{}
 
-		_, err := s.GetMaster().Exec("Update Posts SET DeleteAt = :DeleteAt, UpdateAt = :UpdateAt WHERE Id = :Id OR ParentId = :ParentId OR RootId = :RootId", map[string]interface{}{"DeleteAt": time, "UpdateAt": time, "Id": postId, "ParentId": postId, "RootId": postId})
+		// Revisions left behind by edits keep the time of the edit they were replaced by
+		_, err := s.GetMaster().Exec("Update Posts SET DeleteAt = :DeleteAt, UpdateAt = :UpdateAt WHERE (Id = :Id OR ParentId = :ParentId OR RootId = :RootId) AND OriginalId = ''", map[string]interface{}{"DeleteAt": time, "UpdateAt": time, "Id": postId, "ParentId": postId, "RootId": postId})
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.Delete", "store.sql_post.delete.app_error", nil, "id="+postId+", err="+err.Error())
 		}
@@ -233,7 +490,7 @@ func (s SqlPostStore) permanentDelete(postId string) StoreChannel {
 	go func() {
 		result := StoreResult{}
 
-		_, err := s.GetMaster().Exec("DELETE FROM Posts WHERE Id = :Id OR ParentId = :ParentId OR RootId = :RootId", map[string]interface{}{"Id": postId, "ParentId": postId, "RootId": postId})
+		_, err := s.GetMaster().Exec("DELETE FROM Posts WHERE Id = :Id OR ParentId = :ParentId OR RootId = :RootId OR OriginalId = :OriginalId", map[string]interface{}{"Id": postId, "ParentId": postId, "RootId": postId, "OriginalId": postId})
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.Delete", "store.sql_post.permanent_delete.app_error", nil, "id="+postId+", err="+err.Error())
 		}
@@ -523,6 +780,199 @@ func (s SqlPostStore) getPostsAround(channelId string, postId string, numPosts i
 	return storeChannel
 }
 
+// GetPostsBeforeCursor returns up to limit posts created before the cursor post,
+// newest first. Posts are ordered by (CreateAt, Id) so that paging is stable even
+// when new posts arrive or several posts share the same CreateAt. An empty cursor
+// returns the most recent posts in the channel.
+func (s SqlPostStore) GetPostsBeforeCursor(channelId string, postId string, limit int) StoreChannel {
+	return s.getPostsByCursor(channelId, postId, limit, true, false)
+}
+
+// GetPostsAfterCursor returns up to limit posts created after the cursor post, newest first.
+func (s SqlPostStore) GetPostsAfterCursor(channelId string, postId string, limit int) StoreChannel {
+	return s.getPostsByCursor(channelId, postId, limit, false, true)
+}
+
+// GetPostsAroundCursor returns the cursor post along with up to limit posts on either side of it, newest first.
+func (s SqlPostStore) GetPostsAroundCursor(channelId string, postId string, limit int) StoreChannel {
+	return s.getPostsByCursor(channelId, postId, limit, true, true)
+}
+
+func (s SqlPostStore) getPostsByCursor(channelId string, postId string, limit int, before bool, after bool) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if limit > 1000 {
+			result.Err = model.NewLocAppError("SqlPostStore.getPostsByCursor", "store.sql_post.get_posts_by_cursor.limit.app_error", nil, "channelId="+channelId)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		var cursor *model.Post
+		if len(postId) > 0 {
+			cursor = &model.Post{}
+			if err := s.GetReplica().SelectOne(cursor, "SELECT * FROM Posts WHERE Id = :Id AND ChannelId = :ChannelId", map[string]interface{}{"Id": postId, "ChannelId": channelId}); err != nil {
+				result.Err = model.NewLocAppError("SqlPostStore.getPostsByCursor", "store.sql_post.get_posts_by_cursor.cursor.app_error", nil, "channelId="+channelId+", postId="+postId+", "+err.Error())
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+		} else if after {
+			result.Err = model.NewLocAppError("SqlPostStore.getPostsByCursor", "store.sql_post.get_posts_by_cursor.cursor.app_error", nil, "channelId="+channelId)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		var posts []*model.Post
+
+		if after {
+			var newer []*model.Post
+			if _, err := s.GetReplica().Select(&newer,
+				`SELECT
+				    *
+				FROM
+				    Posts
+				WHERE
+				    ChannelId = :ChannelId
+				        AND DeleteAt = 0
+				        AND (CreateAt > :CreateAt
+				            OR (CreateAt = :CreateAt AND Id > :Id))
+				ORDER BY CreateAt ASC, Id ASC
+				LIMIT :Limit`,
+				map[string]interface{}{"ChannelId": channelId, "CreateAt": cursor.CreateAt, "Id": cursor.Id, "Limit": limit}); err != nil {
+				result.Err = model.NewLocAppError("SqlPostStore.getPostsByCursor", "store.sql_post.get_posts_by_cursor.get.app_error", nil, "channelId="+channelId+", "+err.Error())
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+
+			// newer posts were selected oldest first so flip them around
+			for i := len(newer) - 1; i >= 0; i-- {
+				posts = append(posts, newer[i])
+			}
+		}
+
+		if before && after && cursor.DeleteAt == 0 {
+			posts = append(posts, cursor)
+		}
+
+		if before {
+			var older []*model.Post
+			var err error
+
+			if cursor == nil {
+				_, err = s.GetReplica().Select(&older,
+					`SELECT
+					    *
+					FROM
+					    Posts
+					WHERE
+					    ChannelId = :ChannelId
+					        AND DeleteAt = 0
+					ORDER BY CreateAt DESC, Id DESC
+					LIMIT :Limit`,
+					map[string]interface{}{"ChannelId": channelId, "Limit": limit})
+			} else {
+				_, err = s.GetReplica().Select(&older,
+					`SELECT
+					    *
+					FROM
+					    Posts
+					WHERE
+					    ChannelId = :ChannelId
+					        AND DeleteAt = 0
+					        AND (CreateAt < :CreateAt
+					            OR (CreateAt = :CreateAt AND Id < :Id))
+					ORDER BY CreateAt DESC, Id DESC
+					LIMIT :Limit`,
+					map[string]interface{}{"ChannelId": channelId, "CreateAt": cursor.CreateAt, "Id": cursor.Id, "Limit": limit})
+			}
+
+			if err != nil {
+				result.Err = model.NewLocAppError("SqlPostStore.getPostsByCursor", "store.sql_post.get_posts_by_cursor.get.app_error", nil, "channelId="+channelId+", "+err.Error())
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+
+			posts = append(posts, older...)
+		}
+
+		list := &model.PostList{Order: make([]string, 0, len(posts))}
+
+		for _, p := range posts {
+			list.AddPost(p)
+			list.AddOrder(p.Id)
+		}
+
+		if parents, err := s.getThreadsForPosts(channelId, posts); err != nil {
+			result.Err = err
+		} else {
+			for _, p := range parents {
+				if _, ok := list.Posts[p.Id]; !ok {
+					list.AddPost(p)
+				}
+			}
+
+			list.MakeNonNil()
+			result.Data = list
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// getThreadsForPosts returns the roots and replies of the threads the given posts belong to.
+func (s SqlPostStore) getThreadsForPosts(channelId string, posts []*model.Post) ([]*model.Post, *model.AppError) {
+	rootIds := make(map[string]bool)
+	for _, p := range posts {
+		if len(p.RootId) > 0 {
+			rootIds[p.RootId] = true
+		}
+	}
+
+	if len(rootIds) == 0 {
+		return []*model.Post{}, nil
+	}
+
+	inClause := ""
+	params := map[string]interface{}{"ChannelId": channelId}
+	i := 0
+	for rootId := range rootIds {
+		paramName := "RootId" + strconv.Itoa(i)
+		if i > 0 {
+			inClause += ", "
+		}
+		inClause += ":" + paramName
+		params[paramName] = rootId
+		i++
+	}
+
+	var parents []*model.Post
+	if _, err := s.GetReplica().Select(&parents,
+		`SELECT
+		    *
+		FROM
+		    Posts
+		WHERE
+		    ChannelId = :ChannelId
+		        AND DeleteAt = 0
+		        AND (Id IN (`+inClause+`)
+		            OR RootId IN (`+inClause+`))
+		ORDER BY CreateAt`, params); err != nil {
+		return nil, model.NewLocAppError("SqlPostStore.getThreadsForPosts", "store.sql_post.get_posts_by_cursor.get_parent.app_error", nil, "channelId="+channelId+", "+err.Error())
+	}
+
+	return parents, nil
+}
+
 func (s SqlPostStore) getRootPosts(channelId string, offset int, limit int) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -614,9 +1064,14 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 		termMap := map[string]bool{}
 		terms := params.Terms
 
-		if terms == "" && len(params.InChannels) == 0 && len(params.FromUsers) == 0 {
-			result.Data = []*model.Post{}
+		hasFilters := len(params.InChannels) != 0 || len(params.FromUsers) != 0 || params.CreatedAfter != 0 || params.CreatedBefore != 0 || params.HasFile || params.HasLink
+
+		if terms == "" && !hasFilters {
+			list := &model.PostList{}
+			list.MakeNonNil()
+			result.Data = list
 			storeChannel <- result
+			close(storeChannel)
 			return
 		}
 
@@ -656,6 +1111,7 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 							AND UserId = :UserId
 							AND DeleteAt = 0
 							CHANNEL_FILTER)
+				MODIFIER_FILTER
 				SEARCH_CLAUSE
 				ORDER BY CreateAt DESC
 			LIMIT 100`
@@ -716,8 +1172,52 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 			searchQuery = strings.Replace(searchQuery, "POST_FILTER", "", 1)
 		}
 
+		modifierFilter := ""
+
+		if params.CreatedAfter != 0 {
+			modifierFilter += " AND CreateAt > :CreatedAfter"
+			queryParams["CreatedAfter"] = params.CreatedAfter
+		}
+
+		if params.CreatedBefore != 0 {
+			modifierFilter += " AND CreateAt < :CreatedBefore"
+			queryParams["CreatedBefore"] = params.CreatedBefore
+		}
+
+		if params.HasFile {
+			modifierFilter += " AND Filenames != '[]'"
+		}
+
+		if params.HasLink {
+			modifierFilter += " AND (Message LIKE '%http://%' OR Message LIKE '%https://%')"
+		}
+
+		if len(params.ExcludedTerms) > 0 {
+			excludedTerms := strings.Join(params.ExcludedTerms, " ")
+			for _, c := range specialSearchChar {
+				excludedTerms = strings.Replace(excludedTerms, c, " ", -1)
+			}
+
+			if len(strings.Fields(excludedTerms)) > 0 {
+				if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+					if wildcard, err := regexp.Compile("\\*($| )"); err == nil {
+						excludedTerms = wildcard.ReplaceAllLiteralString(excludedTerms, ":* ")
+					}
+
+					// a post is excluded if it contains any of the terms
+					queryParams["ExcludedTerms"] = strings.Join(strings.Fields(excludedTerms), " | ")
+					modifierFilter += " AND NOT (Message @@ to_tsquery(:ExcludedTerms))"
+				} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_MYSQL {
+					queryParams["ExcludedTerms"] = strings.Join(strings.Fields(excludedTerms), " ")
+					modifierFilter += " AND NOT MATCH (Message) AGAINST (:ExcludedTerms IN BOOLEAN MODE)"
+				}
+			}
+		}
+
+		searchQuery = strings.Replace(searchQuery, "MODIFIER_FILTER", modifierFilter, 1)
+
 		if terms == "" {
-			// we've already confirmed that we have a channel or user to search for
+			// we've already confirmed that we have a filter to search with
 			searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", "", 1)
 		} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
 			// Parse text for wildcards
@@ -783,6 +1283,77 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 	return storeChannel
 }
 
+func (s SqlPostStore) GetPostsByIds(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(postIds) == 0 {
+			result.Data = []*model.Post{}
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		inClause := ""
+		params := make(map[string]interface{})
+		for i, postId := range postIds {
+			paramName := "PostId" + strconv.Itoa(i)
+			if i > 0 {
+				inClause += ", "
+			}
+			inClause += ":" + paramName
+			params[paramName] = postId
+		}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts, "SELECT * FROM Posts WHERE Id IN ("+inClause+") AND DeleteAt = 0", params); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPostsByIds", "store.sql_post.get_posts_by_ids.app_error", nil, err.Error())
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetPostsBatchForIndexing returns live posts in creation order, starting after the
+// post with the given CreateAt and Id so that every post is visited exactly once.
+func (s SqlPostStore) GetPostsBatchForIndexing(createAt int64, postId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts,
+			`SELECT
+			    *
+			FROM
+			    Posts
+			WHERE
+			    DeleteAt = 0
+			        AND (CreateAt > :CreateAt
+			            OR (CreateAt = :CreateAt AND Id > :Id))
+			ORDER BY CreateAt ASC, Id ASC
+			LIMIT :Limit`,
+			map[string]interface{}{"CreateAt": createAt, "Id": postId, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetPostsBatchForIndexing", "store.sql_post.get_posts_batch_for_indexing.app_error", nil, err.Error())
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlPostStore) GetForExport(channelId string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -62,6 +62,168 @@ func TestPostStoreGet(t *testing.T) {
 	}
 }
 
+func TestPostStoreGetPostThread(t *testing.T) {
+	Setup()
+
+	o1 := &model.Post{}
+	o1.ChannelId = model.NewId()
+	o1.UserId = model.NewId()
+	o1.Message = "a" + model.NewId() + "b"
+	o1 = (<-store.Post().Save(o1)).Data.(*model.Post)
+	time.Sleep(2 * time.Millisecond)
+
+	o2 := &model.Post{}
+	o2.ChannelId = o1.ChannelId
+	o2.UserId = model.NewId()
+	o2.Message = "a" + model.NewId() + "b"
+	o2.ParentId = o1.Id
+	o2.RootId = o1.Id
+	o2 = (<-store.Post().Save(o2)).Data.(*model.Post)
+	time.Sleep(2 * time.Millisecond)
+
+	o3 := &model.Post{}
+	o3.ChannelId = o1.ChannelId
+	o3.UserId = model.NewId()
+	o3.Message = "a" + model.NewId() + "b"
+	o3.ParentId = o1.Id
+	o3.RootId = o1.Id
+	o3 = (<-store.Post().Save(o3)).Data.(*model.Post)
+
+	Must(store.Post().Delete(o3.Id, model.GetMillis()))
+
+	pl := Must(store.Post().GetPostThread(o1.Id)).(*model.PostList)
+	if len(pl.Order) != 2 {
+		t.Fatal("thread should contain the root and one live reply")
+	}
+
+	if pl.Order[0] != o2.Id || pl.Order[1] != o1.Id {
+		t.Fatal("thread should be ordered newest first")
+	}
+
+	if err := (<-store.Post().GetPostThread(model.NewId())).Err; err == nil {
+		t.Fatal("missing root should have failed")
+	}
+}
+
+func TestPostStorePinned(t *testing.T) {
+	Setup()
+
+	o1 := &model.Post{}
+	o1.ChannelId = model.NewId()
+	o1.UserId = model.NewId()
+	o1.Message = "a" + model.NewId() + "b"
+	o1 = Must(store.Post().Save(o1)).(*model.Post)
+	time.Sleep(2 * time.Millisecond)
+
+	o2 := &model.Post{}
+	o2.ChannelId = o1.ChannelId
+	o2.UserId = model.NewId()
+	o2.Message = "a" + model.NewId() + "b"
+	o2 = Must(store.Post().Save(o2)).(*model.Post)
+
+	if pl := Must(store.Post().GetPinnedPosts(o1.ChannelId)).(*model.PostList); len(pl.Order) != 0 {
+		t.Fatal("should have no pinned posts")
+	}
+
+	if pinned := Must(store.Post().UpdatePinned(o1.Id, true)).(*model.Post); !pinned.IsPinned || pinned.UpdateAt == o1.UpdateAt {
+		t.Fatal("should have pinned the post")
+	}
+	Must(store.Post().UpdatePinned(o2.Id, true))
+
+	pl := Must(store.Post().GetPinnedPosts(o1.ChannelId)).(*model.PostList)
+	if len(pl.Order) != 2 || pl.Order[0] != o2.Id {
+		t.Fatal("should have returned the pinned posts newest first")
+	}
+
+	Must(store.Post().UpdatePinned(o2.Id, false))
+	Must(store.Post().Delete(o1.Id, model.GetMillis()))
+
+	if pl := Must(store.Post().GetPinnedPosts(o1.ChannelId)).(*model.PostList); len(pl.Order) != 0 {
+		t.Fatal("should not return unpinned or deleted posts")
+	}
+
+	if err := (<-store.Post().UpdatePinned(o1.Id, true)).Err; err == nil {
+		t.Fatal("should not be able to pin a deleted post")
+	}
+}
+
+func TestPostStoreGetFlaggedPosts(t *testing.T) {
+	Setup()
+
+	c1 := &model.Channel{}
+	c1.TeamId = model.NewId()
+	c1.DisplayName = "Channel1"
+	c1.Name = "a" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	c1 = Must(store.Channel().Save(c1)).(*model.Channel)
+
+	userId := model.NewId()
+
+	m1 := &model.ChannelMember{}
+	m1.ChannelId = c1.Id
+	m1.UserId = userId
+	m1.NotifyProps = model.GetDefaultChannelNotifyProps()
+	Must(store.Channel().SaveMember(m1))
+
+	o1 := &model.Post{}
+	o1.ChannelId = c1.Id
+	o1.UserId = model.NewId()
+	o1.Message = "a" + model.NewId() + "b"
+	o1 = Must(store.Post().Save(o1)).(*model.Post)
+	time.Sleep(2 * time.Millisecond)
+
+	o2 := &model.Post{}
+	o2.ChannelId = c1.Id
+	o2.UserId = model.NewId()
+	o2.Message = "a" + model.NewId() + "b"
+	o2 = Must(store.Post().Save(o2)).(*model.Post)
+
+	o3 := &model.Post{}
+	o3.ChannelId = model.NewId()
+	o3.UserId = model.NewId()
+	o3.Message = "a" + model.NewId() + "b"
+	o3 = Must(store.Post().Save(o3)).(*model.Post)
+
+	if pl := Must(store.Post().GetFlaggedPosts(userId, 0, 10)).(*model.PostList); len(pl.Order) != 0 {
+		t.Fatal("should have no flagged posts")
+	}
+
+	preferences := model.Preferences{
+		{UserId: userId, Category: model.PREFERENCE_CATEGORY_FLAGGED_POST, Name: o1.Id, Value: "true"},
+		{UserId: userId, Category: model.PREFERENCE_CATEGORY_FLAGGED_POST, Name: o2.Id, Value: "true"},
+		{UserId: userId, Category: model.PREFERENCE_CATEGORY_FLAGGED_POST, Name: o3.Id, Value: "true"},
+	}
+	Must(store.Preference().Save(&preferences))
+
+	pl := Must(store.Post().GetFlaggedPosts(userId, 0, 10)).(*model.PostList)
+	if len(pl.Order) != 2 || pl.Order[0] != o2.Id {
+		t.Fatal("should have returned the flagged posts in channels the user is a member of")
+	}
+
+	if pl := Must(store.Post().GetFlaggedPosts(userId, 1, 1)).(*model.PostList); len(pl.Order) != 1 || pl.Order[0] != o1.Id {
+		t.Fatal("should have returned the second page")
+	}
+
+	Must(store.Post().Delete(o2.Id, model.GetMillis()))
+
+	if pl := Must(store.Post().GetFlaggedPosts(userId, 0, 10)).(*model.PostList); len(pl.Order) != 1 {
+		t.Fatal("should not return deleted posts")
+	}
+
+	preferences = model.Preferences{
+		{UserId: userId, Category: model.PREFERENCE_CATEGORY_FLAGGED_POST, Name: o1.Id, Value: "false"},
+	}
+	Must(store.Preference().Save(&preferences))
+
+	if pl := Must(store.Post().GetFlaggedPosts(userId, 0, 10)).(*model.PostList); len(pl.Order) != 0 {
+		t.Fatal("should not return unflagged posts")
+	}
+
+	if err := (<-store.Post().GetFlaggedPosts(userId, 0, 1001)).Err; err == nil {
+		t.Fatal("should have failed - limit too large")
+	}
+}
+
 func TestPostStoreUpdate(t *testing.T) {
 	Setup()
 
@@ -128,6 +290,81 @@ func TestPostStoreUpdate(t *testing.T) {
 
 }
 
+func TestPostStoreGetEditHistory(t *testing.T) {
+	Setup()
+
+	o1 := &model.Post{}
+	o1.ChannelId = model.NewId()
+	o1.UserId = model.NewId()
+	o1.Message = "a" + model.NewId() + "b"
+	o1 = Must(store.Post().Save(o1)).(*model.Post)
+
+	id := o1.Id
+	msg1 := o1.Message
+	msg2 := "a" + model.NewId() + "b"
+	msg3 := "a" + model.NewId() + "b"
+
+	ro1 := Must(store.Post().Get(id)).(*model.PostList).Posts[id]
+	Must(store.Post().Update(ro1, msg2, ""))
+	time.Sleep(2 * time.Millisecond)
+
+	ro2 := Must(store.Post().Get(id)).(*model.PostList).Posts[id]
+	Must(store.Post().Update(ro2, msg3, ""))
+
+	pl := Must(store.Post().GetEditHistory(id)).(*model.PostList)
+	if len(pl.Order) != 2 {
+		t.Fatal("should have returned both previous revisions")
+	}
+
+	if pl.Posts[pl.Order[0]].Message != msg2 || pl.Posts[pl.Order[1]].Message != msg1 {
+		t.Fatal("should have returned the most recent revision first")
+	}
+
+	for _, p := range pl.Posts {
+		if p.OriginalId != id || p.DeleteAt == 0 {
+			t.Fatal("revisions should be deleted and linked to the live post")
+		}
+	}
+
+	editedAt := pl.Posts[pl.Order[0]].DeleteAt
+	Must(store.Post().Delete(id, model.GetMillis()+1000))
+
+	pl = Must(store.Post().GetEditHistory(id)).(*model.PostList)
+	if pl.Posts[pl.Order[0]].DeleteAt != editedAt {
+		t.Fatal("deleting the post should not change when its revisions were replaced")
+	}
+}
+
+func TestPostStoreOverwrite(t *testing.T) {
+	Setup()
+
+	o1 := &model.Post{}
+	o1.ChannelId = model.NewId()
+	o1.UserId = model.NewId()
+	o1.Message = "a" + model.NewId() + "b"
+	o1.Type = model.POST_SLACK_ATTACHMENT
+	o1 = Must(store.Post().Save(o1)).(*model.Post)
+
+	ro1 := Must(store.Post().Get(o1.Id)).(*model.PostList).Posts[o1.Id]
+	ro1.Message = "a" + model.NewId() + "b"
+	ro1.AddProp("attachments", []interface{}{map[string]interface{}{"text": "updated"}})
+	Must(store.Post().Overwrite(ro1))
+
+	ro2 := Must(store.Post().Get(o1.Id)).(*model.PostList).Posts[o1.Id]
+	if ro2.Message != ro1.Message || ro2.Props["attachments"] == nil {
+		t.Fatal("should have overwritten the post")
+	}
+
+	if pl := Must(store.Post().GetEditHistory(o1.Id)).(*model.PostList); len(pl.Order) != 0 {
+		t.Fatal("shouldn't have kept a revision")
+	}
+
+	ro2.Message = strings.Repeat("a", 4001)
+	if err := (<-store.Post().Overwrite(ro2)).Err; err == nil {
+		t.Fatal("should have failed - message too long")
+	}
+}
+
 func TestPostStoreDelete(t *testing.T) {
 	Setup()
 
@@ -559,6 +796,65 @@ func TestPostStoreGetPostsBeforeAfter(t *testing.T) {
 	}
 }
 
+func TestPostStoreGetPostsByCursor(t *testing.T) {
+	Setup()
+
+	channelId := model.NewId()
+	createAt := model.GetMillis()
+
+	// p1 and p2 share a CreateAt so they can only be told apart by Id
+	var posts []*model.Post
+	for i, at := range []int64{createAt, createAt + 1, createAt + 1, createAt + 2, createAt + 3} {
+		p := &model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: at}
+		if i == 4 {
+			p.RootId = posts[0].Id
+			p.ParentId = posts[0].Id
+		}
+		posts = append(posts, Must(store.Post().Save(p)).(*model.Post))
+	}
+
+	if posts[1].Id > posts[2].Id {
+		posts[1], posts[2] = posts[2], posts[1]
+	}
+
+	latest := Must(store.Post().GetPostsBeforeCursor(channelId, "", 2)).(*model.PostList)
+	if len(latest.Order) != 2 || latest.Order[0] != posts[4].Id || latest.Order[1] != posts[3].Id {
+		t.Fatal("should have returned the newest posts")
+	}
+
+	if _, ok := latest.Posts[posts[0].Id]; !ok {
+		t.Fatal("should have included the root of the reply")
+	}
+
+	before := Must(store.Post().GetPostsBeforeCursor(channelId, latest.Order[1], 2)).(*model.PostList)
+	if len(before.Order) != 2 || before.Order[0] != posts[2].Id || before.Order[1] != posts[1].Id {
+		t.Fatal("should have paged past posts with the same create time in order")
+	}
+
+	before = Must(store.Post().GetPostsBeforeCursor(channelId, before.Order[1], 2)).(*model.PostList)
+	if len(before.Order) != 1 || before.Order[0] != posts[0].Id {
+		t.Fatal("should have returned the last page")
+	}
+
+	after := Must(store.Post().GetPostsAfterCursor(channelId, posts[1].Id, 2)).(*model.PostList)
+	if len(after.Order) != 2 || after.Order[0] != posts[3].Id || after.Order[1] != posts[2].Id {
+		t.Fatal("should have returned the newer posts newest first")
+	}
+
+	around := Must(store.Post().GetPostsAroundCursor(channelId, posts[2].Id, 1)).(*model.PostList)
+	if len(around.Order) != 3 || around.Order[0] != posts[3].Id || around.Order[1] != posts[2].Id || around.Order[2] != posts[1].Id {
+		t.Fatal("should have returned the posts around the cursor")
+	}
+
+	if err := (<-store.Post().GetPostsAfterCursor(channelId, model.NewId(), 2)).Err; err == nil {
+		t.Fatal("should have failed with an unknown cursor")
+	}
+
+	if err := (<-store.Post().GetPostsBeforeCursor(channelId, "", 1001)).Err; err == nil {
+		t.Fatal("should have failed with too large a limit")
+	}
+}
+
 func TestPostStoreGetPostsSince(t *testing.T) {
 	Setup()
 	o0 := &model.Post{}
@@ -644,6 +940,100 @@ func TestPostStoreGetPostsSince(t *testing.T) {
 	}
 }
 
+func TestPostStoreGetPostsByIds(t *testing.T) {
+	Setup()
+
+	o1 := Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "a" + model.NewId() + "b"})).(*model.Post)
+	o2 := Must(store.Post().Save(&model.Post{ChannelId: o1.ChannelId, UserId: model.NewId(), Message: "a" + model.NewId() + "b"})).(*model.Post)
+	o3 := Must(store.Post().Save(&model.Post{ChannelId: o1.ChannelId, UserId: model.NewId(), Message: "a" + model.NewId() + "b"})).(*model.Post)
+	Must(store.Post().Delete(o3.Id, model.GetMillis()))
+
+	if posts := Must(store.Post().GetPostsByIds([]string{o1.Id, o2.Id, o3.Id, model.NewId()})).([]*model.Post); len(posts) != 2 {
+		t.Fatal("should have returned the two live posts")
+	}
+
+	if posts := Must(store.Post().GetPostsByIds([]string{})).([]*model.Post); len(posts) != 0 {
+		t.Fatal("should have returned no posts")
+	}
+}
+
+func TestPostStoreGetPostsBatchForIndexing(t *testing.T) {
+	Setup()
+
+	createAt := model.GetMillis() + 100000
+
+	o1 := Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: createAt})).(*model.Post)
+	o2 := Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: createAt})).(*model.Post)
+	o3 := Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "a" + model.NewId() + "b", CreateAt: createAt + 1})).(*model.Post)
+
+	if o1.Id > o2.Id {
+		o1, o2 = o2, o1
+	}
+
+	posts := Must(store.Post().GetPostsBatchForIndexing(createAt-1, "", 2)).([]*model.Post)
+	if len(posts) != 2 || posts[0].Id != o1.Id || posts[1].Id != o2.Id {
+		t.Fatal("should have returned the first batch in order")
+	}
+
+	posts = Must(store.Post().GetPostsBatchForIndexing(posts[1].CreateAt, posts[1].Id, 2)).([]*model.Post)
+	if len(posts) != 1 || posts[0].Id != o3.Id {
+		t.Fatal("should have continued after the last post")
+	}
+}
+
+func TestPostStoreSearchModifiers(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	userId := model.NewId()
+
+	c1 := &model.Channel{TeamId: teamId, DisplayName: "Channel1", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	c1 = Must(store.Channel().Save(c1)).(*model.Channel)
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	day := int64(1467331200000) // 2016-07-01 UTC
+
+	o1 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "quarterly report draft", CreateAt: day - 1000})).(*model.Post)
+	o2 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "quarterly report final http://example.com", CreateAt: day + 1000})).(*model.Post)
+	o3 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "quarterly report attached", CreateAt: day + model.SEARCH_DAY_MILLISECONDS + 1000, Filenames: []string{"/" + c1.Id + "/report.pdf"}})).(*model.Post)
+
+	search := func(terms string) *model.PostList {
+		return Must(store.Post().Search(teamId, userId, model.ParseSearchParams(terms)[0])).(*model.PostList)
+	}
+
+	if r := search("quarterly before:2016-07-01"); len(r.Order) != 1 || r.Order[0] != o1.Id {
+		t.Fatal("should have only found posts before the date")
+	}
+
+	if r := search("quarterly after:2016-07-01"); len(r.Order) != 1 || r.Order[0] != o3.Id {
+		t.Fatal("should have only found posts after the date")
+	}
+
+	if r := search("quarterly on:2016-07-01"); len(r.Order) != 1 || r.Order[0] != o2.Id {
+		t.Fatal("should have only found posts on the date")
+	}
+
+	if r := search("quarterly -draft"); len(r.Order) != 2 {
+		t.Fatal("should have excluded the term")
+	}
+
+	if r := search("quarterly -draft -final"); len(r.Order) != 1 || r.Order[0] != o3.Id {
+		t.Fatal("should have excluded every term")
+	}
+
+	if r := search("quarterly has:file"); len(r.Order) != 1 || r.Order[0] != o3.Id {
+		t.Fatal("should have only found posts with files")
+	}
+
+	if r := search("quarterly has:link"); len(r.Order) != 1 || r.Order[0] != o2.Id {
+		t.Fatal("should have only found posts with links")
+	}
+
+	if r := search("has:link"); len(r.Order) != 1 || r.Order[0] != o2.Id {
+		t.Fatal("should have searched with just a modifier")
+	}
+}
+
 func TestPostStoreSearch(t *testing.T) {
 	Setup()
 
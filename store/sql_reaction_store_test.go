@@ -0,0 +1,66 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestReactionStore(t *testing.T) {
+	Setup()
+
+	post := &model.Post{}
+	post.ChannelId = model.NewId()
+	post.UserId = model.NewId()
+	post.Message = "a" + model.NewId() + "b"
+	post = Must(store.Post().Save(post)).(*model.Post)
+
+	userId1 := model.NewId()
+	userId2 := model.NewId()
+
+	reaction1 := &model.Reaction{UserId: userId1, PostId: post.Id, EmojiName: "smile"}
+	Must(store.Reaction().Save(reaction1))
+	Must(store.Reaction().Save(&model.Reaction{UserId: userId1, PostId: post.Id, EmojiName: "smile"}))
+	Must(store.Reaction().Save(&model.Reaction{UserId: userId2, PostId: post.Id, EmojiName: "smile"}))
+	Must(store.Reaction().Save(&model.Reaction{UserId: userId2, PostId: post.Id, EmojiName: "frown"}))
+
+	if err := (<-store.Reaction().Save(&model.Reaction{UserId: userId1, PostId: post.Id})).Err; err == nil {
+		t.Fatal("should have failed validation")
+	}
+
+	if reactions := Must(store.Reaction().GetForPost(post.Id)).([]*model.Reaction); len(reactions) != 3 {
+		t.Fatal("should have three reactions")
+	}
+
+	if reactions := Must(store.Reaction().GetForPosts([]string{post.Id, model.NewId()})).([]*model.Reaction); len(reactions) != 3 {
+		t.Fatal("should have three reactions")
+	}
+
+	if reactions := Must(store.Reaction().GetForPosts([]string{})).([]*model.Reaction); len(reactions) != 0 {
+		t.Fatal("should have no reactions")
+	}
+
+	if reactions := Must(store.Reaction().GetForExport(post.ChannelId)).([]*model.Reaction); len(reactions) != 3 {
+		t.Fatal("should have exported three reactions")
+	}
+
+	updated := Must(store.Post().Get(post.Id)).(*model.PostList).Posts[post.Id]
+	if updated.UpdateAt == post.UpdateAt {
+		t.Fatal("reacting should have updated the post")
+	}
+
+	Must(store.Reaction().Delete(reaction1))
+
+	if reactions := Must(store.Reaction().GetForPost(post.Id)).([]*model.Reaction); len(reactions) != 2 {
+		t.Fatal("should have removed the reaction")
+	}
+
+	Must(store.Reaction().PermanentDeleteByUser(userId2))
+
+	if reactions := Must(store.Reaction().GetForPost(post.Id)).([]*model.Reaction); len(reactions) != 0 {
+		t.Fatal("should have removed the user's reactions")
+	}
+}
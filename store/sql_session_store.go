@@ -24,6 +24,7 @@ func NewSqlSessionStore(sqlStore *SqlStore) SessionStore {
 		table.ColMap("DeviceId").SetMaxSize(512)
 		table.ColMap("Roles").SetMaxSize(64)
 		table.ColMap("Props").SetMaxSize(1000)
+		table.ColMap("Scope").SetMaxSize(128)
 	}
 
 	return us
@@ -35,6 +36,8 @@ func (me SqlSessionStore) UpgradeSchemaIfNeeded() {
 	if len(deviceIdLength) > 0 && deviceIdLength != "512" {
 		me.AlterColumnTypeIfExists("Sessions", "DeviceId", "VARCHAR(512)", "VARCHAR(512)")
 	}
+
+	me.CreateColumnIfNotExists("Sessions", "Scope", "varchar(128)", "varchar(128)", "")
 }
 
 func (me SqlSessionStore) CreateIndexesIfNotExists() {
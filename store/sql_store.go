@@ -52,6 +52,10 @@ type SqlStore struct {
 	preference    PreferenceStore
 	license       LicenseStore
 	recovery      PasswordRecoveryStore
+	notification  NotificationQueueStore
+	thread        ThreadStore
+	reaction      ReactionStore
+	accessToken   UserAccessTokenStore
 	SchemaVersion string
 }
 
@@ -127,6 +131,10 @@ func NewSqlStore() Store {
 	sqlStore.preference = NewSqlPreferenceStore(sqlStore)
 	sqlStore.license = NewSqlLicenseStore(sqlStore)
 	sqlStore.recovery = NewSqlPasswordRecoveryStore(sqlStore)
+	sqlStore.notification = NewSqlNotificationQueueStore(sqlStore)
+	sqlStore.thread = NewSqlThreadStore(sqlStore)
+	sqlStore.reaction = NewSqlReactionStore(sqlStore)
+	sqlStore.accessToken = NewSqlUserAccessTokenStore(sqlStore)
 
 	err := sqlStore.master.CreateTablesIfNotExists()
 	if err != nil {
@@ -149,6 +157,10 @@ func NewSqlStore() Store {
 	sqlStore.preference.(*SqlPreferenceStore).UpgradeSchemaIfNeeded()
 	sqlStore.license.(*SqlLicenseStore).UpgradeSchemaIfNeeded()
 	sqlStore.recovery.(*SqlPasswordRecoveryStore).UpgradeSchemaIfNeeded()
+	sqlStore.notification.(*SqlNotificationQueueStore).UpgradeSchemaIfNeeded()
+	sqlStore.thread.(*SqlThreadStore).UpgradeSchemaIfNeeded()
+	sqlStore.reaction.(*SqlReactionStore).UpgradeSchemaIfNeeded()
+	sqlStore.accessToken.(*SqlUserAccessTokenStore).UpgradeSchemaIfNeeded()
 
 	sqlStore.team.(*SqlTeamStore).CreateIndexesIfNotExists()
 	sqlStore.channel.(*SqlChannelStore).CreateIndexesIfNotExists()
@@ -164,6 +176,10 @@ func NewSqlStore() Store {
 	sqlStore.preference.(*SqlPreferenceStore).CreateIndexesIfNotExists()
 	sqlStore.license.(*SqlLicenseStore).CreateIndexesIfNotExists()
 	sqlStore.recovery.(*SqlPasswordRecoveryStore).CreateIndexesIfNotExists()
+	sqlStore.notification.(*SqlNotificationQueueStore).CreateIndexesIfNotExists()
+	sqlStore.thread.(*SqlThreadStore).CreateIndexesIfNotExists()
+	sqlStore.reaction.(*SqlReactionStore).CreateIndexesIfNotExists()
+	sqlStore.accessToken.(*SqlUserAccessTokenStore).CreateIndexesIfNotExists()
 
 	sqlStore.preference.(*SqlPreferenceStore).DeleteUnusedFeatures()
 
@@ -681,6 +697,22 @@ func (ss SqlStore) PasswordRecovery() PasswordRecoveryStore {
 	return ss.recovery
 }
 
+func (ss SqlStore) NotificationQueue() NotificationQueueStore {
+	return ss.notification
+}
+
+func (ss SqlStore) Thread() ThreadStore {
+	return ss.thread
+}
+
+func (ss SqlStore) Reaction() ReactionStore {
+	return ss.reaction
+}
+
+func (ss SqlStore) UserAccessToken() UserAccessTokenStore {
+	return ss.accessToken
+}
+
 func (ss SqlStore) DropAllTables() {
 	ss.master.TruncateTables()
 }
@@ -698,6 +730,11 @@ func (me mattermConverter) ToDb(val interface{}) (interface{}, error) {
 		return encrypt([]byte(utils.Cfg.SqlSettings.AtRestEncryptKey), model.MapToJson(t))
 	case model.StringInterface:
 		return model.StringInterfaceToJson(t), nil
+	case *model.AutocompleteData:
+		if t == nil {
+			return "", nil
+		}
+		return t.ToJson(), nil
 	}
 
 	return val, nil
@@ -751,6 +788,19 @@ func (me mattermConverter) FromDb(target interface{}) (gorp.CustomScanner, bool)
 			return json.Unmarshal(b, target)
 		}
 		return gorp.CustomScanner{new(string), target, binder}, true
+	case **model.AutocompleteData:
+		binder := func(holder, target interface{}) error {
+			s, ok := holder.(*string)
+			if !ok {
+				return errors.New(utils.T("store.sql.convert_autocomplete_data"))
+			}
+			if len(*s) == 0 {
+				return nil
+			}
+			b := []byte(*s)
+			return json.Unmarshal(b, target)
+		}
+		return gorp.CustomScanner{Holder: new(string), Target: target, Binder: binder}, true
 	}
 
 	return gorp.CustomScanner{}, false
@@ -0,0 +1,259 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlThreadStore struct {
+	*SqlStore
+}
+
+func NewSqlThreadStore(sqlStore *SqlStore) ThreadStore {
+	s := &SqlThreadStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ThreadFollower{}, "ThreadFollowers").SetKeys(false, "RootId", "UserId")
+		table.ColMap("RootId").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlThreadStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlThreadStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_thread_followers_user_id", "ThreadFollowers", "UserId")
+	s.CreateIndexIfNotExists("idx_thread_followers_channel_id", "ThreadFollowers", "ChannelId")
+}
+
+// SaveFollower adds the user as a follower of the thread. Following a thread
+// that the user already follows is not an error and leaves the existing row untouched.
+func (s SqlThreadStore) SaveFollower(follower *model.ThreadFollower) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		follower.PreSave()
+		if result.Err = follower.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().SelectInt(
+			`SELECT
+				COUNT(*)
+			FROM
+				ThreadFollowers
+			WHERE
+				RootId = :RootId
+					AND UserId = :UserId`,
+			map[string]interface{}{"RootId": follower.RootId, "UserId": follower.UserId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.SaveFollower", "store.sql_thread.save_follower.app_error", nil, "root_id="+follower.RootId+", user_id="+follower.UserId+", "+err.Error())
+		} else if count == 0 {
+			if err := s.GetMaster().Insert(follower); err != nil {
+				result.Err = model.NewLocAppError("SqlThreadStore.SaveFollower", "store.sql_thread.save_follower.app_error", nil, "root_id="+follower.RootId+", user_id="+follower.UserId+", "+err.Error())
+			}
+		}
+
+		if result.Err == nil {
+			result.Data = follower
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadStore) GetFollower(rootId string, userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var follower model.ThreadFollower
+		if err := s.GetReplica().SelectOne(&follower,
+			`SELECT
+				*
+			FROM
+				ThreadFollowers
+			WHERE
+				RootId = :RootId
+					AND UserId = :UserId`,
+			map[string]interface{}{"RootId": rootId, "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.GetFollower", "store.sql_thread.get_follower.app_error", nil, "root_id="+rootId+", user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = &follower
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadStore) GetFollowers(rootId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var followers []*model.ThreadFollower
+		if _, err := s.GetReplica().Select(&followers,
+			`SELECT
+				*
+			FROM
+				ThreadFollowers
+			WHERE
+				RootId = :RootId`,
+			map[string]interface{}{"RootId": rootId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.GetFollowers", "store.sql_thread.get_followers.app_error", nil, "root_id="+rootId+", "+err.Error())
+		} else {
+			result.Data = followers
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetFollowedThreads returns the threads the user follows in the channels they still belong to
+// on the given team, most recently followed first.
+func (s SqlThreadStore) GetFollowedThreads(teamId string, userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var followers []*model.ThreadFollower
+		if _, err := s.GetReplica().Select(&followers,
+			`SELECT
+				ThreadFollowers.*
+			FROM
+				ThreadFollowers, Channels, ChannelMembers
+			WHERE
+				ThreadFollowers.UserId = :UserId
+					AND Channels.Id = ThreadFollowers.ChannelId
+					AND (Channels.TeamId = :TeamId OR Channels.TeamId = '')
+					AND Channels.DeleteAt = 0
+					AND ChannelMembers.ChannelId = ThreadFollowers.ChannelId
+					AND ChannelMembers.UserId = ThreadFollowers.UserId
+			ORDER BY ThreadFollowers.CreateAt DESC`,
+			map[string]interface{}{"TeamId": teamId, "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.GetFollowedThreads", "store.sql_thread.get_followed_threads.app_error", nil, "team_id="+teamId+", user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = followers
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadStore) RemoveFollower(rootId string, userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM ThreadFollowers WHERE RootId = :RootId AND UserId = :UserId",
+			map[string]interface{}{"RootId": rootId, "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.RemoveFollower", "store.sql_thread.remove_follower.app_error", nil, "root_id="+rootId+", user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadStore) IncrementMentionCount(rootId string, userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec(
+			`UPDATE
+				ThreadFollowers
+			SET
+				MentionCount = MentionCount + 1
+			WHERE
+				RootId = :RootId
+					AND UserId = :UserId`,
+			map[string]interface{}{"RootId": rootId, "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.IncrementMentionCount", "store.sql_thread.increment_mention_count.app_error", nil, "root_id="+rootId+", user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadStore) UpdateLastViewedAt(rootId string, userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec(
+			`UPDATE
+				ThreadFollowers
+			SET
+				MentionCount = 0,
+				LastViewedAt = :LastViewedAt
+			WHERE
+				RootId = :RootId
+					AND UserId = :UserId`,
+			map[string]interface{}{"RootId": rootId, "UserId": userId, "LastViewedAt": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.UpdateLastViewedAt", "store.sql_thread.update_last_viewed_at.app_error", nil, "root_id="+rootId+", user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadStore) PermanentDeleteByUser(userId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM ThreadFollowers WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlThreadStore.PermanentDeleteByUser", "store.sql_thread.permanent_delete_by_user.app_error", nil, "user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestThreadStore(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	userId1 := model.NewId()
+	userId2 := model.NewId()
+
+	channel := &model.Channel{TeamId: teamId, DisplayName: "Channel1", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	channel = Must(store.Channel().Save(channel)).(*model.Channel)
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: channel.Id, UserId: userId1, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	rootId := model.NewId()
+	channelId := channel.Id
+
+	Must(store.Thread().SaveFollower(&model.ThreadFollower{RootId: rootId, UserId: userId1, ChannelId: channelId}))
+	Must(store.Thread().SaveFollower(&model.ThreadFollower{RootId: rootId, UserId: userId2, ChannelId: channelId}))
+
+	if err := (<-store.Thread().SaveFollower(&model.ThreadFollower{RootId: rootId, UserId: userId1, ChannelId: channelId})).Err; err != nil {
+		t.Fatal("following twice should not fail", err)
+	}
+
+	if err := (<-store.Thread().SaveFollower(&model.ThreadFollower{RootId: rootId})).Err; err == nil {
+		t.Fatal("should have failed validation")
+	}
+
+	followers := Must(store.Thread().GetFollowers(rootId)).([]*model.ThreadFollower)
+	if len(followers) != 2 {
+		t.Fatal("should have two followers")
+	}
+
+	Must(store.Thread().IncrementMentionCount(rootId, userId1))
+	Must(store.Thread().IncrementMentionCount(rootId, userId1))
+
+	follower := Must(store.Thread().GetFollower(rootId, userId1)).(*model.ThreadFollower)
+	if follower.MentionCount != 2 {
+		t.Fatal("mention count should have been incremented")
+	}
+
+	Must(store.Thread().UpdateLastViewedAt(rootId, userId1))
+
+	follower = Must(store.Thread().GetFollower(rootId, userId1)).(*model.ThreadFollower)
+	if follower.MentionCount != 0 {
+		t.Fatal("mention count should have been reset")
+	}
+
+	followed := Must(store.Thread().GetFollowedThreads(teamId, userId1)).([]*model.ThreadFollower)
+	if len(followed) != 1 || followed[0].RootId != rootId {
+		t.Fatal("should follow one thread")
+	}
+
+	if followed := Must(store.Thread().GetFollowedThreads(model.NewId(), userId1)).([]*model.ThreadFollower); len(followed) != 0 {
+		t.Fatal("shouldn't return threads from another team")
+	}
+
+	if followed := Must(store.Thread().GetFollowedThreads(teamId, userId2)).([]*model.ThreadFollower); len(followed) != 0 {
+		t.Fatal("shouldn't return threads in channels the user isn't a member of")
+	}
+
+	Must(store.Thread().RemoveFollower(rootId, userId1))
+
+	if err := (<-store.Thread().GetFollower(rootId, userId1)).Err; err == nil {
+		t.Fatal("follower should have been removed")
+	}
+
+	Must(store.Thread().PermanentDeleteByUser(userId2))
+
+	followers = Must(store.Thread().GetFollowers(rootId)).([]*model.ThreadFollower)
+	if len(followers) != 0 {
+		t.Fatal("followers should have been deleted")
+	}
+}
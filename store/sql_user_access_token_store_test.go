@@ -0,0 +1,54 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestUserAccessTokenStore(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+
+	t1 := &model.UserAccessToken{UserId: userId, Description: "deploy"}
+	t1 = Must(store.UserAccessToken().Save(t1)).(*model.UserAccessToken)
+
+	t2 := &model.UserAccessToken{UserId: userId, Description: "backups"}
+	t2 = Must(store.UserAccessToken().Save(t2)).(*model.UserAccessToken)
+
+	if err := (<-store.UserAccessToken().Save(&model.UserAccessToken{UserId: userId})).Err; err == nil {
+		t.Fatal("should have required a description")
+	}
+
+	if token := Must(store.UserAccessToken().GetByToken(t1.Token)); token == nil || token.(*model.UserAccessToken).Id != t1.Id {
+		t.Fatal("should have found the token by its value")
+	}
+
+	if token := Must(store.UserAccessToken().GetByToken(t1.TokenHash)); token != nil {
+		t.Fatal("should not have found the token by its hash")
+	}
+
+	if token := Must(store.UserAccessToken().Get(t2.Id)).(*model.UserAccessToken); token.Description != "backups" || len(token.Token) != 0 {
+		t.Fatal("should have returned the token without its value")
+	}
+
+	if tokens := Must(store.UserAccessToken().GetByUser(userId)).([]*model.UserAccessToken); len(tokens) != 2 {
+		t.Fatal("should have returned both tokens")
+	}
+
+	Must(store.UserAccessToken().Delete(t1.Id))
+
+	if token := Must(store.UserAccessToken().GetByToken(t1.Token)); token != nil {
+		t.Fatal("should have deleted the token")
+	}
+
+	Must(store.UserAccessToken().PermanentDeleteByUser(userId))
+
+	if tokens := Must(store.UserAccessToken().GetByUser(userId)).([]*model.UserAccessToken); len(tokens) != 0 {
+		t.Fatal("should have deleted the user's tokens")
+	}
+}
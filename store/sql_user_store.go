@@ -41,6 +41,10 @@ func NewSqlUserStore(sqlStore *SqlStore) UserStore {
 		table.ColMap("ThemeProps").SetMaxSize(2000)
 		table.ColMap("Locale").SetMaxSize(5)
 		table.ColMap("MfaSecret").SetMaxSize(128)
+		table.ColMap("MfaRecoveryCodes").SetMaxSize(1024)
+		table.ColMap("PasswordHistory").SetMaxSize(1024)
+		table.ColMap("BotOwnerId").SetMaxSize(26)
+		table.ColMap("BotOwnerType").SetMaxSize(32)
 	}
 
 	return us
@@ -49,6 +53,14 @@ func NewSqlUserStore(sqlStore *SqlStore) UserStore {
 func (us SqlUserStore) UpgradeSchemaIfNeeded() {
 	// ADDED for 2.0 REMOVE for 2.4
 	us.CreateColumnIfNotExists("Users", "Locale", "varchar(5)", "character varying(5)", model.DEFAULT_LOCALE)
+
+	us.CreateColumnIfNotExists("Users", "IsBot", "tinyint(1)", "boolean", "0")
+	us.CreateColumnIfNotExists("Users", "BotOwnerId", "varchar(26)", "varchar(26)", "")
+	us.CreateColumnIfNotExists("Users", "BotOwnerType", "varchar(32)", "varchar(32)", "")
+
+	us.CreateColumnIfNotExists("Users", "MfaRecoveryCodes", "varchar(1024)", "varchar(1024)", "")
+	us.CreateColumnIfNotExists("Users", "MfaLastTimeStep", "bigint(20)", "bigint", "0")
+	us.CreateColumnIfNotExists("Users", "PasswordHistory", "varchar(1024)", "varchar(1024)", "")
 }
 
 func (us SqlUserStore) CreateIndexesIfNotExists() {
@@ -128,6 +140,12 @@ func (us SqlUserStore) Update(user *model.User, trustedUpdateData bool) StoreCha
 			user.FailedAttempts = oldUser.FailedAttempts
 			user.MfaSecret = oldUser.MfaSecret
 			user.MfaActive = oldUser.MfaActive
+			user.MfaRecoveryCodes = oldUser.MfaRecoveryCodes
+			user.MfaLastTimeStep = oldUser.MfaLastTimeStep
+			user.PasswordHistory = oldUser.PasswordHistory
+			user.IsBot = oldUser.IsBot
+			user.BotOwnerId = oldUser.BotOwnerId
+			user.BotOwnerType = oldUser.BotOwnerType
 
 			if !trustedUpdateData {
 				user.Roles = oldUser.Roles
@@ -382,6 +400,96 @@ func (us SqlUserStore) UpdateMfaActive(userId string, active bool) StoreChannel
 	return storeChannel
 }
 
+func (us SqlUserStore) UpdateMfaRecoveryCodes(userId, codes string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		updateAt := model.GetMillis()
+
+		if _, err := us.GetMaster().Exec("UPDATE Users SET MfaRecoveryCodes = :Codes, UpdateAt = :UpdateAt WHERE Id = :UserId", map[string]interface{}{"Codes": codes, "UpdateAt": updateAt, "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlUserStore.UpdateMfaRecoveryCodes", "store.sql_user.update_mfa_recovery_codes.app_error", nil, "id="+userId+", "+err.Error())
+		} else {
+			result.Data = userId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// ReplaceMfaRecoveryCodes only updates the recovery codes if they haven't changed since they were
+// read, the result is false if they have so that a code can't be used twice by concurrent requests.
+func (us SqlUserStore) ReplaceMfaRecoveryCodes(userId, oldCodes, newCodes string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		updateAt := model.GetMillis()
+
+		if sqlResult, err := us.GetMaster().Exec("UPDATE Users SET MfaRecoveryCodes = :NewCodes, UpdateAt = :UpdateAt WHERE Id = :UserId AND MfaRecoveryCodes = :OldCodes", map[string]interface{}{"NewCodes": newCodes, "UpdateAt": updateAt, "UserId": userId, "OldCodes": oldCodes}); err != nil {
+			result.Err = model.NewLocAppError("SqlUserStore.ReplaceMfaRecoveryCodes", "store.sql_user.update_mfa_recovery_codes.app_error", nil, "id="+userId+", "+err.Error())
+		} else {
+			rows, _ := sqlResult.RowsAffected()
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateMfaLastTimeStep records the time step of the last MFA code accepted for the user. The
+// result is false if the step isn't newer than the recorded one, ie. the code was already used.
+func (us SqlUserStore) UpdateMfaLastTimeStep(userId string, step int64) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := us.GetMaster().Exec("UPDATE Users SET MfaLastTimeStep = :Step WHERE Id = :UserId AND MfaLastTimeStep < :Step", map[string]interface{}{"Step": step, "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlUserStore.UpdateMfaLastTimeStep", "store.sql_user.update_mfa_last_time_step.app_error", nil, "id="+userId+", "+err.Error())
+		} else {
+			rows, _ := sqlResult.RowsAffected()
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (us SqlUserStore) UpdatePasswordHistory(userId, history string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := us.GetMaster().Exec("UPDATE Users SET PasswordHistory = :History WHERE Id = :UserId", map[string]interface{}{"History": history, "UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlUserStore.UpdatePasswordHistory", "store.sql_user.update_password_history.app_error", nil, "id="+userId+", "+err.Error())
+		} else {
+			result.Data = userId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (us SqlUserStore) Get(id string) StoreChannel {
 
 	storeChannel := make(StoreChannel)
@@ -710,6 +818,55 @@ func (us SqlUserStore) GetSystemAdminProfiles() StoreChannel {
 	return storeChannel
 }
 
+func (us SqlUserStore) GetBotsByOwner(ownerId string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var users []*model.User
+
+		if _, err := us.GetReplica().Select(&users, "SELECT * FROM Users WHERE IsBot = true AND BotOwnerId = :OwnerId ORDER BY Username", map[string]interface{}{"OwnerId": ownerId}); err != nil {
+			result.Err = model.NewLocAppError("SqlUserStore.GetBotsByOwner", "store.sql_user.get_bots_by_owner.app_error", nil, "owner_id="+ownerId+", "+err.Error())
+		} else {
+			for _, u := range users {
+				u.Password = ""
+				u.AuthData = ""
+			}
+
+			result.Data = users
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (us SqlUserStore) GetAllUsingAuthService(authService string) StoreChannel {
+
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var users []*model.User
+
+		if _, err := us.GetReplica().Select(&users, "SELECT * FROM Users WHERE AuthService = :AuthService ORDER BY Username", map[string]interface{}{"AuthService": authService}); err != nil {
+			result.Err = model.NewLocAppError("SqlUserStore.GetAllUsingAuthService", "store.sql_user.get_all_using_auth_service.app_error", nil, "auth_service="+authService+", "+err.Error())
+		} else {
+			result.Data = users
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (us SqlUserStore) GetByEmail(email string) StoreChannel {
 
 	storeChannel := make(StoreChannel)
@@ -897,7 +1054,7 @@ func (us SqlUserStore) GetTotalActiveUsersCount() StoreChannel {
 
 		time := model.GetMillis() - (1000 * 60 * 60 * 24)
 
-		if count, err := us.GetReplica().SelectInt("SELECT COUNT(Id) FROM Users WHERE LastActivityAt > :Time", map[string]interface{}{"Time": time}); err != nil {
+		if count, err := us.GetReplica().SelectInt("SELECT COUNT(Id) FROM Users WHERE LastActivityAt > :Time AND IsBot = false", map[string]interface{}{"Time": time}); err != nil {
 			result.Err = model.NewLocAppError("SqlUserStore.GetTotalActiveUsersCount", "store.sql_user.get_total_active_users_count.app_error", nil, err.Error())
 		} else {
 			result.Data = count
@@ -434,6 +434,72 @@ func TestUserStoreGetSystemAdminProfiles(t *testing.T) {
 	}
 }
 
+func TestUserStoreBots(t *testing.T) {
+	Setup()
+
+	ownerId := model.NewId()
+
+	u1 := &model.User{}
+	u1.Email = model.NewId()
+	Must(store.User().Save(u1))
+
+	bot := &model.User{}
+	bot.Email = model.NewId()
+	bot.IsBot = true
+	bot.BotOwnerId = ownerId
+	bot.BotOwnerType = model.BOT_OWNER_TYPE_USER
+	Must(store.User().Save(bot))
+
+	before := Must(store.User().GetTotalActiveUsersCount()).(int64)
+
+	Must(store.User().UpdateLastActivityAt(u1.Id, model.GetMillis()))
+	Must(store.User().UpdateLastActivityAt(bot.Id, model.GetMillis()))
+
+	if after := Must(store.User().GetTotalActiveUsersCount()).(int64); after != before+1 {
+		t.Fatal("should not have counted the bot as an active user", before, after)
+	}
+
+	if bots := Must(store.User().GetBotsByOwner(ownerId)).([]*model.User); len(bots) != 1 || bots[0].Id != bot.Id {
+		t.Fatal("should have returned the bot")
+	}
+
+	bot.BotOwnerId = model.NewId()
+	Must(store.User().Update(bot, true))
+
+	if bots := Must(store.User().GetBotsByOwner(ownerId)).([]*model.User); len(bots) != 1 {
+		t.Fatal("should not have changed the bot owner on update")
+	}
+}
+
+func TestUserStoreGetAllUsingAuthService(t *testing.T) {
+	Setup()
+
+	u1 := &model.User{}
+	u1.Email = model.NewId()
+	u1.AuthService = model.USER_AUTH_SERVICE_LDAP
+	u1.AuthData = model.NewId()
+	Must(store.User().Save(u1))
+
+	u2 := &model.User{}
+	u2.Email = model.NewId()
+	Must(store.User().Save(u2))
+
+	users := Must(store.User().GetAllUsingAuthService(model.USER_AUTH_SERVICE_LDAP)).([]*model.User)
+
+	found := false
+	for _, user := range users {
+		if user.Id == u1.Id {
+			found = true
+		} else if user.Id == u2.Id {
+			t.Fatal("should only have returned users of the auth service")
+		}
+	}
+
+	if !found {
+		t.Fatal("should have returned the ldap user")
+	}
+}
+
 func TestUserStoreGetByEmail(t *testing.T) {
 	Setup()
 
@@ -742,6 +808,99 @@ func TestUserStoreUpdateMfaSecret(t *testing.T) {
 	}
 }
 
+func TestUserStoreUpdateMfaRecoveryCodes(t *testing.T) {
+	Setup()
+
+	u1 := model.User{}
+	u1.Email = model.NewId()
+	Must(store.User().Save(&u1))
+
+	if err := (<-store.User().UpdateMfaRecoveryCodes(u1.Id, "hash1 hash2")).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	user := Must(store.User().Get(u1.Id)).(*model.User)
+	if user.MfaRecoveryCodes != "hash1 hash2" {
+		t.Fatal("should have saved the recovery codes")
+	}
+
+	user.Nickname = "nick"
+	Must(store.User().Update(user, false))
+
+	if user := Must(store.User().Get(u1.Id)).(*model.User); user.MfaRecoveryCodes != "hash1 hash2" {
+		t.Fatal("should have kept the recovery codes when updating the user")
+	}
+}
+
+func TestUserStoreReplaceMfaRecoveryCodes(t *testing.T) {
+	Setup()
+
+	u1 := model.User{}
+	u1.Email = model.NewId()
+	Must(store.User().Save(&u1))
+	Must(store.User().UpdateMfaRecoveryCodes(u1.Id, "hash1 hash2"))
+
+	if replaced := Must(store.User().ReplaceMfaRecoveryCodes(u1.Id, "hash1 hash2", "hash2")).(bool); !replaced {
+		t.Fatal("should have replaced the recovery codes")
+	}
+
+	if replaced := Must(store.User().ReplaceMfaRecoveryCodes(u1.Id, "hash1 hash2", "hash1")).(bool); replaced {
+		t.Fatal("shouldn't have replaced codes that have changed")
+	}
+
+	if user := Must(store.User().Get(u1.Id)).(*model.User); user.MfaRecoveryCodes != "hash2" {
+		t.Fatal("should have kept the first replacement")
+	}
+}
+
+func TestUserStoreUpdateMfaLastTimeStep(t *testing.T) {
+	Setup()
+
+	u1 := model.User{}
+	u1.Email = model.NewId()
+	Must(store.User().Save(&u1))
+
+	if updated := Must(store.User().UpdateMfaLastTimeStep(u1.Id, 100)).(bool); !updated {
+		t.Fatal("should have recorded the time step")
+	}
+
+	if updated := Must(store.User().UpdateMfaLastTimeStep(u1.Id, 100)).(bool); updated {
+		t.Fatal("shouldn't have recorded the same time step twice")
+	}
+
+	if updated := Must(store.User().UpdateMfaLastTimeStep(u1.Id, 99)).(bool); updated {
+		t.Fatal("shouldn't have recorded an older time step")
+	}
+
+	if user := Must(store.User().Get(u1.Id)).(*model.User); user.MfaLastTimeStep != 100 {
+		t.Fatal("should have kept the newest time step")
+	}
+}
+
+func TestUserStoreUpdatePasswordHistory(t *testing.T) {
+	Setup()
+
+	u1 := model.User{}
+	u1.Email = model.NewId()
+	Must(store.User().Save(&u1))
+
+	if err := (<-store.User().UpdatePasswordHistory(u1.Id, "hash1 hash2")).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	user := Must(store.User().Get(u1.Id)).(*model.User)
+	if user.PasswordHistory != "hash1 hash2" {
+		t.Fatal("should have saved the password history")
+	}
+
+	user.Nickname = "nick"
+	Must(store.User().Update(user, false))
+
+	if user := Must(store.User().Get(u1.Id)).(*model.User); user.PasswordHistory != "hash1 hash2" {
+		t.Fatal("should have kept the password history when updating the user")
+	}
+}
+
 func TestUserStoreUpdateMfaActive(t *testing.T) {
 	Setup()
 
@@ -7,6 +7,12 @@ import (
 	"github.com/mattermost/platform/model"
 )
 
+const (
+	// hooks used to be deleted right before their channel rather than at the same time, those
+	// deleted shortly before the channel are considered as deleted along with it
+	CHANNEL_WEBHOOKS_DELETE_WINDOW = 5000
+)
+
 type SqlWebhookStore struct {
 	*SqlStore
 }
@@ -33,6 +39,21 @@ func NewSqlWebhookStore(sqlStore *SqlStore) WebhookStore {
 		tableo.ColMap("CallbackURLs").SetMaxSize(1024)
 		tableo.ColMap("DisplayName").SetMaxSize(64)
 		tableo.ColMap("Description").SetMaxSize(128)
+		tableo.ColMap("Secret").SetMaxSize(26)
+		tableo.ColMap("ContentType").SetMaxSize(128)
+
+		tabled := db.AddTableWithName(model.OutgoingWebhookDelivery{}, "OutgoingWebhookDeliveries").SetKeys(false, "Id")
+		tabled.ColMap("Id").SetMaxSize(26)
+		tabled.ColMap("HookId").SetMaxSize(26)
+		tabled.ColMap("TeamId").SetMaxSize(26)
+		tabled.ColMap("ChannelId").SetMaxSize(26)
+		tabled.ColMap("PostId").SetMaxSize(26)
+		tabled.ColMap("URL").SetMaxSize(1024)
+		tabled.ColMap("ContentType").SetMaxSize(128)
+		tabled.ColMap("Payload").SetMaxSize(model.WEBHOOK_DELIVERY_PAYLOAD_MAX_LENGTH)
+		tabled.ColMap("Status").SetMaxSize(32)
+		tabled.ColMap("Error").SetMaxSize(model.WEBHOOK_DELIVERY_ERROR_MAX_LENGTH)
+		tabled.ColMap("SiteURL").SetMaxSize(512)
 	}
 
 	return s
@@ -44,12 +65,22 @@ func (s SqlWebhookStore) UpgradeSchemaIfNeeded() {
 
 	s.CreateColumnIfNotExists("OutgoingWebhooks", "DisplayName", "varchar(64)", "varchar(64)", "")
 	s.CreateColumnIfNotExists("OutgoingWebhooks", "Description", "varchar(128)", "varchar(128)", "")
+	s.CreateColumnIfNotExists("OutgoingWebhooks", "Secret", "varchar(26)", "varchar(26)", "")
+	s.CreateColumnIfNotExists("OutgoingWebhooks", "TriggerWhen", "int(11)", "integer", "0")
+	s.CreateColumnIfNotExists("OutgoingWebhooks", "ContentType", "varchar(128)", "varchar(128)", "")
+	s.CreateColumnIfNotExists("OutgoingWebhooks", "IncludePrivateChannels", "tinyint(1)", "boolean", "0")
+
+	s.CreateColumnIfNotExists("OutgoingWebhookDeliveries", "PostId", "varchar(26)", "varchar(26)", "")
+	s.CreateColumnIfNotExists("OutgoingWebhookDeliveries", "NextAttemptAt", "bigint(20)", "bigint", "0")
+	s.CreateColumnIfNotExists("OutgoingWebhookDeliveries", "SiteURL", "varchar(512)", "varchar(512)", "")
 }
 
 func (s SqlWebhookStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_incoming_webhook_user_id", "IncomingWebhooks", "UserId")
 	s.CreateIndexIfNotExists("idx_incoming_webhook_team_id", "IncomingWebhooks", "TeamId")
 	s.CreateIndexIfNotExists("idx_outgoing_webhook_team_id", "OutgoingWebhooks", "TeamId")
+	s.CreateIndexIfNotExists("idx_outgoing_webhook_delivery_hook_id", "OutgoingWebhookDeliveries", "HookId")
+	s.CreateIndexIfNotExists("idx_outgoing_webhook_delivery_next_attempt_at", "OutgoingWebhookDeliveries", "NextAttemptAt")
 }
 
 func (s SqlWebhookStore) SaveIncoming(webhook *model.IncomingWebhook) StoreChannel {
@@ -125,6 +156,52 @@ func (s SqlWebhookStore) DeleteIncoming(webhookId string, time int64) StoreChann
 	return storeChannel
 }
 
+// RestoreIncomingByChannel re-enables the incoming webhooks of the channel that were deleted
+// along with it, deleteAt is when the channel itself was deleted.
+func (s SqlWebhookStore) RestoreIncomingByChannel(channelId string, deleteAt int64, time int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		_, err := s.GetMaster().Exec("Update IncomingWebhooks SET DeleteAt = 0, UpdateAt = :UpdateAt WHERE ChannelId = :ChannelId AND DeleteAt <= :DeleteAt AND DeleteAt >= :DeletedSince", map[string]interface{}{"UpdateAt": time, "ChannelId": channelId, "DeleteAt": deleteAt, "DeletedSince": deleteAt - CHANNEL_WEBHOOKS_DELETE_WINDOW})
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.RestoreIncomingByChannel", "store.sql_webhooks.restore_incoming_by_channel.app_error", nil, "channelId="+channelId+", err="+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) UpdateIncoming(hook *model.IncomingWebhook) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		hook.PreUpdate()
+		if result.Err = hook.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Update(hook); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.UpdateIncoming", "store.sql_webhooks.update_incoming.app_error", nil, "id="+hook.Id+", "+err.Error())
+		} else {
+			result.Data = hook
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlWebhookStore) PermanentDeleteIncomingByUser(userId string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -300,6 +377,26 @@ func (s SqlWebhookStore) DeleteOutgoing(webhookId string, time int64) StoreChann
 	return storeChannel
 }
 
+// RestoreOutgoingByChannel re-enables the outgoing webhooks of the channel that were deleted
+// along with it, deleteAt is when the channel itself was deleted.
+func (s SqlWebhookStore) RestoreOutgoingByChannel(channelId string, deleteAt int64, time int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		_, err := s.GetMaster().Exec("Update OutgoingWebhooks SET DeleteAt = 0, UpdateAt = :UpdateAt WHERE ChannelId = :ChannelId AND DeleteAt <= :DeleteAt AND DeleteAt >= :DeletedSince", map[string]interface{}{"UpdateAt": time, "ChannelId": channelId, "DeleteAt": deleteAt, "DeletedSince": deleteAt - CHANNEL_WEBHOOKS_DELETE_WINDOW})
+		if err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.RestoreOutgoingByChannel", "store.sql_webhooks.restore_outgoing_by_channel.app_error", nil, "channelId="+channelId+", err="+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlWebhookStore) PermanentDeleteOutgoingByUser(userId string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -339,6 +436,182 @@ func (s SqlWebhookStore) UpdateOutgoing(hook *model.OutgoingWebhook) StoreChanne
 	return storeChannel
 }
 
+func (s SqlWebhookStore) SaveDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(delivery.Id) > 0 {
+			result.Err = model.NewLocAppError("SqlWebhookStore.SaveDelivery",
+				"store.sql_webhooks.save_delivery.existing.app_error", nil, "id="+delivery.Id)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		delivery.PreSave()
+		if result.Err = delivery.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(delivery); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.SaveDelivery", "store.sql_webhooks.save_delivery.app_error", nil, "id="+delivery.Id+", "+err.Error())
+		} else {
+			result.Data = delivery
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) UpdateDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		delivery.PreUpdate()
+		if result.Err = delivery.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Update(delivery); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.UpdateDelivery", "store.sql_webhooks.update_delivery.app_error", nil, "id="+delivery.Id+", "+err.Error())
+		} else {
+			result.Data = delivery
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) GetDelivery(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var delivery model.OutgoingWebhookDelivery
+
+		if err := s.GetReplica().SelectOne(&delivery, "SELECT * FROM OutgoingWebhookDeliveries WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.GetDelivery", "store.sql_webhooks.get_delivery.app_error", nil, "id="+id+", err="+err.Error())
+		}
+
+		result.Data = &delivery
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetDeliveriesByHook returns the logged deliveries of the hook, most recent first.
+func (s SqlWebhookStore) GetDeliveriesByHook(hookId string, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var deliveries []*model.OutgoingWebhookDelivery
+
+		if _, err := s.GetReplica().Select(&deliveries, "SELECT * FROM OutgoingWebhookDeliveries WHERE HookId = :HookId ORDER BY CreateAt DESC LIMIT :Limit OFFSET :Offset", map[string]interface{}{"HookId": hookId, "Offset": offset, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.GetDeliveriesByHook", "store.sql_webhooks.get_deliveries_by_hook.app_error", nil, "hookId="+hookId+", err="+err.Error())
+		}
+
+		result.Data = deliveries
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetDueDeliveries returns the pending deliveries whose next attempt is due at time, oldest first.
+func (s SqlWebhookStore) GetDueDeliveries(time int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var deliveries []*model.OutgoingWebhookDelivery
+
+		if _, err := s.GetMaster().Select(&deliveries,
+			`SELECT
+				*
+			FROM
+				OutgoingWebhookDeliveries
+			WHERE
+				Status = :Status
+				AND NextAttemptAt > 0
+				AND NextAttemptAt <= :Time
+			ORDER BY NextAttemptAt
+			LIMIT :Limit`, map[string]interface{}{"Status": model.WEBHOOK_DELIVERY_STATUS_PENDING, "Time": time, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.GetDueDeliveries", "store.sql_webhooks.get_due_deliveries.app_error", nil, "err="+err.Error())
+		}
+
+		result.Data = deliveries
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// ClaimDelivery moves the next attempt of the delivery to newNextAttemptAt if it's still at
+// nextAttemptAt, so that a single server sends each attempt. The result is true if it was claimed.
+func (s SqlWebhookStore) ClaimDelivery(id string, nextAttemptAt int64, newNextAttemptAt int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("UPDATE OutgoingWebhookDeliveries SET NextAttemptAt = :NewNextAttemptAt WHERE Id = :Id AND NextAttemptAt = :NextAttemptAt",
+			map[string]interface{}{"Id": id, "NextAttemptAt": nextAttemptAt, "NewNextAttemptAt": newNextAttemptAt}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.ClaimDelivery", "store.sql_webhooks.claim_delivery.app_error", nil, "id="+id+", err="+err.Error())
+		} else if rows, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.ClaimDelivery", "store.sql_webhooks.claim_delivery.app_error", nil, "id="+id+", err="+err.Error())
+		} else {
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) PermanentDeleteDelivery(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM OutgoingWebhookDeliveries WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.PermanentDeleteDelivery", "store.sql_webhooks.permanent_delete_delivery.app_error", nil, "id="+id+", err="+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlWebhookStore) AnalyticsIncomingCount(teamId string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
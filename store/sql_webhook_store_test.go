@@ -6,6 +6,7 @@ package store
 import (
 	"github.com/mattermost/platform/model"
 	"testing"
+	"time"
 )
 
 func TestWebhookStoreSaveIncoming(t *testing.T) {
@@ -103,6 +104,100 @@ func TestWebhookStoreDeleteIncoming(t *testing.T) {
 	}
 }
 
+func TestWebhookStoreRestoreByChannel(t *testing.T) {
+	Setup()
+
+	channelId := model.NewId()
+	deleteAt := model.GetMillis()
+
+	o1 := &model.IncomingWebhook{}
+	o1.ChannelId = channelId
+	o1.UserId = model.NewId()
+	o1.TeamId = model.NewId()
+	o1 = Must(store.Webhook().SaveIncoming(o1)).(*model.IncomingWebhook)
+
+	o2 := &model.IncomingWebhook{}
+	o2.ChannelId = channelId
+	o2.UserId = o1.UserId
+	o2.TeamId = o1.TeamId
+	o2 = Must(store.Webhook().SaveIncoming(o2)).(*model.IncomingWebhook)
+
+	o3 := &model.OutgoingWebhook{}
+	o3.ChannelId = channelId
+	o3.CreatorId = model.NewId()
+	o3.TeamId = o1.TeamId
+	o3.CallbackURLs = []string{"http://nowhere.com/"}
+	o3 = Must(store.Webhook().SaveOutgoing(o3)).(*model.OutgoingWebhook)
+
+	o4 := &model.OutgoingWebhook{}
+	o4.ChannelId = channelId
+	o4.CreatorId = o3.CreatorId
+	o4.TeamId = o1.TeamId
+	o4.CallbackURLs = []string{"http://nowhere.com/"}
+	o4 = Must(store.Webhook().SaveOutgoing(o4)).(*model.OutgoingWebhook)
+
+	Must(store.Webhook().DeleteIncoming(o1.Id, deleteAt))
+	Must(store.Webhook().DeleteIncoming(o2.Id, deleteAt-60000))
+	Must(store.Webhook().DeleteOutgoing(o3.Id, deleteAt))
+
+	// channels deleted by older versions deleted their hooks just before the channel
+	Must(store.Webhook().DeleteOutgoing(o4.Id, deleteAt-20))
+
+	if r := <-store.Webhook().RestoreIncomingByChannel(channelId, deleteAt, model.GetMillis()); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	if r := <-store.Webhook().RestoreOutgoingByChannel(channelId, deleteAt, model.GetMillis()); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	if r := <-store.Webhook().GetIncoming(o1.Id); r.Err != nil {
+		t.Fatal("should have restored the hook deleted with the channel", r.Err)
+	}
+
+	if r := <-store.Webhook().GetIncoming(o2.Id); r.Err == nil {
+		t.Fatal("shouldn't have restored a hook deleted before the channel")
+	}
+
+	if r := <-store.Webhook().GetOutgoing(o3.Id); r.Err != nil {
+		t.Fatal("should have restored the hook deleted with the channel", r.Err)
+	}
+
+	if r := <-store.Webhook().GetOutgoing(o4.Id); r.Err != nil {
+		t.Fatal("should have restored the hook deleted right before the channel", r.Err)
+	}
+}
+
+func TestWebhookStoreUpdateIncoming(t *testing.T) {
+	Setup()
+
+	o1 := &model.IncomingWebhook{}
+	o1.ChannelId = model.NewId()
+	o1.UserId = model.NewId()
+	o1.TeamId = model.NewId()
+
+	o1 = (<-store.Webhook().SaveIncoming(o1)).Data.(*model.IncomingWebhook)
+
+	o1.ChannelId = model.NewId()
+	o1.DisplayName = "new name"
+
+	if r1 := <-store.Webhook().UpdateIncoming(o1); r1.Err != nil {
+		t.Fatal(r1.Err)
+	}
+
+	if r2 := <-store.Webhook().GetIncoming(o1.Id); r2.Err != nil {
+		t.Fatal(r2.Err)
+	} else if hook := r2.Data.(*model.IncomingWebhook); hook.ChannelId != o1.ChannelId || hook.DisplayName != o1.DisplayName {
+		t.Fatal("should have updated the hook")
+	}
+
+	o1.ChannelId = "junk"
+
+	if r3 := <-store.Webhook().UpdateIncoming(o1); r3.Err == nil {
+		t.Fatal("should have failed - invalid channel id")
+	}
+}
+
 func TestWebhookStoreDeleteIncomingByUser(t *testing.T) {
 	Setup()
 
@@ -343,3 +438,122 @@ func TestWebhookStoreCountOutgoing(t *testing.T) {
 		}
 	}
 }
+
+func TestWebhookStoreDeliveries(t *testing.T) {
+	Setup()
+
+	hookId := model.NewId()
+
+	d1 := &model.OutgoingWebhookDelivery{}
+	d1.HookId = hookId
+	d1.TeamId = model.NewId()
+	d1.ChannelId = model.NewId()
+	d1.URL = "http://nowhere.com/"
+	d1.ContentType = model.OUTGOING_HOOK_CONTENT_TYPE_FORM
+	d1.Payload = "token=abc&text=hello"
+	d1.Attempts = 1
+	d1.Error = "connection refused"
+
+	if r := <-store.Webhook().SaveDelivery(d1); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	if r := <-store.Webhook().SaveDelivery(d1); r.Err == nil {
+		t.Fatal("shouldn't be able to update from save")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	d2 := &model.OutgoingWebhookDelivery{}
+	d2.HookId = hookId
+	d2.TeamId = d1.TeamId
+	d2.ChannelId = d1.ChannelId
+	d2.URL = "http://nowhere.com/"
+	d2.ContentType = model.OUTGOING_HOOK_CONTENT_TYPE_JSON
+	d2.Payload = `{"text":"hello"}`
+	Must(store.Webhook().SaveDelivery(d2))
+
+	d1.Attempts = 2
+	d1.Status = model.WEBHOOK_DELIVERY_STATUS_FAILED
+	if r := <-store.Webhook().UpdateDelivery(d1); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	if r := <-store.Webhook().GetDelivery(d1.Id); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if delivery := r.Data.(*model.OutgoingWebhookDelivery); delivery.Attempts != 2 || delivery.Status != model.WEBHOOK_DELIVERY_STATUS_FAILED || delivery.Payload != d1.Payload {
+		t.Fatal("invalid returned delivery")
+	}
+
+	if r := <-store.Webhook().GetDeliveriesByHook(hookId, 0, 10); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if deliveries := r.Data.([]*model.OutgoingWebhookDelivery); len(deliveries) != 2 || deliveries[0].Id != d2.Id {
+		t.Fatal("should have returned both deliveries, most recent first")
+	}
+
+	if r := <-store.Webhook().GetDeliveriesByHook(hookId, 1, 10); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if deliveries := r.Data.([]*model.OutgoingWebhookDelivery); len(deliveries) != 1 || deliveries[0].Id != d1.Id {
+		t.Fatal("should have skipped the most recent delivery")
+	}
+}
+
+func TestWebhookStoreDueDeliveries(t *testing.T) {
+	Setup()
+
+	now := model.GetMillis()
+
+	d1 := &model.OutgoingWebhookDelivery{}
+	d1.HookId = model.NewId()
+	d1.TeamId = model.NewId()
+	d1.ChannelId = model.NewId()
+	d1.PostId = model.NewId()
+	d1.URL = "http://nowhere.com/"
+	d1.ContentType = model.OUTGOING_HOOK_CONTENT_TYPE_FORM
+	d1.Payload = "token=abc&text=hello"
+	d1.NextAttemptAt = now - 1000
+	d1 = Must(store.Webhook().SaveDelivery(d1)).(*model.OutgoingWebhookDelivery)
+
+	d2 := &model.OutgoingWebhookDelivery{}
+	d2.HookId = d1.HookId
+	d2.TeamId = d1.TeamId
+	d2.ChannelId = d1.ChannelId
+	d2.URL = "http://nowhere.com/"
+	d2.ContentType = model.OUTGOING_HOOK_CONTENT_TYPE_FORM
+	d2.Payload = "token=abc&text=hello"
+	d2.NextAttemptAt = now + 60000
+	d2 = Must(store.Webhook().SaveDelivery(d2)).(*model.OutgoingWebhookDelivery)
+
+	found := false
+	for _, delivery := range Must(store.Webhook().GetDueDeliveries(now, 1000)).([]*model.OutgoingWebhookDelivery) {
+		if delivery.Id == d1.Id {
+			found = true
+		} else if delivery.Id == d2.Id {
+			t.Fatal("shouldn't have returned a delivery that isn't due")
+		}
+	}
+
+	if !found {
+		t.Fatal("should have returned the due delivery")
+	}
+
+	if !Must(store.Webhook().ClaimDelivery(d1.Id, d1.NextAttemptAt, now+60000)).(bool) {
+		t.Fatal("should have claimed the delivery")
+	}
+
+	if Must(store.Webhook().ClaimDelivery(d1.Id, d1.NextAttemptAt, now+60000)).(bool) {
+		t.Fatal("shouldn't have claimed the delivery twice")
+	}
+
+	for _, delivery := range Must(store.Webhook().GetDueDeliveries(now, 1000)).([]*model.OutgoingWebhookDelivery) {
+		if delivery.Id == d1.Id {
+			t.Fatal("shouldn't have returned a claimed delivery")
+		}
+	}
+
+	Must(store.Webhook().PermanentDeleteDelivery(d2.Id))
+
+	if r := <-store.Webhook().GetDelivery(d2.Id); r.Err == nil {
+		t.Fatal("should have deleted the delivery")
+	}
+}
@@ -42,6 +42,10 @@ type Store interface {
 	Preference() PreferenceStore
 	License() LicenseStore
 	PasswordRecovery() PasswordRecoveryStore
+	NotificationQueue() NotificationQueueStore
+	Thread() ThreadStore
+	Reaction() ReactionStore
+	UserAccessToken() UserAccessTokenStore
 	MarkSystemRanUnitTests()
 	Close()
 	DropAllTables()
@@ -75,10 +79,12 @@ type ChannelStore interface {
 	Get(id string) StoreChannel
 	GetFromMaster(id string) StoreChannel
 	Delete(channelId string, time int64) StoreChannel
+	Restore(channelId string, time int64) StoreChannel
 	PermanentDeleteByTeam(teamId string) StoreChannel
 	GetByName(team_id string, domain string) StoreChannel
 	GetChannels(teamId string, userId string) StoreChannel
 	GetMoreChannels(teamId string, userId string) StoreChannel
+	GetDeletedChannels(teamId string) StoreChannel
 	GetChannelCounts(teamId string, userId string) StoreChannel
 	GetForExport(teamId string) StoreChannel
 
@@ -91,6 +97,7 @@ type ChannelStore interface {
 	PermanentDeleteMembersByUser(userId string) StoreChannel
 	GetExtraMembers(channelId string, limit int) StoreChannel
 	CheckPermissionsTo(teamId string, channelId string, userId string) StoreChannel
+	CheckReadPermissionsTo(teamId string, channelId string, userId string) StoreChannel
 	CheckPermissionsToNoTeam(channelId string, userId string) StoreChannel
 	CheckOpenChannelPermissions(teamId string, channelId string) StoreChannel
 	CheckPermissionsToByName(teamId string, channelName string, userId string) StoreChannel
@@ -103,12 +110,23 @@ type ChannelStore interface {
 type PostStore interface {
 	Save(post *model.Post) StoreChannel
 	Update(post *model.Post, newMessage string, newHashtags string) StoreChannel
+	Overwrite(post *model.Post) StoreChannel
 	Get(id string) StoreChannel
+	GetPostThread(rootId string) StoreChannel
+	UpdatePinned(postId string, isPinned bool) StoreChannel
+	GetPinnedPosts(channelId string) StoreChannel
+	GetFlaggedPosts(userId string, offset int, limit int) StoreChannel
+	GetEditHistory(postId string) StoreChannel
 	Delete(postId string, time int64) StoreChannel
 	PermanentDeleteByUser(userId string) StoreChannel
 	GetPosts(channelId string, offset int, limit int) StoreChannel
 	GetPostsBefore(channelId string, postId string, numPosts int, offset int) StoreChannel
 	GetPostsAfter(channelId string, postId string, numPosts int, offset int) StoreChannel
+	GetPostsBeforeCursor(channelId string, postId string, limit int) StoreChannel
+	GetPostsAfterCursor(channelId string, postId string, limit int) StoreChannel
+	GetPostsAroundCursor(channelId string, postId string, limit int) StoreChannel
+	GetPostsByIds(postIds []string) StoreChannel
+	GetPostsBatchForIndexing(createAt int64, postId string, limit int) StoreChannel
 	GetPostsSince(channelId string, time int64) StoreChannel
 	GetEtag(channelId string) StoreChannel
 	Search(teamId string, userId string, params *model.SearchParams) StoreChannel
@@ -126,9 +144,13 @@ type UserStore interface {
 	UpdateLastActivityAt(userId string, time int64) StoreChannel
 	UpdateUserAndSessionActivity(userId string, sessionId string, time int64) StoreChannel
 	UpdatePassword(userId, newPassword string) StoreChannel
+	UpdatePasswordHistory(userId, history string) StoreChannel
 	UpdateAuthData(userId, service, authData, email string) StoreChannel
 	UpdateMfaSecret(userId, secret string) StoreChannel
 	UpdateMfaActive(userId string, active bool) StoreChannel
+	UpdateMfaRecoveryCodes(userId, codes string) StoreChannel
+	ReplaceMfaRecoveryCodes(userId, oldCodes, newCodes string) StoreChannel
+	UpdateMfaLastTimeStep(userId string, step int64) StoreChannel
 	Get(id string) StoreChannel
 	GetAll() StoreChannel
 	GetAllProfiles() StoreChannel
@@ -148,6 +170,8 @@ type UserStore interface {
 	GetTotalUsersCount() StoreChannel
 	GetTotalActiveUsersCount() StoreChannel
 	GetSystemAdminProfiles() StoreChannel
+	GetBotsByOwner(ownerId string) StoreChannel
+	GetAllUsingAuthService(authService string) StoreChannel
 	PermanentDelete(userId string) StoreChannel
 	AnalyticsUniqueUserCount(teamId string) StoreChannel
 	GetUnreadCount(userId string) StoreChannel
@@ -185,6 +209,10 @@ type OAuthStore interface {
 	UpdateApp(app *model.OAuthApp) StoreChannel
 	GetApp(id string) StoreChannel
 	GetAppByUser(userId string) StoreChannel
+	GetApps() StoreChannel
+	GetAuthorizedApps(userId string) StoreChannel
+	UpdateAppSecret(appId string, clientSecret string) StoreChannel
+	DeleteApp(appId string) StoreChannel
 	SaveAuthData(authData *model.AuthData) StoreChannel
 	GetAuthData(code string) StoreChannel
 	RemoveAuthData(code string) StoreChannel
@@ -192,7 +220,12 @@ type OAuthStore interface {
 	SaveAccessData(accessData *model.AccessData) StoreChannel
 	GetAccessData(token string) StoreChannel
 	GetAccessDataByAuthCode(authCode string) StoreChannel
+	GetAccessDataByRefreshToken(refreshToken string) StoreChannel
+	GetAccessDataByApp(clientId string, userId string) StoreChannel
+	GetAccessDataFamily(authCode string) StoreChannel
+	RotateAccessData(oldToken string, accessData *model.AccessData) StoreChannel
 	RemoveAccessData(token string) StoreChannel
+	RemoveAccessDataFamily(authCode string) StoreChannel
 }
 
 type SystemStore interface {
@@ -209,14 +242,24 @@ type WebhookStore interface {
 	GetIncomingByTeam(teamId string) StoreChannel
 	GetIncomingByChannel(channelId string) StoreChannel
 	DeleteIncoming(webhookId string, time int64) StoreChannel
+	UpdateIncoming(hook *model.IncomingWebhook) StoreChannel
+	RestoreIncomingByChannel(channelId string, deleteAt int64, time int64) StoreChannel
 	PermanentDeleteIncomingByUser(userId string) StoreChannel
 	SaveOutgoing(webhook *model.OutgoingWebhook) StoreChannel
 	GetOutgoing(id string) StoreChannel
 	GetOutgoingByChannel(channelId string) StoreChannel
 	GetOutgoingByTeam(teamId string) StoreChannel
 	DeleteOutgoing(webhookId string, time int64) StoreChannel
+	RestoreOutgoingByChannel(channelId string, deleteAt int64, time int64) StoreChannel
 	PermanentDeleteOutgoingByUser(userId string) StoreChannel
 	UpdateOutgoing(hook *model.OutgoingWebhook) StoreChannel
+	SaveDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel
+	UpdateDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel
+	GetDelivery(id string) StoreChannel
+	GetDeliveriesByHook(hookId string, offset int, limit int) StoreChannel
+	GetDueDeliveries(time int64, limit int) StoreChannel
+	ClaimDelivery(id string, nextAttemptAt int64, newNextAttemptAt int64) StoreChannel
+	PermanentDeleteDelivery(id string) StoreChannel
 	AnalyticsIncomingCount(teamId string) StoreChannel
 	AnalyticsOutgoingCount(teamId string) StoreChannel
 }
@@ -251,3 +294,40 @@ type PasswordRecoveryStore interface {
 	Get(userId string) StoreChannel
 	GetByCode(code string) StoreChannel
 }
+
+type NotificationQueueStore interface {
+	Save(notification *model.QueuedNotification) StoreChannel
+	GetDueUserIds(time int64) StoreChannel
+	GetForUser(userId string) StoreChannel
+	DeleteForUser(userId string, time int64) StoreChannel
+	PermanentDeleteByUser(userId string) StoreChannel
+}
+
+type ThreadStore interface {
+	SaveFollower(follower *model.ThreadFollower) StoreChannel
+	GetFollower(rootId string, userId string) StoreChannel
+	GetFollowers(rootId string) StoreChannel
+	GetFollowedThreads(teamId string, userId string) StoreChannel
+	RemoveFollower(rootId string, userId string) StoreChannel
+	IncrementMentionCount(rootId string, userId string) StoreChannel
+	UpdateLastViewedAt(rootId string, userId string) StoreChannel
+	PermanentDeleteByUser(userId string) StoreChannel
+}
+
+type ReactionStore interface {
+	Save(reaction *model.Reaction) StoreChannel
+	Delete(reaction *model.Reaction) StoreChannel
+	GetForPost(postId string) StoreChannel
+	GetForPosts(postIds []string) StoreChannel
+	GetForExport(channelId string) StoreChannel
+	PermanentDeleteByUser(userId string) StoreChannel
+}
+
+type UserAccessTokenStore interface {
+	Save(token *model.UserAccessToken) StoreChannel
+	Get(tokenId string) StoreChannel
+	GetByToken(tokenString string) StoreChannel
+	GetByUser(userId string) StoreChannel
+	Delete(tokenId string) StoreChannel
+	PermanentDeleteByUser(userId string) StoreChannel
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/mattermost/platform/model"
+)
+
+func IsPasswordValid(password string) *model.AppError {
+	return IsPasswordValidWithSettings(password, &Cfg.PasswordSettings)
+}
+
+// IsPasswordValidWithSettings checks the password against the requirements of the settings and
+// returns an error describing the first one that isn't met.
+func IsPasswordValidWithSettings(password string, settings *model.PasswordSettings) *model.AppError {
+	id := ""
+
+	if len(password) < *settings.MinimumLength {
+		id = "utils.password.length.app_error"
+	} else if *settings.Lowercase && !strings.ContainsAny(password, "abcdefghijklmnopqrstuvwxyz") {
+		id = "utils.password.lowercase.app_error"
+	} else if *settings.Uppercase && !strings.ContainsAny(password, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+		id = "utils.password.uppercase.app_error"
+	} else if *settings.Number && !strings.ContainsAny(password, "0123456789") {
+		id = "utils.password.number.app_error"
+	} else if *settings.Symbol && strings.IndexFunc(password, isPasswordSymbol) == -1 {
+		id = "utils.password.symbol.app_error"
+	}
+
+	if len(id) > 0 {
+		err := model.NewLocAppError("IsPasswordValid", id, map[string]interface{}{"MinLength": *settings.MinimumLength}, "")
+		err.StatusCode = http.StatusBadRequest
+		return err
+	}
+
+	if len(*settings.BannedPasswordsFile) > 0 {
+		if banned, err := isPasswordBanned(*settings.BannedPasswordsFile, password); err != nil {
+			return model.NewLocAppError("IsPasswordValid", "utils.password.banned_file.app_error", nil, err.Error())
+		} else if banned {
+			err := model.NewLocAppError("IsPasswordValid", "utils.password.banned.app_error", nil, "")
+			err.StatusCode = http.StatusBadRequest
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isPasswordSymbol(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsPunct(r) || unicode.IsSymbol(r) || r == ' ')
+}
+
+// isPasswordBanned looks for the SHA-1 hash of the password in the file. The file is read every
+// time since passwords rarely change and the lists can be much larger than what's worth keeping
+// in memory.
+func isPasswordBanned(path string, password string) (bool, error) {
+	file, err := os.Open(FindConfigFile(path))
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	sum := sha1.Sum([]byte(password))
+	hash := hex.EncodeToString(sum[:])
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, ":"); i != -1 {
+			line = line[:i]
+		}
+
+		if strings.EqualFold(line, hash) {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
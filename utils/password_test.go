@@ -0,0 +1,88 @@
+// Copyright (c) 2016 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestIsPasswordValid(t *testing.T) {
+	LoadConfig("config.json")
+
+	settings := &Cfg.PasswordSettings
+
+	if err := IsPasswordValidWithSettings("abcde", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := IsPasswordValidWithSettings("abcd", settings); err == nil || err.Id != "utils.password.length.app_error" {
+		t.Fatal("should have failed - too short")
+	}
+
+	*settings.MinimumLength = 8
+	*settings.Lowercase = true
+	*settings.Uppercase = true
+	*settings.Number = true
+	*settings.Symbol = true
+
+	tests := []struct {
+		password string
+		id       string
+	}{
+		{"aB1!", "utils.password.length.app_error"},
+		{"ABCDEFG1!", "utils.password.lowercase.app_error"},
+		{"abcdefg1!", "utils.password.uppercase.app_error"},
+		{"abcdefgH!", "utils.password.number.app_error"},
+		{"abcdefgH1", "utils.password.symbol.app_error"},
+		{"abcdefgH1é", "utils.password.symbol.app_error"},
+		{"abcdefgH1!", ""},
+		{"abcd efgH1", ""},
+		{strings.Repeat("abcdefgH1!", 10), ""},
+	}
+
+	for _, test := range tests {
+		err := IsPasswordValidWithSettings(test.password, settings)
+		if len(test.id) == 0 && err != nil {
+			t.Fatal(test.password, err)
+		} else if len(test.id) > 0 && (err == nil || err.Id != test.id) {
+			t.Fatal("should have failed with "+test.id, test.password, err)
+		}
+	}
+}
+
+func TestIsPasswordBanned(t *testing.T) {
+	LoadConfig("config.json")
+
+	file, err := ioutil.TempFile("", "banned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	// the SHA-1 hashes of "password" and "letmein"
+	file.WriteString("5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3730471\n")
+	file.WriteString("b7a875fc1ea228b9061041b7cec4bd3c52ab3ce3\n")
+	file.Close()
+
+	settings := &Cfg.PasswordSettings
+	*settings.BannedPasswordsFile = file.Name()
+
+	for _, password := range []string{"password", "letmein"} {
+		if err := IsPasswordValidWithSettings(password, settings); err == nil || err.Id != "utils.password.banned.app_error" {
+			t.Fatal("should have banned the password", password)
+		}
+	}
+
+	if err := IsPasswordValidWithSettings("correct horse", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	*settings.BannedPasswordsFile = file.Name() + ".missing"
+	if err := IsPasswordValidWithSettings("correct horse", settings); err == nil {
+		t.Fatal("should have failed - the file is missing")
+	}
+}
@@ -4,6 +4,7 @@
 package web
 
 import (
+	"net/http"
 	"net/url"
 	"strings"
 	"testing"
@@ -64,7 +65,7 @@ func TestGetAccessToken(t *testing.T) {
 	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	rteam, _ := ApiClient.CreateTeam(&team)
 
-	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Password: "pwd"}
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Password: "passwd"}
 	ruser := ApiClient.Must(ApiClient.CreateUser(&user, "")).Data.(*model.User)
 	api.JoinUserToTeam(rteam.Data.(*model.Team), ruser)
 	store.Must(api.Srv.Store.User().VerifyEmail(ruser.Id))
@@ -79,7 +80,7 @@ func TestGetAccessToken(t *testing.T) {
 		}
 	} else {
 
-		ApiClient.Must(ApiClient.LoginById(ruser.Id, "pwd"))
+		ApiClient.Must(ApiClient.LoginById(ruser.Id, "passwd"))
 		ApiClient.SetTeamId(rteam.Data.(*model.Team).Id)
 		app = ApiClient.Must(ApiClient.RegisterApp(app)).Data.(*model.OAuthApp)
 
@@ -189,13 +190,184 @@ func TestGetAccessToken(t *testing.T) {
 	}
 }
 
+func TestRefreshAccessToken(t *testing.T) {
+	Setup()
+
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		return
+	}
+
+	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	rteam, _ := ApiClient.CreateTeam(&team)
+
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Password: "passwd"}
+	ruser := ApiClient.Must(ApiClient.CreateUser(&user, "")).Data.(*model.User)
+	api.JoinUserToTeam(rteam.Data.(*model.Team), ruser)
+	store.Must(api.Srv.Store.User().VerifyEmail(ruser.Id))
+
+	ApiClient.Must(ApiClient.LoginById(ruser.Id, "passwd"))
+	ApiClient.SetTeamId(rteam.Data.(*model.Team).Id)
+
+	app := &model.OAuthApp{Name: "TestApp" + model.NewId(), Homepage: "https://nowhere.com", Description: "test", CallbackUrls: []string{"https://nowhere.com"}}
+	app = ApiClient.Must(ApiClient.RegisterApp(app)).Data.(*model.OAuthApp)
+
+	redirect := ApiClient.Must(ApiClient.AllowOAuth(model.AUTHCODE_RESPONSE_TYPE, app.Id, app.CallbackUrls[0], "all", "123")).Data.(map[string]string)["redirect"]
+	rurl, _ := url.Parse(redirect)
+
+	ApiClient.Logout()
+
+	data := url.Values{"grant_type": []string{model.ACCESS_TOKEN_GRANT_TYPE}, "client_id": []string{app.Id}, "client_secret": []string{app.ClientSecret}, "code": []string{rurl.Query().Get("code")}, "redirect_uri": []string{app.CallbackUrls[0]}}
+
+	rsp1 := ApiClient.Must(ApiClient.GetAccessToken(data)).Data.(*model.AccessResponse)
+	if len(rsp1.RefreshToken) == 0 {
+		t.Fatal("refresh token not returned")
+	}
+
+	data = url.Values{"grant_type": []string{model.REFRESH_TOKEN_GRANT_TYPE}, "client_id": []string{app.Id}, "client_secret": []string{app.ClientSecret}, "refresh_token": []string{"junk"}}
+	if _, err := ApiClient.GetAccessToken(data); err == nil {
+		t.Fatal("should have failed - bad refresh token")
+	}
+
+	data.Set("refresh_token", rsp1.RefreshToken)
+	data.Set("client_secret", "junk")
+	if _, err := ApiClient.GetAccessToken(data); err == nil {
+		t.Fatal("should have failed - bad client secret")
+	}
+
+	data.Set("client_secret", app.ClientSecret)
+	rsp2 := ApiClient.Must(ApiClient.GetAccessToken(data)).Data.(*model.AccessResponse)
+	if rsp2.AccessToken == rsp1.AccessToken || rsp2.RefreshToken == rsp1.RefreshToken || len(rsp2.RefreshToken) == 0 {
+		t.Fatal("should have rotated both tokens")
+	}
+
+	if _, err := ApiClient.DoApiGet("/users/profiles?access_token="+rsp1.AccessToken, "", ""); err == nil {
+		t.Fatal("should have failed - the refreshed access token should be revoked")
+	}
+
+	if _, err := ApiClient.DoApiGet("/users/profiles?access_token="+rsp2.AccessToken, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ApiClient.GetAccessToken(data); err == nil {
+		t.Fatal("should have failed - tried to reuse a refresh token")
+	}
+
+	if _, err := ApiClient.DoApiGet("/users/profiles?access_token="+rsp2.AccessToken, "", ""); err == nil {
+		t.Fatal("should have failed - reusing a refresh token should revoke the whole family")
+	}
+
+	data.Set("refresh_token", rsp2.RefreshToken)
+	if _, err := ApiClient.GetAccessToken(data); err == nil {
+		t.Fatal("should have failed - the family's refresh tokens should be revoked")
+	}
+
+	ApiClient.Must(ApiClient.LoginById(ruser.Id, "passwd"))
+	redirect = ApiClient.Must(ApiClient.AllowOAuth(model.AUTHCODE_RESPONSE_TYPE, app.Id, app.CallbackUrls[0], "all", "123")).Data.(map[string]string)["redirect"]
+	rurl, _ = url.Parse(redirect)
+	ApiClient.Logout()
+
+	data = url.Values{"grant_type": []string{model.ACCESS_TOKEN_GRANT_TYPE}, "client_id": []string{app.Id}, "client_secret": []string{app.ClientSecret}, "code": []string{rurl.Query().Get("code")}, "redirect_uri": []string{app.CallbackUrls[0]}}
+	rsp3 := ApiClient.Must(ApiClient.GetAccessToken(data)).Data.(*model.AccessResponse)
+
+	ruser.DeleteAt = model.GetMillis()
+	store.Must(api.Srv.Store.User().Update(ruser, true))
+
+	data = url.Values{"grant_type": []string{model.REFRESH_TOKEN_GRANT_TYPE}, "client_id": []string{app.Id}, "client_secret": []string{app.ClientSecret}, "refresh_token": []string{rsp3.RefreshToken}}
+	if _, err := ApiClient.GetAccessToken(data); err == nil {
+		t.Fatal("should have failed - the user is deactivated")
+	}
+
+	if _, err := ApiClient.DoApiGet("/users/profiles?access_token="+rsp3.AccessToken, "", ""); err == nil {
+		t.Fatal("should have failed - the tokens of a deactivated user should be revoked")
+	}
+}
+
+func TestOAuthScopes(t *testing.T) {
+	Setup()
+
+	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
+		return
+	}
+
+	team := model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	rteam := ApiClient.Must(ApiClient.CreateTeam(&team)).Data.(*model.Team)
+
+	user := model.User{Email: strings.ToLower(model.NewId()) + "success+test@simulator.amazonses.com", Password: "passwd"}
+	ruser := ApiClient.Must(ApiClient.CreateUser(&user, "")).Data.(*model.User)
+	api.JoinUserToTeam(rteam, ruser)
+	store.Must(api.Srv.Store.User().VerifyEmail(ruser.Id))
+
+	ApiClient.Must(ApiClient.LoginById(ruser.Id, "passwd"))
+	ApiClient.SetTeamId(rteam.Id)
+
+	channel := &model.Channel{DisplayName: "Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: rteam.Id}
+	channel = ApiClient.Must(ApiClient.CreateChannel(channel)).Data.(*model.Channel)
+
+	app := &model.OAuthApp{Name: "TestApp" + model.NewId(), Homepage: "https://nowhere.com", Description: "test", CallbackUrls: []string{"https://nowhere.com"}}
+	app = ApiClient.Must(ApiClient.RegisterApp(app)).Data.(*model.OAuthApp)
+
+	redirect := ApiClient.Must(ApiClient.AllowOAuth(model.AUTHCODE_RESPONSE_TYPE, app.Id, app.CallbackUrls[0], "junk", "123")).Data.(map[string]string)["redirect"]
+	if rurl, _ := url.Parse(redirect); rurl.Query().Get("error") != "invalid_scope" {
+		t.Fatal("should have rejected an unknown scope")
+	}
+
+	redirect = ApiClient.Must(ApiClient.AllowOAuth(model.AUTHCODE_RESPONSE_TYPE, app.Id, app.CallbackUrls[0], model.SCOPE_READ_USERS+" "+model.SCOPE_READ_POSTS, "123")).Data.(map[string]string)["redirect"]
+	rurl, _ := url.Parse(redirect)
+
+	ApiClient.Logout()
+
+	data := url.Values{"grant_type": []string{model.ACCESS_TOKEN_GRANT_TYPE}, "client_id": []string{app.Id}, "client_secret": []string{app.ClientSecret}, "code": []string{rurl.Query().Get("code")}, "redirect_uri": []string{app.CallbackUrls[0]}}
+
+	rsp := ApiClient.Must(ApiClient.GetAccessToken(data)).Data.(*model.AccessResponse)
+	if rsp.Scope != model.SCOPE_READ_POSTS+" "+model.SCOPE_READ_USERS {
+		t.Fatal("should have returned the granted scope", rsp.Scope)
+	}
+
+	oauthClient := model.NewClient(ApiClient.Url)
+	oauthClient.SetOAuthToken(rsp.AccessToken)
+	oauthClient.SetTeamId(rteam.Id)
+
+	if result, err := oauthClient.GetMe(""); err != nil {
+		t.Fatal(err)
+	} else if user, _ := result.Data.(*model.User); user == nil || user.Id != ruser.Id {
+		t.Fatal("should have returned the user the token was granted by")
+	}
+
+	if _, err := oauthClient.GetPosts(channel.Id, 0, 10, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := oauthClient.CreatePost(&model.Post{ChannelId: channel.Id, Message: "should not post"}); err == nil {
+		t.Fatal("should have failed - token was not granted write:posts")
+	} else if err.StatusCode != http.StatusForbidden {
+		t.Fatal("should have been forbidden", err.StatusCode)
+	}
+
+	if _, err := oauthClient.GetAllPreferences(); err == nil {
+		t.Fatal("should have failed - routes without a scope need full access")
+	}
+
+	// routes that don't require a user are checked too
+	if _, err := oauthClient.GetInitialLoad(); err == nil {
+		t.Fatal("should have failed - initial load has no scope and the token doesn't have full access")
+	} else if err.StatusCode != http.StatusForbidden {
+		t.Fatal("should have been forbidden", err.StatusCode)
+	}
+
+	data.Set("grant_type", model.REFRESH_TOKEN_GRANT_TYPE)
+	data.Set("refresh_token", rsp.RefreshToken)
+	if rsp2 := ApiClient.Must(ApiClient.GetAccessToken(data)).Data.(*model.AccessResponse); rsp2.Scope != rsp.Scope {
+		t.Fatal("should have kept the scope when refreshing")
+	}
+}
+
 func TestIncomingWebhook(t *testing.T) {
 	Setup()
 
 	team := &model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
 	team = ApiClient.Must(ApiClient.CreateTeam(team)).Data.(*model.Team)
 
-	user := &model.User{Email: model.NewId() + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "pwd"}
+	user := &model.User{Email: model.NewId() + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd"}
 	user = ApiClient.Must(ApiClient.CreateUser(user, "")).Data.(*model.User)
 	store.Must(api.Srv.Store.User().VerifyEmail(user.Id))
 	api.JoinUserToTeam(team, user)
@@ -204,7 +376,7 @@ func TestIncomingWebhook(t *testing.T) {
 	c.RequestId = model.NewId()
 	c.IpAddress = "cmd_line"
 	api.UpdateUserRoles(c, user, model.ROLE_SYSTEM_ADMIN)
-	ApiClient.Login(user.Email, "pwd")
+	ApiClient.Login(user.Email, "passwd")
 	ApiClient.SetTeamId(team.Id)
 
 	channel1 := &model.Channel{DisplayName: "Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}